@@ -0,0 +1,14 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import "github.com/spf13/cobra"
+
+func init() {
+	RootCmd.AddCommand(backendCmd)
+}
+
+var backendCmd = &cobra.Command{
+	Use:   "backend",
+	Short: "Work with Trident storage backend configurations",
+}
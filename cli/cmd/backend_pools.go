@@ -0,0 +1,159 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	tridentconfig "github.com/netapp/trident/config"
+	"github.com/netapp/trident/storage"
+	"github.com/netapp/trident/storage/factory"
+	sa "github.com/netapp/trident/storage_attribute"
+)
+
+func init() {
+	backendCmd.AddCommand(backendListPoolsCmd)
+	backendListPoolsCmd.Flags().DurationVar(&backendHTTPTimeout, "backend-http-timeout", 0,
+		"The HTTP client timeout for storage backend API calls (default 1m30s).")
+}
+
+var backendListPoolsCmd = &cobra.Command{
+	Use:   "list-pools <config file>",
+	Short: "List the storage pools offered by a backend configuration",
+	Long: "Start the storage driver for a JSON or YAML backend configuration file and list the " +
+		"storage pools it offers, with their key attributes, e.g. to choose the --volume-pool of " +
+		"'tridentctl install'. No Kubernetes cluster or Trident server is required.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// Keep stdout clear for the pool list
+		initInstallerLogging()
+		log.SetOutput(os.Stderr)
+
+		backend, err := startBackendForPools(args[0])
+		if err != nil {
+			return err
+		}
+		defer backend.Terminate()
+
+		writeBackendPools(backend)
+		return nil
+	},
+}
+
+// backendPoolsResponse is the structured output of 'tridentctl backend list-pools'.
+type backendPoolsResponse struct {
+	Backend           string                  `json:"backend"`
+	StorageDriverName string                  `json:"storageDriverName"`
+	Pools             []*storage.PoolExternal `json:"pools"`
+}
+
+// startBackendForPools starts the storage driver for a backend config outside of any cluster,
+// so credentials must be specified in the config rather than in a Kubernetes secret.
+func startBackendForPools(configFilePath string) (*storage.Backend, error) {
+
+	tridentconfig.CurrentDriverContext = tridentconfig.ContextKubernetes
+	factory.CredentialsResolver = func(secretName string) (map[string]string, error) {
+		return nil, errors.New("credentials secrets can't be read without a cluster; specify the " +
+			"username and password in the backend config")
+	}
+
+	return startStorageDriver(configFilePath)
+}
+
+func writeBackendPools(backend *storage.Backend) {
+
+	poolNames := make([]string, 0, len(backend.Storage))
+	for name := range backend.Storage {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+
+	response := backendPoolsResponse{
+		Backend:           backend.Name,
+		StorageDriverName: backend.GetDriverName(),
+		Pools:             make([]*storage.PoolExternal, 0, len(poolNames)),
+	}
+	for _, name := range poolNames {
+		response.Pools = append(response.Pools, backend.Storage[name].ConstructExternal())
+	}
+
+	switch OutputFormat {
+	case FormatJSON:
+		WriteJSON(response)
+	case FormatYAML:
+		WriteYAML(response)
+	case FormatName:
+		for _, name := range poolNames {
+			fmt.Println(name)
+		}
+	default:
+		writeBackendPoolTable(response.Pools)
+	}
+}
+
+func writeBackendPoolTable(pools []*storage.PoolExternal) {
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Media", "Provisioning", "Snapshots", "Clones", "Encryption", "IOPS"})
+
+	for _, pool := range pools {
+		table.Append([]string{
+			pool.Name,
+			formatOffer(pool.Attributes[sa.Media]),
+			formatOffer(pool.Attributes[sa.ProvisioningType]),
+			formatOffer(pool.Attributes[sa.Snapshots]),
+			formatOffer(pool.Attributes[sa.Clones]),
+			formatOffer(pool.Attributes[sa.Encryption]),
+			formatOffer(pool.Attributes[sa.IOPS]),
+		})
+	}
+
+	table.Render()
+}
+
+// formatOffer renders a storage attribute offer for a table cell.  The offer types aren't
+// exported, so their JSON form is used.
+func formatOffer(offer sa.Offer) string {
+
+	if offer == nil {
+		return ""
+	}
+	offerJSON, err := json.Marshal(offer)
+	if err != nil {
+		return ""
+	}
+	var fields struct {
+		Offer interface{} `json:"offer"`
+		Min   *int        `json:"min"`
+		Max   *int        `json:"max"`
+	}
+	if err = json.Unmarshal(offerJSON, &fields); err != nil {
+		return ""
+	}
+
+	switch value := fields.Offer.(type) {
+	case bool:
+		return strconv.FormatBool(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		return strings.Join(values, ",")
+	}
+	if fields.Min != nil && fields.Max != nil {
+		return fmt.Sprintf("%d-%d", *fields.Min, *fields.Max)
+	}
+	return ""
+}
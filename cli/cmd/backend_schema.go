@@ -0,0 +1,176 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	drivers "github.com/netapp/trident/storage_drivers"
+)
+
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+var schemaDriverName string
+
+func init() {
+	backendCmd.AddCommand(backendSchemaCmd)
+	backendSchemaCmd.Flags().StringVar(&schemaDriverName, "driver", "",
+		"Storage driver name. If omitted, only the common fields are described.")
+}
+
+var backendSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for a backend configuration",
+	Long: "Print the JSON Schema for a Trident backend configuration file, derived from the " +
+		"storage driver's configuration structure. No Trident server is required.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		schema, err := getBackendConfigSchema(schemaDriverName)
+		if err != nil {
+			return err
+		}
+
+		if OutputFormat == FormatYAML {
+			WriteYAML(schema)
+		} else {
+			WriteJSON(schema)
+		}
+		return nil
+	},
+}
+
+// jsonSchema is the subset of the JSON Schema vocabulary needed to describe a backend config.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+}
+
+// backendConfigTypes maps each storage driver name to the config struct its driver unmarshals.
+var backendConfigTypes = map[string]reflect.Type{
+	drivers.OntapNASStorageDriverName:      reflect.TypeOf(drivers.OntapStorageDriverConfig{}),
+	drivers.OntapNASQtreeStorageDriverName: reflect.TypeOf(drivers.OntapStorageDriverConfig{}),
+	drivers.OntapSANStorageDriverName:      reflect.TypeOf(drivers.OntapStorageDriverConfig{}),
+	drivers.SolidfireSANStorageDriverName:  reflect.TypeOf(drivers.SolidfireStorageDriverConfig{}),
+	drivers.EseriesIscsiStorageDriverName:  reflect.TypeOf(drivers.ESeriesStorageDriverConfig{}),
+	drivers.FakeStorageDriverName:          reflect.TypeOf(drivers.FakeStorageDriverConfig{}),
+}
+
+// getBackendConfigSchema builds the JSON Schema for the named driver's backend config.  If
+// no driver is specified, the schema covers only the fields common to all drivers.
+func getBackendConfigSchema(driverName string) (*jsonSchema, error) {
+
+	configType := reflect.TypeOf(drivers.CommonStorageDriverConfig{})
+	driverNames := make([]interface{}, 0)
+
+	if driverName == "" {
+		names := make([]string, 0, len(backendConfigTypes))
+		for name := range backendConfigTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			driverNames = append(driverNames, name)
+		}
+	} else {
+		driverType, ok := backendConfigTypes[driverName]
+		if !ok {
+			return nil, fmt.Errorf("unknown storage driver: %s", driverName)
+		}
+		configType = driverType
+		driverNames = append(driverNames, driverName)
+	}
+
+	schema := schemaForType(configType)
+	schema.Schema = JSONSchemaDraft
+	schema.Title = "Trident backend configuration"
+	if driverName != "" {
+		schema.Title = fmt.Sprintf("Trident %s backend configuration", driverName)
+	}
+	schema.Required = []string{"version", "storageDriverName"}
+	schema.Properties["version"].Enum = []interface{}{drivers.ConfigVersion}
+	schema.Properties["storageDriverName"].Enum = driverNames
+
+	return schema, nil
+}
+
+// schemaForType returns the JSON Schema describing how encoding/json would unmarshal the given type.
+func schemaForType(t reflect.Type) *jsonSchema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Raw JSON values (i.e. storagePrefix) are always supplied as strings in backend configs
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		return &jsonSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: schemaForType(t.Elem())}
+	case reflect.Struct:
+		schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+		addStructProperties(schema, t)
+		return schema
+	default:
+		// Interfaces and anything else may hold any value
+		return &jsonSchema{}
+	}
+}
+
+// addStructProperties adds the JSON fields of a struct to a schema, flattening embedded
+// structs the same way encoding/json does.
+func addStructProperties(schema *jsonSchema, t reflect.Type) {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				addStructProperties(schema, fieldType)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaForType(field.Type)
+	}
+}
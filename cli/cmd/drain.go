@@ -0,0 +1,94 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/netapp/trident/cli/api"
+	"github.com/netapp/trident/frontend/rest"
+)
+
+// maxDrainTimeout keeps a drain, and the time Trident takes to respond once it ends, within the
+// timeout of the REST request waiting for it.
+const maxDrainTimeout = api.HTTPTimeout - 10*time.Second
+
+var (
+	drainTimeout time.Duration
+)
+
+func init() {
+	RootCmd.AddCommand(drainCmd)
+	drainCmd.Flags().DurationVar(&drainTimeout, "timeout", rest.DefaultDrainTimeout, "How long to wait for the storage operations in flight to finish.")
+}
+
+var drainCmd = &cobra.Command{
+	Use:    "drain",
+	Short:  "Stop Trident from starting storage operations before it is shut down",
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateDrainTimeout("timeout", drainTimeout); err != nil {
+			return err
+		}
+		if OperatingMode == ModeTunnel {
+			command := []string{"drain", "--timeout", drainTimeout.String()}
+			TunnelCommand(command)
+			return nil
+		} else {
+			return drain(drainTimeout)
+		}
+	},
+}
+
+// validateDrainTimeout checks a drain timeout flag, which must end before the REST request waiting
+// for the drain times out.
+func validateDrainTimeout(flagName string, timeout time.Duration) error {
+	if timeout > maxDrainTimeout {
+		return fmt.Errorf("'%s' must not be greater than %v", flagName, maxDrainTimeout)
+	}
+	return nil
+}
+
+// drain asks Trident to stop starting storage operations and to wait for those in flight, then
+// writes the operations that didn't finish before the timeout.
+func drain(timeout time.Duration) error {
+
+	baseURL, err := GetBaseURL()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/drain?timeout=%s", baseURL, timeout.String())
+
+	response, responseBody, err := api.InvokeRESTAPI("POST", url, nil, Debug)
+	if err != nil {
+		return err
+	} else if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not drain Trident: %v",
+			GetErrorFromHTTPResponse(response, responseBody))
+	}
+
+	var drainResponse rest.DrainResponse
+	err = json.Unmarshal(responseBody, &drainResponse)
+	if err != nil {
+		return err
+	}
+
+	switch OutputFormat {
+	case FormatJSON:
+		WriteJSON(drainResponse)
+	case FormatYAML:
+		WriteYAML(drainResponse)
+	default:
+		for _, operation := range drainResponse.PendingOperations {
+			fmt.Println(operation)
+		}
+	}
+
+	return nil
+}
@@ -1,23 +1,41 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff"
+	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/netapp/trident/cli/api"
 	"github.com/netapp/trident/cli/k8s_client"
@@ -28,6 +46,7 @@ import (
 	"github.com/netapp/trident/storage"
 	"github.com/netapp/trident/storage/factory"
 	sa "github.com/netapp/trident/storage_attribute"
+	drivers "github.com/netapp/trident/storage_drivers"
 	"github.com/netapp/trident/utils"
 )
 
@@ -39,6 +58,8 @@ const (
 	DefaultVolumeSize  = "2Gi"
 
 	BackendConfigFilename      = "backend.json"
+	BackendConfigYAMLFilename  = "backend.yaml"
+	BackendConfigYMLFilename   = "backend.yml"
 	NamespaceFilename          = "trident-namespace.yaml"
 	ServiceAccountFilename     = "trident-serviceaccount.yaml"
 	ClusterRoleFilename        = "trident-clusterrole.yaml"
@@ -48,6 +69,38 @@ const (
 	ServiceFilename            = "trident-service.yaml"
 	StatefulSetFilename        = "trident-statefulset.yaml"
 	DaemonSetFilename          = "trident-daemonset.yaml"
+	NetworkPolicyFilename      = "trident-networkpolicy.yaml"
+	K8sAPICAFilename           = "trident-k8s-api-ca.yaml"
+	HeadlessServiceFilename    = "trident-headless-service.yaml"
+	StorageClassFilename       = "trident-storageclass.yaml"
+
+	CosignCLI = "cosign"
+
+	ConfigChecksumAnnotation = "trident.netapp.io/config-checksum"
+	PVQoSAnnotationPrefix    = "trident.netapp.io/qos."
+
+	MinBackendHTTPTimeout = 5 * time.Second
+	MaxBackendHTTPTimeout = 30 * time.Minute
+
+	// DefaultResyncPeriod is how often the Trident controller resyncs the objects it watches,
+	// unless --resync-period is specified
+	DefaultResyncPeriod = 60 * time.Second
+	MinResyncPeriod     = 10 * time.Second
+	MaxResyncPeriod     = 24 * time.Hour
+
+	GOMAXPROCSAuto = "auto"
+
+	// EtcdServer is the client URL of the etcd container in the Trident pod
+	EtcdServer = "http://127.0.0.1:8001"
+
+	MaxLogFileSize    = 1024 // MiB
+	MaxLogFileBackups = 10
+
+	TopologySpreadDoNotSchedule  = "DoNotSchedule"
+	TopologySpreadScheduleAnyway = "ScheduleAnyway"
+
+	// DefaultRevisionHistoryLimit is smaller than the Kubernetes default, as Trident is rarely rolled back
+	DefaultRevisionHistoryLimit = 3
 )
 
 var (
@@ -63,14 +116,246 @@ var (
 	volumeSize   string
 	tridentImage string
 	etcdImage    string
+	volumeQoS    string
+	volumePool   string
 	k8sTimeout   time.Duration
 
+	// Private registry mirror of the images not specified explicitly, and the secrets with
+	// which the Trident pods pull their images
+	imageRegistry    string
+	imagePullSecrets []string
+
+	// Timeouts of individual installation steps, as name=duration
+	stepTimeoutArgs []string
+
+	// Timeouts of the PVC bind, Trident pod start, and REST interface waits, if not --k8s-timeout
+	pvcBindTimeout  time.Duration
+	podStartTimeout time.Duration
+	restTimeout     time.Duration
+
+	// Whether to encrypt the Trident volume at rest
+	volumeEncryption bool
+
+	// Percentage of the Trident volume reserved for snapshots, if not the backend's default
+	volumeSnapshotReserve string
+
+	// Whether to ask for confirmation between the pre-checks and the installation
+	confirmInstall bool
+
+	// Whether to require the etcd and Kubernetes versions Trident was qualified with
+	strictVersion bool
+
+	// Existing volume on the backend to clone as the Trident volume
+	cloneSourceVolume string
+
+	// Existing volume on the backend to import as the Trident volume
+	importVolume string
+
+	// Built-in profile of install flag defaults
+	installProfileName string
+
+	// Kubernetes version and flavor to generate YAML for instead of those of the cluster
+	targetK8sVersion string
+	targetFlavor     string
+	targetVersion    *utils.Version
+
+	// Checks that run diagnostic pods in the cluster
+	deepCheck       bool
+	diagnosticImage string
+	backendMTU      int
+
+	// Node limits sampled by diagnostic pods for the CSI node plugin
+	checkNodePrep       bool
+	minInotifyWatches   int64
+	minInotifyInstances int64
+	minOpenFiles        int64
+
+	// Access mode of the Trident PVC and PV, and whether it was specified rather than defaulted
+	volumeAccessMode          string
+	volumeAccessModeSpecified bool
+
+	// Failure handling
+	retainFailedPod bool
+	abortOnEviction bool
+
+	// Wait for a terminating namespace to be deleted rather than failing
+	waitForNamespace bool
+
+	// Remove volume attachments left by an earlier installation
+	pruneVolumeAttachments bool
+
+	// Name of the main Trident container in custom YAML files
+	tridentContainerName string
+
+	// Trident container resources
+	ephemeralStorageRequest string
+	ephemeralStorageLimit   string
+
+	// Pod template annotations
+	configChecksum bool
+
+	// Trident controller tuning
+	controllerWorkers    int
+	controllerQPS        float32
+	controllerBurst      int
+	resyncPeriod         time.Duration
+	backendHTTPTimeout   time.Duration
+	controllerGOMAXPROCS string
+
+	// Scheduler of the Trident pods, if not the default one
+	schedulerName string
+
+	// Termination messages of the Trident and etcd containers
+	terminationMessagePath   string
+	terminationMessagePolicy string
+
+	// DNS name of the Trident controller pod
+	podHostname  string
+	podSubdomain string
+
+	// Replacement of a Released or Failed Trident PV
+	replaceExistingPV bool
+
+	// Kubernetes events about the installation
+	emitEvents bool
+
+	// Rotation of the Trident log file
+	logMaxSize    int
+	logMaxBackups int
+	logMaxAge     time.Duration
+
+	// Secrets referenced by backend configs
+	credentialsSecrets       []string
+	backendCredentialsSecret string
+
+	// Backends to add once Trident is running
+	seedBackends          []string
+	seedConcurrency       int
+	checkExistingBackends bool
+	backendConfigDir      string
+
+	// Replace a same-named iSCSI CHAP secret holding other credentials
+	replaceCHAPSecret bool
+
+	// Record of completed steps, to resume an interrupted installation
+	stateFile string
+
+	// Storage class to create once Trident is running
+	createStorageClass     string
+	defaultVolumeParamArgs []string
+
+	// CSI sidecar images
+	csiAttacherImage    string
+	csiProvisionerImage string
+	csiRegistrarImage   string
+
+	// CSI node liveness probe sidecar
+	csiLivenessProbe      bool
+	csiLivenessProbeImage string
+
+	// Host path of the CSI node plugin's socket reported to the kubelet, if not the default
+	pluginRegistrationPath string
+
+	// Node labeling
+	labelReadyNodes bool
+
+	// Number of nodes whose Trident node pods must be ready, if not every scheduled one
+	expectedNodeCount int
+
+	// Additional volumes of the controller pod
+	extraVolumesFile      string
+	extraVolumeMountsFile string
+	extraVolumes          []v1.Volume
+	extraVolumeMounts     []v1.VolumeMount
+
+	// Container security
+	readOnlyRootFS bool
+
+	// Controller affinity
+	nodeAffinityExpressions []string
+	podAntiAffinityTopology string
+
+	// Controller topology spread constraint
+	topologySpreadKey               string
+	topologySpreadMaxSkew           int32
+	topologySpreadWhenUnsatisfiable string
+
+	// Old ReplicaSets or ControllerRevisions kept by each workload
+	revisionHistoryLimit int32
+
+	// Audience of the controller's projected service account token
+	tokenAudience string
+
+	// Pod DNS config
+	dnsNameservers []string
+	dnsSearches    []string
+	dnsOptions     []string
+
+	// Network policy
+	createNetworkPolicy bool
+	backendCIDRs        []string
+
+	// Resource quotas
+	strictQuota bool
+
+	// Single-file bundle output
+	bundlePath string
+	bundlePV   bool
+
+	// Comparison with an existing installation
+	diffLive bool
+
+	// etcd snapshot from which to restore the Trident metadata
+	restoreSnapshot string
+
+	// File to which a dry run writes its pre-check report
+	dryRunOutputFile string
+
+	// Install summary notification
+	notifyWebhook          string
+	notifyWebhookHeaders   []string
+	notifyWebhookBasicAuth string
+
+	// Overlays
+	overlayDir        string
+	dumpEffectiveYAML bool
+
+	// Backend config debugging
+	showBackendConfig   bool
+	validateBackendOnly bool
+
+	// Cluster role aggregation
+	aggregateToRoles []string
+
+	// Whether Trident is bound to a cluster role created outside the installer
+	useExistingClusterRole bool
+
+	// Image signature verification
+	verifyImageSignature bool
+	imageSignatureKey    string
+
 	// Docker EE / UCP related
 	useKubernetesRBAC bool
 	ucpBearerToken    string
 	ucpHost           string
 	ucpTraceREST      bool
 
+	// API server access
+	k8sAPIServer  string
+	k8sAPIHeaders []string
+	kubeconfig    string
+	kubeContext   string
+
+	// Clusters to install in at once
+	fleetContexts     []string
+	fleetContextsFile string
+	fleetParallelism  int
+
+	// Kubernetes API server CA mounted in the Trident pod
+	k8sAPICAFile           string
+	k8sAPICAFromKubeconfig bool
+	k8sAPICA               []byte
+
 	// CLI-based K8S client
 	client k8s_client.Interface
 
@@ -90,6 +375,10 @@ var (
 	csiServicePath         string
 	csiStatefulSetPath     string
 	csiDaemonSetPath       string
+	networkPolicyPath      string
+	k8sAPICAPath           string
+	headlessServicePath    string
+	storageClassPath       string
 	setupYAMLPaths         []string
 
 	appLabel      string
@@ -98,24 +387,151 @@ var (
 
 	dns1123LabelRegex  = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
 	dns1123DomainRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	yamlLineRegex      = regexp.MustCompile(`line (\d+)`)
+
+	nodeSelectorOperators = map[selection.Operator]v1.NodeSelectorOperator{
+		selection.In:           v1.NodeSelectorOpIn,
+		selection.Equals:       v1.NodeSelectorOpIn,
+		selection.DoubleEquals: v1.NodeSelectorOpIn,
+		selection.NotIn:        v1.NodeSelectorOpNotIn,
+		selection.NotEquals:    v1.NodeSelectorOpNotIn,
+		selection.Exists:       v1.NodeSelectorOpExists,
+		selection.DoesNotExist: v1.NodeSelectorOpDoesNotExist,
+		selection.GreaterThan:  v1.NodeSelectorOpGt,
+		selection.LessThan:     v1.NodeSelectorOpLt,
+	}
 )
 
 func init() {
 	RootCmd.AddCommand(installCmd)
 	installCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Run all the pre-checks, but don't install anything.")
+	installCmd.Flags().StringVar(&dryRunOutputFile, "dry-run-output-file", "", "With --dry-run, also write a JSON report of the pre-checks to this file.")
 	installCmd.Flags().BoolVar(&generateYAML, "generate-custom-yaml", false, "Generate YAML files, but don't install anything.")
+	installCmd.Flags().StringVar(&targetK8sVersion, "target-k8s-version", "", "The Kubernetes version to generate YAML for with --generate-custom-yaml, instead of that of the cluster.")
+	installCmd.Flags().StringVar(&targetFlavor, "target-flavor", "", "The orchestrator flavor (k8s or openshift) to generate YAML for with --generate-custom-yaml, instead of that of the cluster. With --target-k8s-version, no cluster connection is needed.")
 	installCmd.Flags().BoolVar(&useYAML, "use-custom-yaml", false, "Use any existing YAML files that exist in setup directory.")
+	installCmd.Flags().StringVar(&bundlePath, "generate-bundle", "", "Write the YAML of all the objects the installer creates, except the Trident PV, to a single file, but don't install anything.")
+	installCmd.Flags().BoolVar(&bundlePV, "bundle-pv", false, "With --generate-bundle, provision the Trident volume on the storage backend and include the Trident PV, and any iSCSI CHAP secret it uses, in the bundle.")
+	installCmd.Flags().BoolVar(&diffLive, "diff", false, "Show how the objects the installer creates differ from those in the cluster, but don't install anything.")
+	installCmd.Flags().StringVar(&restoreSnapshot, "restore-from-snapshot", "", "Path to an etcd v3 snapshot of Trident's metadata to restore into a new Trident volume.")
 	installCmd.Flags().BoolVar(&silent, "silent", false, "Disable most output during installation.")
 	installCmd.Flags().BoolVar(&csi, "csi", false, "Install CSI Trident (experimental).")
 
+	installCmd.Flags().StringVar(&tridentContainerName, "container-name", tridentconfig.ContainerTrident, "The name of the main Trident container in custom YAML files.")
+
 	installCmd.Flags().StringVar(&pvcName, "pvc", "", "The name of the PVC used by Trident.")
 	installCmd.Flags().StringVar(&pvName, "pv", "", "The name of the PV used by Trident.")
 	installCmd.Flags().StringVar(&volumeName, "volume-name", "", "The name of the storage volume used by Trident.")
 	installCmd.Flags().StringVar(&volumeSize, "volume-size", DefaultVolumeSize, "The size of the storage volume used by Trident.")
+	installCmd.Flags().StringVar(&volumePool, "volume-pool", "", "The storage pool in which to create the storage volume used by Trident. See 'tridentctl backend list-pools'.")
+	installCmd.Flags().StringVar(&volumeSnapshotReserve, "volume-snapshot-reserve", "", "The percentage of the storage volume used by Trident reserved for snapshots (ontap-nas only).")
+	installCmd.Flags().BoolVar(&volumeEncryption, "volume-encryption", false, "Encrypt the storage volume used by Trident at rest, if the backend supports it (such as NVE on ONTAP).")
+	installCmd.Flags().StringVar(&cloneSourceVolume, "clone-source-volume", "", "An existing volume on the backend to clone as the storage volume used by Trident, instead of creating an empty one.")
+	installCmd.Flags().StringVar(&importVolume, "import-volume", "", "An existing volume on the backend, named as it is on the backend, to use as the storage volume used by Trident, instead of creating one.")
+	installCmd.Flags().BoolVar(&strictVersion, "strict-version", false, "Fail if the etcd image or Kubernetes version is not one Trident was qualified with.")
+	installCmd.Flags().StringVar(&volumeAccessMode, "volume-access-mode", string(v1.ReadWriteOnce), "The access mode of the Trident PVC and PV, ReadWriteOnce or ReadWriteMany (NFS only).")
+	installCmd.Flags().StringVar(&volumeQoS, "volume-qos", "", "The QoS of the storage volume used by Trident, as min,max,burst IOPS (solidfire-san only).")
 	installCmd.Flags().StringVar(&tridentImage, "trident-image", "", "The Trident image to install.")
 	installCmd.Flags().StringVar(&etcdImage, "etcd-image", "", "The etcd image to install.")
-
+	installCmd.Flags().StringVar(&imageRegistry, "image-registry", "", "The host (and optional port) of a private registry mirror from which to pull the images not specified explicitly.")
+	installCmd.Flags().StringArrayVar(&imagePullSecrets, "image-pull-secret", []string{}, "Name of a secret in the Trident namespace with which the Trident pods pull their images (repeatable).")
+	installCmd.Flags().StringVar(&csiAttacherImage, "csi-attacher-image", k8s_client.DefaultCSIAttacherImage, "The CSI attacher sidecar image to install.")
+	installCmd.Flags().StringVar(&csiProvisionerImage, "csi-provisioner-image", k8s_client.DefaultCSIProvisionerImage, "The CSI provisioner sidecar image to install.")
+	installCmd.Flags().StringVar(&csiRegistrarImage, "csi-registrar-image", k8s_client.DefaultCSIRegistrarImage, "The CSI driver registrar sidecar image to install.")
+	installCmd.Flags().StringVar(&pluginRegistrationPath, "plugin-registration-path", "", "The host path of the CSI node plugin socket the driver registrar reports to the kubelet, if the kubelet plugin directory isn't /var/lib/kubelet/plugins.")
+	installCmd.Flags().BoolVar(&csiLivenessProbe, "csi-liveness-probe", false, "Add the CSI liveness probe sidecar to the Trident node pods, which restarts an unresponsive node plugin.")
+	installCmd.Flags().StringVar(&csiLivenessProbeImage, "csi-livenessprobe-image", k8s_client.DefaultCSILivenessProbeImage, "The CSI liveness probe sidecar image to install with --csi-liveness-probe.")
+	installCmd.Flags().StringVar(&extraVolumesFile, "extra-volume", "", "Path to a JSON or YAML list of additional volumes of the Trident controller pod.")
+	installCmd.Flags().StringVar(&extraVolumeMountsFile, "extra-volume-mount", "", "Path to a JSON or YAML list of mounts of the --extra-volume volumes in the Trident container.")
+	installCmd.Flags().IntVar(&expectedNodeCount, "expected-node-count", 0, "With --csi, wait until Trident node pods are ready on at least this many nodes, rather than on every node the daemonset is scheduled to, which changes while a cluster autoscales.")
+	installCmd.Flags().BoolVar(&labelReadyNodes, "label-ready-nodes", false, "Label the nodes where the Trident node plugin is ready with "+NodeReadyLabelKey+"="+NodeReadyLabelValue+", and remove the label from other nodes.")
+
+	installCmd.Flags().StringVar(&ephemeralStorageRequest, "trident-ephemeral-storage-request", "", "The ephemeral storage request of the Trident container.")
+	installCmd.Flags().StringVar(&ephemeralStorageLimit, "trident-ephemeral-storage-limit", "", "The ephemeral storage limit of the Trident container.")
+	installCmd.Flags().StringArrayVar(&credentialsSecrets, "credentials-secret", []string{}, "Name of a secret, referenced by the credentials of a backend config, to mount in the Trident controller.")
+	installCmd.Flags().StringVar(&backendCredentialsSecret, "backend-credentials-secret", "", "Name of a secret in which to store the username and password of the --seed-backend configs, which are seeded with a reference to the secret instead.")
+	installCmd.Flags().StringArrayVar(&seedBackends, "seed-backend", []string{}, "Path to a backend config file to add to Trident once it is running.")
+	installCmd.Flags().StringVar(&backendConfigDir, "backend-config-dir", "", "Path to a directory of JSON and YAML backend configs. The first that can hold the Trident volume is used for it, and the others are added to Trident once it is running.")
+	installCmd.Flags().IntVar(&seedConcurrency, "seed-concurrency", DefaultSeedConcurrency, "The number of --seed-backend configs to add to Trident concurrently.")
+	installCmd.Flags().BoolVar(&checkExistingBackends, "check-existing-backends", false, "Compare each --seed-backend config with the backend of the same name that Trident already has, updating the backend only if the config changes it.")
+	installCmd.Flags().StringVar(&stateFile, "state-file", "", "Path of a file in which to record the completed installation steps, so an interrupted installation run again with the same file resumes where it stopped.")
+	installCmd.Flags().StringVar(&createStorageClass, "create-storage-class", "", "Name of a storage class served by Trident to create once it is running.")
+	installCmd.Flags().StringSliceVar(&defaultVolumeParamArgs, "default-volume-params", []string{}, "Parameter (e.g. 'fsType=xfs') of the --create-storage-class storage class.")
+	installCmd.Flags().BoolVar(&readOnlyRootFS, "read-only-root-fs", false, "Run the Trident controller containers with a read-only root filesystem.")
+	installCmd.Flags().DurationVar(&backendHTTPTimeout, "backend-http-timeout", 0, "The HTTP client timeout of Trident for storage backend API calls (default 1m30s).")
+	installCmd.Flags().StringVar(&schedulerName, "scheduler-name", "", "The scheduler of the Trident pods (default the cluster's default scheduler).")
+	installCmd.Flags().StringVar(&terminationMessagePath, "termination-message-path", "", "The absolute path to which the Trident and etcd containers write their termination message (default /dev/termination-log).")
+	installCmd.Flags().StringVar(&terminationMessagePolicy, "termination-message-policy", "", "The termination message policy of the Trident and etcd containers, File or FallbackToLogsOnError (default File).")
+	installCmd.Flags().BoolVar(&emitEvents, "emit-events", false, "Record the progress of the installation as Kubernetes events in the Trident namespace.")
+	installCmd.Flags().StringVar(&podHostname, "pod-hostname", "", "The hostname of the Trident controller pod.")
+	installCmd.Flags().StringVar(&podSubdomain, "pod-subdomain", "", "The subdomain of the Trident controller pod, for which a headless service is created.")
+	installCmd.Flags().BoolVar(&replaceExistingPV, "replace-existing-pv", false, "Delete a Released or Failed Trident PV and create a new one, abandoning the Trident metadata on its volume.")
+	installCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation.")
+	installCmd.Flags().BoolVar(&confirmInstall, "confirm", false, "Once the pre-checks pass, show the changes the installation will make and ask for confirmation before making them.")
+	installCmd.Flags().IntVar(&logMaxSize, "log-max-size", 0, "Write the Trident log to a file in the pod as well, rotating it at this size in MiB.")
+	installCmd.Flags().IntVar(&logMaxBackups, "log-max-backups", 1, "The number of rotated Trident log files to keep.")
+	installCmd.Flags().DurationVar(&logMaxAge, "log-max-age", 0, "The age after which rotated Trident log files are removed (default no limit).")
+	installCmd.Flags().StringVar(&controllerGOMAXPROCS, "controller-gomaxprocs", "", "GOMAXPROCS of the Trident controller, or 'auto' to match its CPU limit (the default when a CPU limit is set).")
+	installCmd.Flags().IntVar(&controllerWorkers, "controller-workers", 1, "The number of PVCs the Trident controller provisions concurrently.")
+	installCmd.Flags().Float32Var(&controllerQPS, "controller-qps", 0, "Queries per second the Trident controller may send to the Kubernetes API server (default client-go's limit).")
+	installCmd.Flags().IntVar(&controllerBurst, "controller-burst", 0, "Burst of queries the Trident controller may send to the Kubernetes API server (default client-go's limit).")
+	installCmd.Flags().DurationVar(&resyncPeriod, "resync-period", 0, "How often the Trident controller resyncs the PVCs, PVs, and storage classes it watches (default "+DefaultResyncPeriod.String()+").")
+	installCmd.Flags().BoolVar(&configChecksum, "config-checksum", false, "Annotate the Trident pods with a checksum of their images, settings, and mounted secrets, so the pods are replaced when they change.")
+	installCmd.Flags().StringArrayVar(&nodeAffinityExpressions, "node-affinity", []string{}, "Label selector expression (e.g. 'zone in (east,west)') that nodes must match to run the Trident controller.")
+	installCmd.Flags().StringVar(&podAntiAffinityTopology, "pod-anti-affinity", "", "Topology key (e.g. 'kubernetes.io/hostname') across which Trident controller pods should be spread.")
+	installCmd.Flags().StringVar(&topologySpreadKey, "topology-spread-key", "", "Topology key (e.g. 'topology.kubernetes.io/zone') across which to spread Trident controller pods.")
+	installCmd.Flags().Int32Var(&topologySpreadMaxSkew, "topology-spread-max-skew", 1, "The maximum skew of Trident controller pods across the topology spread key.")
+	installCmd.Flags().StringVar(&topologySpreadWhenUnsatisfiable, "topology-spread-when-unsatisfiable", TopologySpreadScheduleAnyway, "How to schedule a Trident controller pod that would violate the spread constraint. One of DoNotSchedule|ScheduleAnyway.")
+	installCmd.Flags().Int32Var(&revisionHistoryLimit, "revision-history-limit", DefaultRevisionHistoryLimit, "The number of old ReplicaSets or ControllerRevisions the Trident deployment, statefulset, and daemonset keep for rollback.")
+	installCmd.Flags().StringVar(&tokenAudience, "token-audience", "", "Mount a projected service account token with this audience in the Trident controller, instead of the default token with the API server's audiences.")
+	installCmd.Flags().StringSliceVar(&dnsNameservers, "dns-nameserver", []string{}, "Nameserver IP address to add to the DNS config of the Trident pods.")
+	installCmd.Flags().StringSliceVar(&dnsSearches, "dns-search", []string{}, "Search domain to add to the DNS config of the Trident pods.")
+	installCmd.Flags().StringSliceVar(&dnsOptions, "dns-option", []string{}, "Resolver option (name or name=value) to add to the DNS config of the Trident pods.")
+	installCmd.Flags().BoolVar(&replaceCHAPSecret, "replace-chap-secret", false, "Replace the contents of an existing iSCSI CHAP secret that doesn't hold the credentials of the Trident volume.")
+	installCmd.Flags().BoolVar(&createNetworkPolicy, "create-network-policy", false, "Create a NetworkPolicy that allows the traffic Trident requires.")
+	installCmd.Flags().StringSliceVar(&backendCIDRs, "backend-cidr", []string{}, "CIDR of the storage backend management interfaces to allow in the NetworkPolicy.")
+	installCmd.Flags().BoolVar(&strictQuota, "strict-quota", false, "Fail if the namespace resource quotas would not admit the Trident objects.")
+	installCmd.Flags().StringVar(&overlayDir, "overlay-dir", "", "Directory of strategic merge patches to apply to the generated YAML files.")
+	installCmd.Flags().BoolVar(&dumpEffectiveYAML, "dump-effective-yaml", false, "Print the YAML of each object as it will be created, after applying any overlays, to stderr.")
+	installCmd.Flags().BoolVar(&validateBackendOnly, "validate-backend-only", false, "With --dry-run, only check that the storage backend config is well-formed and complete, without contacting the storage system or running the other pre-checks.")
+	installCmd.Flags().BoolVar(&showBackendConfig, "show-backend-secrets-redacted", false, "Print the storage backend config as loaded by the installer, with its credentials redacted.")
+	installCmd.Flags().BoolVar(&verifyImageSignature, "verify-image-signature", false, "Verify the cosign signature of the Trident image before installing.")
+	installCmd.Flags().StringVar(&imageSignatureKey, "image-signature-key", "", "Path to the public key used to verify the Trident image signature.")
+
+	installCmd.Flags().StringVar(&notifyWebhook, "notify-webhook", "", "URL to which to POST the JSON install summary when the installation completes or fails.")
+	installCmd.Flags().StringArrayVar(&notifyWebhookHeaders, "notify-webhook-header", []string{}, "Header (e.g. 'Authorization: Bearer token') to add to the webhook request.")
+	installCmd.Flags().StringVar(&notifyWebhookBasicAuth, "notify-webhook-basic-auth", "", "Credentials, as user:password, with which to authenticate the webhook request.")
+
+	installCmd.Flags().BoolVar(&waitForNamespace, "wait-for-namespace", false, "If the Trident namespace is terminating, wait for it to be deleted and then recreate it.")
+	installCmd.Flags().BoolVar(&pruneVolumeAttachments, "prune-volume-attachments", false, "Remove volume attachments of the Trident CSI driver, left by an earlier installation, that no pod uses.")
+	installCmd.Flags().BoolVar(&retainFailedPod, "retain-failed-pod", false, "Leave a Trident pod that fails to start in place for debugging.")
+	installCmd.Flags().BoolVar(&abortOnEviction, "abort-on-eviction", false, "Fail the installation if a Trident pod is evicted, instead of waiting for its replacement.")
+
+	installCmd.Flags().BoolVar(&deepCheck, "deep-check", false, "Also run checks that launch short-lived diagnostic pods, such as probing the MTU to the backend.")
+	installCmd.Flags().StringVar(&diagnosticImage, "diagnostic-image", DefaultDiagnosticImage, "The image of the diagnostic pods launched by --deep-check and --check-node-prep.")
+	installCmd.Flags().IntVar(&backendMTU, "backend-mtu", DefaultBackendMTU, "The MTU the backend's data network is configured for, checked by --deep-check.")
+	installCmd.Flags().BoolVar(&checkNodePrep, "check-node-prep", false, "Sample the inotify and open file limits on each node with diagnostic pods, and warn about limits below the thresholds.")
+	installCmd.Flags().Int64Var(&minInotifyWatches, "min-inotify-watches", DefaultMinInotifyWatches, "The fs.inotify.max_user_watches below which --check-node-prep warns.")
+	installCmd.Flags().Int64Var(&minInotifyInstances, "min-inotify-instances", DefaultMinInotifyInstances, "The fs.inotify.max_user_instances below which --check-node-prep warns.")
+	installCmd.Flags().Int64Var(&minOpenFiles, "min-open-files", DefaultMinOpenFiles, "The container open file limit below which --check-node-prep warns.")
+	installCmd.Flags().StringVar(&installProfileName, "profile", "", "A built-in profile of install flag defaults ("+strings.Join(getInstallProfileNames(), ", ")+"). Flags specified on the command line override the profile.")
 	installCmd.Flags().DurationVar(&k8sTimeout, "k8s-timeout", 180*time.Second, "The number of seconds to wait before timing out on Kubernetes operations.")
+	installCmd.Flags().DurationVar(&pvcBindTimeout, "pvc-bind-timeout", 0, "How long to wait for the Trident PVC to be bound, instead of --k8s-timeout.")
+	installCmd.Flags().DurationVar(&podStartTimeout, "pod-start-timeout", 0, "How long to wait for the Trident pod to be running, instead of --k8s-timeout.")
+	installCmd.Flags().DurationVar(&restTimeout, "rest-timeout", 0, "How long to wait for the Trident REST interface to be available, instead of --k8s-timeout.")
+	installCmd.Flags().StringSliceVar(&stepTimeoutArgs, "step-timeout", []string{}, "Timeout (e.g. 'pv=10m') of an installation step, instead of --k8s-timeout. Steps: "+strings.Join(getInstallStepNames(), ", ")+".")
+	installCmd.Flags().StringVar(&k8sAPIServer, "k8s-api-server", "", "URL of the Kubernetes API server, or of a proxy or bastion in front of it.")
+	installCmd.Flags().StringArrayVar(&k8sAPIHeaders, "k8s-api-header", []string{}, "Header (e.g. 'X-Proxy-Token: value') to add to every Kubernetes API request. Requires --k8s-api-server.")
+	installCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use for Kubernetes API requests.")
+	installCmd.Flags().StringVar(&k8sAPICAFile, "k8s-api-ca", "", "Path to a PEM file of the Kubernetes API server CA to mount in the Trident controller.")
+	installCmd.Flags().BoolVar(&k8sAPICAFromKubeconfig, "k8s-api-ca-from-kubeconfig", false, "Mount the Kubernetes API server CA of the current kubeconfig context in the Trident controller.")
+	installCmd.Flags().StringVar(&kubeContext, "context", "", "The kubeconfig context to use for Kubernetes API requests.")
+	installCmd.Flags().StringSliceVar(&fleetContexts, "contexts", []string{}, "Comma-separated kubeconfig contexts of clusters to install Trident in at once.")
+	installCmd.Flags().StringVar(&fleetContextsFile, "contexts-file", "", "Path to a file listing the clusters to install Trident in at once, one per line as a context, or as a kubeconfig path and a context.")
+	installCmd.Flags().IntVar(&fleetParallelism, "parallelism", DefaultFleetParallelism, "The number of clusters to install Trident in at the same time with --contexts or --contexts-file.")
+
+	installCmd.Flags().BoolVar(&useExistingClusterRole, "use-existing-clusterrole", false, "Bind Trident to an existing cluster role with the name of the Trident cluster role instead of creating it, after checking that it grants what Trident needs.")
+	installCmd.Flags().StringSliceVar(&aggregateToRoles, "aggregate-to-roles", []string{}, "Comma-separated default cluster roles (admin or edit) to aggregate the Trident cluster role into.")
 
 	installCmd.Flags().StringVar(&ucpBearerToken, "ucp-bearer-token", "", "UCP authorization token.")
 	installCmd.Flags().StringVar(&ucpHost, "ucp-host", "", "IP address of the UCP host.")
@@ -126,19 +542,92 @@ var installCmd = &cobra.Command{
 	Short: "Install Trident",
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 
+		// Fill in the profile's defaults before any flag is used
+		profileFlags, profileErr := applyInstallProfile(cmd.Flags(), installProfileName)
+
 		initInstallerLogging()
+		if profileErr != nil {
+			log.Fatalf("Invalid arguments; %v", profileErr)
+		} else if installProfileName != "" {
+			log.WithFields(log.Fields{
+				"profile": installProfileName,
+				"flags":   strings.Join(profileFlags, " "),
+			}).Info("Applied install profile.")
+		}
+
+		// Keep stdout clear for the install summary
+		if OutputFormat != "" {
+			log.SetOutput(os.Stderr)
+		}
+
+		// Installing in several clusters runs the installer for each one, which does its own checks
+		if isFleetInstall() {
+			log.SetOutput(os.Stderr)
+			if err := validateFleetArguments(); err != nil {
+				log.Fatalf("Invalid arguments; %v", err)
+			}
+			if OutputFormat != "" && OutputFormat != FormatJSON && OutputFormat != FormatYAML {
+				log.Fatalf("Invalid arguments; the install report may only be written as %s or %s",
+					FormatJSON, FormatYAML)
+			}
+			return
+		}
+
+		// Collect the warnings of a dry run for its pre-check report, which is only written once
+		// its own options are known to be valid
+		if err := validateDryRunOutputArguments(); err != nil {
+			log.Fatalf("Invalid arguments; %v", err)
+		}
+		if dryRunOutputFile != "" {
+			log.AddHook(&preCheckWarningHook{})
+		}
 
 		if err := discoverInstallationEnvironment(); err != nil {
+			writeDryRunReport(err, cmd.Flags())
 			log.Fatalf("Install pre-checks failed; %v", err)
 		}
+		preCheckPassed("environment", "Kubernetes "+getKubernetesVersion().String())
+		volumeAccessModeSpecified = cmd.Flags().Changed("volume-access-mode")
 		processInstallationArguments()
 		if err := validateInstallationArguments(); err != nil {
 			log.Fatalf("Invalid arguments; %v", err)
 		}
+		preCheckPassed("arguments", "")
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
-		if generateYAML {
+		if isFleetInstall() {
+
+			// If contexts were specified, install in each cluster and write the combined report
+			report, err := installFleet()
+			if err != nil {
+				log.Fatalf("Install failed; %v", err)
+			}
+			if OutputFormat == FormatYAML {
+				WriteYAML(report)
+			} else {
+				WriteJSON(report)
+			}
+			if !report.Succeeded {
+				os.Exit(1)
+			}
+
+		} else if bundlePath != "" {
+
+			// If generate-bundle was specified, write all the YAML to one multi-document file
+			if err := writeBundle(bundlePath); err != nil {
+				log.Fatalf("Bundle generation failed; %v", err)
+			}
+			log.WithField("bundle", bundlePath).Info("Wrote installation bundle.")
+
+		} else if diffLive {
+
+			// If diff was specified, compare the generated objects with the live ones
+			if err := diffInstallation(); err != nil {
+				log.Fatalf("Diff failed; %v", err)
+			}
+
+		} else if generateYAML {
 
 			// If generate-custom-yaml was specified, write the YAML files to the setup directory
 			if csi {
@@ -155,7 +644,20 @@ var installCmd = &cobra.Command{
 		} else {
 
 			// Run the installer
-			if err := installTrident(); err != nil {
+			err := installTrident()
+			if dryRun {
+				writeDryRunReport(err, cmd.Flags())
+			}
+			emitInstallResultEvent(err)
+			writeInstallSummary(err)
+			if notifyWebhook != "" {
+				notifyInstallWebhook(err)
+			}
+			if err != nil {
+				if retainFailedPod {
+					log.Fatalf("Install failed; %v.  The Trident pod was retained for debugging; when done, "+
+						"use 'tridentctl uninstall' to clean up and try again.", err)
+				}
 				log.Fatalf("Install failed; %v.  Resolve the issue; use 'tridentctl uninstall' "+
 					"to clean up; and try again.", err)
 			}
@@ -182,6 +684,51 @@ func initInstallerLogging() {
 	log.WithField("logLevel", log.GetLevel().String()).Debug("Initialized logging.")
 }
 
+// getKubectlConfig returns the Kubernetes API server access settings specified on the command line.
+func getKubectlConfig() (k8s_client.KubectlConfig, error) {
+
+	config := k8s_client.KubectlConfig{
+		Server:     k8sAPIServer,
+		Kubeconfig: kubeconfig,
+		Context:    kubeContext,
+	}
+
+	if len(k8sAPIHeaders) > 0 {
+		if k8sAPIServer == "" {
+			return config, errors.New("--k8s-api-header requires --k8s-api-server")
+		}
+		headers, err := parseHTTPHeaders(k8sAPIHeaders)
+		if err != nil {
+			return config, err
+		}
+		config.Headers = headers
+	}
+
+	if k8sAPIServer != "" {
+		serverURL, err := url.Parse(k8sAPIServer)
+		if err != nil || serverURL.Scheme == "" || serverURL.Host == "" {
+			return config, fmt.Errorf("invalid Kubernetes API server URL '%s'", k8sAPIServer)
+		}
+	}
+
+	return config, nil
+}
+
+// parseHTTPHeaders parses headers specified on the command line as 'Name: value'.
+func parseHTTPHeaders(headers []string) (map[string]string, error) {
+
+	headerMap := make(map[string]string)
+	for _, header := range headers {
+		nameValue := strings.SplitN(header, ":", 2)
+		name := strings.TrimSpace(nameValue[0])
+		if len(nameValue) != 2 || name == "" {
+			return nil, fmt.Errorf("invalid HTTP header '%s'; expected 'Name: value'", header)
+		}
+		headerMap[name] = strings.TrimSpace(nameValue[1])
+	}
+	return headerMap, nil
+}
+
 // discoverInstallationEnvironment inspects the current environment and checks
 // that everything looks good for Trident installation, but it makes no changes
 // to the environment.
@@ -193,27 +740,66 @@ func discoverInstallationEnvironment() error {
 
 	// Default deployment image to what Trident was built with
 	if tridentImage == "" {
-		tridentImage = tridentconfig.BuildImage
+		tridentImage = getRegistryImage(tridentconfig.BuildImage)
 	}
 
 	// Default deployment image to what etcd was built with
 	if etcdImage == "" {
-		etcdImage = tridentconfig.BuildEtcdImage
-	} else if !strings.Contains(etcdImage, tridentconfig.BuildEtcdVersion) {
-		log.Warningf("Trident was qualified with etcd %s. You appear to be using a different version.", tridentconfig.BuildEtcdVersion)
+		etcdImage = getRegistryImage(tridentconfig.BuildEtcdImage)
 	}
 
+	// Pull the default sidecar images from the same mirror
+	applyImageRegistry()
+
 	// Ensure we're on Linux
 	if runtime.GOOS != "linux" {
 		return errors.New("the Trident installer only runs on Linux")
 	}
 
+	if err = parseTargetEnvironment(); err != nil {
+		return err
+	}
+
+	// Generating YAML for a known version and flavor doesn't need the cluster, which may not exist yet
+	if isOfflineGeneration() {
+		if TridentPodNamespace == "" {
+			TridentPodNamespace = PreferredNamespace
+		}
+		if err = checkQualifiedVersions(); err != nil {
+			return err
+		}
+		if err = checkImagePullSecrets(false); err != nil {
+			return err
+		}
+		if err = prepareYAMLFilePaths(); err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"installationNamespace": TridentPodNamespace,
+			"kubernetesVersion":     targetVersion.String(),
+			"flavor":                targetFlavor,
+		}).Info("Generating YAML without a cluster connection.")
+		return nil
+	}
+
 	// Create the CLI-based Kubernetes client
-	client, err = k8s_client.NewKubectlClient()
+	kubectlConfig, err := getKubectlConfig()
+	if err != nil {
+		return err
+	}
+	client, err = k8s_client.NewKubectlClientForConfig(kubectlConfig)
 	if err != nil {
+		if kubectlConfig.Server != "" {
+			return fmt.Errorf("could not reach the Kubernetes API server at %s; %v", kubectlConfig.Server, err)
+		}
 		return fmt.Errorf("could not initialize Kubernetes client; %v", err)
 	}
 
+	// Ensure the versions are ones Trident was qualified with, if required
+	if err = checkQualifiedVersions(); err != nil {
+		return err
+	}
+
 	useKubernetesRBAC = true
 	if ucpBearerToken != "" || ucpHost != "" {
 		useKubernetesRBAC = false
@@ -242,14 +828,186 @@ func discoverInstallationEnvironment() error {
 	// Direct all subsequent client commands to the chosen namespace
 	client.SetNamespace(TridentPodNamespace)
 
+	// The image pull secrets must already exist, as the installer doesn't create them
+	if err = checkImagePullSecrets(true); err != nil {
+		return err
+	}
+
 	log.WithFields(log.Fields{
 		"installationNamespace": TridentPodNamespace,
-		"kubernetesVersion":     client.Version().String(),
+		"kubernetesVersion":     getKubernetesVersion().String(),
 	}).Debug("Validated Trident installation environment.")
 
 	return nil
 }
 
+// parseTargetEnvironment validates the Kubernetes version and flavor to generate YAML for, if any.
+func parseTargetEnvironment() error {
+
+	if targetK8sVersion == "" && targetFlavor == "" {
+		return nil
+	}
+	if !generateYAML {
+		return errors.New("--target-k8s-version and --target-flavor may only be used with --generate-custom-yaml")
+	}
+	return parseTargetVersionAndFlavor()
+}
+
+// parseTargetVersionAndFlavor validates the Kubernetes version and flavor specified on the command
+// line, and sets targetVersion if a version was specified.
+func parseTargetVersionAndFlavor() error {
+
+	if targetK8sVersion != "" {
+		version, err := utils.ParseSemantic(targetK8sVersion)
+		if err != nil {
+			return fmt.Errorf("invalid --target-k8s-version '%s'; %v", targetK8sVersion, err)
+		}
+		minSupportedVersion := utils.MustParseSemantic(tridentconfig.KubernetesVersionMin)
+		if !version.AtLeast(minSupportedVersion) {
+			return fmt.Errorf("Trident requires Kubernetes %s or later", minSupportedVersion.ShortString())
+		}
+		targetVersion = version
+	}
+
+	switch k8s_client.OrchestratorFlavor(targetFlavor) {
+	case "", k8s_client.FlavorKubernetes, k8s_client.FlavorOpenShift:
+	default:
+		return fmt.Errorf("--target-flavor must be %s or %s, not '%s'", k8s_client.FlavorKubernetes,
+			k8s_client.FlavorOpenShift, targetFlavor)
+	}
+
+	return nil
+}
+
+// isOfflineGeneration returns whether YAML is generated for a specified Kubernetes version and
+// flavor, in which case there is no Kubernetes client.
+func isOfflineGeneration() bool {
+	return generateYAML && targetVersion != nil && targetFlavor != ""
+}
+
+// getKubernetesVersion returns the Kubernetes version to install for, which is the cluster's
+// unless --target-k8s-version was specified.
+func getKubernetesVersion() *utils.Version {
+	if targetVersion != nil {
+		return targetVersion
+	}
+	return client.Version()
+}
+
+// refreshKubernetesVersion discovers the Kubernetes version again before the installation step
+// that depends on it, since a cluster being upgraded may change versions during a long
+// installation.  A change of minor version is logged, as earlier steps were based on the earlier
+// version.
+func refreshKubernetesVersion(step string) {
+
+	previousVersion := client.Version()
+	currentVersion, err := client.RefreshVersion()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"step":  step,
+			"error": err,
+		}).Debug("Could not refresh the Kubernetes version.")
+		return
+	}
+
+	if err = k8s_client.CheckVersionSkew(previousVersion, currentVersion); err != nil {
+		log.WithField("step", step).Warningf("%v during installation, as when the cluster is upgraded; "+
+			"earlier steps were based on Kubernetes %s.", err, previousVersion.ShortString())
+		installResult.K8sVersionSkew = true
+	}
+}
+
+// getKubernetesFlavor returns the orchestrator flavor to install for, which is the cluster's
+// unless --target-flavor was specified.
+func getKubernetesFlavor() k8s_client.OrchestratorFlavor {
+	if targetFlavor != "" {
+		return k8s_client.OrchestratorFlavor(targetFlavor)
+	}
+	return client.Flavor()
+}
+
+// checkQualifiedVersions compares the etcd image and the Kubernetes version with the ones Trident
+// was qualified with.  Any mismatch is a warning, or an error with --strict-version.
+func checkQualifiedVersions() error {
+
+	var problems []string
+
+	if !strings.Contains(etcdImage, tridentconfig.BuildEtcdVersion) {
+		problems = append(problems, fmt.Sprintf("Trident was qualified with etcd %s, but the etcd image is %s",
+			tridentconfig.BuildEtcdVersion, etcdImage))
+	}
+	if err := k8s_client.CheckQualifiedVersion(getKubernetesVersion()); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	for _, problem := range problems {
+		if strictVersion {
+			return fmt.Errorf("%s; remove --strict-version to install anyway", problem)
+		}
+		log.Warning(problem + ".")
+	}
+	return nil
+}
+
+// getImageTagVersion returns the version in the tag of an image, or nil if the image has no tag,
+// is specified by digest, or has a tag that isn't a version, such as 'latest'.
+func getImageTagVersion(image string) *utils.Version {
+
+	if strings.Contains(image, "@") {
+		return nil
+	}
+	tagIndex := strings.LastIndex(image, ":")
+	if tagIndex < strings.LastIndex(image, "/") || tagIndex < 0 {
+		return nil
+	}
+	version, err := utils.ParseDate(image[tagIndex+1:])
+	if err != nil {
+		return nil
+	}
+	return version
+}
+
+// checkTridentVersion compares the version reported by the running Trident with the version in
+// the tag of the Trident image.  With an image pull policy of IfNotPresent, a node may keep
+// running a stale image that was pushed with the same name, so a mismatch is a warning, or an
+// error with --strict-version.
+func checkTridentVersion(serverVersion string) error {
+
+	installResult.TridentVersion = serverVersion
+
+	imageVersion := getImageTagVersion(tridentImage)
+	if imageVersion == nil {
+		log.WithField("image", tridentImage).Debug("Trident image tag is not a version, not checking " +
+			"the Trident version.")
+		return nil
+	}
+	installResult.ImageVersion = imageVersion.String()
+
+	runningVersion, err := utils.ParseDate(serverVersion)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"version": serverVersion,
+			"error":   err,
+		}).Warning("Could not parse the Trident version.")
+		return nil
+	}
+
+	if runningVersion.MajorVersion() == imageVersion.MajorVersion() &&
+		runningVersion.MinorVersion() == imageVersion.MinorVersion() &&
+		runningVersion.PatchVersion() == imageVersion.PatchVersion() {
+		return nil
+	}
+
+	installResult.VersionMismatch = true
+	problem := fmt.Sprintf("Trident reports version %s, but the Trident image %s is version %s; a node may "+
+		"have a stale copy of the image", serverVersion, tridentImage, imageVersion.String())
+	if strictVersion {
+		return fmt.Errorf("%s; remove --strict-version to install anyway", problem)
+	}
+	log.Warning(problem + ".")
+	return nil
+}
+
 func processInstallationArguments() {
 
 	if pvcName == "" {
@@ -303,555 +1061,2600 @@ func validateInstallationArguments() error {
 	if !dns1123DomainRegex.MatchString(pvName) {
 		return fmt.Errorf("'%s' is not a valid PV name; %s", pvName, subdomainFormat)
 	}
-
-	return nil
-}
-
-// prepareYAMLFilePaths sets up the absolute file paths to all files
-func prepareYAMLFilePaths() error {
-
-	var err error
-
-	// Get directory of installer
-	installerDirectoryPath, err = filepath.Abs(filepath.Dir(os.Args[0]))
-	if err != nil {
-		return fmt.Errorf("could not determine installer working directory; %v", err)
+	if volumeAccessMode != string(v1.ReadWriteOnce) && volumeAccessMode != string(v1.ReadWriteMany) {
+		return fmt.Errorf("--volume-access-mode must be %s or %s, not '%s'", v1.ReadWriteOnce, v1.ReadWriteMany,
+			volumeAccessMode)
 	}
-
-	setupPath = path.Join(installerDirectoryPath, "setup")
-	backendConfigFilePath = path.Join(setupPath, BackendConfigFilename)
-	namespacePath = path.Join(setupPath, NamespaceFilename)
-	serviceAccountPath = path.Join(setupPath, ServiceAccountFilename)
-	clusterRolePath = path.Join(setupPath, ClusterRoleFilename)
-	clusterRoleBindingPath = path.Join(setupPath, ClusterRoleBindingFilename)
-	pvcPath = path.Join(setupPath, PVCFilename)
-	deploymentPath = path.Join(setupPath, DeploymentFilename)
-	csiServicePath = path.Join(setupPath, ServiceFilename)
-	csiStatefulSetPath = path.Join(setupPath, StatefulSetFilename)
-	csiDaemonSetPath = path.Join(setupPath, DaemonSetFilename)
-
-	setupYAMLPaths = []string{
-		namespacePath, serviceAccountPath, clusterRolePath, clusterRoleBindingPath,
-		pvcPath, deploymentPath, csiServicePath, csiStatefulSetPath, csiDaemonSetPath,
+	if podHostname != "" && !dns1123LabelRegex.MatchString(podHostname) {
+		return fmt.Errorf("'%s' is not a valid pod hostname; %s", podHostname, labelFormat)
 	}
-
-	return nil
-}
-
-func cleanYAMLFiles() {
-
-	for _, filePath := range setupYAMLPaths {
-		os.Remove(filePath)
+	if podSubdomain != "" && !dns1123LabelRegex.MatchString(podSubdomain) {
+		return fmt.Errorf("'%s' is not a valid pod subdomain; %s", podSubdomain, labelFormat)
+	}
+	if (podHostname != "" || podSubdomain != "") && csi {
+		return errors.New("--pod-hostname and --pod-subdomain are not supported with --csi")
+	}
+	if schedulerName != "" && !dns1123DomainRegex.MatchString(schedulerName) {
+		return fmt.Errorf("'%s' is not a valid scheduler name; %s", schedulerName, subdomainFormat)
+	}
+	if terminationMessagePath != "" && !path.IsAbs(terminationMessagePath) {
+		return fmt.Errorf("--termination-message-path must be an absolute path, not '%s'", terminationMessagePath)
+	}
+	switch v1.TerminationMessagePolicy(terminationMessagePolicy) {
+	case "", v1.TerminationMessageReadFile, v1.TerminationMessageFallbackToLogsOnError:
+	default:
+		return fmt.Errorf("--termination-message-policy must be %s or %s, not '%s'",
+			v1.TerminationMessageReadFile, v1.TerminationMessageFallbackToLogsOnError, terminationMessagePolicy)
+	}
+	if revisionHistoryLimit < 0 {
+		return errors.New("--revision-history-limit must not be negative")
+	}
+	if tridentContainerName != tridentconfig.ContainerTrident && !useYAML {
+		return errors.New("--container-name may only be specified with --use-custom-yaml")
+	}
+	if bundlePath != "" && (generateYAML || useYAML) {
+		return errors.New("--generate-bundle may not be combined with --generate-custom-yaml or --use-custom-yaml")
+	}
+	if bundlePV && bundlePath == "" {
+		return errors.New("--bundle-pv may only be specified with --generate-bundle")
+	}
+	if diffLive && (bundlePath != "" || generateYAML) {
+		return errors.New("--diff may not be combined with --generate-bundle or --generate-custom-yaml")
+	}
+	if OutputFormat != "" {
+		if OutputFormat != FormatJSON && OutputFormat != FormatYAML {
+			return fmt.Errorf("the install summary may only be written as %s or %s", FormatJSON, FormatYAML)
+		}
+		if bundlePath != "" || generateYAML || diffLive {
+			return errors.New("--output may not be combined with --diff, --generate-bundle, or --generate-custom-yaml")
+		}
+	}
+	if err := validateNotifyWebhookArguments(); err != nil {
+		return err
+	}
+	if err := validateFleetArguments(); err != nil {
+		return err
+	}
+	if err := validateExtraVolumeArguments(); err != nil {
+		return err
+	}
+	if err := validateStepTimeoutArguments(); err != nil {
+		return err
+	}
+	if err := validateBackendConfigDirArguments(); err != nil {
+		return err
+	}
+	if err := validateSeedBackendArguments(); err != nil {
+		return err
+	}
+	if err := validateBackendCredentialsSecretArguments(); err != nil {
+		return err
+	}
+	if err := validateStorageClassArguments(); err != nil {
+		return err
+	}
+	if err := validateStateFileArguments(); err != nil {
+		return err
+	}
+	if err := validateRestoreSnapshotArguments(); err != nil {
+		return err
+	}
+	if volumeQoS != "" {
+		if err := validateVolumeQoS(volumeQoS); err != nil {
+			return err
+		}
+	}
+	if backendMTU < MinBackendMTU || backendMTU > MaxBackendMTU {
+		return fmt.Errorf("--backend-mtu must be between %d and %d", MinBackendMTU, MaxBackendMTU)
+	}
+	if deepCheck || checkNodePrep {
+		if err := validateImageName("diagnostic-image", diagnosticImage); err != nil {
+			return err
+		}
+	}
+	if cloneSourceVolume != "" && volumeEncryption {
+		return errors.New("--volume-encryption cannot be used with --clone-source-volume, as a clone " +
+			"is encrypted only if its source volume is")
+	}
+	if cloneSourceVolume != "" && volumePool != "" {
+		return errors.New("--volume-pool cannot be used with --clone-source-volume, as a clone is " +
+			"created in the pool of its source volume")
+	}
+	if importVolume != "" {
+		switch {
+		case cloneSourceVolume != "":
+			return errors.New("--import-volume cannot be used with --clone-source-volume")
+		case volumePool != "":
+			return errors.New("--volume-pool cannot be used with --import-volume, as an imported volume " +
+				"stays in its pool")
+		case volumeEncryption:
+			return errors.New("--volume-encryption cannot be used with --import-volume, as an imported " +
+				"volume is encrypted only if it was created encrypted")
+		}
+	}
+	if volumeSnapshotReserve != "" {
+		if reserve, err := strconv.Atoi(volumeSnapshotReserve); err != nil || reserve < 0 || reserve >= 100 {
+			return fmt.Errorf("--volume-snapshot-reserve must be a percentage from 0 to 99, not '%s'",
+				volumeSnapshotReserve)
+		}
+		if cloneSourceVolume != "" || importVolume != "" {
+			return errors.New("--volume-snapshot-reserve cannot be used with --clone-source-volume or " +
+				"--import-volume, as the installer doesn't create the volume")
+		}
+	}
+	if backendHTTPTimeout != 0 &&
+		(backendHTTPTimeout < MinBackendHTTPTimeout || backendHTTPTimeout > MaxBackendHTTPTimeout) {
+		return fmt.Errorf("--backend-http-timeout must be between %v and %v", MinBackendHTTPTimeout,
+			MaxBackendHTTPTimeout)
+	}
+	if controllerGOMAXPROCS != "" && controllerGOMAXPROCS != GOMAXPROCSAuto {
+		if procs, err := strconv.Atoi(controllerGOMAXPROCS); err != nil || procs < 1 {
+			return fmt.Errorf("--controller-gomaxprocs must be a positive integer or '%s', not '%s'",
+				GOMAXPROCSAuto, controllerGOMAXPROCS)
+		}
+	}
+	if err := validateImageRegistryArguments(); err != nil {
+		return err
+	}
+	for flagName, image := range map[string]string{
+		"csi-attacher-image":      csiAttacherImage,
+		"csi-provisioner-image":   csiProvisionerImage,
+		"csi-registrar-image":     csiRegistrarImage,
+		"csi-livenessprobe-image": csiLivenessProbeImage,
+	} {
+		if err := validateImageName(flagName, image); err != nil {
+			return err
+		}
+	}
+	if controllerWorkers < 1 {
+		return fmt.Errorf("--controller-workers must be positive, not %d", controllerWorkers)
+	}
+	if controllerWorkers > 1 && csi {
+		return errors.New("--controller-workers is not supported with --csi")
+	}
+	if err := validateAPIRateLimitArguments(); err != nil {
+		return err
+	}
+	if err := validateResyncPeriodArguments(); err != nil {
+		return err
+	}
+	if err := validateAggregateToRolesArguments(); err != nil {
+		return err
+	}
+	if csiLivenessProbe && !csi {
+		return errors.New("--csi-liveness-probe may only be specified with --csi")
+	}
+	if csiLivenessProbeImage != k8s_client.DefaultCSILivenessProbeImage && !csiLivenessProbe {
+		return errors.New("--csi-livenessprobe-image may only be specified with --csi-liveness-probe")
+	}
+	if labelReadyNodes && !csi {
+		return errors.New("--label-ready-nodes may only be specified with --csi")
+	}
+	if err := validateNodePrepArguments(); err != nil {
+		return err
+	}
+	if err := validatePluginRegistrationArguments(); err != nil {
+		return err
+	}
+	if err := validateExistingClusterRoleArguments(); err != nil {
+		return err
+	}
+	if validateBackendOnly && !dryRun {
+		return errors.New("--validate-backend-only may only be specified with --dry-run")
+	}
+	if validateBackendOnly && backendConfigDir != "" {
+		return errors.New("--validate-backend-only cannot be used with --backend-config-dir")
+	}
+	if pruneVolumeAttachments && !csi {
+		return errors.New("--prune-volume-attachments may only be specified with --csi")
+	}
+	if expectedNodeCount < 0 {
+		return fmt.Errorf("--expected-node-count must be positive, not %d", expectedNodeCount)
+	}
+	if expectedNodeCount > 0 && !csi {
+		return errors.New("--expected-node-count may only be specified with --csi")
+	}
+	if err := validateLogRotationArguments(); err != nil {
+		return err
+	}
+	if err := validateEphemeralStorageArguments(); err != nil {
+		return err
+	}
+	if err := validateDNSConfigArguments(); err != nil {
+		return err
+	}
+	if _, err := getControllerAffinity(); err != nil {
+		return err
+	}
+	if err := validateTopologySpreadArguments(); err != nil {
+		return err
+	}
+	if len(backendCIDRs) > 0 && !createNetworkPolicy {
+		return errors.New("--backend-cidr requires --create-network-policy")
+	}
+	for _, cidr := range backendCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("'%s' is not a valid backend CIDR; %v", cidr, err)
+		}
+	}
+	if overlayDir != "" {
+		if info, err := os.Stat(overlayDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("overlay directory %s does not exist", overlayDir)
+		}
+	}
+	if err := validateK8sAPICAArguments(); err != nil {
+		return err
+	}
+	if verifyImageSignature {
+		if imageSignatureKey == "" {
+			return errors.New("--image-signature-key must be specified with --verify-image-signature")
+		}
+		if !fileExists(imageSignatureKey) {
+			return fmt.Errorf("image signature key %s does not exist", imageSignatureKey)
+		}
+	}
+
+	return nil
+}
+
+// validateVolumeQoS checks that a volume QoS is specified as min,max,burst IOPS, with each
+// limit no lower than the one before it.
+func validateVolumeQoS(qos string) error {
+
+	limits := strings.Split(qos, ",")
+	if len(limits) != 3 {
+		return fmt.Errorf("invalid volume QoS '%s'; expected min,max,burst IOPS", qos)
+	}
+	var previous int64
+	for _, limit := range limits {
+		iops, err := strconv.ParseInt(strings.TrimSpace(limit), 10, 64)
+		if err != nil || iops < 1 {
+			return fmt.Errorf("invalid volume QoS '%s'; IOPS limits must be positive integers", qos)
+		}
+		if iops < previous {
+			return fmt.Errorf("invalid volume QoS '%s'; expected min <= max <= burst IOPS", qos)
+		}
+		previous = iops
+	}
+
+	return nil
+}
+
+// validateK8sAPICAArguments checks the Kubernetes API server CA options and loads the CA,
+// which must contain at least one certificate, none of them expired.
+func validateK8sAPICAArguments() error {
+
+	if k8sAPICAFile == "" && !k8sAPICAFromKubeconfig {
+		return nil
+	}
+	if k8sAPICAFile != "" && k8sAPICAFromKubeconfig {
+		return errors.New("--k8s-api-ca may not be combined with --k8s-api-ca-from-kubeconfig")
+	}
+	if csi {
+		return errors.New("a custom Kubernetes API server CA is not supported with --csi")
 	}
-}
-
-func prepareYAMLFiles() error {
 
 	var err error
+	if k8sAPICAFile != "" {
+		if k8sAPICA, err = ioutil.ReadFile(k8sAPICAFile); err != nil {
+			return fmt.Errorf("could not read Kubernetes API server CA; %v", err)
+		}
+	} else {
+		if isOfflineGeneration() {
+			return errors.New("--k8s-api-ca-from-kubeconfig requires a cluster connection; use --k8s-api-ca")
+		}
+		if k8sAPICA, err = client.GetAPIServerCA(); err != nil {
+			return err
+		}
+		if len(k8sAPICA) == 0 {
+			return errors.New("the current kubeconfig context does not specify an API server CA")
+		}
+	}
 
-	cleanYAMLFiles()
+	certs, err := parsePEMCertificates(k8sAPICA)
+	if err != nil {
+		return fmt.Errorf("invalid Kubernetes API server CA; %v", err)
+	}
+	for _, cert := range certs {
+		if time.Now().After(cert.NotAfter) {
+			return fmt.Errorf("Kubernetes API server CA certificate '%s' expired at %v",
+				cert.Subject.CommonName, cert.NotAfter)
+		}
+	}
+
+	return nil
+}
+
+// parsePEMCertificates returns the certificates in a PEM bundle, or an error if the bundle
+// contains anything else.
+func parsePEMCertificates(pemBytes []byte) ([]*x509.Certificate, error) {
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block of type %s", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificates found")
+	}
+
+	return certs, nil
+}
+
+// verifyK8sAPICA checks that the API server presents a certificate signed by the CA to be
+// mounted in the Trident pod.
+func verifyK8sAPICA() error {
+
+	// A proxy or bastion presents its own certificate, so only a direct connection may be checked
+	if k8sAPIServer != "" {
+		log.WithField("server", k8sAPIServer).Warning("The Kubernetes API server is accessed through " +
+			"--k8s-api-server, so the API server CA can't be verified.")
+		return nil
+	}
+
+	serverURL, err := client.GetAPIServerURL()
+	if err != nil {
+		return err
+	}
+	parsedURL, err := url.Parse(serverURL)
+	if err != nil || parsedURL.Hostname() == "" {
+		return fmt.Errorf("invalid Kubernetes API server URL '%s' in the kubeconfig", serverURL)
+	}
+	address := parsedURL.Host
+	if parsedURL.Port() == "" {
+		address = net.JoinHostPort(parsedURL.Hostname(), "443")
+	}
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(k8sAPICA)
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: parsedURL.Hostname(),
+	})
+	if err != nil {
+		return fmt.Errorf("the Kubernetes API server at %s could not be verified with the CA; %v", serverURL, err)
+	}
+	conn.Close()
+
+	log.WithField("server", serverURL).Debug("Verified the Kubernetes API server with the CA.")
+	return nil
+}
+
+// validateAPIRateLimitArguments checks the client-side rate limits of the Trident controller.
+// Zero leaves client-go's defaults in place.
+func validateAPIRateLimitArguments() error {
+
+	if controllerQPS < 0 {
+		return fmt.Errorf("--controller-qps must be positive, not %v", controllerQPS)
+	}
+	if controllerBurst < 0 {
+		return fmt.Errorf("--controller-burst must be positive, not %d", controllerBurst)
+	}
+	if controllerQPS == 0 && controllerBurst == 0 {
+		return nil
+	}
+	if csi {
+		return errors.New("--controller-qps and --controller-burst are not supported with --csi")
+	}
+
+	log.WithFields(log.Fields{
+		"qps":   controllerQPS,
+		"burst": controllerBurst,
+	}).Warning("Raising the Trident controller's API rate limits increases the load it may put on " +
+		"the Kubernetes API server.")
+	return nil
+}
+
+// validateResyncPeriodArguments checks the resync period of the Trident controller.  Zero leaves
+// Trident's default in place.
+func validateResyncPeriodArguments() error {
+
+	if resyncPeriod == 0 {
+		return nil
+	}
+	if resyncPeriod < MinResyncPeriod || resyncPeriod > MaxResyncPeriod {
+		return fmt.Errorf("--resync-period must be between %v and %v", MinResyncPeriod, MaxResyncPeriod)
+	}
+	if csi {
+		return errors.New("--resync-period is not supported with --csi")
+	}
+
+	if resyncPeriod < DefaultResyncPeriod {
+		log.WithField("resyncPeriod", resyncPeriod).Warning("Resyncing more often than every " +
+			DefaultResyncPeriod.String() + " increases the load the Trident controller puts on the " +
+			"Kubernetes API server.")
+	}
+	return nil
+}
+
+// validateLogRotationArguments checks the bounds on the Trident log file.
+func validateLogRotationArguments() error {
+
+	if logMaxSize == 0 {
+		if logMaxBackups != 1 || logMaxAge != 0 {
+			return errors.New("--log-max-backups and --log-max-age require --log-max-size")
+		}
+		return nil
+	}
+	if logMaxSize < 0 || logMaxSize > MaxLogFileSize {
+		return fmt.Errorf("--log-max-size must be between 1 and %d MiB, not %d", MaxLogFileSize, logMaxSize)
+	}
+	if logMaxBackups < 0 || logMaxBackups > MaxLogFileBackups {
+		return fmt.Errorf("--log-max-backups must be between 0 and %d, not %d", MaxLogFileBackups, logMaxBackups)
+	}
+	if logMaxAge < 0 {
+		return fmt.Errorf("--log-max-age must not be negative, not %v", logMaxAge)
+	}
+	return nil
+}
+
+// getLogVolumeSizeLimit returns the size limit of the volume holding the Trident log files.  A
+// log file is rotated just after it exceeds the maximum size, so the limit allows for one extra file.
+func getLogVolumeSizeLimit() string {
+	return fmt.Sprintf("%dMi", logMaxSize*(logMaxBackups+2))
+}
+
+// validateEphemeralStorageArguments checks that the ephemeral storage request and limit
+// are valid quantities and that the request doesn't exceed the limit.
+func validateEphemeralStorageArguments() error {
+
+	var request, limit resource.Quantity
+	var err error
+
+	if ephemeralStorageRequest != "" {
+		if request, err = resource.ParseQuantity(ephemeralStorageRequest); err != nil {
+			return fmt.Errorf("trident-ephemeral-storage-request '%s' is invalid; %v", ephemeralStorageRequest, err)
+		}
+	}
+	if ephemeralStorageLimit != "" {
+		if limit, err = resource.ParseQuantity(ephemeralStorageLimit); err != nil {
+			return fmt.Errorf("trident-ephemeral-storage-limit '%s' is invalid; %v", ephemeralStorageLimit, err)
+		}
+	}
+	if ephemeralStorageRequest != "" && ephemeralStorageLimit != "" && request.Cmp(limit) > 0 {
+		return fmt.Errorf("trident-ephemeral-storage-request %s exceeds trident-ephemeral-storage-limit %s",
+			request.String(), limit.String())
+	}
+
+	return nil
+}
+
+// validateDNSConfigArguments checks the pod DNS config switches against the limits
+// Kubernetes enforces on a pod's dnsConfig.
+func validateDNSConfigArguments() error {
+
+	if len(dnsNameservers) > 3 {
+		return errors.New("no more than 3 DNS nameservers may be specified")
+	}
+	for _, nameserver := range dnsNameservers {
+		if net.ParseIP(nameserver) == nil {
+			return fmt.Errorf("'%s' is not a valid DNS nameserver IP address", nameserver)
+		}
+	}
+
+	if len(dnsSearches) > 6 {
+		return errors.New("no more than 6 DNS search domains may be specified")
+	}
+	for _, search := range dnsSearches {
+		if !dns1123DomainRegex.MatchString(strings.TrimSuffix(search, ".")) {
+			return fmt.Errorf("'%s' is not a valid DNS search domain", search)
+		}
+	}
+
+	for _, option := range dnsOptions {
+		if strings.Split(option, "=")[0] == "" {
+			return fmt.Errorf("'%s' is not a valid DNS resolver option", option)
+		}
+	}
+
+	return nil
+}
+
+// getControllerAffinity returns the affinity rules for the Trident controller pod specified
+// on the command line, or nil if none were specified.  Each node affinity expression uses the
+// label selector syntax, and all of them must match for a node to be eligible.
+func getControllerAffinity() (*v1.Affinity, error) {
+
+	if len(nodeAffinityExpressions) == 0 && podAntiAffinityTopology == "" {
+		return nil, nil
+	}
+
+	affinity := &v1.Affinity{}
+
+	if len(nodeAffinityExpressions) > 0 {
+		term := v1.NodeSelectorTerm{}
+		for _, expression := range nodeAffinityExpressions {
+			requirements, err := labels.ParseToRequirements(expression)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not a valid node affinity expression; %v", expression, err)
+			}
+			for _, requirement := range requirements {
+				operator, ok := nodeSelectorOperators[requirement.Operator()]
+				if !ok {
+					return nil, fmt.Errorf("node affinity expression '%s' uses an unsupported operator %s",
+						expression, requirement.Operator())
+				}
+				term.MatchExpressions = append(term.MatchExpressions, v1.NodeSelectorRequirement{
+					Key:      requirement.Key(),
+					Operator: operator,
+					Values:   requirement.Values().List(),
+				})
+			}
+		}
+		affinity.NodeAffinity = &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{term},
+			},
+		}
+	}
+
+	if podAntiAffinityTopology != "" {
+		if errs := validation.IsQualifiedName(podAntiAffinityTopology); len(errs) > 0 {
+			return nil, fmt.Errorf("'%s' is not a valid pod anti-affinity topology key; %s",
+				podAntiAffinityTopology, strings.Join(errs, "; "))
+		}
+		affinity.PodAntiAffinity = &v1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{{
+				Weight: 100,
+				PodAffinityTerm: v1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{appLabelKey: appLabelValue},
+					},
+					TopologyKey: podAntiAffinityTopology,
+				},
+			}},
+		}
+	}
+
+	return affinity, nil
+}
+
+// validateTopologySpreadArguments checks the topology spread constraint switches.
+func validateTopologySpreadArguments() error {
+
+	if topologySpreadKey == "" {
+		return nil
+	}
+	if errs := validation.IsQualifiedName(topologySpreadKey); len(errs) > 0 {
+		return fmt.Errorf("'%s' is not a valid topology spread key; %s",
+			topologySpreadKey, strings.Join(errs, "; "))
+	}
+	if topologySpreadMaxSkew < 1 {
+		return fmt.Errorf("topology-spread-max-skew must be at least 1, not %d", topologySpreadMaxSkew)
+	}
+	switch topologySpreadWhenUnsatisfiable {
+	case TopologySpreadDoNotSchedule, TopologySpreadScheduleAnyway:
+	default:
+		return fmt.Errorf("'%s' is not a valid topology-spread-when-unsatisfiable value; must be %s or %s",
+			topologySpreadWhenUnsatisfiable, TopologySpreadDoNotSchedule, TopologySpreadScheduleAnyway)
+	}
+
+	return nil
+}
+
+// getConfigChecksum returns a checksum of the inputs the Trident pods consume: their images, their
+// pod template options, which include the Trident arguments and mounted secrets, and the contents
+// of the config map and secrets they mount.  Stamping it on the pod templates means that applying
+// YAML generated from a changed configuration rolls the pods, rather than leaving them running with
+// stale settings.  The backend config isn't included, since the pods never read it.
+func getConfigChecksum(options k8s_client.PodTemplateOptions) string {
+
+	hash := sha256.New()
+	hash.Write([]byte(tridentImage + "\n" + etcdImage + "\n" + strconv.FormatBool(Debug) + "\n"))
+
+	options.PodAnnotations = nil
+	if optionsJSON, err := json.Marshal(options); err != nil {
+		log.Warningf("Could not include the pod template options in the config checksum; %v", err)
+	} else {
+		hash.Write(optionsJSON)
+	}
+
+	hash.Write(k8sAPICA)
+
+	// Secrets can't be read without a cluster connection, in which case only their names count
+	for _, secretName := range options.CredentialsSecrets {
+		if isOfflineGeneration() {
+			break
+		}
+		secret, err := client.GetSecret(secretName)
+		if err != nil {
+			log.WithField("secret", secretName).Warningf("Could not include the secret in the config "+
+				"checksum; %v", err)
+			continue
+		}
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			hash.Write([]byte(secretName + "/" + key + "\n"))
+			hash.Write(secret.Data[key])
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// getCredentialsSecrets returns the secrets to mount in the Trident controller, which are any
+// referenced by the setup backend config plus any specified on the command line.
+func getCredentialsSecrets() []string {
+
+	secrets := make([]string, 0)
+	seen := make(map[string]bool)
+
+	if configPath, err := findBackendConfigFile(); err == nil {
+		if configBytes, err := ioutil.ReadFile(configPath); err == nil {
+			if configJSON, err := backendConfigToJSON(configPath, configBytes); err == nil {
+				var commonConfig drivers.CommonStorageDriverConfig
+				if err = json.Unmarshal([]byte(configJSON), &commonConfig); err == nil {
+					// An invalid credentials field is reported when the storage driver is loaded
+					if secretName, _ := drivers.GetCredentialsSecretName(&commonConfig); secretName != "" {
+						secrets = append(secrets, secretName)
+						seen[secretName] = true
+					}
+				}
+			}
+		}
+	}
+
+	// The installer creates the secret for the seeded backends' credentials
+	if backendCredentialsSecret != "" && !seen[backendCredentialsSecret] {
+		secrets = append(secrets, backendCredentialsSecret)
+		seen[backendCredentialsSecret] = true
+	}
+
+	for _, secretName := range credentialsSecrets {
+		if !seen[secretName] {
+			secrets = append(secrets, secretName)
+			seen[secretName] = true
+		}
+	}
+
+	return secrets
+}
+
+// getKubernetesCredentials resolves a backend config's credentials secret through the Kubernetes
+// API, since the installer can't read the secret the way the Trident pod does.
+func getKubernetesCredentials(secretName string) (map[string]string, error) {
+
+	secret, err := client.GetSecret(secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make(map[string]string)
+	for key, value := range secret.Data {
+		credentials[key] = strings.TrimSpace(string(value))
+	}
+	return credentials, nil
+}
+
+// getPodTemplateOptions returns the optional pod settings specified on the command line.
+func getPodTemplateOptions() k8s_client.PodTemplateOptions {
+
+	options := k8s_client.PodTemplateOptions{
+		SchedulerName:        schedulerName,
+		PodHostname:          podHostname,
+		PodSubdomain:         podSubdomain,
+		ReadOnlyRootFS:       readOnlyRootFS,
+		CSIAttacherImage:     csiAttacherImage,
+		CSIProvisionerImage:  csiProvisionerImage,
+		CSIRegistrarImage:    csiRegistrarImage,
+		RevisionHistoryLimit: &revisionHistoryLimit,
+		TokenAudience:        tokenAudience,
+
+		TerminationMessagePath:   terminationMessagePath,
+		TerminationMessagePolicy: v1.TerminationMessagePolicy(terminationMessagePolicy),
+	}
+
+	if csiLivenessProbe {
+		options.CSILivenessProbeImage = csiLivenessProbeImage
+	}
+
+	options.CredentialsSecrets = getCredentialsSecrets()
+	options.EtcdRestore = restoreSnapshot != ""
+	options.ExtraVolumes = extraVolumes
+	options.ExtraVolumeMounts = extraVolumeMounts
+	options.ImagePullSecrets = imagePullSecrets
+	options.PluginRegistrationPath = pluginRegistrationPath
+
+	if len(k8sAPICA) > 0 {
+		options.KubernetesAPICA = true
+		options.TridentArgs = append(options.TridentArgs, "-k8s_api_ca_file="+
+			path.Join(tridentconfig.KubernetesAPICAPath, tridentconfig.KubernetesAPICAFilename))
+	}
+
+	if controllerWorkers > 1 {
+		options.TridentArgs = append(options.TridentArgs, fmt.Sprintf("-k8s_workers=%d", controllerWorkers))
+	}
+	if controllerQPS > 0 {
+		options.TridentArgs = append(options.TridentArgs,
+			"-k8s_api_qps="+strconv.FormatFloat(float64(controllerQPS), 'g', -1, 32))
+	}
+	if controllerBurst > 0 {
+		options.TridentArgs = append(options.TridentArgs, fmt.Sprintf("-k8s_api_burst=%d", controllerBurst))
+	}
+	if resyncPeriod != 0 {
+		options.TridentArgs = append(options.TridentArgs, "-k8s_resync_period="+resyncPeriod.String())
+	}
+	if backendHTTPTimeout != 0 {
+		options.TridentArgs = append(options.TridentArgs, "-backend_http_timeout="+backendHTTPTimeout.String())
+	}
+	if logMaxSize > 0 {
+		options.LogVolumeSizeLimit = getLogVolumeSizeLimit()
+		options.TridentArgs = append(options.TridentArgs, "-log_file",
+			fmt.Sprintf("-log_max_size=%d", logMaxSize), fmt.Sprintf("-log_max_backups=%d", logMaxBackups))
+		if logMaxAge != 0 {
+			options.TridentArgs = append(options.TridentArgs, "-log_max_age="+logMaxAge.String())
+		}
+	}
+
+	// Affinity errors are reported during argument validation
+	options.ControllerAffinity, _ = getControllerAffinity()
+
+	if topologySpreadKey != "" {
+		options.TopologySpread = []k8s_client.TopologySpreadConstraint{{
+			MaxSkew:           topologySpreadMaxSkew,
+			TopologyKey:       topologySpreadKey,
+			WhenUnsatisfiable: topologySpreadWhenUnsatisfiable,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{appLabelKey: appLabelValue},
+			},
+		}}
+	}
+
+	if ephemeralStorageRequest != "" || ephemeralStorageLimit != "" {
+		options.TridentResources = &v1.ResourceRequirements{}
+		if ephemeralStorageRequest != "" {
+			options.TridentResources.Requests = v1.ResourceList{
+				v1.ResourceEphemeralStorage: resource.MustParse(ephemeralStorageRequest),
+			}
+		}
+		if ephemeralStorageLimit != "" {
+			options.TridentResources.Limits = v1.ResourceList{
+				v1.ResourceEphemeralStorage: resource.MustParse(ephemeralStorageLimit),
+			}
+		}
+	}
+
+	if len(dnsNameservers) > 0 || len(dnsSearches) > 0 || len(dnsOptions) > 0 {
+		options.DNSConfig = &v1.PodDNSConfig{
+			Nameservers: dnsNameservers,
+			Searches:    dnsSearches,
+		}
+		for _, option := range dnsOptions {
+			nameValue := strings.SplitN(option, "=", 2)
+			dnsOption := v1.PodDNSConfigOption{Name: nameValue[0]}
+			if len(nameValue) == 2 {
+				value := nameValue[1]
+				dnsOption.Value = &value
+			}
+			options.DNSConfig.Options = append(options.DNSConfig.Options, dnsOption)
+		}
+	}
+
+	if gomaxprocsEnv := getGOMAXPROCSEnv(options.TridentResources); gomaxprocsEnv != nil {
+		options.TridentEnv = append(options.TridentEnv, *gomaxprocsEnv)
+	}
+
+	// The checksum covers every other option, so it must be computed last
+	if configChecksum {
+		options.PodAnnotations = map[string]string{ConfigChecksumAnnotation: getConfigChecksum(options)}
+	}
+
+	return options
+}
+
+// getGOMAXPROCSEnv returns the GOMAXPROCS environment variable of the Trident container, so the
+// Go runtime doesn't size itself to every core of a node when its CPU quota is much smaller.
+// With 'auto', or by default when the container has a CPU limit, the downward API sets it to
+// the CPU limit, rounded up to a whole number of cores.
+func getGOMAXPROCSEnv(resources *v1.ResourceRequirements) *v1.EnvVar {
+
+	auto := controllerGOMAXPROCS == GOMAXPROCSAuto
+	if controllerGOMAXPROCS == "" && resources != nil {
+		_, auto = resources.Limits[v1.ResourceCPU]
+	}
+
+	if auto {
+		return &v1.EnvVar{
+			Name: "GOMAXPROCS",
+			ValueFrom: &v1.EnvVarSource{
+				ResourceFieldRef: &v1.ResourceFieldSelector{
+					ContainerName: tridentconfig.ContainerTrident,
+					Resource:      "limits.cpu",
+					Divisor:       resource.MustParse("1"),
+				},
+			},
+		}
+	}
+	if controllerGOMAXPROCS != "" {
+		return &v1.EnvVar{Name: "GOMAXPROCS", Value: controllerGOMAXPROCS}
+	}
+	return nil
+}
+
+// prepareYAMLFilePaths sets up the absolute file paths to all files
+func prepareYAMLFilePaths() error {
+
+	var err error
+
+	// Get directory of installer
+	installerDirectoryPath, err = filepath.Abs(filepath.Dir(os.Args[0]))
+	if err != nil {
+		return fmt.Errorf("could not determine installer working directory; %v", err)
+	}
+
+	setupPath = path.Join(installerDirectoryPath, "setup")
+	backendConfigFilePath = path.Join(setupPath, BackendConfigFilename)
+	namespacePath = path.Join(setupPath, NamespaceFilename)
+	serviceAccountPath = path.Join(setupPath, ServiceAccountFilename)
+	clusterRolePath = path.Join(setupPath, ClusterRoleFilename)
+	clusterRoleBindingPath = path.Join(setupPath, ClusterRoleBindingFilename)
+	pvcPath = path.Join(setupPath, PVCFilename)
+	deploymentPath = path.Join(setupPath, DeploymentFilename)
+	csiServicePath = path.Join(setupPath, ServiceFilename)
+	csiStatefulSetPath = path.Join(setupPath, StatefulSetFilename)
+	csiDaemonSetPath = path.Join(setupPath, DaemonSetFilename)
+	networkPolicyPath = path.Join(setupPath, NetworkPolicyFilename)
+	k8sAPICAPath = path.Join(setupPath, K8sAPICAFilename)
+	headlessServicePath = path.Join(setupPath, HeadlessServiceFilename)
+	storageClassPath = path.Join(setupPath, StorageClassFilename)
+
+	setupYAMLPaths = []string{
+		namespacePath, serviceAccountPath, clusterRolePath, clusterRoleBindingPath,
+		pvcPath, deploymentPath, csiServicePath, csiStatefulSetPath, csiDaemonSetPath, networkPolicyPath,
+		k8sAPICAPath, headlessServicePath, storageClassPath,
+	}
+
+	return nil
+}
+
+func cleanYAMLFiles() {
+
+	for _, filePath := range setupYAMLPaths {
+		os.Remove(filePath)
+	}
+}
+
+func prepareYAMLFiles() error {
+
+	var err error
+
+	cleanYAMLFiles()
+
+	namespaceYAML := k8s_client.GetNamespaceYAML(TridentPodNamespace)
+	if err = writeFile(namespacePath, namespaceYAML); err != nil {
+		return fmt.Errorf("could not write namespace YAML file; %v", err)
+	}
+
+	serviceAccountYAML := k8s_client.GetServiceAccountYAML(false)
+	if err = writeFile(serviceAccountPath, serviceAccountYAML); err != nil {
+		return fmt.Errorf("could not write service account YAML file; %v", err)
+	}
+
+	clusterRoleYAML := k8s_client.GetClusterRoleYAML(getKubernetesFlavor(), getKubernetesVersion(), false,
+		getClusterRoleLabels())
+	if err = writeFile(clusterRolePath, clusterRoleYAML); err != nil {
+		return fmt.Errorf("could not write cluster role YAML file; %v", err)
+	}
+
+	clusterRoleBindingYAML := k8s_client.GetClusterRoleBindingYAML(
+		TridentPodNamespace, getKubernetesFlavor(), getKubernetesVersion(), false)
+	if err = writeFile(clusterRoleBindingPath, clusterRoleBindingYAML); err != nil {
+		return fmt.Errorf("could not write cluster role binding YAML file; %v", err)
+	}
+
+	pvcYAML := k8s_client.GetPVCYAML(pvcName, TridentPodNamespace, volumeSize, volumeAccessMode, appLabelValue)
+	if err = writeFile(pvcPath, pvcYAML); err != nil {
+		return fmt.Errorf("could not write PVC YAML file; %v", err)
+	}
+
+	if len(k8sAPICA) > 0 {
+		k8sAPICAYAML := k8s_client.GetK8sAPICAConfigMapYAML(appLabelValue, string(k8sAPICA))
+		if err = writeFile(k8sAPICAPath, k8sAPICAYAML); err != nil {
+			return fmt.Errorf("could not write Kubernetes API server CA YAML file; %v", err)
+		}
+	}
+
+	if podSubdomain != "" {
+		headlessServiceYAML := k8s_client.GetHeadlessServiceYAML(podSubdomain, appLabelValue)
+		if err = writeFile(headlessServicePath, headlessServiceYAML); err != nil {
+			return fmt.Errorf("could not write headless service YAML file; %v", err)
+		}
+	}
+
+	deploymentYAML := k8s_client.GetDeploymentYAML(
+		pvcName, tridentImage, etcdImage, appLabelValue, Debug, getKubernetesVersion(), getPodTemplateOptions())
+	if err = writeFile(deploymentPath, deploymentYAML); err != nil {
+		return fmt.Errorf("could not write deployment YAML file; %v", err)
+	}
+
+	if createNetworkPolicy {
+		networkPolicyYAML := k8s_client.GetNetworkPolicyYAML(appLabelValue, backendCIDRs, false)
+		if err = writeFile(networkPolicyPath, networkPolicyYAML); err != nil {
+			return fmt.Errorf("could not write network policy YAML file; %v", err)
+		}
+	}
+
+	if createStorageClass != "" {
+		if err = writeFile(storageClassPath, getStorageClassYAML()); err != nil {
+			return fmt.Errorf("could not write storage class YAML file; %v", err)
+		}
+	}
+
+	return nil
+}
+
+func prepareCSIYAMLFiles() error {
+
+	var err error
+
+	cleanYAMLFiles()
+
+	namespaceYAML := k8s_client.GetNamespaceYAML(TridentPodNamespace)
+	if err = writeFile(namespacePath, namespaceYAML); err != nil {
+		return fmt.Errorf("could not write namespace YAML file; %v", err)
+	}
+
+	serviceAccountYAML := k8s_client.GetServiceAccountYAML(true)
+	if err = writeFile(serviceAccountPath, serviceAccountYAML); err != nil {
+		return fmt.Errorf("could not write service account YAML file; %v", err)
+	}
+
+	clusterRoleYAML := k8s_client.GetClusterRoleYAML(getKubernetesFlavor(), getKubernetesVersion(), true,
+		getClusterRoleLabels())
+	if err = writeFile(clusterRolePath, clusterRoleYAML); err != nil {
+		return fmt.Errorf("could not write cluster role YAML file; %v", err)
+	}
+
+	clusterRoleBindingYAML := k8s_client.GetClusterRoleBindingYAML(
+		TridentPodNamespace, getKubernetesFlavor(), getKubernetesVersion(), true)
+	if err = writeFile(clusterRoleBindingPath, clusterRoleBindingYAML); err != nil {
+		return fmt.Errorf("could not write cluster role binding YAML file; %v", err)
+	}
+
+	pvcYAML := k8s_client.GetPVCYAML(pvcName, TridentPodNamespace, volumeSize, volumeAccessMode, appLabelValue)
+	if err = writeFile(pvcPath, pvcYAML); err != nil {
+		return fmt.Errorf("could not write PVC YAML file; %v", err)
+	}
+
+	serviceYAML := k8s_client.GetCSIServiceYAML(appLabelValue)
+	if err = writeFile(csiServicePath, serviceYAML); err != nil {
+		return fmt.Errorf("could not write service YAML file; %v", err)
+	}
+
+	statefulSetYAML := k8s_client.GetCSIStatefulSetYAML(
+		pvcName, tridentImage, etcdImage, appLabelValue, Debug, getKubernetesVersion(), getPodTemplateOptions())
+	if err = writeFile(csiStatefulSetPath, statefulSetYAML); err != nil {
+		return fmt.Errorf("could not write statefulset YAML file; %v", err)
+	}
+
+	daemonSetYAML := k8s_client.GetCSIDaemonSetYAML(
+		tridentImage, TridentNodeLabelValue, Debug, getKubernetesVersion(), getPodTemplateOptions())
+	if err = writeFile(csiDaemonSetPath, daemonSetYAML); err != nil {
+		return fmt.Errorf("could not write daemonset YAML file; %v", err)
+	}
+
+	if createNetworkPolicy {
+		networkPolicyYAML := k8s_client.GetNetworkPolicyYAML(appLabelValue, backendCIDRs, true)
+		if err = writeFile(networkPolicyPath, networkPolicyYAML); err != nil {
+			return fmt.Errorf("could not write network policy YAML file; %v", err)
+		}
+	}
+
+	if createStorageClass != "" {
+		if err = writeFile(storageClassPath, getStorageClassYAML()); err != nil {
+			return fmt.Errorf("could not write storage class YAML file; %v", err)
+		}
+	}
+
+	return nil
+}
+
+// setupObject is a Kubernetes object generated by the installer, along with the name of the
+// setup file that may be used to customize it.
+type setupObject struct {
+	fileName string
+	yaml     string
+}
+
+// getSetupObjects returns the generated YAML for each object the installer creates, in the
+// order in which they are created.  The Trident PV isn't included, since its YAML depends on
+// the volume provisioned on the storage backend.
+func getSetupObjects() []setupObject {
+
+	podOptions := getPodTemplateOptions()
+	flavor, version := getKubernetesFlavor(), getKubernetesVersion()
+
+	objects := []setupObject{
+		{NamespaceFilename, k8s_client.GetNamespaceYAML(TridentPodNamespace)},
+		{ServiceAccountFilename, k8s_client.GetServiceAccountYAML(csi)},
+	}
+
+	if useKubernetesRBAC {
+		objects = append(objects,
+			setupObject{ClusterRoleFilename, k8s_client.GetClusterRoleYAML(
+				flavor, version, csi, getClusterRoleLabels())},
+			setupObject{ClusterRoleBindingFilename, k8s_client.GetClusterRoleBindingYAML(
+				TridentPodNamespace, flavor, version, csi)},
+		)
+	}
+
+	if createNetworkPolicy {
+		objects = append(objects, setupObject{NetworkPolicyFilename,
+			k8s_client.GetNetworkPolicyYAML(appLabelValue, backendCIDRs, csi)})
+	}
+
+	objects = append(objects, setupObject{PVCFilename,
+		k8s_client.GetPVCYAML(pvcName, TridentPodNamespace, volumeSize, volumeAccessMode, appLabelValue)})
+
+	if len(k8sAPICA) > 0 {
+		objects = append(objects, setupObject{K8sAPICAFilename,
+			k8s_client.GetK8sAPICAConfigMapYAML(appLabelValue, string(k8sAPICA))})
+	}
+
+	if !csi {
+		if podSubdomain != "" {
+			objects = append(objects, setupObject{HeadlessServiceFilename,
+				k8s_client.GetHeadlessServiceYAML(podSubdomain, appLabelValue)})
+		}
+		objects = append(objects, setupObject{DeploymentFilename,
+			k8s_client.GetDeploymentYAML(pvcName, tridentImage, etcdImage, appLabelValue, Debug, version, podOptions)})
+	} else {
+		objects = append(objects,
+			setupObject{ServiceFilename, k8s_client.GetCSIServiceYAML(appLabelValue)},
+			setupObject{StatefulSetFilename,
+				k8s_client.GetCSIStatefulSetYAML(
+					pvcName, tridentImage, etcdImage, appLabelValue, Debug, version, podOptions)},
+			setupObject{DaemonSetFilename,
+				k8s_client.GetCSIDaemonSetYAML(tridentImage, TridentNodeLabelValue, Debug, version, podOptions)},
+		)
+	}
+
+	if createStorageClass != "" {
+		objects = append(objects, setupObject{StorageClassFilename, getStorageClassYAML()})
+	}
+
+	return objects
+}
+
+// writeBundle writes the YAML of every object the installer creates, after applying any overlays,
+// to a single multi-document file in creation order.  The Trident PV can't be generated until the
+// installer provisions the Trident volume on the storage backend, so it is only included if
+// --bundle-pv is specified, in which case the volume is provisioned first, just as the installer
+// would, and destroyed again if the bundle can't be written.
+func writeBundle(filePath string) error {
+
+	if !bundlePV {
+		log.WithFields(log.Fields{
+			"pvc":   pvcName,
+			"label": appLabel,
+		}).Warning("The bundle doesn't include the Trident PV. Create a PV with the Trident label " +
+			"for the Trident PVC to bind to, or specify --bundle-pv.")
+		return writeBundleFile(filePath, nil)
+	}
+
+	pvExists, err := client.CheckPVExists(pvName)
+	if err != nil {
+		return fmt.Errorf("could not check for an existing PV %s; %v", pvName, err)
+	} else if pvExists {
+		log.WithField("pv", pvName).Info("PV exists, so the bundle doesn't include it.")
+		return writeBundleFile(filePath, nil)
+	}
+
+	sb, err := loadStorageDriver()
+	if err != nil {
+		return err
+	}
+	defer sb.Terminate()
+
+	volume, err := provisionTridentVolume(sb)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"backend": sb.Name,
+		"volume":  volume.Config.InternalName,
+	}).Info("Provisioned the Trident volume for the bundle's PV.")
+
+	pvObjects, err := getBundlePVObjects(sb, volume)
+	if err == nil {
+		err = writeBundleFile(filePath, pvObjects)
+	}
+	if err != nil {
+		// Don't leave behind a volume that no PV refers to, unless it existed before
+		if importVolume == "" {
+			if destroyErr := sb.RemoveVolume(volume); destroyErr != nil {
+				log.WithField("volume", volume.Config.InternalName).Errorf(
+					"Could not destroy the Trident volume; %v", destroyErr)
+			} else {
+				log.WithField("volume", volume.Config.InternalName).Info("Destroyed the Trident volume.")
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// writeBundleFile writes the YAML of every object the installer creates to the bundle file, with
+// the specified PV objects following the PVC they are bound to.
+func writeBundleFile(filePath string, pvObjects []string) error {
+
+	var bundle string
+	for _, object := range getSetupObjects() {
+		effectiveYAML, err := applyOverlay(object.fileName, object.yaml)
+		if err != nil {
+			return err
+		}
+		bundle += "---\n" + strings.TrimPrefix(strings.TrimSpace(effectiveYAML), "---\n") + "\n"
+
+		// The PV follows the PVC it is bound to
+		if object.fileName == PVCFilename {
+			for _, pvObject := range pvObjects {
+				bundle += "---\n" + strings.TrimPrefix(strings.TrimSpace(pvObject), "---\n") + "\n"
+			}
+		}
+	}
+
+	if err := writeFile(filePath, bundle); err != nil {
+		return fmt.Errorf("could not write bundle file %s; %v", filePath, err)
+	}
+
+	return nil
+}
+
+// getBundlePVObjects returns the YAML of the Trident PV for the provisioned Trident volume,
+// preceded by that of the volume's iSCSI CHAP secret if it uses CHAP.
+func getBundlePVObjects(sb *storage.Backend, volume *storage.Volume) ([]string, error) {
+
+	pvYAML, err := getTridentPVYAML(sb, volume, getPVQoSAnnotations(getVolumeQoS(sb, volume)))
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]string, 0, 2)
+	if usesCHAP(volume) {
+		secretName := volume.ConstructExternal().GetCHAPSecretName()
+		objects = append(objects, k8s_client.GetCHAPSecretYAML(secretName,
+			volume.Config.AccessInfo.IscsiUsername,
+			volume.Config.AccessInfo.IscsiInitiatorSecret,
+			volume.Config.AccessInfo.IscsiTargetSecret,
+			appLabelValue))
+		log.WithField("secret", secretName).Warning("The bundle includes the iSCSI CHAP secret of the " +
+			"Trident volume; protect the bundle file accordingly.")
+	}
+	return append(objects, pvYAML), nil
+}
+
+// createObjectByYAML creates a Kubernetes object from generated YAML, after applying any
+// overlay patch that exists for the corresponding setup file.
+func createObjectByYAML(fileName, objectYAML string) error {
+
+	effectiveYAML, err := applyOverlay(fileName, objectYAML)
+	if err != nil {
+		return err
+	}
+	return client.CreateObjectByYAML(effectiveYAML)
+}
+
+// applyOverlay applies a strategic merge patch from the overlay directory to the generated
+// YAML for a setup file.  The patch must have the same name as the setup file.  If no overlay
+// directory was specified, or it contains no patch for this file, the YAML is returned unchanged.
+func applyOverlay(fileName, objectYAML string) (string, error) {
+
+	if overlayDir == "" {
+		return objectYAML, nil
+	}
+
+	patchPath := path.Join(overlayDir, fileName)
+	if !fileExists(patchPath) {
+		return objectYAML, nil
+	}
+
+	patchBytes, err := ioutil.ReadFile(patchPath)
+	if err != nil {
+		return "", fmt.Errorf("could not read overlay %s; %v", patchPath, err)
+	}
+	patchJSON, err := yaml.YAMLToJSON(patchBytes)
+	if err != nil {
+		return "", fmt.Errorf("overlay %s is not valid YAML; %v", patchPath, err)
+	}
+
+	originalJSON, err := yaml.YAMLToJSON([]byte(objectYAML))
+	if err != nil {
+		return "", fmt.Errorf("could not parse generated YAML for %s; %v", fileName, err)
+	}
+
+	var typeMeta metav1.TypeMeta
+	if err = json.Unmarshal(originalJSON, &typeMeta); err != nil {
+		return "", fmt.Errorf("could not determine the kind of %s; %v", fileName, err)
+	}
+	dataStruct, err := getOverlayDataStruct(typeMeta.Kind)
+	if err != nil {
+		return "", fmt.Errorf("could not apply overlay %s; %v", patchPath, err)
+	}
+
+	mergedJSON, err := strategicpatch.StrategicMergePatch(originalJSON, patchJSON, dataStruct)
+	if err != nil {
+		return "", fmt.Errorf("could not apply overlay %s; %v", patchPath, err)
+	}
+	mergedYAML, err := yaml.JSONToYAML(mergedJSON)
+	if err != nil {
+		return "", fmt.Errorf("could not convert patched %s to YAML; %v", fileName, err)
+	}
+
+	log.WithField("overlay", patchPath).Debug("Applied overlay.")
+
+	return "---\n" + string(mergedYAML), nil
+}
+
+// getOverlayDataStruct returns an empty object of the type corresponding to a Kubernetes kind,
+// which the strategic merge patch library uses to determine how lists are merged.
+func getOverlayDataStruct(kind string) (interface{}, error) {
+
+	switch kind {
+	case "Namespace":
+		return &v1.Namespace{}, nil
+	case "ServiceAccount":
+		return &v1.ServiceAccount{}, nil
+	case "ClusterRole":
+		return &rbacv1.ClusterRole{}, nil
+	case "ClusterRoleBinding":
+		return &rbacv1.ClusterRoleBinding{}, nil
+	case "PersistentVolumeClaim":
+		return &v1.PersistentVolumeClaim{}, nil
+	case "Service":
+		return &v1.Service{}, nil
+	case "Deployment":
+		return &appsv1.Deployment{}, nil
+	case "StatefulSet":
+		return &appsv1.StatefulSet{}, nil
+	case "DaemonSet":
+		return &appsv1.DaemonSet{}, nil
+	default:
+		return nil, fmt.Errorf("overlays are not supported for kind %s", kind)
+	}
+}
+
+// validateOverlays ensures that any overlays apply cleanly to the generated YAML, and it prints
+// the effective YAML of each object to stderr if requested, so it doesn't mix with the install
+// summary or the output of a fleet install on stdout.
+func validateOverlays() error {
+
+	for _, object := range getSetupObjects() {
+
+		// Objects read from custom YAML files are created as is
+		if useYAML && fileExists(path.Join(setupPath, object.fileName)) {
+			continue
+		}
+
+		effectiveYAML, err := applyOverlay(object.fileName, object.yaml)
+		if err != nil {
+			return err
+		}
+		if dumpEffectiveYAML {
+			fmt.Fprintf(os.Stderr, "# %s\n%s", object.fileName, effectiveYAML)
+		}
+	}
+
+	return nil
+}
+
+// getEffectiveSetupYAML returns the YAML of a setup object as the installer would create it, from
+// the custom YAML file if one is used, or else from the generated YAML with any overlay.
+func getEffectiveSetupYAML(fileName, filePath string) (string, error) {
+
+	if useYAML && fileExists(filePath) {
+		yamlBytes, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return "", err
+		}
+		return string(yamlBytes), nil
+	}
+
+	for _, object := range getSetupObjects() {
+		if object.fileName == fileName {
+			return applyOverlay(object.fileName, object.yaml)
+		}
+	}
+	return "", fmt.Errorf("the installer doesn't create %s", fileName)
+}
+
+func writeFile(filePath, data string) error {
+	return ioutil.WriteFile(filePath, []byte(data), 0644)
+}
+
+func installTrident() (returnError error) {
+
+	var (
+		logFields           log.Fields
+		pvcExists           bool
+		pvExists            bool
+		pvReplaced          bool
+		pvc                 *v1.PersistentVolumeClaim
+		pv                  *v1.PersistentVolume
+		pvRequestedQuantity resource.Quantity
+		storageBackend      *storage.Backend
+	)
+
+	// Validate volume size
+	pvRequestedQuantity, err := resource.ParseQuantity(volumeSize)
+	if err != nil {
+		return fmt.Errorf("volume-size '%s' is invalid; %v", volumeSize, err)
+	}
+	log.WithField("quantity", pvRequestedQuantity.String()).Debug("Parsed requested volume size.")
+
+	// Check just the backend config, without contacting the storage system
+	if validateBackendOnly {
+		return validateBackendConfig()
+	}
+
+	// Ensure the Trident operator doesn't manage pods with the same label
+	if err = checkOperatorManagedPods(); err != nil {
+		return err
+	}
+	preCheckPassed("operatorConflict", "No Trident pods are managed by the Trident operator.")
+
+	if !csi {
+		log.WithFields(log.Fields{
+			"useKubernetesRBAC": useKubernetesRBAC,
+			"ucpBearerToken":    ucpBearerToken,
+			"ucpHost":           ucpHost,
+		}).Debug("Dumping RBAC fields.")
+
+		// Ensure Trident isn't already installed
+		if installed, namespace, err := isTridentInstalled(); err != nil {
+			return fmt.Errorf("could not check if Trident deployment exists; %v", err)
+		} else if installed && !(namespace == TridentPodNamespace && resumeProgress.completed(ResumeStepDeployment)) {
+			return fmt.Errorf("Trident is already installed in namespace %s", namespace)
+		}
+		preCheckPassed("existingInstallation", "Trident is not installed.")
+
+	} else {
+
+		// Ensure CSI minimum requirements are met
+		minCSIVersion := utils.MustParseSemantic(tridentconfig.KubernetesCSIVersionMin)
+		if !client.Version().AtLeast(minCSIVersion) {
+			return fmt.Errorf("CSI Trident requires Kubernetes %s or later", minCSIVersion.ShortString())
+		}
+
+		// Ensure the CSI sidecars work with this Kubernetes version
+		if err := checkCSISidecarImages(); err != nil {
+			return err
+		}
+		preCheckPassed("csiSidecars", "The CSI sidecar images support this Kubernetes version.")
+
+		// Ensure CSI Trident isn't already installed
+		if installed, namespace, err := isCSITridentInstalled(); err != nil {
+			return fmt.Errorf("could not check if Trident statefulset exists; %v", err)
+		} else if installed && !(namespace == TridentPodNamespace && resumeProgress.completed(ResumeStepStatefulSet)) {
+			return fmt.Errorf("CSI Trident is already installed in namespace %s", namespace)
+		}
+		preCheckPassed("existingInstallation", "CSI Trident is not installed.")
+
+		// Attachments left by an uncleaned uninstall can block new attachments of their volumes
+		checkStaleVolumeAttachments()
+
+		log.Warning("CSI Trident for Kubernetes is a technology preview " +
+			"and should not be installed in production environments!")
+	}
+
+	// Pod dnsConfig is enabled by default as of Kubernetes 1.10
+	podOptions := getPodTemplateOptions()
+	if podOptions.DNSConfig != nil {
+		if !client.Version().AtLeast(utils.MustParseSemantic("v1.10.0")) {
+			return errors.New("a custom pod DNS config requires Kubernetes 1.10 or later")
+		}
+		log.WithFields(log.Fields{
+			"nameservers": strings.Join(dnsNameservers, ","),
+			"searches":    strings.Join(dnsSearches, ","),
+			"options":     strings.Join(dnsOptions, ","),
+		}).Info("Using custom DNS config for the Trident pods.")
+	}
+
+	// The CSI node plugin is privileged and writes to host paths, so it keeps a writable root filesystem
+	if readOnlyRootFS && csi {
+		log.Info("The Trident controller will use a read-only root filesystem; the privileged CSI node " +
+			"daemonset is exempt.")
+	}
+
+	// Pod topology spread constraints are enabled by default as of Kubernetes 1.18
+	if len(podOptions.TopologySpread) > 0 {
+		if !client.Version().AtLeast(utils.MustParseSemantic("v1.18.0")) {
+			return errors.New("pod topology spread constraints require Kubernetes 1.18 or later")
+		}
+		log.WithFields(log.Fields{
+			"topologyKey":       topologySpreadKey,
+			"maxSkew":           topologySpreadMaxSkew,
+			"whenUnsatisfiable": topologySpreadWhenUnsatisfiable,
+		}).Info("Using topology spread constraint for the Trident controller.")
+	}
+
+	// Projected service account tokens, and the CA config map in every namespace that the projected
+	// token volume includes, are enabled by default as of Kubernetes 1.20
+	if podOptions.TokenAudience != "" {
+		if !client.Version().AtLeast(utils.MustParseSemantic("v1.20.0")) {
+			return errors.New("--token-audience requires Kubernetes 1.20 or later")
+		}
+		log.WithField("audience", tokenAudience).Info("Using a projected service account token for the " +
+			"Trident controller.")
+	}
+	if err := checkTokenAudience(); err != nil {
+		return err
+	}
+
+	// NetworkPolicy egress rules require Kubernetes 1.8
+	if createNetworkPolicy && !client.Version().AtLeast(utils.MustParseSemantic("v1.8.0")) {
+		return errors.New("--create-network-policy requires Kubernetes 1.8 or later")
+	}
+
+	// The Trident pod can't start unless the credentials secrets it mounts exist
+	for _, secretName := range podOptions.CredentialsSecrets {
+		if secretName == backendCredentialsSecret {
+			if _, err := checkBackendCredentialsSecret(); err != nil {
+				return err
+			}
+			log.WithField("secret", secretName).Info("Mounting backend credentials secret in the Trident pod.")
+			continue
+		}
+		if secretExists, err := client.CheckSecretExists(secretName); err != nil {
+			return fmt.Errorf("could not check for credentials secret %s; %v", secretName, err)
+		} else if !secretExists {
+			return fmt.Errorf("credentials secret %s does not exist in namespace %s; create it "+
+				"before installing Trident", secretName, TridentPodNamespace)
+		}
+		log.WithField("secret", secretName).Info("Mounting backend credentials secret in the Trident pod.")
+	}
+
+	// The Trident pod must be able to verify the API server with the CA it mounts
+	if len(k8sAPICA) > 0 {
+		if err := verifyK8sAPICA(); err != nil {
+			return err
+		}
+		log.Info("Mounting the Kubernetes API server CA in the Trident pod.")
+	}
+
+	// Check if the required namespace exists
+	namespaceExists, returnError := client.CheckNamespaceExists(TridentPodNamespace)
+	if returnError != nil {
+		returnError = fmt.Errorf("could not check if namespace %s exists; %v", TridentPodNamespace, returnError)
+		return
+	}
+	if namespaceExists {
+		log.WithField("namespace", TridentPodNamespace).Debug("Namespace exists.")
+
+		// Objects can't be created in a namespace that is being deleted
+		if namespaceExists, returnError = checkNamespaceTerminating(); returnError != nil {
+			return
+		}
+	} else {
+		log.WithField("namespace", TridentPodNamespace).Debug("Namespace does not exist.")
+	}
+
+	// Check for PVC (also returns (false, nil) if namespace does not exist)
+	pvcExists, returnError = client.CheckPVCExists(pvcName)
+	if returnError != nil {
+		returnError = fmt.Errorf("could not establish the presence of PVC %s; %v", pvcName, returnError)
+		return
+	}
+	if pvcExists {
+		pvc, returnError = client.GetPVC(pvcName)
+		if returnError != nil {
+			returnError = fmt.Errorf("could not retrieve PVC %s; %v", pvcName, returnError)
+			return
+		}
+
+		// Ensure that the PVC is in a state that we can work with
+		if pvc.Status.Phase == v1.ClaimLost {
+			returnError = fmt.Errorf("PVC %s phase is Lost; please delete it and try again", pvcName)
+			return
+		}
+		if pvc.Status.Phase == v1.ClaimBound && pvc.Spec.VolumeName != pvName {
+			returnError = fmt.Errorf("PVC %s is Bound, but not to PV %s; "+
+				"please specify a different PV and/or PVC", pvcName, pvName)
+			return
+		}
+		if pvc.Labels == nil || pvc.Labels[appLabelKey] != appLabelValue {
+			returnError = fmt.Errorf("PVC %s does not have %s label; "+
+				"please add label or delete PVC and try again", pvcName, appLabel)
+			return
+		}
+		if pvc.Status.Phase != v1.ClaimBound && !hasAccessMode(pvc.Spec.AccessModes, volumeAccessMode) {
+			returnError = fmt.Errorf("PVC %s doesn't request access mode %s; please delete it and try again",
+				pvcName, volumeAccessMode)
+			return
+		}
+
+		log.WithFields(log.Fields{
+			"pvc":       pvcName,
+			"namespace": pvc.Namespace,
+			"phase":     pvc.Status.Phase,
+		}).Debug("PVC already exists.")
+
+	} else {
+		log.WithField("pvc", pvcName).Debug("PVC does not exist.")
+	}
+
+	// Check for PV
+	pvExists, returnError = client.CheckPVExists(pvName)
+	if returnError != nil {
+		returnError = fmt.Errorf("could not establish the presence of PV %s; %v", pvName, returnError)
+		return
+	}
+	if pvExists {
+		pv, returnError = client.GetPV(pvName)
+		if returnError != nil {
+			returnError = fmt.Errorf("could not retrieve PV %s; %v", pvName, returnError)
+			return
+		}
+
+		// Ensure that the PV is in a state we can work with, or replace it if requested
+		if pv.Status.Phase == v1.VolumeReleased || pv.Status.Phase == v1.VolumeFailed {
+			if !replaceExistingPV {
+				returnError = fmt.Errorf("PV %s phase is %s; please delete it, or use --replace-existing-pv, "+
+					"and try again", pvName, pv.Status.Phase)
+				return
+			}
+			if returnError = confirmReplaceExistingPV(pv); returnError != nil {
+				return
+			}
+			pvExists, pvReplaced = false, true
+		}
+		if pv.Status.Phase == v1.VolumeBound && pv.Spec.ClaimRef != nil {
+			if pv.Spec.ClaimRef.Name != pvcName {
+				returnError = fmt.Errorf("PV %s is Bound, but not to PVC %s; "+
+					"please delete PV and try again", pvName, pvcName)
+				return
+			}
+			if pv.Spec.ClaimRef.Namespace != TridentPodNamespace {
+				returnError = fmt.Errorf("PV %s is Bound to a PVC in namespace %s; "+
+					"please delete PV and try again", pvName, pv.Spec.ClaimRef.Namespace)
+				return
+			}
+		}
+		if pv.Labels == nil || pv.Labels[appLabelKey] != appLabelValue {
+			returnError = fmt.Errorf("PV %s does not have %s label; "+
+				"please add label or delete PV and try again", pvName, appLabel)
+			return
+		}
+
+		// Ensure PV size matches the request, unless the PV is being replaced
+		if !pvExists {
+			log.WithField("pv", pvName).Debug("PV will be replaced.")
+		} else if pvActualQuantity, ok := pv.Spec.Capacity[v1.ResourceStorage]; !ok {
+			log.WithField("pv", pvName).Warning("Could not determine size of existing PV.")
+		} else if pvRequestedQuantity.Cmp(pvActualQuantity) != 0 {
+			log.WithFields(log.Fields{
+				"existing": pvActualQuantity.String(),
+				"request":  pvRequestedQuantity.String(),
+				"pv":       pvName,
+			}).Warning("Existing PV size does not match request.")
+		}
+
+		if pvExists {
+			log.WithFields(log.Fields{
+				"pv":    pvName,
+				"phase": pv.Status.Phase,
+			}).Debug("PV already exists.")
+		}
+
+	} else {
+		log.WithField("pv", pvName).Debug("PV does not exist.")
+	}
+
+	// A snapshot is only restored into a new, empty Trident volume, such as one an interrupted
+	// installation created
+	if restoreSnapshot != "" && (pvcExists || pvExists) && !resumeProgress.completed(ResumeStepPV) {
+		returnError = fmt.Errorf("--restore-from-snapshot requires a new Trident volume, but PVC %s or "+
+			"PV %s already exists", pvcName, pvName)
+		return
+	}
+
+	preCheckPassed("volume", fmt.Sprintf("PVC %s exists: %t; PV %s exists: %t.", pvcName, pvcExists,
+		pvName, pvExists))
+
+	// Ensure the namespace has enough quota remaining for the objects we will create
+	if namespaceExists {
+		if returnError = checkResourceQuotas(!pvcExists, pvRequestedQuantity); returnError != nil {
+			return
+		}
+		preCheckPassed("resourceQuotas", "Namespace "+TridentPodNamespace+" has enough quota.")
+	}
+
+	// If the PV doesn't exist, we will need the storage driver to create it. Load the driver
+	// here to detect any problems before starting the installation steps.
+	if !pvExists {
+		if storageBackend, returnError = loadStorageDriver(); returnError != nil {
+			return
+		}
+		preCheckPassed("storageBackend", "Started the "+storageBackend.GetDriverName()+" storage driver.")
+
+		// A storage system in maintenance may accept a connection but not serve requests,
+		// which would leave the installation waiting on the volume to be created.
+		if returnError = checkBackendState(storageBackend); returnError != nil {
+			return
+		}
+		preCheckPassed("backendState", "Backend "+storageBackend.Name+" is ready to serve requests.")
+	} else {
+		log.Debug("PV exists, skipping storage driver check.")
+	}
+
+	// The requested storage pool must be one the backend offers
+	if volumePool != "" {
+		if pvExists {
+			log.WithField("pv", pvName).Warning("PV exists, so --volume-pool is ignored.")
+		} else if _, ok := storageBackend.Storage[volumePool]; !ok {
+			returnError = fmt.Errorf("backend %s has no storage pool named %s; use 'tridentctl backend "+
+				"list-pools' to list its pools", storageBackend.Name, volumePool)
+			return
+		}
+	}
+
+	// The clone source must exist on a backend whose driver can clone it
+	if cloneSourceVolume != "" {
+		if pvExists {
+			log.WithField("pv", pvName).Warning("PV exists, so --clone-source-volume is ignored.")
+		} else {
+			if returnError = validateCloneSourceVolume(storageBackend); returnError != nil {
+				return
+			}
+			preCheckPassed("cloneSourceVolume", "Volume "+cloneSourceVolume+" can be cloned.")
+		}
+	}
+
+	// The volume to import must exist on the backend, be large enough, and not be used by another PV
+	if importVolume != "" {
+		if pvExists {
+			log.WithField("pv", pvName).Warning("PV exists, so --import-volume is ignored.")
+		} else {
+			if returnError = validateImportVolume(storageBackend); returnError != nil {
+				return
+			}
+			preCheckPassed("importVolume", "Volume "+importVolume+" can be imported.")
+		}
+	}
+
+	// The access mode must be one the backend's protocol supports
+	if pvExists {
+
+		// The PVC only binds to the existing PV if it requests an access mode the PV offers, so an
+		// explicit access mode must match, and the default is replaced by the PV's own
+		if len(pv.Spec.AccessModes) > 0 && !hasAccessMode(pv.Spec.AccessModes, volumeAccessMode) {
+			if volumeAccessModeSpecified {
+				returnError = fmt.Errorf("PV %s doesn't offer access mode %s, so the Trident PVC couldn't bind "+
+					"to it; omit --volume-access-mode or specify one of the PV's access modes", pvName,
+					volumeAccessMode)
+				return
+			}
+			volumeAccessMode = string(pv.Spec.AccessModes[0])
+			log.WithFields(log.Fields{
+				"pv":         pvName,
+				"accessMode": volumeAccessMode,
+			}).Info("PV exists, so the Trident PVC requests the PV's access mode.")
+		}
+	} else {
+		if returnError = validateVolumeAccessMode(storageBackend.GetProtocol()); returnError != nil {
+			return
+		}
+		log.WithFields(log.Fields{
+			"accessMode": volumeAccessMode,
+			"protocol":   storageBackend.GetProtocol(),
+		}).Info("Chose the access mode of the Trident volume.")
+	}
+
+	// Only drivers that apply a QoS from the volume config can honor --volume-qos
+	if volumeQoS != "" {
+		if pvExists {
+			log.WithField("pv", pvName).Warning("PV exists, so --volume-qos is ignored.")
+		} else if storageBackend.GetDriverName() != drivers.SolidfireSANStorageDriverName {
+			returnError = fmt.Errorf("--volume-qos is not supported by the %s driver", storageBackend.GetDriverName())
+			return
+		}
+	}
+
+	// Only the ontap-nas driver sets the snapshot reserve of the volumes it creates
+	if volumeSnapshotReserve != "" {
+		if pvExists {
+			log.WithField("pv", pvName).Warning("PV exists, so --volume-snapshot-reserve is ignored.")
+		} else if storageBackend.GetDriverName() != drivers.OntapNASStorageDriverName {
+			returnError = fmt.Errorf("--volume-snapshot-reserve is not supported by the %s driver",
+				storageBackend.GetDriverName())
+			return
+		}
+	}
+
+	// The Trident volume holds backend credentials, so encryption must not silently be skipped
+	if volumeEncryption {
+		if pvExists {
+			log.WithField("pv", pvName).Warning("PV exists, so --volume-encryption is ignored.")
+		} else if pools := getEncryptionPools(storageBackend); len(pools) == 0 {
+			returnError = fmt.Errorf("--volume-encryption is not supported by backend %s", storageBackend.Name)
+			return
+		} else if _, ok := pools[volumePool]; volumePool != "" && !ok {
+			returnError = fmt.Errorf("--volume-encryption is not supported by storage pool %s", volumePool)
+			return
+		} else {
+			preCheckPassed("volumeEncryption", "Backend "+storageBackend.Name+" supports volume encryption.")
+		}
+	}
+
+	// Ensure any overlays apply cleanly to the generated YAML
+	if returnError = validateOverlays(); returnError != nil {
+		return
+	}
+	preCheckPassed("overlays", "")
+
+	// Ensure the Trident image is signed by the expected key before any pods are created
+	if verifyImageSignature {
+		if returnError = verifyTridentImageSignature(tridentImage, imageSignatureKey); returnError != nil {
+			return
+		}
+		preCheckPassed("imageSignature", "Image "+tridentImage+" is signed by the expected key.")
+	}
+
+	if schedulerName != "" {
+		log.WithField("scheduler", schedulerName).Info("Trident pods will be placed by a non-default scheduler.")
+	}
+
+	// A cluster role created outside the installer must grant what Trident needs
+	if useExistingClusterRole {
+		if returnError = checkExistingClusterRole(); returnError != nil {
+			return
+		}
+	}
+
+	// An image built for another architecture would crash on start, a controller pod with no
+	// room on any node would stay pending, and a cluster role aggregated into another would grant
+	// Trident's permissions to its subjects, so check before a dry run ends
+	if dryRun {
+		checkImageArchitectures()
+		checkControllerNodeCapacity()
+		checkClusterRoleAggregation()
+	}
+
+	// The kubelet attaches an FC Trident volume over the host bus adapters of the Trident pod's node
+	if dryRun && !pvExists && backendUsesFC(storageBackend) {
+		if returnError = checkFCNodes(); returnError != nil {
+			return
+		}
+	}
+
+	// Run the checks that need diagnostic pods
+	if deepCheck {
+		if pvExists {
+			log.WithField("pv", pvName).Warning("PV exists, so the storage driver wasn't started; " +
+				"skipping the MTU and DNS checks.")
+		} else {
+			checkBackendMTU(storageBackend)
+			checkBackendDNS(storageBackend)
+		}
+	}
+	if checkNodePrep {
+		checkNodePrepLimits()
+	}
+
+	// If dry-run was specified, stop before we change anything
+	if dryRun {
+		log.Info("Dry run completed, no problems found.")
+		return
+	}
+
+	// Give the user a last chance to review the changes before any are made
+	returnError = confirmInstallation(installState{
+		namespaceExists: namespaceExists,
+		pvcExists:       pvcExists,
+		pvExists:        pvExists,
+		pvReplaced:      pvReplaced,
+		storageBackend:  storageBackend,
+	})
+	if returnError != nil {
+		return
+	}
+
+	// All checks succeeded, so proceed with installation
+	log.WithField("namespace", TridentPodNamespace).Info("Starting Trident installation.")
+	refreshKubernetesVersion("pre-checks")
+
+	// Delete a Released or Failed PV that is being replaced
+	if pvReplaced {
+		if returnError = client.DeleteObjectByName("pv", pvName, true); returnError != nil {
+			returnError = fmt.Errorf("could not delete PV %s; %v", pvName, returnError)
+			return
+		}
+		log.WithField("pv", pvName).Info("Deleted PV.")
+	}
+
+	// Create namespace if it doesn't exist
+	if !namespaceExists {
+		if useYAML && fileExists(namespacePath) {
+			returnError = client.CreateObjectByFile(namespacePath)
+			logFields = log.Fields{"path": namespacePath}
+		} else {
+			returnError = createObjectByYAML(NamespaceFilename, k8s_client.GetNamespaceYAML(TridentPodNamespace))
+			logFields = log.Fields{"namespace": TridentPodNamespace}
+		}
+		if returnError != nil {
+			returnError = fmt.Errorf("could not create namespace %s; %v", TridentPodNamespace, returnError)
+			return
+		}
+		log.WithFields(logFields).Info("Created namespace.")
+	}
+	installEventsReady = true
+	emitInstallEvent(v1.EventTypeNormal, EventReasonInstallStarted, "Starting Trident installation.")
+
+	// Remove any RBAC objects from a previous Trident installation
+	if anyCleanupErrors := removeRBACObjects(log.DebugLevel); anyCleanupErrors {
+		returnError = fmt.Errorf("could not remove one or more previous Trident artifacts; " +
+			"please delete them manually and try again")
+		return
+	}
+
+	// Create the RBAC objects
+	if returnError = createRBACObjects(); returnError != nil {
+		return
+	}
+	emitInstallEvent(v1.EventTypeNormal, EventReasonCreatedRBAC, "Created the Trident RBAC objects.")
+
+	// Store the seeded backends' credentials before the Trident pod that mounts them is created
+	if backendCredentialsSecret != "" {
+		if returnError = createBackendCredentialsSecret(); returnError != nil {
+			return
+		}
+	}
+
+	// Create the network policy if requested
+	if createNetworkPolicy && !resumeProgress.skip(ResumeStepNetworkPolicy) {
+		if useYAML && fileExists(networkPolicyPath) {
+			returnError = client.CreateObjectByFile(networkPolicyPath)
+			logFields = log.Fields{"path": networkPolicyPath}
+		} else {
+			returnError = createObjectByYAML(NetworkPolicyFilename,
+				k8s_client.GetNetworkPolicyYAML(appLabelValue, backendCIDRs, csi))
+			logFields = log.Fields{}
+		}
+		if returnError != nil {
+			returnError = fmt.Errorf("could not create network policy; %v", returnError)
+			return
+		}
+		log.WithFields(logFields).Info("Created network policy.")
+		resumeProgress.complete(ResumeStepNetworkPolicy)
+	}
+
+	// Create PVC if necessary
+	if !pvcExists {
+		if useYAML && fileExists(pvcPath) {
+			returnError = validateTridentPVC()
+			if returnError != nil {
+				returnError = fmt.Errorf("please correct the PVC YAML file; %v", returnError)
+				return
+			}
+			returnError = client.CreateObjectByFile(pvcPath)
+			logFields = log.Fields{"path": pvcPath}
+		} else {
+			returnError = createObjectByYAML(PVCFilename, k8s_client.GetPVCYAML(
+				pvcName, TridentPodNamespace, volumeSize, volumeAccessMode, appLabelValue))
+			logFields = log.Fields{}
+		}
+		if returnError != nil {
+			returnError = fmt.Errorf("could not create PVC %s; %v", pvcName, returnError)
+			return
+		}
+		log.WithFields(logFields).Info("Created PVC.")
+		emitInstallEvent(v1.EventTypeNormal, EventReasonCreatedPVC, "Created PVC "+pvcName+".")
+	}
+
+	// Create PV if necessary
+	if !pvExists {
+		refreshKubernetesVersion("Trident PV")
+		returnError = createPV(storageBackend)
+		if returnError != nil {
+			returnError = fmt.Errorf("could not create PV %s; %v", pvName, returnError)
+			return
+		}
+		log.WithField("pv", pvName).Info("Created PV.")
+		emitInstallEvent(v1.EventTypeNormal, EventReasonCreatedPV, "Created PV "+pvName+".")
+		resumeProgress.complete(ResumeStepPV)
+	}
+
+	// Wait for PV/PVC to be bound
+	checkPVCBound := func() error {
+		bound, err := client.CheckPVCBound(pvcName)
+		if err != nil || !bound {
+			return errors.New("PVC not bound")
+		}
+		return nil
+	}
+	if checkError := checkPVCBound(); checkError != nil {
+		pvcNotify := func(err error, duration time.Duration) {
+			log.WithFields(log.Fields{
+				"pvc":       pvcName,
+				"increment": duration,
+			}).Debugf("PVC not yet bound, waiting.")
+		}
+		pvcBackoff := backoff.NewExponentialBackOff()
+		pvcBackoff.MaxElapsedTime = getStepTimeout(StepPV)
+
+		log.WithField("pvc", pvcName).Info("Waiting for PVC to be bound.")
+
+		if err := backoff.RetryNotify(checkPVCBound, pvcBackoff, pvcNotify); err != nil {
+			returnError = fmt.Errorf("PVC %s was not bound after %3.2f seconds", pvcName,
+				pvcBackoff.MaxElapsedTime.Seconds())
+			return
+		}
+	}
+
+	// Create the Kubernetes API server CA config map if requested
+	if len(k8sAPICA) > 0 && !resumeProgress.skip(ResumeStepCAConfigMap) {
+		if useYAML && fileExists(k8sAPICAPath) {
+			returnError = client.CreateObjectByFile(k8sAPICAPath)
+			logFields = log.Fields{"path": k8sAPICAPath}
+		} else {
+			returnError = createObjectByYAML(K8sAPICAFilename,
+				k8s_client.GetK8sAPICAConfigMapYAML(appLabelValue, string(k8sAPICA)))
+			logFields = log.Fields{}
+		}
+		if returnError != nil {
+			returnError = fmt.Errorf("could not create Kubernetes API server CA config map; %v", returnError)
+			return
+		}
+		log.WithFields(logFields).Info("Created Kubernetes API server CA config map.")
+		resumeProgress.complete(ResumeStepCAConfigMap)
+	}
+
+	// Create the headless service for the pod's subdomain if requested
+	if podSubdomain != "" && !resumeProgress.skip(ResumeStepHeadlessService) {
+		if useYAML && fileExists(headlessServicePath) {
+			returnError = client.CreateObjectByFile(headlessServicePath)
+			logFields = log.Fields{"path": headlessServicePath}
+		} else {
+			returnError = createObjectByYAML(HeadlessServiceFilename,
+				k8s_client.GetHeadlessServiceYAML(podSubdomain, appLabelValue))
+			logFields = log.Fields{"service": podSubdomain}
+		}
+		if returnError != nil {
+			returnError = fmt.Errorf("could not create headless service; %v", returnError)
+			return
+		}
+		log.WithFields(logFields).Info("Created headless service.")
+		resumeProgress.complete(ResumeStepHeadlessService)
+	}
+
+	refreshKubernetesVersion("Trident workloads")
+
+	if !csi {
+
+		// Create the deployment
+		if !resumeProgress.skip(ResumeStepDeployment) {
+			if useYAML && fileExists(deploymentPath) {
+				returnError = validateTridentDeployment()
+				if returnError != nil {
+					returnError = fmt.Errorf("please correct the deployment YAML file; %v", returnError)
+					return
+				}
+				returnError = client.CreateObjectByFile(deploymentPath)
+				logFields = log.Fields{"path": deploymentPath}
+			} else {
+				returnError = createObjectByYAML(DeploymentFilename,
+					k8s_client.GetDeploymentYAML(pvcName, tridentImage, etcdImage, appLabelValue, Debug, client.Version(), podOptions))
+				logFields = log.Fields{}
+			}
+			if returnError != nil {
+				returnError = fmt.Errorf("could not create Trident deployment; %v", returnError)
+				return
+			}
+			log.WithFields(logFields).Info("Created Trident deployment.")
+			emitInstallEvent(v1.EventTypeNormal, EventReasonCreatedController, "Created the Trident deployment.")
+			resumeProgress.complete(ResumeStepDeployment)
+		}
+
+	} else {
+
+		// Create the service
+		if !resumeProgress.skip(ResumeStepService) {
+			if useYAML && fileExists(csiServicePath) {
+				returnError = validateTridentService()
+				if returnError != nil {
+					returnError = fmt.Errorf("please correct the service YAML file; %v", returnError)
+					return
+				}
+				returnError = client.CreateObjectByFile(csiServicePath)
+				logFields = log.Fields{"path": csiServicePath}
+			} else {
+				returnError = createObjectByYAML(ServiceFilename, k8s_client.GetCSIServiceYAML(appLabelValue))
+				logFields = log.Fields{}
+			}
+			if returnError != nil {
+				returnError = fmt.Errorf("could not create Trident service; %v", returnError)
+				return
+			}
+			log.WithFields(logFields).Info("Created Trident service.")
+			resumeProgress.complete(ResumeStepService)
+		}
+
+		// Create the statefulset
+		if !resumeProgress.skip(ResumeStepStatefulSet) {
+			if useYAML && fileExists(csiStatefulSetPath) {
+				returnError = validateTridentStatefulSet()
+				if returnError != nil {
+					returnError = fmt.Errorf("please correct the statefulset YAML file; %v", returnError)
+					return
+				}
+				returnError = client.CreateObjectByFile(csiStatefulSetPath)
+				logFields = log.Fields{"path": csiStatefulSetPath}
+			} else {
+				returnError = createObjectByYAML(StatefulSetFilename,
+					k8s_client.GetCSIStatefulSetYAML(
+						pvcName, tridentImage, etcdImage, appLabelValue, Debug, client.Version(), podOptions))
+				logFields = log.Fields{}
+			}
+			if returnError != nil {
+				returnError = fmt.Errorf("could not create Trident statefulset; %v", returnError)
+				return
+			}
+			log.WithFields(logFields).Info("Created Trident statefulset.")
+			resumeProgress.complete(ResumeStepStatefulSet)
+		}
+
+		// Create the daemonset
+		if !resumeProgress.skip(ResumeStepDaemonSet) {
+			if useYAML && fileExists(csiDaemonSetPath) {
+				returnError = validateTridentDaemonSet()
+				if returnError != nil {
+					returnError = fmt.Errorf("please correct the daemonset YAML file; %v", returnError)
+					return
+				}
+				returnError = client.CreateObjectByFile(csiDaemonSetPath)
+				logFields = log.Fields{"path": csiDaemonSetPath}
+			} else {
+				returnError = createObjectByYAML(DaemonSetFilename,
+					k8s_client.GetCSIDaemonSetYAML(tridentImage, TridentNodeLabelValue, Debug, client.Version(), podOptions))
+				logFields = log.Fields{}
+			}
+			if returnError != nil {
+				returnError = fmt.Errorf("could not create Trident daemonset; %v", returnError)
+				return
+			}
+			log.WithFields(logFields).Info("Created Trident daemonset.")
+			resumeProgress.complete(ResumeStepDaemonSet)
+		}
+		emitInstallEvent(v1.EventTypeNormal, EventReasonCreatedController,
+			"Created the Trident statefulset and daemonset.")
+	}
+
+	// Restore the etcd snapshot before the Trident pod's containers start
+	if restoreSnapshot != "" && !resumeProgress.skip(ResumeStepRestore) {
+		if returnError = copyEtcdSnapshot(); returnError != nil {
+			if retainFailedPod {
+				logFailedPodInspectionCommands()
+			}
+			return
+		}
+		resumeProgress.complete(ResumeStepRestore)
+	}
+
+	// Wait for Trident pod to be running
+	var tridentPod *v1.Pod
+
+	tridentPod, returnError = waitForTridentPod()
+	if returnError != nil {
+		if retainFailedPod {
+			logFailedPodInspectionCommands()
+		}
+		return
+	}
+	emitInstallEvent(v1.EventTypeNormal, EventReasonTridentPodRunning, "Trident pod "+tridentPod.Name+" is running.")
 
-	namespaceYAML := k8s_client.GetNamespaceYAML(TridentPodNamespace)
-	if err = writeFile(namespacePath, namespaceYAML); err != nil {
-		return fmt.Errorf("could not write namespace YAML file; %v", err)
+	// Wait for Trident REST interface to be available
+	TridentPodName = tridentPod.Name
+	var serverVersion string
+	serverVersion, returnError = waitForRESTInterface()
+	if returnError != nil {
+		if retainFailedPod {
+			logFailedPodInspectionCommands()
+		}
+		returnError = fmt.Errorf("%v; use 'tridentctl logs' to learn more", returnError)
+		return
 	}
 
-	serviceAccountYAML := k8s_client.GetServiceAccountYAML(false)
-	if err = writeFile(serviceAccountPath, serviceAccountYAML); err != nil {
-		return fmt.Errorf("could not write service account YAML file; %v", err)
+	// Ensure the pod runs the requested version, not a stale image cached on its node
+	if returnError = checkTridentVersion(serverVersion); returnError != nil {
+		return
 	}
 
-	clusterRoleYAML := k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), false)
-	if err = writeFile(clusterRolePath, clusterRoleYAML); err != nil {
-		return fmt.Errorf("could not write cluster role YAML file; %v", err)
+	// Ensure etcd is healthy, not just running, before relying on it
+	if returnError = waitForEtcdHealthy(tridentPod); returnError != nil {
+		if retainFailedPod {
+			logFailedPodInspectionCommands()
+		}
+		returnError = fmt.Errorf("%v; use 'tridentctl logs -l etcd' to learn more", returnError)
+		return
+	}
+	if restoreSnapshot != "" {
+		if returnError = verifyRestoredState(tridentPod); returnError != nil {
+			return
+		}
 	}
 
-	clusterRoleBindingYAML := k8s_client.GetClusterRoleBindingYAML(
-		TridentPodNamespace, client.Flavor(), client.Version(), false)
-	if err = writeFile(clusterRoleBindingPath, clusterRoleBindingYAML); err != nil {
-		return fmt.Errorf("could not write cluster role binding YAML file; %v", err)
+	// Ensure the node plugin is running and registered with the kubelet on each node
+	if csi {
+		var nodeNames []string
+		if nodeNames, returnError = waitForDaemonSetReady(); returnError != nil {
+			return
+		}
+		if returnError = waitForPluginSockets(nodeNames); returnError != nil {
+			return
+		}
+		if returnError = waitForCSINodeRegistration(nodeNames); returnError != nil {
+			return
+		}
+		var unhealthyNodeNames []string
+		if csiLivenessProbe {
+			unhealthyNodeNames = reportUnhealthyCSINodes()
+		}
+		if labelReadyNodes {
+			if returnError = labelTridentReadyNodes(nodeNames, unhealthyNodeNames); returnError != nil {
+				return
+			}
+		}
 	}
 
-	pvcYAML := k8s_client.GetPVCYAML(pvcName, TridentPodNamespace, volumeSize, appLabelValue)
-	if err = writeFile(pvcPath, pvcYAML); err != nil {
-		return fmt.Errorf("could not write PVC YAML file; %v", err)
+	emitInstallEvent(v1.EventTypeNormal, EventReasonTridentReady, "Trident is ready.")
+
+	// Add any backends to seed now that Trident is running
+	if len(seedBackends) > 0 && !resumeProgress.skip(ResumeStepSeedBackends) {
+		if returnError = seedTridentBackends(); returnError != nil {
+			return
+		}
+		resumeProgress.complete(ResumeStepSeedBackends)
 	}
 
-	deploymentYAML := k8s_client.GetDeploymentYAML(pvcName, tridentImage, etcdImage, appLabelValue, Debug)
-	if err = writeFile(deploymentPath, deploymentYAML); err != nil {
-		return fmt.Errorf("could not write deployment YAML file; %v", err)
+	// Create the storage class if requested
+	if createStorageClass != "" {
+		if returnError = createTridentStorageClass(); returnError != nil {
+			return
+		}
 	}
 
+	resumeProgress.finish()
+
+	log.Info("Trident installation succeeded.")
 	return nil
 }
 
-func prepareCSIYAMLFiles() error {
-
-	var err error
-
-	cleanYAMLFiles()
+func loadStorageDriver() (backend *storage.Backend, returnError error) {
 
-	namespaceYAML := k8s_client.GetNamespaceYAML(TridentPodNamespace)
-	if err = writeFile(namespacePath, namespaceYAML); err != nil {
-		return fmt.Errorf("could not write namespace YAML file; %v", err)
+	// Set up telemetry so any PV we create has the correct metadata
+	tridentconfig.OrchestratorTelemetry = tridentconfig.Telemetry{
+		TridentVersion:  tridentconfig.OrchestratorVersion.String(),
+		Platform:        "kubernetes",
+		PlatformVersion: client.Version().ShortString(),
 	}
 
-	serviceAccountYAML := k8s_client.GetServiceAccountYAML(true)
-	if err = writeFile(serviceAccountPath, serviceAccountYAML); err != nil {
-		return fmt.Errorf("could not write service account YAML file; %v", err)
+	if csi {
+		tridentconfig.CurrentDriverContext = tridentconfig.ContextCSI
+	} else {
+		tridentconfig.CurrentDriverContext = tridentconfig.ContextKubernetes
 	}
 
-	clusterRoleYAML := k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), true)
-	if err = writeFile(clusterRolePath, clusterRoleYAML); err != nil {
-		return fmt.Errorf("could not write cluster role YAML file; %v", err)
+	// Ensure the setup directory & backend config file are present
+	if _, returnError = os.Stat(setupPath); os.IsNotExist(returnError) {
+		returnError = fmt.Errorf("setup directory does not exist; %v", returnError)
+		return
 	}
+	factory.CredentialsResolver = getKubernetesCredentials
 
-	clusterRoleBindingYAML := k8s_client.GetClusterRoleBindingYAML(
-		TridentPodNamespace, client.Flavor(), client.Version(), true)
-	if err = writeFile(clusterRoleBindingPath, clusterRoleBindingYAML); err != nil {
-		return fmt.Errorf("could not write cluster role binding YAML file; %v", err)
+	// Choose the first capable backend of a config directory instead of the setup directory's config
+	if backendConfigDir != "" {
+		return startBackendConfigDirDriver()
 	}
-
-	pvcYAML := k8s_client.GetPVCYAML(pvcName, TridentPodNamespace, volumeSize, appLabelValue)
-	if err = writeFile(pvcPath, pvcYAML); err != nil {
-		return fmt.Errorf("could not write PVC YAML file; %v", err)
+	if backendConfigFilePath, returnError = findBackendConfigFile(); returnError != nil {
+		return
 	}
 
-	serviceYAML := k8s_client.GetCSIServiceYAML(appLabelValue)
-	if err = writeFile(csiServicePath, serviceYAML); err != nil {
-		return fmt.Errorf("could not write service YAML file; %v", err)
-	}
+	// Try to start the driver, which is the source of many installation problems and
+	// will be needed to if we have to provision the Trident PV.
+	return startStorageDriver(backendConfigFilePath)
+}
 
-	statefulSetYAML := k8s_client.GetCSIStatefulSetYAML(pvcName, tridentImage, etcdImage, appLabelValue, Debug)
-	if err = writeFile(csiStatefulSetPath, statefulSetYAML); err != nil {
-		return fmt.Errorf("could not write statefulset YAML file; %v", err)
-	}
+// checkBackendState fails if the backend's storage system reports that it can't serve requests.
+// Drivers that can't report their state are assumed to be ready.
+func checkBackendState(sb *storage.Backend) error {
 
-	daemonSetYAML := k8s_client.GetCSIDaemonSetYAML(tridentImage, TridentNodeLabelValue, Debug)
-	if err = writeFile(csiDaemonSetPath, daemonSetYAML); err != nil {
-		return fmt.Errorf("could not write daemonset YAML file; %v", err)
+	stateReporter, ok := sb.Driver.(storage.StateReporter)
+	if !ok {
+		log.WithField("driver", sb.GetDriverName()).Debug("Driver does not report the backend state.")
+		return nil
 	}
 
+	reason, err := stateReporter.GetBackendState()
+	if err != nil {
+		log.WithFields(log.Fields{
+			"backend": sb.Name,
+			"error":   err,
+		}).Warning("Could not get the backend state.")
+		return nil
+	}
+	if reason != "" {
+		return fmt.Errorf("backend %s can't serve requests; %s", sb.Name, reason)
+	}
 	return nil
 }
 
-func writeFile(filePath, data string) error {
-	return ioutil.WriteFile(filePath, []byte(data), 0644)
-}
-
-func installTrident() (returnError error) {
-
-	var (
-		logFields           log.Fields
-		pvcExists           bool
-		pvExists            bool
-		pvc                 *v1.PersistentVolumeClaim
-		pv                  *v1.PersistentVolume
-		pvRequestedQuantity resource.Quantity
-		storageBackend      *storage.Backend
-	)
+// startStorageDriver starts the storage driver for a JSON or YAML backend config file.
+func startStorageDriver(configFilePath string) (*storage.Backend, error) {
 
-	// Validate volume size
-	pvRequestedQuantity, err := resource.ParseQuantity(volumeSize)
+	log.WithField("backend", configFilePath).Info("Starting storage driver.")
+	configFileBytes, err := ioutil.ReadFile(configFilePath)
 	if err != nil {
-		return fmt.Errorf("volume-size '%s' is invalid; %v", volumeSize, err)
+		return nil, fmt.Errorf("could not read the storage backend config file; %v", err)
+	}
+	configJSON, err := backendConfigToJSON(configFilePath, configFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateStorageDriverName(configJSON); err != nil {
+		return nil, err
+	}
+	if showBackendConfig {
+		printRedactedBackendConfig(configFilePath, configJSON)
+	}
+	if backendHTTPTimeout != 0 {
+		tridentconfig.StorageAPITimeout = backendHTTPTimeout
+	}
+	backend, err := factory.NewStorageBackendForConfig(configJSON)
+	if err != nil {
+		return nil, fmt.Errorf("could not start the storage backend driver; %v", err)
 	}
-	log.WithField("quantity", pvRequestedQuantity.String()).Debug("Parsed requested volume size.")
-
-	if !csi {
-		log.WithFields(log.Fields{
-			"useKubernetesRBAC": useKubernetesRBAC,
-			"ucpBearerToken":    ucpBearerToken,
-			"ucpHost":           ucpHost,
-		}).Debug("Dumping RBAC fields.")
-
-		// Ensure Trident isn't already installed
-		if installed, namespace, err := isTridentInstalled(); err != nil {
-			return fmt.Errorf("could not check if Trident deployment exists; %v", err)
-		} else if installed {
-			return fmt.Errorf("Trident is already installed in namespace %s", namespace)
-		}
-
-	} else {
 
-		// Ensure CSI minimum requirements are met
-		minCSIVersion := utils.MustParseSemantic(tridentconfig.KubernetesCSIVersionMin)
-		if !client.Version().AtLeast(minCSIVersion) {
-			return fmt.Errorf("CSI Trident requires Kubernetes %s or later", minCSIVersion.ShortString())
-		}
+	log.WithField("driver", backend.GetDriverName()).Info("Storage driver loaded.")
+	return backend, nil
+}
 
-		// Ensure CSI Trident isn't already installed
-		if installed, namespace, err := isCSITridentInstalled(); err != nil {
-			return fmt.Errorf("could not check if Trident statefulset exists; %v", err)
-		} else if installed {
-			return fmt.Errorf("CSI Trident is already installed in namespace %s", namespace)
-		}
+// validateBackendConfig checks the storage backend config without starting its storage driver,
+// which would contact the storage system, and reports the driver and the protocol of its volumes.
+func validateBackendConfig() error {
 
-		log.Warning("CSI Trident for Kubernetes is a technology preview " +
-			"and should not be installed in production environments!")
+	configFilePath, err := findBackendConfigFile()
+	if err != nil {
+		return err
 	}
+	log.WithField("backend", configFilePath).Info("Validating storage backend config.")
 
-	// Check if the required namespace exists
-	namespaceExists, returnError := client.CheckNamespaceExists(TridentPodNamespace)
-	if returnError != nil {
-		returnError = fmt.Errorf("could not check if namespace %s exists; %v", TridentPodNamespace, returnError)
-		return
+	configFileBytes, err := ioutil.ReadFile(configFilePath)
+	if err != nil {
+		return fmt.Errorf("could not read the storage backend config file; %v", err)
 	}
-	if namespaceExists {
-		log.WithField("namespace", TridentPodNamespace).Debug("Namespace exists.")
-	} else {
-		log.WithField("namespace", TridentPodNamespace).Debug("Namespace does not exist.")
+	configJSON, err := backendConfigToJSON(configFilePath, configFileBytes)
+	if err != nil {
+		return err
 	}
-
-	// Check for PVC (also returns (false, nil) if namespace does not exist)
-	pvcExists, returnError = client.CheckPVCExists(pvcName)
-	if returnError != nil {
-		returnError = fmt.Errorf("could not establish the presence of PVC %s; %v", pvcName, returnError)
-		return
+	if err = validateStorageDriverName(configJSON); err != nil {
+		return err
+	}
+	if showBackendConfig {
+		printRedactedBackendConfig(configFilePath, configJSON)
+	}
+	commonConfig, protocol, err := factory.ValidateStorageBackendConfig(configJSON)
+	if err != nil {
+		return fmt.Errorf("the storage backend config %s is invalid; %v", configFilePath, err)
 	}
-	if pvcExists {
-		pvc, returnError = client.GetPVC(pvcName)
-		if returnError != nil {
-			returnError = fmt.Errorf("could not retrieve PVC %s; %v", pvcName, returnError)
-			return
-		}
-
-		// Ensure that the PVC is in a state that we can work with
-		if pvc.Status.Phase == v1.ClaimLost {
-			returnError = fmt.Errorf("PVC %s phase is Lost; please delete it and try again", pvcName)
-			return
-		}
-		if pvc.Status.Phase == v1.ClaimBound && pvc.Spec.VolumeName != pvName {
-			returnError = fmt.Errorf("PVC %s is Bound, but not to PV %s; "+
-				"please specify a different PV and/or PVC", pvcName, pvName)
-			return
-		}
-		if pvc.Labels == nil || pvc.Labels[appLabelKey] != appLabelValue {
-			returnError = fmt.Errorf("PVC %s does not have %s label; "+
-				"please add label or delete PVC and try again", pvcName, appLabel)
-			return
-		}
 
-		log.WithFields(log.Fields{
-			"pvc":       pvcName,
-			"namespace": pvc.Namespace,
-			"phase":     pvc.Status.Phase,
-		}).Debug("PVC already exists.")
+	installResult.BackendDriver = commonConfig.StorageDriverName
+	installResult.BackendProtocol = string(protocol)
+	log.WithFields(log.Fields{
+		"backend":  configFilePath,
+		"driver":   commonConfig.StorageDriverName,
+		"protocol": protocol,
+	}).Info("Storage backend config is valid; the storage driver wasn't started.")
+	preCheckPassed("backendConfig", fmt.Sprintf("The config of the %s driver, with protocol %s, is valid.",
+		commonConfig.StorageDriverName, protocol))
+	return nil
+}
 
-	} else {
-		log.WithField("pvc", pvcName).Debug("PVC does not exist.")
+// printRedactedBackendConfig prints a backend config as the storage driver will receive it, with
+// any credentials secret resolved, so users can confirm the installer parsed what they expected.
+// The credentials are redacted.  A secret that can't be resolved is left for the driver to report,
+// and the config is printed as it is.  The config is written to stderr, alongside the installer's
+// log, so it doesn't mix with the install summary or the output of a fleet install on stdout.
+func printRedactedBackendConfig(configFilePath, configJSON string) {
+
+	if commonConfig, err := drivers.ValidateCommonSettings(configJSON); err == nil {
+		if resolvedJSON, err := drivers.InjectCredentials(configJSON, commonConfig,
+			factory.CredentialsResolver); err == nil {
+			configJSON = resolvedJSON
+		}
 	}
 
-	// Check for PV
-	pvExists, returnError = client.CheckPVExists(pvName)
-	if returnError != nil {
-		returnError = fmt.Errorf("could not establish the presence of PV %s; %v", pvName, returnError)
+	redactedJSON, err := drivers.RedactConfig(configJSON)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"backend": configFilePath,
+			"error":   err,
+		}).Warning("Could not redact the storage backend config.")
 		return
 	}
-	if pvExists {
-		pv, returnError = client.GetPV(pvName)
-		if returnError != nil {
-			returnError = fmt.Errorf("could not retrieve PV %s; %v", pvName, returnError)
-			return
-		}
+	fmt.Fprintf(os.Stderr, "# %s\n%s\n", configFilePath, redactedJSON)
+}
 
-		// Ensure that the PV is in a state we can work with
-		if pv.Status.Phase == v1.VolumeReleased {
-			returnError = fmt.Errorf("PV %s phase is Released; please delete it and try again", pvName)
-			return
-		}
-		if pv.Status.Phase == v1.VolumeFailed {
-			returnError = fmt.Errorf("PV %s phase is Failed; please delete it and try again", pvName)
-			return
-		}
-		if pv.Status.Phase == v1.VolumeBound && pv.Spec.ClaimRef != nil {
-			if pv.Spec.ClaimRef.Name != pvcName {
-				returnError = fmt.Errorf("PV %s is Bound, but not to PVC %s; "+
-					"please delete PV and try again", pvName, pvcName)
-				return
-			}
-			if pv.Spec.ClaimRef.Namespace != TridentPodNamespace {
-				returnError = fmt.Errorf("PV %s is Bound to a PVC in namespace %s; "+
-					"please delete PV and try again", pvName, pv.Spec.ClaimRef.Namespace)
-				return
-			}
-		}
-		if pv.Labels == nil || pv.Labels[appLabelKey] != appLabelValue {
-			returnError = fmt.Errorf("PV %s does not have %s label; "+
-				"please add label or delete PV and try again", pvName, appLabel)
-			return
-		}
+// checkNamespaceTerminating handles a Trident namespace left terminating by a recent uninstall.
+// With --wait-for-namespace, it waits for the namespace to be deleted so it can be recreated;
+// otherwise it returns an error.  It returns whether the namespace still exists.
+func checkNamespaceTerminating() (bool, error) {
 
-		// Ensure PV size matches the request
-		if pvActualQuantity, ok := pv.Spec.Capacity[v1.ResourceStorage]; !ok {
-			log.WithField("pv", pvName).Warning("Could not determine size of existing PV.")
-		} else if pvRequestedQuantity.Cmp(pvActualQuantity) != 0 {
-			log.WithFields(log.Fields{
-				"existing": pvActualQuantity.String(),
-				"request":  pvRequestedQuantity.String(),
-				"pv":       pvName,
-			}).Warning("Existing PV size does not match request.")
-		}
+	namespace, err := client.GetNamespace(TridentPodNamespace)
+	if err != nil {
+		return false, fmt.Errorf("could not get namespace %s; %v", TridentPodNamespace, err)
+	}
+	if namespace == nil {
+		return false, nil
+	}
+	if namespace.Status.Phase != v1.NamespaceTerminating {
+		return true, nil
+	}
 
-		log.WithFields(log.Fields{
-			"pv":    pvName,
-			"phase": pv.Status.Phase,
-		}).Debug("PV already exists.")
+	logFields := log.Fields{"namespace": TridentPodNamespace}
 
-	} else {
-		log.WithField("pv", pvName).Debug("PV does not exist.")
+	if !waitForNamespace {
+		return true, fmt.Errorf("namespace %s is terminating; wait for it to be deleted, or use "+
+			"--wait-for-namespace", TridentPodNamespace)
+	}
+	if dryRun {
+		log.WithFields(logFields).Info("Namespace is terminating; the installer will wait for it to be deleted.")
+		return false, nil
 	}
 
-	// If the PV doesn't exist, we will need the storage driver to create it. Load the driver
-	// here to detect any problems before starting the installation steps.
-	if !pvExists {
-		if storageBackend, returnError = loadStorageDriver(); returnError != nil {
-			return
+	checkNamespaceDeleted := func() error {
+		exists, err := client.CheckNamespaceExists(TridentPodNamespace)
+		if err != nil || exists {
+			return errors.New("namespace still exists")
 		}
-	} else {
-		log.Debug("PV exists, skipping storage driver check.")
+		return nil
 	}
+	namespaceNotify := func(err error, duration time.Duration) {
+		log.WithFields(logFields).WithField("increment", duration).Debug("Namespace still terminating, waiting.")
+	}
+	namespaceBackoff := backoff.NewExponentialBackOff()
+	namespaceBackoff.MaxElapsedTime = getStepTimeout(StepNamespace)
 
-	// If dry-run was specified, stop before we change anything
-	if dryRun {
-		log.Info("Dry run completed, no problems found.")
-		return
+	log.WithFields(logFields).Info("Waiting for terminating namespace to be deleted.")
+
+	if err := backoff.RetryNotify(checkNamespaceDeleted, namespaceBackoff, namespaceNotify); err != nil {
+		return true, fmt.Errorf("namespace %s was still terminating after %3.2f seconds", TridentPodNamespace,
+			namespaceBackoff.MaxElapsedTime.Seconds())
 	}
 
-	// All checks succeeded, so proceed with installation
-	log.WithField("namespace", TridentPodNamespace).Info("Starting Trident installation.")
+	log.WithFields(logFields).Info("Terminating namespace was deleted.")
+	return false, nil
+}
 
-	// Create namespace if it doesn't exist
-	if !namespaceExists {
-		if useYAML && fileExists(namespacePath) {
-			returnError = client.CreateObjectByFile(namespacePath)
-			logFields = log.Fields{"path": namespacePath}
-		} else {
-			returnError = client.CreateObjectByYAML(k8s_client.GetNamespaceYAML(TridentPodNamespace))
-			logFields = log.Fields{"namespace": TridentPodNamespace}
-		}
-		if returnError != nil {
-			returnError = fmt.Errorf("could not create namespace %s; %v", TridentPodNamespace, returnError)
-			return
+// validateStorageDriverName checks that the driver named in a backend config is one this build
+// includes, which gives clearer feedback for a typo than the driver factory does.
+func validateStorageDriverName(configJSON string) error {
+
+	var config struct {
+		StorageDriverName string `json:"storageDriverName"`
+	}
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return fmt.Errorf("could not parse the storage backend config; %v", err)
+	}
+
+	supportedNames := factory.SupportedDriverNames()
+	if config.StorageDriverName == "" {
+		return fmt.Errorf("the storage backend config must specify storageDriverName; supported drivers "+
+			"are %s", strings.Join(supportedNames, ", "))
+	}
+	for _, name := range supportedNames {
+		if config.StorageDriverName == name {
+			return nil
 		}
-		log.WithFields(logFields).Info("Created namespace.")
 	}
+	return fmt.Errorf("storage driver '%s' is not supported by this build of tridentctl; supported "+
+		"drivers are %s", config.StorageDriverName, strings.Join(supportedNames, ", "))
+}
 
-	// Remove any RBAC objects from a previous Trident installation
-	if anyCleanupErrors := removeRBACObjects(log.DebugLevel); anyCleanupErrors {
-		returnError = fmt.Errorf("could not remove one or more previous Trident artifacts; " +
-			"please delete them manually and try again")
-		return
+// checkResourceQuotas compares the resources the installer will consume in the Trident namespace
+// against the quota remaining in any resource quotas there.  Any shortfall is logged, and it is
+// returned as an error if --strict-quota was specified.
+func checkResourceQuotas(pvcNeeded bool, pvcSize resource.Quantity) error {
+
+	quotas, err := client.GetResourceQuotas(TridentPodNamespace)
+	if err != nil {
+		return fmt.Errorf("could not list resource quotas in namespace %s; %v", TridentPodNamespace, err)
+	}
+	if len(quotas) == 0 {
+		log.WithField("namespace", TridentPodNamespace).Debug("No resource quotas found.")
+		return nil
 	}
 
-	// Create the RBAC objects
-	if returnError = createRBACObjects(); returnError != nil {
-		return
+	// Tally up what the installer will create in the namespace
+	podCount := int64(1)
+	if csi {
+		nodes, err := client.GetNodes()
+		if err != nil {
+			return fmt.Errorf("could not list nodes; %v", err)
+		}
+		podCount += int64(len(nodes))
+	}
+	planned := v1.ResourceList{
+		v1.ResourcePods: *resource.NewQuantity(podCount, resource.DecimalSI),
+	}
+	if pvcNeeded {
+		planned[v1.ResourcePersistentVolumeClaims] = *resource.NewQuantity(1, resource.DecimalSI)
+		planned[v1.ResourceRequestsStorage] = pvcSize
+	}
+	if csi {
+		planned[v1.ResourceServices] = *resource.NewQuantity(1, resource.DecimalSI)
 	}
 
-	// Create PVC if necessary
-	if !pvcExists {
-		if useYAML && fileExists(pvcPath) {
-			returnError = validateTridentPVC()
-			if returnError != nil {
-				returnError = fmt.Errorf("please correct the PVC YAML file; %v", returnError)
-				return
+	// Quotas on compute resources reject any pod that doesn't declare them
+	computeResources := []v1.ResourceName{
+		v1.ResourceCPU, v1.ResourceMemory,
+		v1.ResourceRequestsCPU, v1.ResourceRequestsMemory,
+		v1.ResourceLimitsCPU, v1.ResourceLimitsMemory,
+	}
+
+	var shortfalls []string
+	for _, quota := range quotas {
+		for name, plannedQuantity := range planned {
+			hard, ok := quota.Status.Hard[name]
+			if !ok {
+				continue
+			}
+			used := quota.Status.Used[name]
+			remaining := hard.DeepCopy()
+			remaining.Sub(used)
+			if plannedQuantity.Cmp(remaining) > 0 {
+				shortfalls = append(shortfalls, fmt.Sprintf("quota %s allows %s %s, %s used, but Trident needs %s",
+					quota.Name, hard.String(), name, used.String(), plannedQuantity.String()))
 			}
-			returnError = client.CreateObjectByFile(pvcPath)
-			logFields = log.Fields{"path": pvcPath}
-		} else {
-			returnError = client.CreateObjectByYAML(k8s_client.GetPVCYAML(
-				pvcName, TridentPodNamespace, volumeSize, appLabelValue))
-			logFields = log.Fields{}
 		}
-		if returnError != nil {
-			returnError = fmt.Errorf("could not create PVC %s; %v", pvcName, returnError)
-			return
+		for _, name := range computeResources {
+			if _, ok := quota.Status.Hard[name]; ok {
+				shortfalls = append(shortfalls, fmt.Sprintf("quota %s limits %s, which the Trident pods "+
+					"do not specify, so they will be rejected unless a LimitRange provides defaults",
+					quota.Name, name))
+			}
 		}
-		log.WithFields(logFields).Info("Created PVC.")
 	}
 
-	// Create PV if necessary
-	if !pvExists {
-		returnError = createPV(storageBackend)
-		if returnError != nil {
-			returnError = fmt.Errorf("could not create PV %s; %v", pvName, returnError)
-			return
-		}
-		log.WithField("pv", pvName).Info("Created PV.")
+	if len(shortfalls) == 0 {
+		log.WithField("namespace", TridentPodNamespace).Debug("Resource quotas have room for Trident.")
+		return nil
 	}
 
-	// Wait for PV/PVC to be bound
-	checkPVCBound := func() error {
-		bound, err := client.CheckPVCBound(pvcName)
-		if err != nil || !bound {
-			return errors.New("PVC not bound")
-		}
-		return nil
+	for _, shortfall := range shortfalls {
+		log.WithField("namespace", TridentPodNamespace).Warning("Resource quota shortfall: " + shortfall + ".")
+	}
+	if strictQuota {
+		return fmt.Errorf("namespace %s resource quotas would not admit Trident; %s",
+			TridentPodNamespace, strings.Join(shortfalls, "; "))
 	}
-	if checkError := checkPVCBound(); checkError != nil {
-		pvcNotify := func(err error, duration time.Duration) {
-			log.WithFields(log.Fields{
-				"pvc":       pvcName,
-				"increment": duration,
-			}).Debugf("PVC not yet bound, waiting.")
-		}
-		pvcBackoff := backoff.NewExponentialBackOff()
-		pvcBackoff.MaxElapsedTime = k8sTimeout
 
-		log.WithField("pvc", pvcName).Info("Waiting for PVC to be bound.")
+	return nil
+}
 
-		if err := backoff.RetryNotify(checkPVCBound, pvcBackoff, pvcNotify); err != nil {
-			returnError = fmt.Errorf("PVC %s was not bound after %d seconds", pvcName, k8sTimeout)
-			return
-		}
-	}
+// findBackendConfigFile returns the path to the storage backend config file in the setup
+// directory.  A JSON config is preferred, but a YAML config is accepted if no JSON one exists.
+func findBackendConfigFile() (string, error) {
 
-	if !csi {
+	candidates := []string{
+		backendConfigFilePath,
+		path.Join(setupPath, BackendConfigYAMLFilename),
+		path.Join(setupPath, BackendConfigYMLFilename),
+	}
 
-		// Create the deployment
-		if useYAML && fileExists(deploymentPath) {
-			returnError = validateTridentDeployment()
-			if returnError != nil {
-				returnError = fmt.Errorf("please correct the deployment YAML file; %v", returnError)
-				return
-			}
-			returnError = client.CreateObjectByFile(deploymentPath)
-			logFields = log.Fields{"path": deploymentPath}
-		} else {
-			returnError = client.CreateObjectByYAML(
-				k8s_client.GetDeploymentYAML(pvcName, tridentImage, etcdImage, appLabelValue, Debug))
-			logFields = log.Fields{}
-		}
-		if returnError != nil {
-			returnError = fmt.Errorf("could not create Trident deployment; %v", returnError)
-			return
+	for _, candidate := range candidates {
+		if fileExists(candidate) {
+			return candidate, nil
 		}
-		log.WithFields(logFields).Info("Created Trident deployment.")
+	}
 
-	} else {
+	return "", fmt.Errorf("storage backend config file does not exist; expected one of %s, %s, or %s "+
+		"in %s", BackendConfigFilename, BackendConfigYAMLFilename, BackendConfigYMLFilename, setupPath)
+}
 
-		// Create the service
-		if useYAML && fileExists(csiServicePath) {
-			returnError = validateTridentService()
-			if returnError != nil {
-				returnError = fmt.Errorf("please correct the service YAML file; %v", returnError)
-				return
-			}
-			returnError = client.CreateObjectByFile(csiServicePath)
-			logFields = log.Fields{"path": csiServicePath}
-		} else {
-			returnError = client.CreateObjectByYAML(k8s_client.GetCSIServiceYAML(appLabelValue))
-			logFields = log.Fields{}
-		}
-		if returnError != nil {
-			returnError = fmt.Errorf("could not create Trident service; %v", returnError)
-			return
-		}
-		log.WithFields(logFields).Info("Created Trident service.")
+// backendConfigToJSON returns the storage backend config as JSON.  Files with a YAML extension,
+// or whose content isn't valid JSON, are converted from YAML.  Any YAML syntax error is reported
+// along with the offending line of the original file.
+func backendConfigToJSON(filePath string, configBytes []byte) (string, error) {
 
-		// Create the statefulset
-		if useYAML && fileExists(csiStatefulSetPath) {
-			returnError = validateTridentStatefulSet()
-			if returnError != nil {
-				returnError = fmt.Errorf("please correct the statefulset YAML file; %v", returnError)
-				return
-			}
-			returnError = client.CreateObjectByFile(csiStatefulSetPath)
-			logFields = log.Fields{"path": csiStatefulSetPath}
-		} else {
-			returnError = client.CreateObjectByYAML(
-				k8s_client.GetCSIStatefulSetYAML(pvcName, tridentImage, etcdImage, appLabelValue, Debug))
-			logFields = log.Fields{}
-		}
-		if returnError != nil {
-			returnError = fmt.Errorf("could not create Trident statefulset; %v", returnError)
-			return
-		}
-		log.WithFields(logFields).Info("Created Trident statefulset.")
+	extension := strings.ToLower(filepath.Ext(filePath))
+	if extension != ".yaml" && extension != ".yml" && json.Valid(configBytes) {
+		return string(configBytes), nil
+	}
 
-		// Create the daemonset
-		if useYAML && fileExists(csiDaemonSetPath) {
-			returnError = validateTridentDaemonSet()
-			if returnError != nil {
-				returnError = fmt.Errorf("please correct the daemonset YAML file; %v", returnError)
-				return
+	jsonBytes, err := yaml.YAMLToJSON(configBytes)
+	if err != nil {
+		if match := yamlLineRegex.FindStringSubmatch(err.Error()); match != nil {
+			lines := strings.Split(string(configBytes), "\n")
+			lineNumber, convErr := strconv.Atoi(match[1])
+			if convErr == nil && lineNumber > 0 && lineNumber <= len(lines) {
+				return "", fmt.Errorf("storage backend config file %s is not valid YAML; %v (line %d: %q)",
+					filePath, err, lineNumber, strings.TrimRight(lines[lineNumber-1], "\r"))
 			}
-			returnError = client.CreateObjectByFile(csiDaemonSetPath)
-			logFields = log.Fields{"path": csiDaemonSetPath}
-		} else {
-			returnError = client.CreateObjectByYAML(
-				k8s_client.GetCSIDaemonSetYAML(tridentImage, TridentNodeLabelValue, Debug))
-			logFields = log.Fields{}
 		}
-		if returnError != nil {
-			returnError = fmt.Errorf("could not create Trident daemonset; %v", returnError)
-			return
-		}
-		log.WithFields(logFields).Info("Created Trident daemonset.")
+		return "", fmt.Errorf("storage backend config file %s is not valid YAML; %v", filePath, err)
 	}
 
-	// Wait for Trident pod to be running
-	var tridentPod *v1.Pod
-
-	tridentPod, returnError = waitForTridentPod()
-	if returnError != nil {
-		return
+	// Ensure the converted config is a single object, as expected by the storage driver factory
+	var configMap map[string]interface{}
+	if err = json.Unmarshal(jsonBytes, &configMap); err != nil || configMap == nil {
+		return "", fmt.Errorf("storage backend config file %s must contain a single object", filePath)
 	}
 
-	// Wait for Trident REST interface to be available
-	TridentPodName = tridentPod.Name
-	returnError = waitForRESTInterface()
-	if returnError != nil {
-		returnError = fmt.Errorf("%v; use 'tridentctl logs' to learn more", returnError)
-		return
-	}
+	log.WithField("backend", filePath).Debug("Converted storage backend config from YAML to JSON.")
 
-	log.Info("Trident installation succeeded.")
-	return nil
+	return string(jsonBytes), nil
 }
 
-func loadStorageDriver() (backend *storage.Backend, returnError error) {
+// verifyTridentImageSignature uses cosign to check the signature of an image against a public
+// key.  Cosign runs with the installer's environment, so any proxy settings and local registry
+// credentials are honored.  No changes are made to the cluster.
+func verifyTridentImageSignature(image, keyPath string) error {
 
-	// Set up telemetry so any PV we create has the correct metadata
-	tridentconfig.OrchestratorTelemetry = tridentconfig.Telemetry{
-		TridentVersion:  tridentconfig.OrchestratorVersion.String(),
-		Platform:        "kubernetes",
-		PlatformVersion: client.Version().ShortString(),
+	if _, err := exec.LookPath(CosignCLI); err != nil {
+		return fmt.Errorf("could not find %s, which is required to verify image signatures", CosignCLI)
 	}
 
-	if csi {
-		tridentconfig.CurrentDriverContext = tridentconfig.ContextCSI
-	} else {
-		tridentconfig.CurrentDriverContext = tridentconfig.ContextKubernetes
+	log.WithFields(log.Fields{
+		"image": image,
+		"key":   keyPath,
+	}).Info("Verifying image signature.")
+
+	out, err := exec.Command(CosignCLI, "verify", "--key", keyPath, image).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("signature verification failed for image %s; %v; %s",
+			image, err, strings.TrimSpace(string(out)))
 	}
 
-	// Ensure the setup directory & backend config file are present
-	if _, returnError = os.Stat(setupPath); os.IsNotExist(returnError) {
-		returnError = fmt.Errorf("setup directory does not exist; %v", returnError)
-		return
+	log.WithField("image", image).Info("Image signature verified.")
+
+	return nil
+}
+
+// checkCSISidecarImages validates the CSI sidecar images against the Kubernetes version.  Images
+// whose version is not in the compatibility matrix are allowed, with a warning.
+func checkCSISidecarImages() error {
+
+	sidecarImages := map[string]string{
+		k8s_client.CSIAttacher:    csiAttacherImage,
+		k8s_client.CSIProvisioner: csiProvisionerImage,
+		k8s_client.CSIRegistrar:   csiRegistrarImage,
 	}
-	if _, returnError = os.Stat(backendConfigFilePath); os.IsNotExist(returnError) {
-		returnError = fmt.Errorf("storage backend config file does not exist; %v", returnError)
-		return
+	if csiLivenessProbe {
+		sidecarImages[k8s_client.CSILivenessProbe] = csiLivenessProbeImage
 	}
 
-	// Try to start the driver, which is the source of many installation problems and
-	// will be needed to if we have to provision the Trident PV.
-	log.WithField("backend", backendConfigFilePath).Info("Starting storage driver.")
-	configFileBytes, returnError := ioutil.ReadFile(backendConfigFilePath)
-	if returnError != nil {
-		returnError = fmt.Errorf("could not read the storage backend config file; %v", returnError)
-		return
-	}
-	backend, returnError = factory.NewStorageBackendForConfig(string(configFileBytes))
-	if returnError != nil {
-		returnError = fmt.Errorf("could not start the storage backend driver; %v", returnError)
-		return
+	for sidecar, image := range sidecarImages {
+		known, err := k8s_client.CheckCSISidecarImage(sidecar, image, client.Version())
+		if err != nil {
+			return fmt.Errorf("CSI sidecar image %s is incompatible with this cluster; %v", image, err)
+		}
+		logFields := log.Fields{"sidecar": sidecar, "image": image}
+		if !known {
+			log.WithFields(logFields).Warning("Could not determine whether the CSI sidecar image " +
+				"is compatible with this Kubernetes version.")
+		} else {
+			log.WithFields(logFields).Debug("CSI sidecar image is compatible with this Kubernetes version.")
+		}
 	}
 
-	log.WithField("driver", backend.GetDriverName()).Info("Storage driver loaded.")
-	return
+	return nil
 }
 
 func createRBACObjects() (returnError error) {
@@ -863,7 +3666,7 @@ func createRBACObjects() (returnError error) {
 		returnError = client.CreateObjectByFile(serviceAccountPath)
 		logFields = log.Fields{"path": serviceAccountPath}
 	} else {
-		returnError = client.CreateObjectByYAML(k8s_client.GetServiceAccountYAML(csi))
+		returnError = createObjectByYAML(ServiceAccountFilename, k8s_client.GetServiceAccountYAML(csi))
 		logFields = log.Fields{}
 	}
 	if returnError != nil {
@@ -874,26 +3677,31 @@ func createRBACObjects() (returnError error) {
 
 	if useKubernetesRBAC {
 
-		// Create cluster role
-		if useYAML && fileExists(clusterRolePath) {
-			returnError = client.CreateObjectByFile(clusterRolePath)
-			logFields = log.Fields{"path": clusterRolePath}
+		// Create cluster role, unless one created outside the installer is used
+		if useExistingClusterRole {
+			log.Info("Using the existing cluster role.")
 		} else {
-			returnError = client.CreateObjectByYAML(k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), csi))
-			logFields = log.Fields{}
-		}
-		if returnError != nil {
-			returnError = fmt.Errorf("could not create cluster role; %v", returnError)
-			return
+			if useYAML && fileExists(clusterRolePath) {
+				returnError = client.CreateObjectByFile(clusterRolePath)
+				logFields = log.Fields{"path": clusterRolePath}
+			} else {
+				returnError = createObjectByYAML(ClusterRoleFilename,
+					k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), csi, getClusterRoleLabels()))
+				logFields = log.Fields{}
+			}
+			if returnError != nil {
+				returnError = fmt.Errorf("could not create cluster role; %v", returnError)
+				return
+			}
+			log.WithFields(logFields).Info("Created cluster role.")
 		}
-		log.WithFields(logFields).Info("Created cluster role.")
 
 		// Create cluster role binding
 		if useYAML && fileExists(clusterRoleBindingPath) {
 			returnError = client.CreateObjectByFile(clusterRoleBindingPath)
 			logFields = log.Fields{"path": clusterRoleBindingPath}
 		} else {
-			returnError = client.CreateObjectByYAML(k8s_client.GetClusterRoleBindingYAML(
+			returnError = createObjectByYAML(ClusterRoleBindingFilename, k8s_client.GetClusterRoleBindingYAML(
 				TridentPodNamespace, client.Flavor(), client.Version(), csi))
 			logFields = log.Fields{}
 		}
@@ -903,6 +3711,15 @@ func createRBACObjects() (returnError error) {
 		}
 		log.WithFields(logFields).Info("Created cluster role binding.")
 
+		// Record the existing cluster role on the binding, so uninstalling Trident keeps the role
+		if useExistingClusterRole {
+			if returnError = recordExistingClusterRole(); returnError != nil {
+				returnError = fmt.Errorf("could not record the existing cluster role; %v", returnError)
+				return
+			}
+			log.Info("Recorded the existing cluster role on the cluster role binding.")
+		}
+
 		// If OpenShift, add Trident to security context constraint
 		if client.Flavor() == k8s_client.FlavorOpenShift {
 			if returnError = client.AddTridentUserToOpenShiftSCC(); returnError != nil {
@@ -940,6 +3757,19 @@ func removeRBACObjects(logLevel log.Level) (anyErrors bool) {
 
 	if useKubernetesRBAC {
 
+		// Check whether the binding records a cluster role created outside the installer before
+		// deleting the binding
+		keepClusterRole := useExistingClusterRole
+		if !keepClusterRole {
+			recorded, err := isExistingClusterRoleRecorded()
+			if err != nil {
+				log.WithField("error", err).Warning("Could not check whether Trident used an existing " +
+					"cluster role, keeping the cluster role.")
+				anyErrors = true
+			}
+			keepClusterRole = recorded || err != nil
+		}
+
 		// Delete cluster role binding
 		clusterRoleBindingYAML := k8s_client.GetClusterRoleBindingYAML(
 			TridentPodNamespace, client.Flavor(), client.Version(), csi)
@@ -950,9 +3780,11 @@ func removeRBACObjects(logLevel log.Level) (anyErrors bool) {
 			logFunc("Deleted cluster role binding.")
 		}
 
-		// Delete cluster role
-		clusterRoleYAML := k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), csi)
-		if err := client.DeleteObjectByYAML(clusterRoleYAML, true); err != nil {
+		// Delete cluster role, unless it was created outside the installer
+		clusterRoleYAML := k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), csi, nil)
+		if keepClusterRole {
+			logFunc("Kept the existing cluster role.")
+		} else if err := client.DeleteObjectByYAML(clusterRoleYAML, true); err != nil {
 			log.WithField("error", err).Warning("Could not delete cluster role.")
 			anyErrors = true
 		} else {
@@ -1027,7 +3859,8 @@ func createRBACObjects() (returnError error) {
 			returnError = client.CreateObjectByFile(clusterRolePath)
 			logFields = log.Fields{"path": clusterRolePath}
 		} else {
-			returnError = client.CreateObjectByYAML(k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), csi))
+			returnError = client.CreateObjectByYAML(k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(),
+				csi, getClusterRoleLabels()))
 			logFields = log.Fields{}
 		}
 		if returnError != nil {
@@ -1091,7 +3924,7 @@ func removeRBACObjects(logLevel log.Level) (anyErrors bool) {
 	}
 
 	// Delete cluster role
-	clusterRoleYAML := k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), csi)
+	clusterRoleYAML := k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), csi, nil)
 	if err := client.DeleteObjectByYAML(clusterRoleYAML, true); err != nil {
 		log.WithField("error", err).Warning("Could not delete cluster role.")
 		anyErrors = true
@@ -1145,12 +3978,12 @@ func validateTridentDeployment() error {
 
 	tridentImage := ""
 	for _, container := range deployment.Spec.Template.Spec.Containers {
-		if container.Name == tridentconfig.ContainerTrident {
+		if container.Name == tridentContainerName {
 			tridentImage = container.Image
 		}
 	}
 	if tridentImage == "" {
-		return fmt.Errorf("the Trident deployment must define the %s container", tridentconfig.ContainerTrident)
+		return fmt.Errorf("the Trident deployment must define the %s container", tridentContainerName)
 	}
 
 	return nil
@@ -1196,12 +4029,12 @@ func validateTridentStatefulSet() error {
 
 	tridentImage := ""
 	for _, container := range statefulset.Spec.Template.Spec.Containers {
-		if container.Name == tridentconfig.ContainerTrident {
+		if container.Name == tridentContainerName {
 			tridentImage = container.Image
 		}
 	}
 	if tridentImage == "" {
-		return fmt.Errorf("the Trident statefulset must define the %s container", tridentconfig.ContainerTrident)
+		return fmt.Errorf("the Trident statefulset must define the %s container", tridentContainerName)
 	}
 
 	return nil
@@ -1230,12 +4063,12 @@ func validateTridentDaemonSet() error {
 
 	tridentImage := ""
 	for _, container := range daemonset.Spec.Template.Spec.Containers {
-		if container.Name == tridentconfig.ContainerTrident {
+		if container.Name == tridentContainerName {
 			tridentImage = container.Image
 		}
 	}
 	if tridentImage == "" {
-		return fmt.Errorf("the Trident daemonset must define the %s container", tridentconfig.ContainerTrident)
+		return fmt.Errorf("the Trident daemonset must define the %s container", tridentContainerName)
 	}
 
 	return nil
@@ -1260,25 +4093,62 @@ func validateTridentPVC() error {
 		return fmt.Errorf("the Trident PVC must be named %s", pvcName)
 	}
 
-	// Check the namespace
-	if pvc.Namespace != TridentPodNamespace {
-		return fmt.Errorf("the Trident PVC must specify namespace %s", TridentPodNamespace)
+	// Check the namespace
+	if pvc.Namespace != TridentPodNamespace {
+		return fmt.Errorf("the Trident PVC must specify namespace %s", TridentPodNamespace)
+	}
+
+	return nil
+}
+
+func createPV(sb *storage.Backend) error {
+
+	volume, err := provisionTridentVolume(sb)
+	if err != nil {
+		return err
+	}
+
+	// Record any QoS policy the backend applied to the volume
+	qos := getVolumeQoS(sb, volume)
+	annotations := getPVQoSAnnotations(qos)
+	installResult.PVAnnotations = annotations
+
+	if volumeEncryption {
+		installResult.VolumeEncrypted = true
+		log.WithField("volume", volume.Config.InternalName).Info("The Trident volume is encrypted.")
+	}
+
+	// Report the volume as the backend created it, which may differ from the request
+	installResult.Volume = getProvisionedVolume(sb, volume, qos)
+
+	pvYAML, err := getTridentPVYAML(sb, volume, annotations)
+	if err != nil {
+		return err
+	}
+
+	// The PV refers to the CHAP secret, so create it first
+	if usesCHAP(volume) {
+		if _, err = createCHAPSecret(volume); err != nil {
+			return err
+		}
+	}
+
+	// Create the PV
+	err = client.CreateObjectByYAML(pvYAML)
+	if err != nil {
+		return fmt.Errorf("could not create PV %s; %v", pvName, err)
 	}
 
 	return nil
 }
 
-func createPV(sb *storage.Backend) error {
+// usesCHAP returns whether the Trident volume is reached over iSCSI with CHAP authentication.
+func usesCHAP(volume *storage.Volume) bool {
+	return volume.Config.AccessInfo.IscsiTargetPortal != "" && volume.Config.AccessInfo.IscsiTargetSecret != ""
+}
 
-	// Choose a pool
-	if len(sb.Storage) == 0 {
-		return fmt.Errorf("backend %s has no storage pools", sb.Name)
-	}
-	var pool *storage.Pool
-	for _, pool = range sb.Storage {
-		// Let Golang's map iteration randomization choose a pool for us
-		break
-	}
+// provisionTridentVolume creates, clones, or imports the Trident volume on the storage backend.
+func provisionTridentVolume(sb *storage.Backend) (*storage.Volume, error) {
 
 	// Create the volume config
 	volConfig := &storage.VolumeConfig{
@@ -1286,17 +4156,81 @@ func createPV(sb *storage.Backend) error {
 		Name:     volumeName,
 		Size:     volumeSize,
 		Protocol: sb.GetProtocol(),
+		QoS:      strings.Replace(volumeQoS, " ", "", -1),
+
+		SnapshotReserve: volumeSnapshotReserve,
+	}
+
+	if err := validateVolumeAccessMode(volConfig.Protocol); err != nil {
+		return nil, err
 	}
 
-	volAttributes := make(map[string]sa.Request)
+	var volume *storage.Volume
+	var err error
+
+	if importVolume != "" {
 
-	// Create the volume on the backend
-	volume, err := sb.AddVolume(volConfig, pool, volAttributes)
-	if err != nil {
-		return fmt.Errorf("could not create a volume on the storage backend; %v", err)
+		// Import the volume, which is named as it is on the backend
+		volConfig.InternalName = importVolume
+		if volume, err = sb.ImportVolume(volConfig); err != nil {
+			return nil, fmt.Errorf("could not import volume %s from the storage backend; %v", importVolume, err)
+		}
+		log.WithField("volume", importVolume).Info("Imported the Trident volume.")
+
+	} else if cloneSourceVolume != "" {
+
+		// Clone the volume on the backend.  The source is named as it is on the backend,
+		// so it is used as the internal name as well.
+		volConfig.CloneSourceVolume = cloneSourceVolume
+		volConfig.CloneSourceVolumeInternal = cloneSourceVolume
+		if volume, err = sb.CloneVolume(volConfig); err != nil {
+			return nil, fmt.Errorf("could not clone volume %s on the storage backend; %v", cloneSourceVolume, err)
+		}
+		log.WithField("source", cloneSourceVolume).Info("Cloned the Trident volume.")
+
+	} else {
+
+		// Choose a pool, which must support encryption if requested
+		pools := sb.Storage
+		if volumeEncryption {
+			pools = getEncryptionPools(sb)
+		}
+		if len(pools) == 0 {
+			return nil, fmt.Errorf("backend %s has no suitable storage pools", sb.Name)
+		}
+		var pool *storage.Pool
+		if volumePool != "" {
+			if pool = pools[volumePool]; pool == nil {
+				return nil, fmt.Errorf("backend %s has no suitable storage pool named %s", sb.Name, volumePool)
+			}
+		} else {
+			for _, pool = range pools {
+				// Let Golang's map iteration randomization choose a pool for us
+				break
+			}
+		}
+		log.WithField("pool", pool.Name).Debug("Chose storage pool for the Trident volume.")
+
+		volAttributes := make(map[string]sa.Request)
+		if volumeEncryption {
+			volConfig.Encryption = "true"
+			volAttributes[sa.Encryption] = sa.NewBoolRequest(true)
+		}
+
+		// Create the volume on the backend
+		if volume, err = sb.AddVolume(volConfig, pool, volAttributes); err != nil {
+			return nil, fmt.Errorf("could not create a volume on the storage backend; %v", err)
+		}
 	}
 
-	// Get the PV YAML (varies by volume protocol type)
+	return volume, nil
+}
+
+// getTridentPVYAML returns the YAML of the Trident PV for the provisioned Trident volume, which
+// varies by volume protocol type.  If the volume uses iSCSI CHAP, the PV refers to the CHAP secret
+// the volume's credentials are stored in.
+func getTridentPVYAML(sb *storage.Backend, volume *storage.Volume, annotations map[string]string) (string, error) {
+
 	var pvYAML string
 	switch {
 	case volume.Config.AccessInfo.NfsAccessInfo.NfsServerIP != "":
@@ -1304,7 +4238,7 @@ func createPV(sb *storage.Backend) error {
 		pvYAML = k8s_client.GetNFSPVYAML(pvName, volumeSize, pvcName, TridentPodNamespace,
 			volume.Config.AccessInfo.NfsAccessInfo.NfsServerIP,
 			volume.Config.AccessInfo.NfsAccessInfo.NfsPath,
-			appLabelValue)
+			volumeAccessMode, appLabelValue, annotations)
 
 	case volume.Config.AccessInfo.IscsiAccessInfo.IscsiTargetPortal != "":
 
@@ -1312,20 +4246,16 @@ func createPV(sb *storage.Backend) error {
 
 			// Validate CHAP support in Kubernetes
 			if !client.Version().AtLeast(utils.MustParseSemantic("v1.7.0")) {
-				return errors.New("iSCSI CHAP requires Kubernetes 1.7.0 or later")
+				return "", errors.New("iSCSI CHAP requires Kubernetes 1.7.0 or later")
 			}
 
 			// Using CHAP
-			secretName, err := createCHAPSecret(volume)
-			if err != nil {
-				return err
-			}
-
+			secretName := volume.ConstructExternal().GetCHAPSecretName()
 			pvYAML = k8s_client.GetCHAPISCSIPVYAML(pvName, volumeSize, pvcName, TridentPodNamespace, secretName,
 				volume.Config.AccessInfo.IscsiAccessInfo.IscsiTargetPortal,
 				volume.Config.AccessInfo.IscsiAccessInfo.IscsiTargetIQN,
 				volume.Config.AccessInfo.IscsiAccessInfo.IscsiLunNumber,
-				appLabelValue)
+				appLabelValue, annotations)
 
 		} else {
 
@@ -1334,22 +4264,260 @@ func createPV(sb *storage.Backend) error {
 				volume.Config.AccessInfo.IscsiAccessInfo.IscsiTargetPortal,
 				volume.Config.AccessInfo.IscsiAccessInfo.IscsiTargetIQN,
 				volume.Config.AccessInfo.IscsiAccessInfo.IscsiLunNumber,
-				appLabelValue)
+				appLabelValue, annotations)
 		}
 
+	case len(volume.Config.AccessInfo.FcAccessInfo.FcTargetWWNs) > 0:
+
+		pvYAML = k8s_client.GetFCPVYAML(pvName, volumeSize, pvcName, TridentPodNamespace,
+			volume.Config.AccessInfo.FcAccessInfo.FcTargetWWNs,
+			volume.Config.AccessInfo.FcAccessInfo.FcLunNumber,
+			volumeAccessMode, appLabelValue, annotations)
+
 	default:
-		return errors.New("unrecognized volume type")
+		return "", fmt.Errorf("unrecognized access info for a %s volume on backend %s; no PV can be created "+
+			"for it", volume.Config.Protocol, sb.Name)
 	}
 
-	// Create the PV
-	err = client.CreateObjectByYAML(pvYAML)
+	return pvYAML, nil
+}
+
+// getEncryptionPools returns the storage pools of a backend that can encrypt volumes at rest.
+func getEncryptionPools(sb *storage.Backend) map[string]*storage.Pool {
+
+	encryptionRequest := sa.NewBoolRequest(true)
+
+	pools := make(map[string]*storage.Pool)
+	for name, pool := range sb.Storage {
+		if offer, ok := pool.Attributes[sa.Encryption]; ok && offer.Matches(encryptionRequest) {
+			pools[name] = pool
+		}
+	}
+	return pools
+}
+
+// validateCloneSourceVolume checks that the backend's driver can clone volumes and that the
+// clone source exists on the backend.
+func validateCloneSourceVolume(sb *storage.Backend) error {
+
+	switch sb.GetDriverName() {
+	case drivers.EseriesIscsiStorageDriverName, drivers.OntapNASQtreeStorageDriverName:
+		return fmt.Errorf("--clone-source-volume is not supported by the %s driver", sb.GetDriverName())
+	}
+
+	if err := sb.Driver.Get(cloneSourceVolume); err != nil {
+		return fmt.Errorf("could not find clone source volume %s on backend %s; %v", cloneSourceVolume, sb.Name, err)
+	}
+	return nil
+}
+
+// validateImportVolume checks that the volume to import exists on the backend, is at least as large
+// as the requested volume size, and isn't the volume of any PV in the cluster.  Only PVs that name
+// the volume, such as CSI PVs created by Trident and NFS PVs of a whole volume, can be checked.
+func validateImportVolume(sb *storage.Backend) error {
+
+	switch sb.GetDriverName() {
+	case drivers.OntapNASQtreeStorageDriverName:
+		return fmt.Errorf("--import-volume is not supported by the %s driver", sb.GetDriverName())
+	}
+
+	volume, err := sb.Driver.GetVolumeExternal(importVolume)
 	if err != nil {
-		return fmt.Errorf("could not create PV %s; %v", pvName, err)
+		return fmt.Errorf("could not find volume %s to import on backend %s; %v", importVolume, sb.Name, err)
+	}
+
+	requestedSize, err := utils.ConvertSizeToBytes(volumeSize)
+	if err != nil {
+		return fmt.Errorf("could not convert volume size %s; %v", volumeSize, err)
+	}
+	requestedBytes, _ := strconv.ParseUint(requestedSize, 10, 64)
+	if volumeBytes, err := strconv.ParseUint(volume.Config.Size, 10, 64); err != nil {
+		log.WithFields(log.Fields{
+			"volume": importVolume,
+			"size":   volume.Config.Size,
+		}).Warning("Could not determine the size of the volume to import.")
+	} else if volumeBytes < requestedBytes {
+		return fmt.Errorf("volume %s to import has %d bytes, less than the requested volume size %s",
+			importVolume, volumeBytes, volumeSize)
+	}
+
+	pvs, err := client.GetPVs()
+	if err != nil {
+		return fmt.Errorf("could not list PVs; %v", err)
+	}
+	for _, pv := range pvs {
+		inUse := false
+		if pv.Spec.CSI != nil && pv.Spec.CSI.VolumeAttributes["internalName"] == importVolume {
+			inUse = true
+		}
+		if pv.Spec.NFS != nil && strings.TrimSuffix(pv.Spec.NFS.Path, "/") == "/"+importVolume {
+			inUse = true
+		}
+		if inUse {
+			return fmt.Errorf("volume %s to import is already used by PV %s", importVolume, pv.Name)
+		}
+	}
+	return nil
+}
+
+// validateVolumeAccessMode checks that the Trident volume can be mounted with the requested access
+// mode over a backend protocol.  Block volumes can only be mounted by one node.
+func validateVolumeAccessMode(protocol tridentconfig.Protocol) error {
+
+	if volumeAccessMode == string(v1.ReadWriteMany) && protocol != tridentconfig.File {
+		return fmt.Errorf("--volume-access-mode %s requires a backend with the %s protocol, not %s",
+			volumeAccessMode, tridentconfig.File, protocol)
+	}
+	return nil
+}
+
+func hasAccessMode(accessModes []v1.PersistentVolumeAccessMode, accessMode string) bool {
+	for _, mode := range accessModes {
+		if string(mode) == accessMode {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmReplaceExistingPV checks that a Released or Failed PV may be replaced, and asks the user
+// to confirm it unless --yes was specified.  Only a PV with the Trident label is ever replaced.
+func confirmReplaceExistingPV(pv *v1.PersistentVolume) error {
+
+	if pv.Labels == nil || pv.Labels[appLabelKey] != appLabelValue {
+		return fmt.Errorf("PV %s does not have %s label, so it will not be replaced; "+
+			"please delete PV and try again", pv.Name, appLabel)
+	}
+
+	logFields := log.Fields{"pv": pv.Name, "phase": pv.Status.Phase}
+
+	if dryRun {
+		log.WithFields(logFields).Info("PV would be deleted and replaced.")
+		return nil
 	}
 
+	log.WithFields(logFields).Warning("PV will be deleted and replaced. The Trident metadata on its volume, " +
+		"including the backends and volumes Trident knows about, will no longer be used.")
+	if !assumeYes && !confirmAction(fmt.Sprintf("Delete PV %s and replace it?", pv.Name)) {
+		return fmt.Errorf("replacement of PV %s was not confirmed", pv.Name)
+	}
 	return nil
 }
 
+// qosReporter is implemented by storage drivers that can report the QoS policy applied to a volume.
+type qosReporter interface {
+	GetVolumeQoS(name string) (map[string]string, error)
+}
+
+// getVolumeQoS returns the QoS limits the backend applied to the Trident volume, if the driver
+// can report them.  Failing to get the limits doesn't prevent installation.
+func getVolumeQoS(sb *storage.Backend, volume *storage.Volume) map[string]string {
+
+	reporter, ok := sb.Driver.(qosReporter)
+	if !ok {
+		return nil
+	}
+	qos, err := reporter.GetVolumeQoS(volume.Config.InternalName)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not get the QoS policy of the Trident volume.")
+		return nil
+	}
+	log.WithFields(log.Fields{"volume": volume.Config.InternalName, "qos": qos}).Info(
+		"Trident volume QoS policy.")
+
+	return qos
+}
+
+// getPVQoSAnnotations returns the QoS limits of the Trident volume as PV annotations, so they are
+// visible with 'kubectl describe pv'.
+func getPVQoSAnnotations(qos map[string]string) map[string]string {
+
+	if qos == nil {
+		return nil
+	}
+	annotations := make(map[string]string, len(qos))
+	for key, value := range qos {
+		annotations[PVQoSAnnotationPrefix+key] = value
+	}
+	return annotations
+}
+
+// getProvisionedVolume returns the attributes of the Trident volume as the backend reports them,
+// such as a size the storage system rounded up.  If the backend can't report the volume, the
+// attributes known from creating it are returned.
+func getProvisionedVolume(sb *storage.Backend, volume *storage.Volume, qos map[string]string) *volumeDetails {
+
+	provisioned := &volumeDetails{
+		InternalName:  volume.Config.InternalName,
+		Backend:       sb.Name,
+		Protocol:      string(volume.Config.Protocol),
+		RequestedSize: volumeSize,
+		QoS:           qos,
+		Encrypted:     volumeEncryption,
+	}
+
+	external, err := sb.Driver.GetVolumeExternal(volume.Config.InternalName)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"volume": volume.Config.InternalName,
+			"error":  err,
+		}).Warning("Could not get the provisioned attributes of the Trident volume.")
+	} else {
+		provisioned.SizeBytes = external.Config.Size
+		provisioned.Pool = external.Pool
+		provisioned.SnapReserve = external.Config.SnapshotReserve
+	}
+	if provisioned.Pool == "" && volume.Pool != drivers.UnsetPool {
+		provisioned.Pool = volume.Pool
+	}
+	if provisioned.SnapReserve == "" {
+		provisioned.SnapReserve = volume.Config.SnapshotReserve
+	}
+	provisioned.UsableBytes = getUsableVolumeSize(provisioned.SizeBytes, provisioned.SnapReserve)
+
+	logFields := log.Fields{
+		"volume":        provisioned.InternalName,
+		"backend":       provisioned.Backend,
+		"pool":          provisioned.Pool,
+		"protocol":      provisioned.Protocol,
+		"requestedSize": provisioned.RequestedSize,
+		"sizeBytes":     provisioned.SizeBytes,
+	}
+	if provisioned.UsableBytes != "" {
+		logFields["snapshotReserve"] = provisioned.SnapReserve + "%"
+		logFields["usableBytes"] = provisioned.UsableBytes
+	}
+	log.WithFields(logFields).Info("Provisioned the Trident volume.")
+
+	// A large default snapshot reserve can leave less room than was asked for
+	if provisioned.UsableBytes != "" {
+		requested, _ := utils.ConvertSizeToBytes(volumeSize)
+		requestedBytes, err := strconv.ParseUint(requested, 10, 64)
+		usableBytes, _ := strconv.ParseUint(provisioned.UsableBytes, 10, 64)
+		if err == nil && usableBytes < requestedBytes {
+			log.WithFields(logFields).Warning("The snapshot reserve of the Trident volume leaves less usable " +
+				"space than requested. Use --volume-snapshot-reserve to set a smaller reserve.")
+		}
+	}
+
+	return provisioned
+}
+
+// getUsableVolumeSize returns the bytes of a volume left for data once its snapshot reserve is
+// set aside, or an empty string if the size or reserve isn't known.
+func getUsableVolumeSize(sizeBytes, snapshotReserve string) string {
+
+	size, err := strconv.ParseUint(sizeBytes, 10, 64)
+	if err != nil {
+		return ""
+	}
+	reserve, err := strconv.ParseUint(snapshotReserve, 10, 64)
+	if err != nil || reserve > 100 {
+		return ""
+	}
+	return strconv.FormatUint(size/100*(100-reserve)+size%100*(100-reserve)/100, 10)
+}
+
 func createCHAPSecret(volume *storage.Volume) (secretName string, returnError error) {
 
 	secretName = volume.ConstructExternal().GetCHAPSecretName()
@@ -1367,7 +4535,8 @@ func createCHAPSecret(volume *storage.Volume) (secretName string, returnError er
 		secretYAML := k8s_client.GetCHAPSecretYAML(secretName,
 			volume.Config.AccessInfo.IscsiUsername,
 			volume.Config.AccessInfo.IscsiInitiatorSecret,
-			volume.Config.AccessInfo.IscsiTargetSecret)
+			volume.Config.AccessInfo.IscsiTargetSecret,
+			appLabelValue)
 
 		// Create the secret
 		err = client.CreateObjectByYAML(secretYAML)
@@ -1378,19 +4547,92 @@ func createCHAPSecret(volume *storage.Volume) (secretName string, returnError er
 		log.WithField("secret", secretName).Info("Created iSCSI CHAP secret.")
 	} else {
 		log.WithField("secret", secretName).Debug("iSCSI CHAP secret already exists.")
+
+		// A secret of the same name with other credentials would break attaching the volume
+		secret, err := client.GetSecret(secretName)
+		if err != nil {
+			returnError = fmt.Errorf("could not check existing iSCSI CHAP secret; %v", err)
+			return
+		}
+		mismatchedKeys := getCHAPSecretMismatches(secret, &volume.Config.AccessInfo.IscsiAccessInfo)
+		if len(mismatchedKeys) > 0 {
+			if !replaceCHAPSecret {
+				returnError = fmt.Errorf("iSCSI CHAP secret %s already exists, but doesn't hold the "+
+					"credentials of volume %s (mismatched: %s); delete or rename the secret, or use "+
+					"--replace-chap-secret to replace its contents", secretName, volume.Config.InternalName,
+					strings.Join(mismatchedKeys, ", "))
+				return
+			}
+			log.WithFields(log.Fields{
+				"secret":     secretName,
+				"mismatched": strings.Join(mismatchedKeys, ", "),
+			}).Warning("iSCSI CHAP secret doesn't hold the volume's credentials, replacing it.")
+			returnError = updateCHAPSecret(TridentPodNamespace, secretName, &volume.Config.AccessInfo.IscsiAccessInfo)
+			if returnError != nil {
+				returnError = fmt.Errorf("could not replace iSCSI CHAP secret; %v", returnError)
+				return
+			}
+		}
 	}
 
 	return
 }
 
+// getCHAPSecretMismatches returns the keys of an existing iSCSI CHAP secret that are missing or
+// don't hold the specified credentials, along with 'type' if it isn't a CHAP secret.  The
+// credentials themselves are never returned, so they can't end up in the log.
+func getCHAPSecretMismatches(secret *v1.Secret, accessInfo *utils.IscsiAccessInfo) []string {
+
+	mismatchedKeys := make([]string, 0)
+	if string(secret.Type) != k8s_client.CHAPSecretType {
+		mismatchedKeys = append(mismatchedKeys, "type")
+	}
+
+	expectedData := k8s_client.GetCHAPSecretData(
+		accessInfo.IscsiUsername, accessInfo.IscsiInitiatorSecret, accessInfo.IscsiTargetSecret)
+	for key, value := range expectedData {
+		if actual, ok := secret.Data[key]; !ok || string(actual) != value {
+			mismatchedKeys = append(mismatchedKeys, key)
+		}
+	}
+	sort.Strings(mismatchedKeys)
+
+	return mismatchedKeys
+}
+
 func waitForTridentPod() (*v1.Pod, error) {
 
 	var pod *v1.Pod
+	var evictionError error
+	evictedPods := make(map[string]bool)
 
 	checkPodRunning := func() error {
-		var podError error
-		pod, podError = client.GetPodByLabel(appLabel, false)
-		if podError != nil || pod.Status.Phase != v1.PodRunning {
+		pods, podError := client.GetPodsByLabel(appLabel, false)
+		if podError != nil {
+			return errors.New("pod not running")
+		}
+
+		// An evicted pod stays in place while its controller starts a replacement
+		activePods := make([]v1.Pod, 0, len(pods))
+		for _, candidate := range pods {
+			if !isPodEvicted(&candidate) {
+				activePods = append(activePods, candidate)
+			} else if !evictedPods[candidate.Name] {
+				evictedPods[candidate.Name] = true
+				evictionError = getPodEvictionError(&candidate)
+				log.WithField("pod", candidate.Name).Warningf("%v.", evictionError)
+			}
+		}
+		if evictionError != nil && abortOnEviction {
+			return nil
+		}
+
+		if len(activePods) != 1 {
+			pod = nil
+			return errors.New("pod not running")
+		}
+		pod = &activePods[0]
+		if pod.Status.Phase != v1.PodRunning {
 			return errors.New("pod not running")
 		}
 		return nil
@@ -1401,16 +4643,24 @@ func waitForTridentPod() (*v1.Pod, error) {
 		}).Debugf("Trident pod not yet running, waiting.")
 	}
 	podBackoff := backoff.NewExponentialBackOff()
-	podBackoff.MaxElapsedTime = k8sTimeout
+	podBackoff.MaxElapsedTime = getStepTimeout(StepPod)
 
 	log.Info("Waiting for Trident pod to start.")
 
-	if err := backoff.RetryNotify(checkPodRunning, podBackoff, podNotify); err != nil {
+	err := backoff.RetryNotify(checkPodRunning, podBackoff, podNotify)
+	if err == nil && evictionError != nil && abortOnEviction {
+		return nil, fmt.Errorf("Trident %v; remove --abort-on-eviction to wait for a replacement pod",
+			evictionError)
+	}
+	if err != nil {
 
 		// Build up an error message with as much detail as available.
 		var errMessages []string
 		errMessages = append(errMessages,
-			fmt.Sprintf("Trident pod was not running after %3.2f seconds.", k8sTimeout.Seconds()))
+			fmt.Sprintf("Trident pod was not running after %3.2f seconds.", podBackoff.MaxElapsedTime.Seconds()))
+		if evictionError != nil {
+			errMessages = append(errMessages, fmt.Sprintf("%v.", evictionError))
+		}
 
 		if pod != nil {
 			if pod.Status.Phase != "" {
@@ -1419,12 +4669,20 @@ func waitForTridentPod() (*v1.Pod, error) {
 					errMessages = append(errMessages, fmt.Sprintf("%s", pod.Status.Message))
 				}
 			}
+			errMessages = append(errMessages, getContainerTerminationMessages(pod)...)
 			errMessages = append(errMessages,
 				fmt.Sprintf("Use '%s describe pod %s -n %s' for more information.",
 					client.CLI(), pod.Name, client.Namespace()))
 		}
 
 		log.Error(strings.Join(errMessages, " "))
+
+		// A volume that can't be attached or mounted is the most common cause, so say so precisely
+		if pod != nil {
+			if volumeErr := getPodVolumeError(pod.Name); volumeErr != nil {
+				return nil, volumeErr
+			}
+		}
 		return nil, err
 	}
 
@@ -1433,17 +4691,136 @@ func waitForTridentPod() (*v1.Pod, error) {
 		"namespace": TridentPodNamespace,
 	}).Info("Trident pod started.")
 
+	deleteEvictedPods(evictedPods)
+
 	return pod, nil
 }
 
-func waitForRESTInterface() error {
+// getContainerTerminationMessages describes each container of a pod that terminated with a
+// termination message, from its current state or, for a container that was restarted, from its
+// last one.  With the FallbackToLogsOnError policy, the message of a failed container is the end
+// of its log if it wrote none.
+func getContainerTerminationMessages(pod *v1.Pod) []string {
+
+	messages := make([]string, 0)
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		if terminated == nil {
+			terminated = status.LastTerminationState.Terminated
+		}
+		if terminated == nil || strings.TrimSpace(terminated.Message) == "" {
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("Container %s terminated with exit code %d: %s.",
+			status.Name, terminated.ExitCode, strings.TrimRight(strings.TrimSpace(terminated.Message), ".")))
+	}
+	return messages
+}
+
+// isPodEvicted returns whether the kubelet evicted a pod, as it does under node pressure.  An
+// evicted pod remains, failed, until it is deleted.
+func isPodEvicted(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodFailed && pod.Status.Reason == "Evicted"
+}
+
+// deleteEvictedPods deletes the evicted pods once their replacements are running, since lookups
+// of a Trident pod by label fail while an evicted pod shares its label.  A pod that can't be
+// deleted is only reported.
+func deleteEvictedPods(podNames map[string]bool) {
+
+	names := make([]string, 0, len(podNames))
+	for name := range podNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := client.DeleteObjectByName("pod", name, true); err != nil {
+			log.WithFields(log.Fields{
+				"pod":   name,
+				"error": err,
+			}).Warning("Could not delete evicted pod.")
+		} else {
+			log.WithField("pod", name).Info("Deleted evicted pod.")
+		}
+	}
+}
+
+// getPodEvictionError describes the eviction of a pod, including the kubelet's reason.
+func getPodEvictionError(pod *v1.Pod) error {
+	if pod.Status.Message != "" {
+		return fmt.Errorf("pod %s was evicted from node %s; %s", pod.Name, pod.Spec.NodeName,
+			strings.TrimRight(strings.TrimSpace(pod.Status.Message), "."))
+	}
+	return fmt.Errorf("pod %s was evicted from node %s", pod.Name, pod.Spec.NodeName)
+}
+
+// getPodVolumeError returns an error describing the most recent failure to attach or mount
+// a volume for the specified pod, or nil if there is no such failure.
+func getPodVolumeError(podName string) error {
+
+	events, err := client.GetEventsForObject("Pod", podName)
+	if err != nil {
+		log.WithField("error", err).Debug("Could not get events for Trident pod.")
+		return nil
+	}
+
+	var latest *v1.Event
+	for i, event := range events {
+		switch event.Reason {
+		case "FailedMount", "FailedAttachVolume", "FailedMapVolume":
+			if latest == nil || latest.LastTimestamp.Before(&event.LastTimestamp) {
+				latest = &events[i]
+			}
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+
+	return fmt.Errorf("Trident pod %s could not use its volume; %s: %s; check the storage backend's "+
+		"export policy, network interfaces, and CHAP settings for PV %s",
+		podName, latest.Reason, strings.TrimSpace(latest.Message), pvName)
+}
+
+// logFailedPodInspectionCommands lists the commands an administrator may use to inspect
+// a Trident pod that failed to start, which the installer leaves in place.
+func logFailedPodInspectionCommands() {
+
+	pod, err := client.GetPodByLabel(appLabel, false)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not find the failed Trident pod.")
+		return
+	}
+
+	commands := []string{
+		fmt.Sprintf("%s describe pod %s -n %s", client.CLI(), pod.Name, pod.Namespace),
+		fmt.Sprintf("%s get events -n %s --field-selector involvedObject.name=%s",
+			client.CLI(), pod.Namespace, pod.Name),
+	}
+	for _, container := range pod.Spec.Containers {
+		commands = append(commands,
+			fmt.Sprintf("%s logs %s -n %s -c %s", client.CLI(), pod.Name, pod.Namespace, container.Name),
+			fmt.Sprintf("%s logs %s -n %s -c %s --previous", client.CLI(), pod.Name, pod.Namespace, container.Name))
+	}
+
+	log.WithFields(log.Fields{
+		"pod":       pod.Name,
+		"namespace": pod.Namespace,
+	}).Warning("Retaining failed Trident pod for debugging. Inspect it with the following commands.")
+	for _, command := range commands {
+		log.Warning("  " + command)
+	}
+}
+
+func waitForRESTInterface() (string, error) {
 
 	var version string
 
 	checkRESTInterface := func() error {
 
 		cliCommand := []string{"tridentctl", "-s", PodServer, "version", "-o", "json"}
-		versionJSON, err := client.Exec(TridentPodName, tridentconfig.ContainerTrident, cliCommand)
+		versionJSON, err := client.Exec(TridentPodName, tridentContainerName, cliCommand)
 		if err != nil {
 			if versionJSON != nil && len(versionJSON) > 0 {
 				err = fmt.Errorf("%v; %s", err, strings.TrimSpace(string(versionJSON)))
@@ -1466,16 +4843,102 @@ func waitForRESTInterface() error {
 		}).Debugf("REST interface not yet up, waiting.")
 	}
 	restBackoff := backoff.NewExponentialBackOff()
-	restBackoff.MaxElapsedTime = k8sTimeout
+	restBackoff.MaxElapsedTime = getStepTimeout(StepREST)
 
 	log.Info("Waiting for Trident REST interface.")
 
 	if err := backoff.RetryNotify(checkRESTInterface, restBackoff, restNotify); err != nil {
-		log.Errorf("Trident REST interface was not available after %3.2f seconds.", k8sTimeout.Seconds())
-		return err
+		log.Errorf("Trident REST interface was not available after %3.2f seconds.", restBackoff.MaxElapsedTime.Seconds())
+		return "", err
 	}
 
 	log.WithField("version", version).Info("Trident REST interface is up.")
 
+	return version, nil
+}
+
+// etcdEndpointStatus is the part of the output of 'etcdctl endpoint status -w json' that the
+// installer reports.
+type etcdEndpointStatus struct {
+	Endpoint string `json:"Endpoint"`
+	Status   struct {
+		Version string `json:"version"`
+		DBSize  int64  `json:"dbSize"`
+		Leader  uint64 `json:"leader"`
+	} `json:"Status"`
+}
+
+// waitForEtcdHealthy confirms that the etcd in the Trident pod serves requests and has a leader.
+// A running but unhealthy etcd causes provisioning failures that look like REST interface
+// problems.  The check is skipped if the pod has no etcd container, as when custom YAML
+// configures an external etcd.
+func waitForEtcdHealthy(pod *v1.Pod) error {
+
+	hasEtcd := false
+	for _, container := range pod.Spec.Containers {
+		if container.Name == tridentconfig.ContainerEtcd {
+			hasEtcd = true
+		}
+	}
+	if !hasEtcd {
+		log.Debug("Trident pod has no etcd container, skipping etcd health check.")
+		return nil
+	}
+
+	var status etcdEndpointStatus
+
+	checkEtcdHealth := func() error {
+
+		healthOutput, err := client.Exec(pod.Name, tridentconfig.ContainerEtcd, getEtcdctlCommand("endpoint", "health"))
+		if err != nil {
+			return fmt.Errorf("%v; %s", err, strings.TrimSpace(string(healthOutput)))
+		}
+
+		statusJSON, err := client.Exec(pod.Name, tridentconfig.ContainerEtcd,
+			getEtcdctlCommand("endpoint", "status", "-w", "json"))
+		if err != nil {
+			return fmt.Errorf("%v; %s", err, strings.TrimSpace(string(statusJSON)))
+		}
+		var statuses []etcdEndpointStatus
+		if err = json.Unmarshal(statusJSON, &statuses); err != nil {
+			return fmt.Errorf("could not parse etcd status; %v", err)
+		}
+		if len(statuses) == 0 {
+			return errors.New("etcd returned no status")
+		}
+		if statuses[0].Status.Leader == 0 {
+			return errors.New("etcd has no leader")
+		}
+
+		status = statuses[0]
+		return nil
+	}
+	etcdNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+			"error":     err,
+		}).Debug("etcd not yet healthy, waiting.")
+	}
+	etcdBackoff := backoff.NewExponentialBackOff()
+	etcdBackoff.MaxElapsedTime = getStepTimeout(StepEtcd)
+
+	log.Info("Waiting for etcd to be healthy.")
+
+	if err := backoff.RetryNotify(checkEtcdHealth, etcdBackoff, etcdNotify); err != nil {
+		log.Errorf("etcd was not healthy after %3.2f seconds.", etcdBackoff.MaxElapsedTime.Seconds())
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"version": status.Status.Version,
+		"dbSize":  status.Status.DBSize,
+		"leader":  fmt.Sprintf("%x", status.Status.Leader),
+	}).Info("etcd is healthy.")
+
 	return nil
 }
+
+// getEtcdctlCommand returns an etcdctl command using the v3 API against the etcd in the Trident pod.
+func getEtcdctlCommand(args ...string) []string {
+	return []string{"sh", "-c", "ETCDCTL_API=3 etcdctl --endpoints=" + EtcdServer + " " + strings.Join(args, " ")}
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SkopeoCLI reads image manifests from a registry without pulling the image
+	SkopeoCLI = "skopeo"
+
+	manifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	imageIndexMediaType   = "application/vnd.oci.image.index.v1+json"
+)
+
+// getImageArchitectures returns the CPU architectures an image is built for, as listed in its
+// registry manifest.  A multi-arch image lists a manifest per platform; a single-arch image
+// records its architecture in its config, which skopeo reports.
+func getImageArchitectures(image string) ([]string, error) {
+
+	reference := "docker://" + image
+
+	out, err := exec.Command(SkopeoCLI, "inspect", "--raw", reference).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the manifest of image %s; %v", image, err)
+	}
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+		Manifests []struct {
+			Platform struct {
+				Architecture string `json:"architecture"`
+				OS           string `json:"os"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	if err = json.Unmarshal(out, &manifest); err != nil {
+		return nil, fmt.Errorf("could not parse the manifest of image %s; %v", image, err)
+	}
+
+	if manifest.MediaType == manifestListMediaType || manifest.MediaType == imageIndexMediaType {
+		architectures := make([]string, 0)
+		for _, platformManifest := range manifest.Manifests {
+			if platformManifest.Platform.OS == "linux" {
+				architectures = append(architectures, platformManifest.Platform.Architecture)
+			}
+		}
+		return architectures, nil
+	}
+
+	out, err = exec.Command(SkopeoCLI, "inspect", reference).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not inspect image %s; %v", image, err)
+	}
+	var config struct {
+		Architecture string `json:"Architecture"`
+	}
+	if err = json.Unmarshal(out, &config); err != nil {
+		return nil, fmt.Errorf("could not parse the config of image %s; %v", image, err)
+	}
+	return []string{config.Architecture}, nil
+}
+
+// checkImageArchitectures warns if the Trident or etcd image isn't built for the architecture of
+// every node or of the installer.  Kubernetes pulls a single-arch image onto any node, where
+// its container crashes at startup with an exec format error that doesn't name the cause.
+func checkImageArchitectures() {
+
+	if _, err := exec.LookPath(SkopeoCLI); err != nil {
+		log.Warningf("Could not find %s, so the image architectures can't be checked.", SkopeoCLI)
+		return
+	}
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		log.WithField("error", err).Warning("Could not get the nodes, so the image architectures " +
+			"can't be checked.")
+		return
+	}
+	nodeArchitectures := make(map[string][]string)
+	for _, node := range nodes {
+		architecture := node.Status.NodeInfo.Architecture
+		nodeArchitectures[architecture] = append(nodeArchitectures[architecture], node.Name)
+	}
+
+	for _, image := range []string{tridentImage, etcdImage} {
+
+		imageArchitectures, err := getImageArchitectures(image)
+		if err != nil {
+			log.WithField("error", err).Warning("Could not check the image architectures.")
+			continue
+		}
+		supported := make(map[string]bool)
+		for _, architecture := range imageArchitectures {
+			supported[architecture] = true
+		}
+		logFields := log.Fields{"image": image, "imageArchitectures": strings.Join(imageArchitectures, ",")}
+
+		mismatched := false
+		for architecture, nodeNames := range nodeArchitectures {
+			if !supported[architecture] {
+				sort.Strings(nodeNames)
+				log.WithFields(logFields).WithFields(log.Fields{
+					"nodeArchitecture": architecture,
+					"nodes":            strings.Join(nodeNames, ","),
+				}).Warning("The image is not built for the architecture of some nodes; its containers " +
+					"will fail to start there with an exec format error.")
+				mismatched = true
+			}
+		}
+		if !supported[runtime.GOARCH] {
+			log.WithFields(logFields).WithField("installerArchitecture", runtime.GOARCH).Warning(
+				"The image is not built for the architecture of this installer; make sure the " +
+					"installer and image are for the same platform.")
+			mismatched = true
+		}
+
+		if !mismatched {
+			preCheckPassed("imageArchitecture", fmt.Sprintf("Image %s is built for %s.",
+				image, strings.Join(imageArchitectures, ",")))
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netapp/trident/storage"
+	drivers "github.com/netapp/trident/storage_drivers"
+)
+
+// The uses of a file in the --backend-config-dir
+const (
+	ConfigDirUseVolume  = "volume"
+	ConfigDirUseSeed    = "seed"
+	ConfigDirUseSkipped = "skipped"
+)
+
+// configDirEntry is the outcome of one backend config file in the --backend-config-dir.
+type configDirEntry struct {
+	ConfigFile string `json:"configFile"`
+	Use        string `json:"use"`
+	Error      string `json:"error,omitempty"`
+}
+
+// backendConfigDirEntries are the backend config files in the --backend-config-dir, in name order.
+var backendConfigDirEntries []*configDirEntry
+
+// validateBackendConfigDirArguments reads the backend configs in the --backend-config-dir, in
+// name order.  Each valid config is seeded once Trident is running, unless it is chosen for the
+// Trident volume when the storage driver is loaded.  Invalid configs are skipped, but at least
+// one must be valid.
+func validateBackendConfigDirArguments() error {
+
+	if backendConfigDir == "" {
+		return nil
+	}
+
+	fileInfos, err := ioutil.ReadDir(backendConfigDir)
+	if err != nil {
+		return fmt.Errorf("could not read backend config directory; %v", err)
+	}
+
+	valid := 0
+	for _, fileInfo := range fileInfos {
+		extension := strings.ToLower(filepath.Ext(fileInfo.Name()))
+		if fileInfo.IsDir() || (extension != ".json" && extension != ".yaml" && extension != ".yml") {
+			continue
+		}
+
+		entry := &configDirEntry{
+			ConfigFile: filepath.Join(backendConfigDir, fileInfo.Name()),
+			Use:        ConfigDirUseSeed,
+		}
+		if _, err = readSeedBackendConfig(entry.ConfigFile); err != nil {
+			entry.Use = ConfigDirUseSkipped
+			entry.Error = err.Error()
+			log.WithFields(log.Fields{
+				"configFile": entry.ConfigFile,
+				"error":      err,
+			}).Warning("Skipping invalid backend config.")
+		} else {
+			seedBackends = append(seedBackends, entry.ConfigFile)
+			valid++
+		}
+		backendConfigDirEntries = append(backendConfigDirEntries, entry)
+	}
+
+	if valid == 0 {
+		return fmt.Errorf("backend config directory %s has no valid JSON or YAML backend configs", backendConfigDir)
+	}
+
+	installResult.BackendConfigs = getBackendConfigDirResults()
+	return nil
+}
+
+// startBackendConfigDirDriver starts the storage driver of the first valid config in the
+// --backend-config-dir whose backend can hold the Trident volume as requested.  That config is
+// used for the Trident volume instead of being seeded.
+func startBackendConfigDirDriver() (*storage.Backend, error) {
+
+	for _, entry := range backendConfigDirEntries {
+		if entry.Use != ConfigDirUseSeed {
+			continue
+		}
+		logFields := log.Fields{"configFile": entry.ConfigFile}
+
+		backend, err := startStorageDriver(entry.ConfigFile)
+		if err == nil {
+			if err = checkVolumeBackend(backend); err != nil {
+				backend.Terminate()
+			}
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			logFields["error"] = err
+			log.WithFields(logFields).Info("Backend can't hold the Trident volume, it will be seeded.")
+			continue
+		}
+
+		entry.Use = ConfigDirUseVolume
+		entry.Error = ""
+		backendConfigFilePath = entry.ConfigFile
+		removeSeedBackend(entry.ConfigFile)
+		installResult.BackendConfigs = getBackendConfigDirResults()
+
+		logFields["backend"] = backend.Name
+		log.WithFields(logFields).Info("Chose the backend of the Trident volume.")
+		return backend, nil
+	}
+
+	installResult.BackendConfigs = getBackendConfigDirResults()
+	return nil, fmt.Errorf("no backend config in %s can hold the Trident volume; see the "+
+		"backendConfigs of the install summary for the reason of each", backendConfigDir)
+}
+
+// checkVolumeBackend fails if a backend can't hold the Trident volume with the requested
+// access mode, storage pool, QoS, and encryption.
+func checkVolumeBackend(sb *storage.Backend) error {
+
+	if err := checkBackendState(sb); err != nil {
+		return err
+	}
+	if err := validateVolumeAccessMode(sb.GetProtocol()); err != nil {
+		return err
+	}
+	if len(sb.Storage) == 0 {
+		return errors.New("backend has no storage pools")
+	}
+	if _, ok := sb.Storage[volumePool]; volumePool != "" && !ok {
+		return fmt.Errorf("backend has no storage pool named %s", volumePool)
+	}
+	if volumeQoS != "" && sb.GetDriverName() != drivers.SolidfireSANStorageDriverName {
+		return fmt.Errorf("--volume-qos is not supported by the %s driver", sb.GetDriverName())
+	}
+	if volumeEncryption {
+		pools := getEncryptionPools(sb)
+		if len(pools) == 0 {
+			return errors.New("--volume-encryption is not supported by the backend")
+		}
+		if _, ok := pools[volumePool]; volumePool != "" && !ok {
+			return fmt.Errorf("--volume-encryption is not supported by storage pool %s", volumePool)
+		}
+	}
+	return nil
+}
+
+// removeSeedBackend removes a config from the backends to seed.
+func removeSeedBackend(configFile string) {
+
+	remaining := make([]string, 0, len(seedBackends))
+	for _, seedBackend := range seedBackends {
+		if seedBackend != configFile {
+			remaining = append(remaining, seedBackend)
+		}
+	}
+	seedBackends = remaining
+}
+
+// getBackendConfigDirResults returns the outcome of each file in the --backend-config-dir for
+// the install summary.
+func getBackendConfigDirResults() []configDirEntry {
+
+	results := make([]configDirEntry, 0, len(backendConfigDirEntries))
+	for _, entry := range backendConfigDirEntries {
+		results = append(results, *entry)
+	}
+	return results
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/netapp/trident/utils"
+)
+
+// AggregateToLabelPrefix is the prefix of the labels by which the default cluster roles aggregate
+// the rules of other cluster roles
+const AggregateToLabelPrefix = "rbac.authorization.k8s.io/aggregate-to-"
+
+// aggregatableRoles are the default cluster roles the Trident cluster role may be aggregated into.
+// Cluster-admin is left out, since it already grants everything, and view, since it is meant for
+// read-only users who must not read secrets.
+var aggregatableRoles = []string{"admin", "edit"}
+
+// validateAggregateToRolesArguments checks the cluster roles given with --aggregate-to-roles.
+// Aggregation requires Kubernetes RBAC, which has aggregated cluster roles since Kubernetes 1.9.
+func validateAggregateToRolesArguments() error {
+
+	if len(aggregateToRoles) == 0 {
+		return nil
+	}
+	if !useKubernetesRBAC {
+		return errors.New("--aggregate-to-roles requires Kubernetes RBAC")
+	}
+	if !getKubernetesVersion().AtLeast(utils.MustParseSemantic("v1.9.0")) {
+		return errors.New("--aggregate-to-roles requires Kubernetes 1.9 or later")
+	}
+	for _, role := range aggregateToRoles {
+		if role == "view" {
+			return errors.New("Trident cannot be aggregated into the view cluster role, as that would let " +
+				"read-only users read secrets")
+		}
+		if !containsString(aggregatableRoles, role) {
+			return fmt.Errorf("'%s' is not a cluster role Trident can be aggregated into; use one of %s",
+				role, strings.Join(aggregatableRoles, ", "))
+		}
+	}
+	log.WithField("roles", strings.Join(aggregateToRoles, ",")).Warning("Aggregating the Trident cluster " +
+		"role grants its permissions, including reading secrets and managing PVs, to every subject bound to " +
+		"these cluster roles in any namespace; consider binding the Trident cluster role to the subjects " +
+		"that need it instead.")
+	return nil
+}
+
+// getClusterRoleLabels returns the labels of the Trident cluster role that aggregate it into the
+// cluster roles given with --aggregate-to-roles, or nil if there are none.
+func getClusterRoleLabels() map[string]string {
+
+	if len(aggregateToRoles) == 0 {
+		return nil
+	}
+	clusterRoleLabels := make(map[string]string)
+	for _, role := range aggregateToRoles {
+		clusterRoleLabels[AggregateToLabelPrefix+role] = "true"
+	}
+	return clusterRoleLabels
+}
+
+// checkClusterRoleAggregation reports the cluster roles whose aggregation rules would select the
+// Trident cluster role, as the installer would create it, and so grant its permissions to their
+// subjects.  It warns about any aggregation not requested with --aggregate-to-roles, since labels
+// added by custom YAML or overlays can aggregate Trident's access to secrets and PVs into a
+// widely bound role, and about any requested aggregation that no cluster role would perform.
+func checkClusterRoleAggregation() {
+
+	if !useKubernetesRBAC {
+		return
+	}
+
+	clusterRoleYAML, err := getEffectiveSetupYAML(ClusterRoleFilename, clusterRolePath)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not get the Trident cluster role, skipping " +
+			"aggregation check.")
+		return
+	}
+	var tridentClusterRole rbacv1.ClusterRole
+	if err = yaml.Unmarshal([]byte(clusterRoleYAML), &tridentClusterRole); err != nil {
+		log.WithField("error", err).Warning("Could not parse the Trident cluster role, skipping " +
+			"aggregation check.")
+		return
+	}
+
+	clusterRoles, err := client.GetClusterRoles()
+	if err != nil {
+		log.WithField("error", err).Warning("Could not list cluster roles, skipping aggregation check.")
+		return
+	}
+
+	aggregatedInto := getAggregatingClusterRoles(clusterRoles, &tridentClusterRole)
+
+	for _, role := range aggregatedInto {
+		if !containsString(aggregateToRoles, role) {
+			log.WithFields(log.Fields{
+				"clusterRole": tridentClusterRole.Name,
+				"aggregateTo": role,
+			}).Warning("The labels of the Trident cluster role aggregate it into a cluster role that " +
+				"wasn't requested, which grants Trident's permissions to the subjects of that role. " +
+				"Remove the labels from the custom YAML or overlay.")
+		}
+	}
+	for _, role := range aggregateToRoles {
+		if !containsString(aggregatedInto, role) {
+			log.WithFields(log.Fields{
+				"clusterRole": tridentClusterRole.Name,
+				"aggregateTo": role,
+			}).Warning("The Trident cluster role won't be aggregated into the requested cluster role, " +
+				"which doesn't exist or doesn't select the aggregation label.")
+		}
+	}
+
+	installResult.AggregatedInto = aggregatedInto
+	if len(aggregatedInto) == 0 {
+		preCheckPassed("clusterRoleAggregation", "The Trident cluster role isn't aggregated into "+
+			"any cluster role.")
+		return
+	}
+	preCheckPassed("clusterRoleAggregation", "The Trident cluster role is aggregated into "+
+		strings.Join(aggregatedInto, ", ")+".")
+}
+
+// getAggregatingClusterRoles returns the names, sorted, of the cluster roles whose aggregation
+// rules select a cluster role by its labels.
+func getAggregatingClusterRoles(clusterRoles []rbacv1.ClusterRole, clusterRole *rbacv1.ClusterRole) []string {
+
+	aggregatedInto := make([]string, 0)
+	for _, aggregatingRole := range clusterRoles {
+		if aggregatingRole.AggregationRule == nil || aggregatingRole.Name == clusterRole.Name {
+			continue
+		}
+		for _, labelSelector := range aggregatingRole.AggregationRule.ClusterRoleSelectors {
+			selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"clusterRole": aggregatingRole.Name,
+					"error":       err,
+				}).Debug("Could not parse cluster role selector.")
+				continue
+			}
+			if selector.Matches(labels.Set(clusterRole.Labels)) {
+				aggregatedInto = append(aggregatedInto, aggregatingRole.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(aggregatedInto)
+	return aggregatedInto
+}
+
+// containsString returns whether a slice contains a string.
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	"github.com/netapp/trident/storage"
+)
+
+// installState records what the pre-checks found in the cluster, which determines the changes
+// an installation makes.
+type installState struct {
+	namespaceExists bool
+	pvcExists       bool
+	pvExists        bool
+	pvReplaced      bool
+	storageBackend  *storage.Backend
+}
+
+// getInstallPlan describes each change the installation will make, in the order it makes them.
+func getInstallPlan(state installState) []string {
+
+	plan := make([]string, 0)
+
+	if state.pvReplaced {
+		plan = append(plan, fmt.Sprintf("Delete PV %s, which will be replaced", pvName))
+	}
+	if !state.namespaceExists {
+		plan = append(plan, fmt.Sprintf("Create namespace %s", TridentPodNamespace))
+	}
+	if useKubernetesRBAC {
+		plan = append(plan, "Replace the Trident service account, cluster role, and cluster role binding")
+		if client.Flavor() == k8s_client.FlavorOpenShift {
+			plan = append(plan, fmt.Sprintf("Add the Trident service account to security context constraint %s",
+				k8s_client.OpenShiftSCCName))
+		}
+	} else {
+		plan = append(plan, "Replace the Trident service account and UCP role")
+	}
+	if createNetworkPolicy {
+		plan = append(plan, "Create the Trident network policy")
+	}
+	if !state.pvcExists {
+		plan = append(plan, fmt.Sprintf("Create PVC %s (%s, %s)", pvcName, volumeSize, volumeAccessMode))
+	}
+	if !state.pvExists {
+		volumeAction := "Create"
+		if importVolume != "" {
+			volumeAction = "Import " + importVolume + " as"
+		} else if cloneSourceVolume != "" {
+			volumeAction = "Clone " + cloneSourceVolume + " as"
+		}
+		plan = append(plan, fmt.Sprintf("%s volume %s on backend %s, and create PV %s", volumeAction,
+			volumeName, state.storageBackend.Name, pvName))
+	}
+	if len(k8sAPICA) > 0 {
+		plan = append(plan, fmt.Sprintf("Create config map %s", k8s_client.K8sAPICAConfigMapName))
+	}
+	if podSubdomain != "" {
+		plan = append(plan, fmt.Sprintf("Create headless service %s", podSubdomain))
+	}
+	if !csi {
+		plan = append(plan, fmt.Sprintf("Create the Trident deployment with image %s", tridentImage))
+	} else {
+		plan = append(plan, fmt.Sprintf("Create the Trident CSI service, statefulset, and daemonset with "+
+			"image %s", tridentImage))
+	}
+	if len(seedBackends) > 0 {
+		plan = append(plan, fmt.Sprintf("Add %d backends to Trident", len(seedBackends)))
+	}
+	if createStorageClass != "" {
+		plan = append(plan, fmt.Sprintf("Create storage class %s", createStorageClass))
+	}
+
+	return plan
+}
+
+// confirmInstallation shows the changes the installation will make and asks the user to confirm
+// them, if --confirm was specified.  Without a terminal to ask on, or with --yes, installation
+// proceeds as it would without --confirm.
+func confirmInstallation(state installState) error {
+
+	if !confirmInstall || assumeYes {
+		return nil
+	}
+	if stdin, err := os.Stdin.Stat(); err != nil || stdin.Mode()&os.ModeCharDevice == 0 {
+		log.Info("No terminal to confirm the installation on, proceeding.")
+		return nil
+	}
+
+	fmt.Printf("The pre-checks passed. Installing Trident in namespace %s will:\n", TridentPodNamespace)
+	for _, step := range getInstallPlan(state) {
+		fmt.Printf("  - %s\n", step)
+	}
+	if !confirmAction("Proceed with the installation?") {
+		return errors.New("installation was not confirmed")
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	drivers "github.com/netapp/trident/storage_drivers"
+)
+
+// backendCredentials are the username and password moved from the --seed-backend configs into
+// the --backend-credentials-secret, and credentialsSecretConfigs are the configs they came from.
+var (
+	backendCredentials       map[string]string
+	credentialsSecretConfigs = make(map[string]bool)
+)
+
+// validateBackendCredentialsSecretArguments checks that the --seed-backend configs have a
+// username and password to move into the --backend-credentials-secret.  Every config with
+// credentials must have the same ones, since they are all stored in one secret.  Configs that
+// already reference a credentials secret are left as they are.
+func validateBackendCredentialsSecretArguments() error {
+
+	if backendCredentialsSecret == "" {
+		return nil
+	}
+	if len(seedBackends) == 0 {
+		return errors.New("--backend-credentials-secret requires --seed-backend or --backend-config-dir")
+	}
+	if !dns1123DomainRegex.MatchString(backendCredentialsSecret) {
+		return fmt.Errorf("'%s' is not a valid secret name; a DNS-1123 subdomain must consist of "+
+			"lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric "+
+			"character", backendCredentialsSecret)
+	}
+
+	for _, configFile := range seedBackends {
+		configJSON, err := readSeedBackendConfig(configFile)
+		if err != nil {
+			return err
+		}
+		var commonConfig drivers.CommonStorageDriverConfig
+		if err = json.Unmarshal([]byte(configJSON), &commonConfig); err != nil {
+			return fmt.Errorf("could not parse backend config %s; %v", configFile, err)
+		}
+		var config map[string]interface{}
+		if err = json.Unmarshal([]byte(configJSON), &config); err != nil {
+			return fmt.Errorf("could not parse backend config %s; %v", configFile, err)
+		}
+
+		username, _ := config[drivers.CredentialsKeyUsername].(string)
+		password, _ := config[drivers.CredentialsKeyPassword].(string)
+		if len(commonConfig.Credentials) > 0 || (username == "" && password == "") {
+			log.WithField("backend", configFile).Debug("Backend config has no username and password to " +
+				"store in the credentials secret.")
+			continue
+		}
+		if username == "" || password == "" {
+			return fmt.Errorf("backend config %s must have both a username and a password to store them "+
+				"in a credentials secret", configFile)
+		}
+
+		// The driver must accept a credentials field in place of the username and password
+		commonConfig.Credentials = map[string]string{
+			drivers.CredentialsKeyName: backendCredentialsSecret,
+			drivers.CredentialsKeyType: drivers.CredentialsTypeSecret,
+		}
+		if _, err = drivers.GetCredentialsSecretName(&commonConfig); err != nil {
+			return fmt.Errorf("backend config %s can't use --backend-credentials-secret; %v", configFile, err)
+		}
+
+		if backendCredentials == nil {
+			backendCredentials = map[string]string{
+				drivers.CredentialsKeyUsername: username,
+				drivers.CredentialsKeyPassword: password,
+			}
+		} else if backendCredentials[drivers.CredentialsKeyUsername] != username ||
+			backendCredentials[drivers.CredentialsKeyPassword] != password {
+			return fmt.Errorf("backend config %s has other credentials than the other --seed-backend "+
+				"configs, so they can't share --backend-credentials-secret %s", configFile,
+				backendCredentialsSecret)
+		}
+		credentialsSecretConfigs[configFile] = true
+	}
+
+	if backendCredentials == nil {
+		return errors.New("none of the --seed-backend configs has a username and password to store in " +
+			"--backend-credentials-secret")
+	}
+	return nil
+}
+
+// checkBackendCredentialsSecret returns whether the --backend-credentials-secret already exists.
+// An existing secret is only reused if it holds the credentials of the --seed-backend configs, so
+// the secret can't be silently overwritten and no backend is seeded with credentials it doesn't
+// have.
+func checkBackendCredentialsSecret() (bool, error) {
+
+	secretExists, err := client.CheckSecretExists(backendCredentialsSecret)
+	if err != nil {
+		return false, fmt.Errorf("could not check for backend credentials secret %s; %v",
+			backendCredentialsSecret, err)
+	}
+	if !secretExists {
+		return false, nil
+	}
+
+	secret, err := client.GetSecret(backendCredentialsSecret)
+	if err != nil {
+		return true, fmt.Errorf("could not check backend credentials secret %s; %v", backendCredentialsSecret, err)
+	}
+
+	// Only the names of mismatched keys are reported, so the credentials can't end up in the log
+	mismatchedKeys := make([]string, 0)
+	for _, key := range []string{drivers.CredentialsKeyUsername, drivers.CredentialsKeyPassword} {
+		if strings.TrimSpace(string(secret.Data[key])) != backendCredentials[key] {
+			mismatchedKeys = append(mismatchedKeys, key)
+		}
+	}
+	if len(mismatchedKeys) > 0 {
+		return true, fmt.Errorf("backend credentials secret %s already exists, but doesn't hold the "+
+			"credentials of the --seed-backend configs (mismatched: %s); use another name, or update the "+
+			"secret with 'tridentctl update-backend-credentials'", backendCredentialsSecret,
+			strings.Join(mismatchedKeys, ", "))
+	}
+
+	log.WithField("secret", backendCredentialsSecret).Info("Backend credentials secret already exists.")
+	return true, nil
+}
+
+// createBackendCredentialsSecret creates the --backend-credentials-secret, unless a secret
+// holding the same credentials already exists.
+func createBackendCredentialsSecret() error {
+
+	secretExists, err := checkBackendCredentialsSecret()
+	if err != nil || secretExists {
+		return err
+	}
+
+	secretYAML := k8s_client.GetCredentialsSecretYAML(backendCredentialsSecret, appLabelValue,
+		backendCredentials[drivers.CredentialsKeyUsername], backendCredentials[drivers.CredentialsKeyPassword])
+	if err = client.CreateObjectByYAML(secretYAML); err != nil {
+		return fmt.Errorf("could not create backend credentials secret %s; %v", backendCredentialsSecret, err)
+	}
+
+	log.WithField("secret", backendCredentialsSecret).Info("Created backend credentials secret.")
+	return nil
+}
+
+// useBackendCredentialsSecret returns a --seed-backend config in which the username and password
+// are replaced by a reference to the --backend-credentials-secret.  Configs whose credentials
+// weren't moved into the secret are returned unchanged.
+func useBackendCredentialsSecret(configFile, configJSON string) (string, error) {
+
+	if !credentialsSecretConfigs[configFile] {
+		return configJSON, nil
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return "", fmt.Errorf("could not parse backend config %s; %v", configFile, err)
+	}
+	delete(config, drivers.CredentialsKeyUsername)
+	delete(config, drivers.CredentialsKeyPassword)
+	config["credentials"] = map[string]string{
+		drivers.CredentialsKeyName: backendCredentialsSecret,
+		drivers.CredentialsKeyType: drivers.CredentialsTypeSecret,
+	}
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(configBytes), nil
+}
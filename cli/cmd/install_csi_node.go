@@ -0,0 +1,332 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// CSIDriverName is the name with which the Trident node plugin registers with the kubelet
+	CSIDriverName = "io.netapp.trident.csi"
+
+	// CSINodeIDAnnotation lists the CSI drivers registered on a node, with the node ID of each
+	CSINodeIDAnnotation = "csi.volume.kubernetes.io/nodeid"
+
+	// NodeReadyLabelKey is set on nodes where the Trident node plugin is running and registered
+	NodeReadyLabelKey   = "trident.netapp.io/node-ready"
+	NodeReadyLabelValue = "true"
+)
+
+// imageNameRegex matches a container image reference, with an optional registry, tag, and digest.
+var imageNameRegex = regexp.MustCompile(
+	`^[a-z0-9]+([._-][a-z0-9]+)*(:[0-9]+)?(/[a-z0-9]+([._-][a-z0-9]+)*)*(:[\w][\w.-]{0,127})?(@sha256:[a-f0-9]{64})?$`)
+
+// validateImageName checks the syntax of the image specified with a command line flag.
+func validateImageName(flagName, image string) error {
+	if !imageNameRegex.MatchString(image) {
+		return fmt.Errorf("--%s '%s' is not a valid image name", flagName, image)
+	}
+	return nil
+}
+
+// waitForDaemonSetReady waits until the Trident daemonset has a ready pod on every node it is
+// scheduled to, and returns the names of those nodes.  With --expected-node-count, it instead
+// waits until at least that many pods are ready, since the number of nodes the daemonset is
+// scheduled to changes while a cluster autoscales, and returns the nodes of the ready pods.
+func waitForDaemonSetReady() ([]string, error) {
+
+	var desired, ready int32
+	var evictionError error
+	evictedPods := make(map[string]bool)
+	evictedNodes := make(map[string]bool)
+
+	checkDaemonSetReady := func() error {
+
+		// The daemonset controller replaces a pod evicted from a node, but the eviction is reported
+		// for each node, since it is likely to recur under the same node pressure
+		if pods, err := client.GetPodsByLabel(TridentNodeLabel, false); err == nil {
+			for _, pod := range pods {
+				if isPodEvicted(&pod) && !evictedPods[pod.Name] {
+					evictedPods[pod.Name] = true
+					evictedNodes[pod.Spec.NodeName] = true
+					evictionError = getPodEvictionError(&pod)
+					log.WithFields(log.Fields{
+						"pod":  pod.Name,
+						"node": pod.Spec.NodeName,
+					}).Warningf("%v.", evictionError)
+				}
+			}
+		}
+		if evictionError != nil && abortOnEviction {
+			return nil
+		}
+
+		daemonset, err := client.GetDaemonSetByLabel(TridentNodeLabel, false)
+		if err != nil {
+			return err
+		}
+		desired, ready = daemonset.Status.DesiredNumberScheduled, daemonset.Status.NumberReady
+		if daemonset.Status.ObservedGeneration < daemonset.Generation {
+			return fmt.Errorf("%d of %d pods ready, daemonset not yet observed", ready, desired)
+		}
+		if expectedNodeCount > 0 {
+			if ready < int32(expectedNodeCount) {
+				return fmt.Errorf("%d of %d pods ready, expecting %d", ready, desired, expectedNodeCount)
+			}
+		} else if ready < desired {
+			return fmt.Errorf("%d of %d pods ready", ready, desired)
+		}
+		return nil
+	}
+	daemonSetNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+			"status":    err,
+		}).Debug("Trident daemonset not yet ready, waiting.")
+	}
+	daemonSetBackoff := backoff.NewExponentialBackOff()
+	daemonSetBackoff.MaxElapsedTime = getStepTimeout(StepDaemonSet)
+
+	log.Info("Waiting for Trident daemonset to be ready.")
+
+	err := backoff.RetryNotify(checkDaemonSetReady, daemonSetBackoff, daemonSetNotify)
+	if err == nil && evictionError != nil && abortOnEviction {
+		return nil, fmt.Errorf("Trident node %v; remove --abort-on-eviction to wait for a replacement pod",
+			evictionError)
+	}
+	if err != nil {
+		if len(evictedNodes) > 0 {
+			nodes := make([]string, 0, len(evictedNodes))
+			for node := range evictedNodes {
+				nodes = append(nodes, node)
+			}
+			sort.Strings(nodes)
+			log.WithField("nodes", strings.Join(nodes, ",")).Error("Trident node pods were evicted from these nodes.")
+		}
+		expected := ""
+		if expectedNodeCount > 0 {
+			expected = fmt.Sprintf(" (expecting %d)", expectedNodeCount)
+		}
+		return nil, fmt.Errorf("only %d of %d Trident node pods%s were ready after %3.2f seconds; use "+
+			"'%s get pods -l %s -n %s' for more information", ready, desired, expected,
+			daemonSetBackoff.MaxElapsedTime.Seconds(), client.CLI(), TridentNodeLabel, TridentPodNamespace)
+	}
+
+	pods, err := client.GetPodsByLabel(TridentNodeLabel, false)
+	if err != nil {
+		return nil, fmt.Errorf("could not list Trident node pods; %v", err)
+	}
+	nodeNames := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		// An evicted pod that hasn't been deleted yet shares its node with the replacement pod
+		if pod.Spec.NodeName == "" || isPodEvicted(&pod) {
+			continue
+		}
+		// Pods on nodes added while autoscaling may not be ready yet
+		if expectedNodeCount > 0 && !isPodReady(&pod) {
+			continue
+		}
+		nodeNames = append(nodeNames, pod.Spec.NodeName)
+	}
+	sort.Strings(nodeNames)
+
+	logFields := log.Fields{"nodes": len(nodeNames), "ready": ready, "desired": desired}
+	if expectedNodeCount > 0 {
+		logFields["expected"] = expectedNodeCount
+	}
+	log.WithFields(logFields).Info("Trident daemonset is ready.")
+	deleteEvictedPods(evictedPods)
+	if len(nodeNames) == 0 {
+		log.Warning("The Trident daemonset isn't scheduled to any nodes.")
+	}
+
+	return nodeNames, nil
+}
+
+// isPodReady returns whether all of a pod's containers are ready.
+func isPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// waitForCSINodeRegistration waits until the CSI driver registrar has registered Trident with the
+// kubelet on each node, which the kubelet records in an annotation on the node.  A failed
+// registration otherwise only shows up later, as mount failures.
+func waitForCSINodeRegistration(nodeNames []string) error {
+
+	var unregistered []string
+
+	checkNodesRegistered := func() error {
+		nodes, err := client.GetNodes()
+		if err != nil {
+			return err
+		}
+		registered := make(map[string]bool)
+		for _, node := range nodes {
+			registered[node.Name] = isCSIDriverRegistered(&node)
+		}
+
+		unregistered = nil
+		for _, nodeName := range nodeNames {
+			if !registered[nodeName] {
+				unregistered = append(unregistered, nodeName)
+			}
+		}
+		if len(unregistered) > 0 {
+			return errors.New("driver not registered on all nodes")
+		}
+		return nil
+	}
+	registrationNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment":    duration,
+			"unregistered": strings.Join(unregistered, ","),
+		}).Debug("CSI driver not yet registered on all nodes, waiting.")
+	}
+	registrationBackoff := backoff.NewExponentialBackOff()
+	registrationBackoff.MaxElapsedTime = getStepTimeout(StepRegistration)
+
+	log.Info("Waiting for the CSI driver to be registered on each node.")
+
+	if err := backoff.RetryNotify(checkNodesRegistered, registrationBackoff, registrationNotify); err != nil {
+		return fmt.Errorf("the CSI driver was not registered on nodes %s after %3.2f seconds; use "+
+			"'tridentctl logs' and the %s container logs of the Trident node pods to learn more",
+			strings.Join(unregistered, ", "), registrationBackoff.MaxElapsedTime.Seconds(), "driver-registrar")
+	}
+
+	log.WithField("nodes", len(nodeNames)).Info("CSI driver is registered on each node.")
+	return nil
+}
+
+// isCSIDriverRegistered returns whether the kubelet of a node has registered the Trident CSI driver.
+func isCSIDriverRegistered(node *v1.Node) bool {
+	var driverNodeIDs map[string]string
+	if err := json.Unmarshal([]byte(node.Annotations[CSINodeIDAnnotation]), &driverNodeIDs); err != nil {
+		return false
+	}
+	_, registered := driverNodeIDs[CSIDriverName]
+	return registered
+}
+
+// reportUnhealthyCSINodes warns about each Trident node pod whose liveness probe has failed, which
+// means the node plugin stopped answering the CSI Probe calls of the liveness probe sidecar and
+// was, or will be, restarted by the kubelet.  It returns the names of the nodes of those pods.
+func reportUnhealthyCSINodes() []string {
+
+	pods, err := client.GetPodsByLabel(TridentNodeLabel, false)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not check the health of the Trident node pods.")
+		return nil
+	}
+
+	unhealthy := make([]string, 0)
+	for _, pod := range pods {
+		events, err := client.GetEventsForObject("Pod", pod.Name)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"pod":   pod.Name,
+				"error": err,
+			}).Warning("Could not get the events of the Trident node pod.")
+			continue
+		}
+		for _, event := range events {
+			if event.Reason == "Unhealthy" {
+				log.WithFields(log.Fields{
+					"node":    pod.Spec.NodeName,
+					"pod":     pod.Name,
+					"message": event.Message,
+				}).Warning("Trident node plugin failed its liveness probe.")
+				unhealthy = append(unhealthy, pod.Spec.NodeName)
+				break
+			}
+		}
+	}
+
+	if len(unhealthy) == 0 {
+		log.WithField("nodes", len(pods)).Info("Trident node plugins passed their liveness probes.")
+	}
+	return unhealthy
+}
+
+// labelTridentReadyNodes sets the node-ready label on each node where the CSI node plugin is running
+// and registered, and removes it from any other node that has it, such as a node whose plugin failed
+// its liveness probe or has failed since an earlier installation.  Workloads may then select ready nodes.
+func labelTridentReadyNodes(readyNodeNames, unhealthyNodeNames []string) error {
+
+	ready := make(map[string]bool)
+	for _, nodeName := range readyNodeNames {
+		ready[nodeName] = true
+	}
+	for _, nodeName := range unhealthyNodeNames {
+		delete(ready, nodeName)
+	}
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		return fmt.Errorf("could not list nodes; %v", err)
+	}
+
+	labeled, unlabeled := 0, 0
+	for _, node := range nodes {
+		_, hasLabel := node.Labels[NodeReadyLabelKey]
+		if ready[node.Name] {
+			if node.Labels[NodeReadyLabelKey] != NodeReadyLabelValue {
+				if err = client.AddNodeLabel(node.Name, NodeReadyLabelKey, NodeReadyLabelValue); err != nil {
+					return err
+				}
+			}
+			labeled++
+		} else if hasLabel {
+			if err = client.RemoveNodeLabel(node.Name, NodeReadyLabelKey); err != nil {
+				return err
+			}
+			log.WithField("node", node.Name).Info("Removed the node-ready label from a node without a ready " +
+				"Trident node plugin.")
+			unlabeled++
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"label":     NodeReadyLabelKey + "=" + NodeReadyLabelValue,
+		"labeled":   labeled,
+		"unlabeled": unlabeled,
+	}).Info("Labeled the nodes with a ready Trident node plugin.")
+	return nil
+}
+
+// removeNodeReadyLabels removes the node-ready label from every node that has it.
+func removeNodeReadyLabels() (anyErrors bool) {
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		log.WithField("error", err).Warning("Could not list nodes to remove the node-ready label.")
+		return true
+	}
+	for _, node := range nodes {
+		if _, ok := node.Labels[NodeReadyLabelKey]; !ok {
+			continue
+		}
+		if err = client.RemoveNodeLabel(node.Name, NodeReadyLabelKey); err != nil {
+			log.WithField("error", err).Warning("Could not remove the node-ready label.")
+			anyErrors = true
+		} else {
+			log.WithField("node", node.Name).Debug("Removed the node-ready label.")
+		}
+	}
+	return anyErrors
+}
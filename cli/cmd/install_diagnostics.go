@@ -0,0 +1,318 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	"github.com/netapp/trident/storage"
+)
+
+const (
+	// DefaultDiagnosticImage has the tools the deep checks run, including a ping that can set
+	// the Don't Fragment bit
+	DefaultDiagnosticImage = "centos:7"
+
+	DiagnosticPodLabelValue = "trident-diagnostic.netapp.io"
+	DiagnosticPodLabel      = "app=" + DiagnosticPodLabelValue
+
+	// The IPv4 and ICMP headers that a ping adds to its payload
+	icmpHeaderSize = 28
+
+	DefaultBackendMTU = 1500
+	MinBackendMTU     = 576
+	MaxBackendMTU     = 9216
+)
+
+// diagnosticPodResult is the outcome of running a diagnostic pod to completion.
+type diagnosticPodResult struct {
+	NodeName  string
+	Succeeded bool
+	Output    string
+}
+
+// runDiagnosticPod runs a command in a pod to completion and returns its output, deleting the pod
+// afterward.  The pod runs in the Trident namespace, or in the default namespace if the Trident
+// namespace doesn't exist yet, as during a dry run.
+func runDiagnosticPod(podName, nodeName string, hostNetwork bool, command []string) (*diagnosticPodResult, error) {
+
+	namespace := TridentPodNamespace
+	if exists, err := client.CheckNamespaceExists(namespace); err != nil {
+		return nil, err
+	} else if !exists {
+		namespace = "default"
+	}
+	client.SetNamespace(namespace)
+	defer client.SetNamespace(TridentPodNamespace)
+
+	logFields := log.Fields{"pod": podName, "namespace": namespace}
+
+	podYAML := k8s_client.GetDiagnosticPodYAML(podName, DiagnosticPodLabelValue, diagnosticImage, nodeName,
+		hostNetwork, command)
+	if err := client.CreateObjectByYAML(podYAML); err != nil {
+		return nil, fmt.Errorf("could not create diagnostic pod %s; %v", podName, err)
+	}
+	log.WithFields(logFields).Debug("Created diagnostic pod.")
+
+	defer func() {
+		if err := client.DeleteObjectByName("pod", podName, true); err != nil {
+			log.WithFields(logFields).WithField("error", err).Warning("Could not delete diagnostic pod.")
+		} else {
+			log.WithFields(logFields).Debug("Deleted diagnostic pod.")
+		}
+	}()
+
+	var pod v1.Pod
+	checkPodCompleted := func() error {
+		podJSON, err := client.GetObjectJSON("pod", podName)
+		if err != nil {
+			return err
+		} else if podJSON == nil {
+			return errors.New("pod not found")
+		}
+		if err = json.Unmarshal(podJSON, &pod); err != nil {
+			return err
+		}
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			return fmt.Errorf("pod is %s", pod.Status.Phase)
+		}
+		return nil
+	}
+	podNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+			"status":    err,
+		}).Debug("Diagnostic pod not yet completed, waiting.")
+	}
+	podBackoff := backoff.NewExponentialBackOff()
+	podBackoff.MaxElapsedTime = getStepTimeout(StepDiagnostic)
+
+	if err := backoff.RetryNotify(checkPodCompleted, podBackoff, podNotify); err != nil {
+		return nil, fmt.Errorf("diagnostic pod %s did not complete after %3.2f seconds; %v",
+			podName, podBackoff.MaxElapsedTime.Seconds(), err)
+	}
+
+	output, err := client.GetPodLogs(podName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &diagnosticPodResult{
+		NodeName:  pod.Spec.NodeName,
+		Succeeded: pod.Status.Phase == v1.PodSucceeded,
+		Output:    strings.TrimSpace(string(output)),
+	}, nil
+}
+
+// getBackendDataAddress returns the address on which a backend serves volume I/O, or an
+// empty string if its config doesn't name one.
+func getBackendDataAddress(sb *storage.Backend) string {
+
+	var dataConfig struct {
+		DataLIF    string `json:"dataLIF"`
+		SVIP       string `json:"svip"`
+		HostDataIP string `json:"hostDataIP"`
+	}
+	configJSON, err := json.Marshal(sb.Driver.GetExternalConfig())
+	if err != nil {
+		return ""
+	}
+	if err = json.Unmarshal(configJSON, &dataConfig); err != nil {
+		return ""
+	}
+
+	switch {
+	case dataConfig.DataLIF != "":
+		return dataConfig.DataLIF
+	case dataConfig.SVIP != "":
+		// The SolidFire storage VIP includes the iSCSI port
+		if host, _, err := net.SplitHostPort(dataConfig.SVIP); err == nil {
+			return host
+		}
+		return dataConfig.SVIP
+	default:
+		return dataConfig.HostDataIP
+	}
+}
+
+// checkBackendMTU probes the path MTU from a node to the backend's data address by pinging it
+// with the Don't Fragment bit set, and warns if full-size frames of the expected MTU don't get
+// through.  Misconfigured jumbo frames tend to let small packets through while large reads and
+// writes stall, so the problem otherwise shows up only as slow or hung volume I/O.
+func checkBackendMTU(sb *storage.Backend) {
+
+	address := getBackendDataAddress(sb)
+	if address == "" {
+		log.WithField("backend", sb.Name).Warning("Backend config has no data address, skipping MTU check.")
+		return
+	}
+	logFields := log.Fields{"backend": sb.Name, "address": address, "expectedMTU": backendMTU}
+
+	// Try the expected MTU first, then fall back to smaller ones to learn the effective MTU
+	mtus := []string{strconv.Itoa(backendMTU)}
+	for _, mtu := range []int{DefaultBackendMTU, MinBackendMTU} {
+		if mtu < backendMTU {
+			mtus = append(mtus, strconv.Itoa(mtu))
+		}
+	}
+	script := fmt.Sprintf("for mtu in %s; do "+
+		"if ping -M do -c 3 -W 2 -s $((mtu - %d)) %s >/dev/null 2>&1; then echo $mtu; exit 0; fi; "+
+		"done; echo 0", strings.Join(mtus, " "), icmpHeaderSize, address)
+
+	result, err := runDiagnosticPod("trident-mtu-probe", "", true, []string{"sh", "-c", script})
+	if err != nil {
+		log.WithFields(logFields).WithField("error", err).Warning("Could not probe the MTU to the backend.")
+		return
+	}
+	logFields["node"] = result.NodeName
+
+	effectiveMTU, err := strconv.Atoi(result.Output)
+	if !result.Succeeded || err != nil {
+		log.WithFields(logFields).WithField("output", result.Output).Warning(
+			"Could not probe the MTU to the backend.")
+		return
+	}
+
+	switch {
+	case effectiveMTU == 0:
+		log.WithFields(logFields).Warning("The backend did not answer pings with the Don't Fragment bit set " +
+			"from the node; the MTU to the backend could not be determined.")
+	case effectiveMTU < backendMTU:
+		log.WithFields(logFields).WithField("effectiveMTU", effectiveMTU).Warning(
+			"The path MTU from the node to the backend is lower than expected; check the MTU of the " +
+				"node, switch, and backend interfaces, or volume I/O may be slow or hang.")
+	default:
+		log.WithFields(logFields).Info("The path MTU from the node to the backend is as expected.")
+		preCheckPassed("backendMTU", fmt.Sprintf("Path MTU to %s is at least %d.", address, backendMTU))
+	}
+}
+
+// getBackendHostnames returns the names, rather than IP addresses, by which a backend config
+// refers to its storage system's management and data interfaces.
+func getBackendHostnames(sb *storage.Backend) []string {
+
+	var addressConfig struct {
+		ManagementLIF string `json:"managementLIF"`
+		DataLIF       string `json:"dataLIF"`
+		EndPoint      string `json:"endPoint"`
+		SVIP          string `json:"svip"`
+		ControllerA   string `json:"controllerA"`
+		ControllerB   string `json:"controllerB"`
+		HostDataIP    string `json:"hostDataIP"`
+	}
+	configJSON, err := json.Marshal(sb.Driver.GetExternalConfig())
+	if err != nil {
+		return nil
+	}
+	if err = json.Unmarshal(configJSON, &addressConfig); err != nil {
+		return nil
+	}
+
+	// The SolidFire endpoint is a URL, and its storage VIP includes the iSCSI port
+	endpointHost := ""
+	if endpointURL, err := url.Parse(addressConfig.EndPoint); err == nil {
+		endpointHost = endpointURL.Hostname()
+	}
+	svipHost := addressConfig.SVIP
+	if host, _, err := net.SplitHostPort(addressConfig.SVIP); err == nil {
+		svipHost = host
+	}
+
+	hostnames := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, address := range []string{addressConfig.ManagementLIF, addressConfig.DataLIF, endpointHost,
+		svipHost, addressConfig.ControllerA, addressConfig.ControllerB, addressConfig.HostDataIP} {
+		address = strings.Trim(address, "[]")
+		if address == "" || net.ParseIP(address) != nil || seen[address] {
+			continue
+		}
+		seen[address] = true
+		hostnames = append(hostnames, address)
+	}
+	return hostnames
+}
+
+// checkBackendDNS resolves the backend's hostnames from a pod, and warns about any that the
+// cluster's DNS can't resolve, or resolves to other addresses than the installer's host does.
+// With split DNS, the installer can reach a backend that the Trident pod can't, so provisioning
+// otherwise fails only once Trident is running.
+func checkBackendDNS(sb *storage.Backend) {
+
+	hostnames := make([]string, 0)
+	for _, hostname := range getBackendHostnames(sb) {
+		if !dns1123DomainRegex.MatchString(strings.ToLower(hostname)) {
+			log.WithField("hostname", hostname).Warning("Not a valid hostname, skipping DNS check.")
+			continue
+		}
+		hostnames = append(hostnames, hostname)
+	}
+	if len(hostnames) == 0 {
+		log.WithField("backend", sb.Name).Debug("Backend config has no hostnames, skipping DNS check.")
+		return
+	}
+
+	script := fmt.Sprintf("for host in %s; do "+
+		"echo $host $(getent ahosts $host | awk '{print $1}' | sort -u); "+
+		"done", strings.Join(hostnames, " "))
+
+	result, err := runDiagnosticPod("trident-dns-probe", "", false, []string{"sh", "-c", script})
+	if err != nil {
+		log.WithField("error", err).Warning("Could not resolve the backend hostnames from the cluster.")
+		return
+	}
+
+	podAddresses := make(map[string][]string)
+	for _, line := range strings.Split(result.Output, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			podAddresses[fields[0]] = fields[1:]
+		}
+	}
+
+	resolved := true
+	for _, hostname := range hostnames {
+		logFields := log.Fields{"backend": sb.Name, "hostname": hostname, "node": result.NodeName}
+
+		clusterAddresses := podAddresses[hostname]
+		if len(clusterAddresses) == 0 {
+			resolved = false
+			log.WithFields(logFields).Warning("The backend hostname can't be resolved from inside the " +
+				"cluster; Trident won't be able to reach the backend by it.")
+			continue
+		}
+		logFields["clusterAddresses"] = strings.Join(clusterAddresses, ",")
+
+		hostAddresses, err := net.LookupHost(hostname)
+		if err != nil {
+			log.WithFields(logFields).WithField("error", err).Debug(
+				"Could not resolve the backend hostname on the installer's host.")
+			continue
+		}
+		sort.Strings(hostAddresses)
+		logFields["hostAddresses"] = strings.Join(hostAddresses, ",")
+
+		if strings.Join(hostAddresses, ",") != strings.Join(clusterAddresses, ",") {
+			resolved = false
+			log.WithFields(logFields).Warning("The backend hostname resolves to other addresses inside " +
+				"the cluster than on the installer's host; check for split DNS.")
+			continue
+		}
+		log.WithFields(logFields).Debug("The backend hostname resolves to the same addresses inside the cluster.")
+	}
+
+	if resolved {
+		preCheckPassed("backendDNS", "The backend hostnames resolve the same inside the cluster.")
+	}
+}
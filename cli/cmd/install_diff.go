@@ -0,0 +1,247 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change in a diff.
+const diffContextLines = 3
+
+// diffInstallation compares each object the installer would create with the live object of the
+// same kind and name, and prints a unified diff for each one that differs.  Only the fields the
+// installer sets are compared, so defaults and status added by Kubernetes aren't reported.
+func diffInstallation() error {
+
+	changed := 0
+	for _, object := range getSetupObjects() {
+
+		desiredYAML, err := applyOverlay(object.fileName, object.yaml)
+		if err != nil {
+			return err
+		}
+		desiredJSON, err := yaml.YAMLToJSON([]byte(desiredYAML))
+		if err != nil {
+			return fmt.Errorf("could not parse the generated YAML for %s; %v", object.fileName, err)
+		}
+		var desired map[string]interface{}
+		if err = json.Unmarshal(desiredJSON, &desired); err != nil {
+			return fmt.Errorf("could not parse the generated YAML for %s; %v", object.fileName, err)
+		}
+		kind, _ := desired["kind"].(string)
+		metadata, _ := desired["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		if kind == "" || name == "" {
+			return fmt.Errorf("the generated YAML for %s has no kind or name", object.fileName)
+		}
+
+		liveJSON, err := client.GetObjectJSON(strings.ToLower(kind), name)
+		if err != nil {
+			return fmt.Errorf("could not get %s %s; %v", kind, name, err)
+		}
+		var liveText string
+		if liveJSON != nil {
+			var live interface{}
+			if err = json.Unmarshal(liveJSON, &live); err != nil {
+				return fmt.Errorf("could not parse %s %s; %v", kind, name, err)
+			}
+			if liveText, err = getDiffText(pruneToDesired(live, desired)); err != nil {
+				return err
+			}
+		}
+		desiredText, err := getDiffText(desired)
+		if err != nil {
+			return err
+		}
+
+		objectPath := strings.ToLower(kind) + "/" + name
+		if diff := unifiedDiff("live/"+objectPath, "desired/"+objectPath, liveText, desiredText); diff != "" {
+			fmt.Print(diff)
+			changed++
+		} else {
+			log.WithFields(log.Fields{"kind": kind, "name": name}).Debug("Object is unchanged.")
+		}
+	}
+
+	log.WithField("changedObjects", changed).Info("Compared the installation with the live objects.")
+	return nil
+}
+
+// getDiffText returns an object as YAML for comparison.  The API version is omitted, since the
+// API server returns each object in its preferred version rather than the one it was created with.
+func getDiffText(object interface{}) (string, error) {
+
+	if objectMap, ok := object.(map[string]interface{}); ok {
+		trimmed := make(map[string]interface{}, len(objectMap))
+		for key, value := range objectMap {
+			if key != "apiVersion" {
+				trimmed[key] = value
+			}
+		}
+		object = trimmed
+	}
+
+	objectYAML, err := yaml.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+	return string(objectYAML), nil
+}
+
+// pruneToDesired returns the parts of a live object that correspond to fields of the desired
+// object, which removes the fields Kubernetes adds, such as defaults and status.
+func pruneToDesired(live, desired interface{}) interface{} {
+
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		liveMap, ok := live.(map[string]interface{})
+		if !ok {
+			return live
+		}
+		pruned := make(map[string]interface{})
+		for key, desiredField := range desiredValue {
+			if liveField, ok := liveMap[key]; ok {
+				pruned[key] = pruneToDesired(liveField, desiredField)
+			}
+		}
+		return pruned
+
+	case []interface{}:
+		liveList, ok := live.([]interface{})
+		if !ok {
+			return live
+		}
+		pruned := make([]interface{}, len(liveList))
+		for i, liveItem := range liveList {
+			if i < len(desiredValue) {
+				pruned[i] = pruneToDesired(liveItem, desiredValue[i])
+			} else {
+				pruned[i] = liveItem
+			}
+		}
+		return pruned
+	}
+
+	return live
+}
+
+// diffLine is one line of an edit script, marked ' ' if unchanged, '-' if removed, or '+' if added.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the unified diff between two texts, or an empty string if they're equal.
+func unifiedDiff(fromName, toName, from, to string) string {
+
+	a := splitDiffLines(from)
+	b := splitDiffLines(to)
+
+	// Find the longest common subsequence of lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Build the edit script
+	var script []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			script = append(script, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			script = append(script, diffLine{'-', a[i]})
+			i++
+		default:
+			script = append(script, diffLine{'+', b[j]})
+			j++
+		}
+	}
+
+	// Record where each line of the edit script falls in the two texts
+	aLines := make([]int, len(script)+1)
+	bLines := make([]int, len(script)+1)
+	for k, line := range script {
+		aLines[k+1], bLines[k+1] = aLines[k], bLines[k]
+		if line.op != '+' {
+			aLines[k+1]++
+		}
+		if line.op != '-' {
+			bLines[k+1]++
+		}
+	}
+
+	// Group nearby changes into hunks with surrounding context
+	var diff strings.Builder
+	for k := 0; k < len(script); {
+		if script[k].op == ' ' {
+			k++
+			continue
+		}
+
+		changeEnd := k + 1
+		for next := changeEnd; next < len(script) && next-changeEnd <= 2*diffContextLines; next++ {
+			if script[next].op != ' ' {
+				changeEnd = next + 1
+			}
+		}
+		hunkStart := k - diffContextLines
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		hunkEnd := changeEnd + diffContextLines
+		if hunkEnd > len(script) {
+			hunkEnd = len(script)
+		}
+
+		if diff.Len() == 0 {
+			diff.WriteString("--- " + fromName + "\n+++ " + toName + "\n")
+		}
+		diff.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			diffRange(aLines[hunkStart], aLines[hunkEnd]), diffRange(bLines[hunkStart], bLines[hunkEnd])))
+		for _, line := range script[hunkStart:hunkEnd] {
+			diff.WriteString(string(line.op) + line.text + "\n")
+		}
+
+		k = hunkEnd
+	}
+
+	return diff.String()
+}
+
+// diffRange returns a hunk's range of lines in one text, which by convention starts at the
+// preceding line if the hunk has none.
+func diffRange(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, end-start)
+}
+
+func splitDiffLines(text string) []string {
+
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+
+	lines := func(lines ...string) string {
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	for _, test := range []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "both empty",
+			from:     "",
+			to:       "",
+			expected: "",
+		},
+		{
+			name:     "equal",
+			from:     lines("a", "b", "c"),
+			to:       lines("a", "b", "c"),
+			expected: "",
+		},
+		{
+			name: "from empty",
+			from: "",
+			to:   lines("a", "b"),
+			expected: lines(
+				"--- live", "+++ generated",
+				"@@ -0,0 +1,2 @@",
+				"+a", "+b"),
+		},
+		{
+			name: "to empty",
+			from: lines("a", "b"),
+			to:   "",
+			expected: lines(
+				"--- live", "+++ generated",
+				"@@ -1,2 +0,0 @@",
+				"-a", "-b"),
+		},
+		{
+			name: "insertion",
+			from: lines("a", "b", "c", "d"),
+			to:   lines("a", "b", "x", "c", "d"),
+			expected: lines(
+				"--- live", "+++ generated",
+				"@@ -1,4 +1,5 @@",
+				" a", " b", "+x", " c", " d"),
+		},
+		{
+			name: "deletion",
+			from: lines("a", "b", "c", "d"),
+			to:   lines("a", "c", "d"),
+			expected: lines(
+				"--- live", "+++ generated",
+				"@@ -1,4 +1,3 @@",
+				" a", "-b", " c", " d"),
+		},
+		{
+			name: "edit",
+			from: lines("a", "b", "c"),
+			to:   lines("a", "x", "c"),
+			expected: lines(
+				"--- live", "+++ generated",
+				"@@ -1,3 +1,3 @@",
+				" a", "-b", "+x", " c"),
+		},
+		{
+			name: "context limited to three lines",
+			from: lines("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			to:   lines("1", "2", "3", "4", "x", "6", "7", "8", "9"),
+			expected: lines(
+				"--- live", "+++ generated",
+				"@@ -2,7 +2,7 @@",
+				" 2", " 3", " 4", "-5", "+x", " 6", " 7", " 8"),
+		},
+		{
+			name: "distant edits in separate hunks",
+			from: lines("1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"),
+			to:   lines("x", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "y"),
+			expected: lines(
+				"--- live", "+++ generated",
+				"@@ -1,4 +1,4 @@",
+				"-1", "+x", " 2", " 3", " 4",
+				"@@ -9,4 +9,4 @@",
+				" 9", " 10", " 11", "-12", "+y"),
+		},
+		{
+			name: "nearby edits in one hunk",
+			from: lines("1", "2", "3", "4", "5", "6", "7", "8"),
+			to:   lines("x", "2", "3", "4", "5", "6", "7", "y"),
+			expected: lines(
+				"--- live", "+++ generated",
+				"@@ -1,8 +1,8 @@",
+				"-1", "+x", " 2", " 3", " 4", " 5", " 6", " 7", "-8", "+y"),
+		},
+		{
+			name:     "missing final newline",
+			from:     "a\nb",
+			to:       "a\nb\n",
+			expected: "",
+		},
+	} {
+		diff := unifiedDiff("live", "generated", test.from, test.to)
+		if diff != test.expected {
+			t.Errorf("%s: expected diff\n%s\ngot\n%s", test.name, test.expected, diff)
+		}
+	}
+}
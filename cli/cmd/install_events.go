@@ -0,0 +1,58 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+
+	"github.com/netapp/trident/cli/k8s_client"
+)
+
+// Reasons of the events emitted with --emit-events
+const (
+	EventReasonInstallStarted    = "InstallStarted"
+	EventReasonCreatedRBAC       = "CreatedRBAC"
+	EventReasonCreatedPVC        = "CreatedPVC"
+	EventReasonCreatedPV         = "CreatedPV"
+	EventReasonCreatedController = "CreatedController"
+	EventReasonTridentPodRunning = "TridentPodRunning"
+	EventReasonTridentReady      = "TridentReady"
+	EventReasonInstallSucceeded  = "InstallSucceeded"
+	EventReasonInstallFailed     = "InstallFailed"
+)
+
+// installEventsReady is set once the Trident namespace exists, since events are created in it.
+var installEventsReady bool
+
+// emitInstallEvent records an installation milestone as an event on the Trident namespace, if
+// --emit-events was specified, so 'kubectl get events' shows the progress of the installation.
+// Events are informational, so failing to create one is only logged.
+func emitInstallEvent(eventType, reason, message string) {
+
+	if !emitEvents || !installEventsReady {
+		return
+	}
+
+	eventYAML := k8s_client.GetInstallEventYAML(TridentPodNamespace, eventType, reason, message, time.Now())
+	if err := client.CreateObjectByYAML(eventYAML); err != nil {
+		log.WithFields(log.Fields{
+			"reason": reason,
+			"error":  err,
+		}).Warning("Could not emit install event.")
+		return
+	}
+	log.WithField("reason", reason).Debug("Emitted install event.")
+}
+
+// emitInstallResultEvent records the outcome of the installation.
+func emitInstallResultEvent(installError error) {
+	if installError != nil {
+		emitInstallEvent(v1.EventTypeWarning, EventReasonInstallFailed, "Trident installation failed: "+
+			installError.Error())
+	} else {
+		emitInstallEvent(v1.EventTypeNormal, EventReasonInstallSucceeded, "Trident installation succeeded.")
+	}
+}
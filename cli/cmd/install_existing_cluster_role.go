@@ -0,0 +1,179 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/netapp/trident/cli/k8s_client"
+)
+
+// ExistingClusterRoleAnnotation marks the Trident cluster role binding of an installation that
+// uses a cluster role created outside the installer, so uninstalling Trident keeps the role.
+const ExistingClusterRoleAnnotation = "trident.netapp.io/existing-clusterrole"
+
+// validateExistingClusterRoleArguments checks the options that use a cluster role created outside
+// the installer, which can't be labeled for aggregation by the installer.
+func validateExistingClusterRoleArguments() error {
+
+	if !useExistingClusterRole {
+		return nil
+	}
+	if !useKubernetesRBAC {
+		return errors.New("--use-existing-clusterrole requires Kubernetes RBAC")
+	}
+	if len(aggregateToRoles) > 0 {
+		return errors.New("--aggregate-to-roles cannot be used with --use-existing-clusterrole, as the " +
+			"installer doesn't create the cluster role")
+	}
+	return nil
+}
+
+// checkExistingClusterRole compares the rules of the existing Trident cluster role with those of
+// the cluster role the installer would create, which is the reference for what Trident needs.  It
+// fails if the existing role doesn't grant a permission of the generated one, since Trident would
+// then fail in ways that only show up later, and reports any permission it grants beyond them.
+func checkExistingClusterRole() error {
+
+	var generated rbacv1.ClusterRole
+	generatedYAML := k8s_client.GetClusterRoleYAML(client.Flavor(), client.Version(), csi, nil)
+	if err := yaml.Unmarshal([]byte(generatedYAML), &generated); err != nil {
+		return fmt.Errorf("could not parse the generated cluster role; %v", err)
+	}
+
+	existing, err := client.GetClusterRole(generated.Name)
+	if err != nil {
+		return fmt.Errorf("could not get cluster role %s; %v", generated.Name, err)
+	} else if existing == nil {
+		return fmt.Errorf("cluster role %s does not exist; create it before installing Trident with "+
+			"--use-existing-clusterrole", generated.Name)
+	}
+
+	// A rule limited to named objects doesn't grant the access Trident needs to any object
+	existingRules := make([]rbacv1.PolicyRule, 0, len(existing.Rules))
+	for _, rule := range existing.Rules {
+		if len(rule.ResourceNames) > 0 {
+			log.WithFields(log.Fields{
+				"clusterRole":   existing.Name,
+				"resources":     strings.Join(rule.Resources, ","),
+				"resourceNames": strings.Join(rule.ResourceNames, ","),
+			}).Debug("Ignoring a cluster role rule limited to named objects.")
+			continue
+		}
+		existingRules = append(existingRules, rule)
+	}
+
+	missing := getUngrantedRBACRules(generated.Rules, existingRules)
+	extra := getUngrantedRBACRules(existingRules, generated.Rules)
+
+	for _, rule := range extra {
+		log.WithFields(getRBACRuleLogFields(existing.Name, rule)).Info("Existing cluster role grants a " +
+			"permission Trident doesn't need.")
+	}
+	for _, rule := range missing {
+		log.WithFields(getRBACRuleLogFields(existing.Name, rule)).Error("Existing cluster role lacks a " +
+			"permission Trident needs.")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cluster role %s lacks %d permissions Trident needs; use 'tridentctl rbac-report' "+
+			"to list the permissions of the cluster role the installer creates", existing.Name, len(missing))
+	}
+
+	preCheckPassed("existingClusterRole", fmt.Sprintf("Cluster role %s grants the permissions Trident "+
+		"needs, and %d others.", existing.Name, len(extra)))
+	return nil
+}
+
+// getUngrantedRBACRules returns the verbs, by resource, that the wanted rules include but the
+// granted rules don't, taking the wildcards of the granted rules into account.
+func getUngrantedRBACRules(wanted, granted []rbacv1.PolicyRule) []rbacReportRule {
+
+	grantedRules := groupRBACRules(granted)
+	isGranted := func(apiGroup, resource, verb string) bool {
+		for _, rule := range grantedRules {
+			if (rule.APIGroup != apiGroup && rule.APIGroup != rbacv1.APIGroupAll) ||
+				(rule.Resource != resource && rule.Resource != rbacv1.ResourceAll) {
+				continue
+			}
+			if containsString(rule.Verbs, verb) || containsString(rule.Verbs, rbacv1.VerbAll) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ungranted := make([]rbacReportRule, 0)
+	for _, rule := range groupRBACRules(wanted) {
+		var verbs []string
+		for _, verb := range rule.Verbs {
+			if !isGranted(rule.APIGroup, rule.Resource, verb) {
+				verbs = append(verbs, verb)
+			}
+		}
+		if len(verbs) > 0 {
+			ungranted = append(ungranted, rbacReportRule{APIGroup: rule.APIGroup, Resource: rule.Resource,
+				Verbs: verbs})
+		}
+	}
+	return ungranted
+}
+
+// getRBACRuleLogFields returns the log fields describing the verbs of a rule of a cluster role.
+func getRBACRuleLogFields(clusterRole string, rule rbacReportRule) log.Fields {
+
+	apiGroup := rule.APIGroup
+	if apiGroup == "" {
+		apiGroup = "core"
+	}
+	return log.Fields{
+		"clusterRole": clusterRole,
+		"apiGroup":    apiGroup,
+		"resource":    rule.Resource,
+		"verbs":       strings.Join(rule.Verbs, ","),
+	}
+}
+
+// getClusterRoleBindingName returns the name of the Trident cluster role binding.
+func getClusterRoleBindingName() (string, error) {
+
+	var binding rbacv1.ClusterRoleBinding
+	bindingYAML := k8s_client.GetClusterRoleBindingYAML(TridentPodNamespace, client.Flavor(), client.Version(), csi)
+	if err := yaml.Unmarshal([]byte(bindingYAML), &binding); err != nil {
+		return "", fmt.Errorf("could not parse the generated cluster role binding; %v", err)
+	}
+	return binding.Name, nil
+}
+
+// recordExistingClusterRole annotates the Trident cluster role binding to record that the cluster
+// role it binds was created outside the installer.
+func recordExistingClusterRole() error {
+
+	name, err := getClusterRoleBindingName()
+	if err != nil {
+		return err
+	}
+	return client.AnnotateObject("clusterrolebinding", name, ExistingClusterRoleAnnotation, "true")
+}
+
+// isExistingClusterRoleRecorded returns whether the Trident cluster role binding records that the
+// cluster role it binds was created outside the installer.  A missing binding records nothing.
+func isExistingClusterRoleRecorded() (bool, error) {
+
+	name, err := getClusterRoleBindingName()
+	if err != nil {
+		return false, err
+	}
+	binding, err := client.GetClusterRoleBinding(name)
+	if err != nil {
+		return false, fmt.Errorf("could not get cluster role binding %s; %v", name, err)
+	} else if binding == nil {
+		return false, nil
+	}
+	return binding.Annotations[ExistingClusterRoleAnnotation] == "true", nil
+}
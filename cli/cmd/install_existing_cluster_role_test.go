@@ -0,0 +1,124 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	"github.com/netapp/trident/utils"
+)
+
+// clusterRoleBindingClient is a Kubernetes client holding cluster role bindings.  Only the methods
+// that read and annotate them are implemented.
+type clusterRoleBindingClient struct {
+	k8s_client.Interface
+	bindings map[string]*rbacv1.ClusterRoleBinding
+	getError error
+}
+
+func (c *clusterRoleBindingClient) Version() *utils.Version {
+	return utils.MustParseSemantic("v1.11.0")
+}
+
+func (c *clusterRoleBindingClient) Flavor() k8s_client.OrchestratorFlavor {
+	return k8s_client.FlavorKubernetes
+}
+
+func (c *clusterRoleBindingClient) GetClusterRoleBinding(name string) (*rbacv1.ClusterRoleBinding, error) {
+	return c.bindings[name], c.getError
+}
+
+func (c *clusterRoleBindingClient) AnnotateObject(typeName, objectName, key, value string) error {
+	binding, ok := c.bindings[objectName]
+	if typeName != "clusterrolebinding" || !ok {
+		return errors.New("not found")
+	}
+	if binding.Annotations == nil {
+		binding.Annotations = make(map[string]string)
+	}
+	binding.Annotations[key] = value
+	return nil
+}
+
+func TestExistingClusterRoleRecord(t *testing.T) {
+
+	defer func(c k8s_client.Interface, csiValue bool) {
+		client, csi = c, csiValue
+	}(client, csi)
+
+	newBinding := func(name string) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	for _, test := range []struct {
+		name        string
+		csi         bool
+		bindings    map[string]*rbacv1.ClusterRoleBinding
+		getError    error
+		record      bool
+		expected    bool
+		expectError bool
+	}{
+		{
+			name:     "recorded",
+			bindings: map[string]*rbacv1.ClusterRoleBinding{"trident": newBinding("trident")},
+			record:   true,
+			expected: true,
+		},
+		{
+			name:     "recorded CSI",
+			csi:      true,
+			bindings: map[string]*rbacv1.ClusterRoleBinding{"trident-csi": newBinding("trident-csi")},
+			record:   true,
+			expected: true,
+		},
+		{
+			name:     "not recorded",
+			bindings: map[string]*rbacv1.ClusterRoleBinding{"trident": newBinding("trident")},
+			expected: false,
+		},
+		{
+			name:     "other flavor's binding not read",
+			bindings: map[string]*rbacv1.ClusterRoleBinding{"trident-csi": newBinding("trident-csi")},
+			expected: false,
+		},
+		{
+			name:     "missing binding",
+			bindings: map[string]*rbacv1.ClusterRoleBinding{},
+			expected: false,
+		},
+		{
+			name:        "binding unreadable",
+			bindings:    map[string]*rbacv1.ClusterRoleBinding{},
+			getError:    errors.New("forbidden"),
+			expectError: true,
+		},
+	} {
+		client = &clusterRoleBindingClient{bindings: test.bindings, getError: test.getError}
+		csi = test.csi
+
+		if test.record {
+			if err := recordExistingClusterRole(); err != nil {
+				t.Errorf("%s: unexpected error recording the existing cluster role; %v", test.name, err)
+				continue
+			}
+		}
+		recorded, err := isExistingClusterRoleRecorded()
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error; %v", test.name, err)
+		} else if recorded != test.expected {
+			t.Errorf("%s: expected recorded %v, got %v", test.name, test.expected, recorded)
+		}
+	}
+}
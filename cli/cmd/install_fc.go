@@ -0,0 +1,89 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netapp/trident/storage"
+)
+
+// fcPortStateScript prints the state of each port of the node's FC host bus adapters, which sysfs
+// shows to containers without any host mounts.
+const fcPortStateScript = "cat /sys/class/fc_host/host*/port_state 2>/dev/null || true"
+
+// backendUsesFC returns whether the Trident volume of a backend would be attached over FC.
+func backendUsesFC(sb *storage.Backend) bool {
+
+	fcReporter, ok := sb.Driver.(storage.FCReporter)
+	return ok && fcReporter.UsesFC()
+}
+
+// checkFCNodes samples the FC ports of each ready node with a diagnostic pod, since the kubelet
+// attaches an FC Trident volume over the host bus adapters of the node the Trident pod runs on.
+// It fails if no node has an online FC port, and warns about the nodes without one, on which the
+// Trident pod would fail to start.  Nodes are sampled one at a time.
+func checkFCNodes() error {
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		return fmt.Errorf("could not list nodes to check for FC ports; %v", err)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	var fcNodes, nonFCNodes []string
+	for _, node := range nodes {
+		if !isNodeReady(&node) {
+			log.WithField("node", node.Name).Debug("Node is not ready, skipping FC check.")
+			continue
+		}
+
+		// Each pod gets its own name, since a deleted pod may linger while it terminates
+		podName := fmt.Sprintf("trident-fc-check-%d", len(fcNodes)+len(nonFCNodes))
+		podResult, err := runDiagnosticPod(podName, node.Name, false, []string{"sh", "-c", fcPortStateScript})
+		if err == nil && !podResult.Succeeded {
+			err = fmt.Errorf("diagnostic pod failed; %s", podResult.Output)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"node":  node.Name,
+				"error": err,
+			}).Warning("Could not check the node for FC ports.")
+			nonFCNodes = append(nonFCNodes, node.Name)
+			continue
+		}
+
+		onlinePorts := 0
+		for _, state := range strings.Fields(podResult.Output) {
+			if state == "Online" {
+				onlinePorts++
+			}
+		}
+		log.WithFields(log.Fields{
+			"node":        node.Name,
+			"onlinePorts": onlinePorts,
+		}).Debug("Checked the node for FC ports.")
+
+		if onlinePorts == 0 {
+			nonFCNodes = append(nonFCNodes, node.Name)
+		} else {
+			fcNodes = append(fcNodes, node.Name)
+		}
+	}
+
+	if len(fcNodes) == 0 {
+		return fmt.Errorf("the storage backend attaches the Trident volume over FC, but no ready node has " +
+			"an online FC port")
+	}
+	if len(nonFCNodes) > 0 {
+		log.WithField("nodes", strings.Join(nonFCNodes, ",")).Warning("These nodes have no online FC " +
+			"port, so the Trident pod can't start on them; use --node-affinity to keep it off them.")
+	}
+
+	preCheckPassed("fcNodes", fmt.Sprintf("%d nodes have an online FC port.", len(fcNodes)))
+	return nil
+}
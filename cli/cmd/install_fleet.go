@@ -0,0 +1,323 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const DefaultFleetParallelism = 4
+
+// fleetCluster is a cluster to install Trident in, named by a context of the default kubeconfig
+// or of a specified one.
+type fleetCluster struct {
+	Kubeconfig string
+	Context    string
+}
+
+// key returns the name of the cluster in the fleet install report.
+func (c fleetCluster) key() string {
+	if c.Kubeconfig != "" {
+		return c.Kubeconfig + ":" + c.Context
+	}
+	return c.Context
+}
+
+// fleetClusterResult is the outcome of installing Trident in one cluster of a fleet.
+type fleetClusterResult struct {
+	Succeeded bool            `json:"succeeded"`
+	Error     string          `json:"error,omitempty"`
+	Summary   *installSummary `json:"summary,omitempty"`
+}
+
+// fleetInstallReport is the combined outcome of installing Trident in every cluster of a fleet,
+// keyed by context.
+type fleetInstallReport struct {
+	Succeeded bool                           `json:"succeeded"`
+	Clusters  map[string]*fleetClusterResult `json:"clusters"`
+}
+
+// isFleetInstall returns whether Trident is being installed in several clusters at once.
+func isFleetInstall() bool {
+	return len(fleetContexts) > 0 || fleetContextsFile != ""
+}
+
+// validateFleetArguments checks the fleet install options.  The options that write files, that
+// ask for confirmation, or that choose a single cluster don't apply to several clusters at once.
+func validateFleetArguments() error {
+
+	if !isFleetInstall() {
+		if fleetParallelism != DefaultFleetParallelism {
+			return errors.New("--parallelism may only be specified with --contexts or --contexts-file")
+		}
+		return nil
+	}
+
+	switch {
+	case len(fleetContexts) > 0 && fleetContextsFile != "":
+		return errors.New("--contexts cannot be used with --contexts-file")
+	case fleetParallelism < 1:
+		return errors.New("--parallelism must be at least 1")
+	case kubeContext != "" || k8sAPIServer != "":
+		return errors.New("--context and --k8s-api-server cannot be used with --contexts or --contexts-file")
+	case generateYAML || bundlePath != "" || diffLive:
+		return errors.New("--contexts and --contexts-file cannot be used to generate YAML or compare objects")
+	case dryRunOutputFile != "":
+		return errors.New("--dry-run-output-file cannot be used with --contexts or --contexts-file, as " +
+			"the summary of each cluster includes whether its pre-checks passed")
+	case confirmInstall:
+		return errors.New("--confirm cannot be used with --contexts or --contexts-file")
+	case stateFile != "":
+		return errors.New("--state-file cannot be used with --contexts or --contexts-file")
+	}
+	return nil
+}
+
+// getFleetClusters returns the clusters specified by --contexts or --contexts-file.  Each line of
+// the file is a context of the default kubeconfig, or a kubeconfig path and a context separated by
+// whitespace.  Blank lines and lines starting with '#' are ignored.
+func getFleetClusters() ([]fleetCluster, error) {
+
+	clusters := make([]fleetCluster, 0)
+
+	if fleetContextsFile == "" {
+		for _, context := range fleetContexts {
+			if context = strings.TrimSpace(context); context != "" {
+				clusters = append(clusters, fleetCluster{Kubeconfig: kubeconfig, Context: context})
+			}
+		}
+	} else {
+		file, err := os.Open(fleetContextsFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read contexts file; %v", err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for lineNumber := 1; scanner.Scan(); lineNumber++ {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			switch fields := strings.Fields(line); len(fields) {
+			case 1:
+				clusters = append(clusters, fleetCluster{Kubeconfig: kubeconfig, Context: fields[0]})
+			case 2:
+				clusters = append(clusters, fleetCluster{Kubeconfig: fields[0], Context: fields[1]})
+			default:
+				return nil, fmt.Errorf("line %d of the contexts file must be a context, or a kubeconfig "+
+					"path and a context", lineNumber)
+			}
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, fmt.Errorf("could not read contexts file; %v", err)
+		}
+	}
+
+	if len(clusters) == 0 {
+		return nil, errors.New("no contexts were specified")
+	}
+	seen := make(map[string]bool)
+	for _, cluster := range clusters {
+		if seen[cluster.key()] {
+			return nil, fmt.Errorf("context %s is specified more than once", cluster.key())
+		}
+		seen[cluster.key()] = true
+	}
+	return clusters, nil
+}
+
+// installFleet installs Trident in each cluster specified by --contexts or --contexts-file, at
+// most --parallelism at a time, and returns the combined report.  The install flow keeps its state
+// in this process, so each cluster is installed by running this installer again for its context,
+// which creates its own Kubernetes client for the context.  A failure in one cluster doesn't stop
+// the installation in the others.
+func installFleet() (*fleetInstallReport, error) {
+
+	clusters, err := getFleetClusters()
+	if err != nil {
+		return nil, err
+	}
+
+	installer, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("could not find the installer executable; %v", err)
+	}
+	args := getFleetInstallArgs(os.Args[1:])
+
+	report := &fleetInstallReport{
+		Succeeded: true,
+		Clusters:  make(map[string]*fleetClusterResult),
+	}
+	var reportLock sync.Mutex
+
+	logWriter := &fleetLogWriter{out: os.Stderr}
+
+	clusterQueue := make(chan fleetCluster, len(clusters))
+	for _, cluster := range clusters {
+		clusterQueue <- cluster
+	}
+	close(clusterQueue)
+
+	workers := fleetParallelism
+	if workers > len(clusters) {
+		workers = len(clusters)
+	}
+	log.WithFields(log.Fields{
+		"clusters":    len(clusters),
+		"parallelism": workers,
+	}).Info("Installing Trident in each cluster.")
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cluster := range clusterQueue {
+				result := installFleetCluster(installer, args, cluster, logWriter)
+
+				reportLock.Lock()
+				report.Clusters[cluster.key()] = result
+				if !result.Succeeded {
+					report.Succeeded = false
+				}
+				reportLock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report, nil
+}
+
+// installFleetCluster installs Trident in one cluster by running the installer for its context,
+// and returns the outcome read from the installer's JSON install summary.
+func installFleetCluster(
+	installer string, args []string, cluster fleetCluster, logWriter *fleetLogWriter,
+) *fleetClusterResult {
+
+	logFields := log.Fields{"context": cluster.key()}
+	log.WithFields(logFields).Info("Installing Trident.")
+
+	clusterArgs := append([]string{}, args...)
+	clusterArgs = append(clusterArgs, "--context", cluster.Context, "--output", FormatJSON)
+	if cluster.Kubeconfig != "" {
+		clusterArgs = append(clusterArgs, "--kubeconfig", cluster.Kubeconfig)
+	}
+
+	var stdout bytes.Buffer
+	clusterLog := logWriter.forContext(cluster.key())
+	cmd := exec.Command(installer, clusterArgs...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = clusterLog
+	runErr := cmd.Run()
+	clusterLog.Flush()
+
+	result := &fleetClusterResult{}
+	summary := &installSummary{}
+	if err := json.Unmarshal(stdout.Bytes(), summary); err == nil && stdout.Len() > 0 {
+		result.Summary = summary
+		result.Succeeded = summary.Succeeded && runErr == nil
+		result.Error = summary.Error
+	}
+	if result.Error == "" && runErr != nil {
+		// The installer failed before it could write a summary, such as in its pre-checks
+		result.Error = fmt.Sprintf("installer failed; %v", runErr)
+	}
+
+	if result.Succeeded {
+		log.WithFields(logFields).Info("Trident installation succeeded.")
+	} else {
+		log.WithFields(logFields).WithField("error", result.Error).Error("Trident installation failed.")
+	}
+	return result
+}
+
+// getFleetInstallArgs returns the command-line arguments of this installer without the options
+// that choose the clusters and the output format, which are set for each cluster's installer.
+func getFleetInstallArgs(args []string) []string {
+
+	// Options that take a value, whose value may be the next argument
+	removedOptions := map[string]bool{
+		"--contexts":      true,
+		"--contexts-file": true,
+		"--parallelism":   true,
+		"--kubeconfig":    true,
+		"--output":        true,
+		"-o":              true,
+	}
+
+	installArgs := make([]string, 0)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name := strings.SplitN(arg, "=", 2)[0]
+		switch {
+		case removedOptions[name]:
+			if !strings.Contains(arg, "=") {
+				i++
+			}
+		case strings.HasPrefix(arg, "-o") && !strings.HasPrefix(arg, "--"):
+			// The short output option with its value attached, such as '-ojson'
+		default:
+			installArgs = append(installArgs, arg)
+		}
+	}
+	return installArgs
+}
+
+// fleetLogWriter writes the log of each cluster's installer to stderr, a line at a time with the
+// cluster's context, so the logs of clusters installed at the same time don't interleave mid-line.
+type fleetLogWriter struct {
+	out  io.Writer
+	lock sync.Mutex
+}
+
+func (w *fleetLogWriter) forContext(context string) *fleetContextLogWriter {
+	return &fleetContextLogWriter{parent: w, prefix: "[" + context + "] "}
+}
+
+type fleetContextLogWriter struct {
+	parent *fleetLogWriter
+	prefix string
+	buffer bytes.Buffer
+}
+
+func (w *fleetContextLogWriter) Write(p []byte) (int, error) {
+
+	w.buffer.Write(p)
+	for {
+		line, err := w.buffer.ReadBytes('\n')
+		if err != nil {
+			// Keep the partial line until the rest of it is written
+			w.buffer.Write(line)
+			break
+		}
+		w.writeLine(line)
+	}
+	return len(p), nil
+}
+
+// Flush writes any final partial line.
+func (w *fleetContextLogWriter) Flush() {
+	if w.buffer.Len() > 0 {
+		w.writeLine(append(w.buffer.Bytes(), '\n'))
+		w.buffer.Reset()
+	}
+}
+
+func (w *fleetContextLogWriter) writeLine(line []byte) {
+	w.parent.lock.Lock()
+	defer w.parent.lock.Unlock()
+	fmt.Fprint(w.parent.out, w.prefix+string(line))
+}
@@ -0,0 +1,344 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// nodeCapacityResources are the resources whose requests must fit on a node's allocatable
+// headroom for the Trident controller pod to be scheduled there.
+var nodeCapacityResources = []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, v1.ResourceEphemeralStorage}
+
+// getControllerPodSpec returns the pod spec of the Trident controller the installer would create,
+// from the custom YAML file if one is used, or else from the generated YAML with any overlay.
+func getControllerPodSpec() (*v1.PodSpec, error) {
+
+	fileName, filePath := DeploymentFilename, deploymentPath
+	if csi {
+		fileName, filePath = StatefulSetFilename, csiStatefulSetPath
+	}
+
+	controllerYAML, err := getEffectiveSetupYAML(fileName, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deployments and statefulsets share the layout of their pod template
+	var controller struct {
+		Spec struct {
+			Template v1.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}
+	if err = yaml.Unmarshal([]byte(controllerYAML), &controller); err != nil {
+		return nil, fmt.Errorf("could not parse %s; %v", fileName, err)
+	}
+	return &controller.Spec.Template.Spec, nil
+}
+
+// getPodRequests returns the resources a pod requests, which is the sum of its containers'
+// requests, or the request of its largest init container if that is more.
+func getPodRequests(podSpec *v1.PodSpec) v1.ResourceList {
+
+	requests := v1.ResourceList{}
+	for _, container := range podSpec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+	for _, container := range podSpec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if total, ok := requests[name]; !ok || quantity.Cmp(total) > 0 {
+				requests[name] = quantity
+			}
+		}
+	}
+	return requests
+}
+
+// checkControllerNodeCapacity warns if no node could take the Trident controller pod, because
+// the nodes are cordoned, not ready, excluded by its node selector, affinity, or tolerations, or
+// lack the allocatable headroom for its resource requests.  Each candidate node is reported with
+// its free resources.  A controller pod that doesn't fit would otherwise stay pending, or be
+// scheduled onto a node that evicts it.
+func checkControllerNodeCapacity() {
+
+	podSpec, err := getControllerPodSpec()
+	if err != nil {
+		log.WithField("error", err).Warning("Could not get the Trident controller pod, skipping node capacity check.")
+		return
+	}
+	requests := getPodRequests(podSpec)
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		log.WithField("error", err).Warning("Could not list nodes, skipping node capacity check.")
+		return
+	}
+	pods, err := client.GetPodsByLabel("", true)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not list pods, skipping node capacity check.")
+		return
+	}
+
+	// Pods that have finished no longer hold their requested resources
+	nodeRequests := make(map[string]v1.ResourceList)
+	nodePodCounts := make(map[string]int64)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		used, ok := nodeRequests[pod.Spec.NodeName]
+		if !ok {
+			used = v1.ResourceList{}
+			nodeRequests[pod.Spec.NodeName] = used
+		}
+		for name, quantity := range getPodRequests(&pod.Spec) {
+			total := used[name]
+			total.Add(quantity)
+			used[name] = total
+		}
+		nodePodCounts[pod.Spec.NodeName]++
+	}
+
+	candidates := 0
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	for _, node := range nodes {
+		logFields := log.Fields{"node": node.Name}
+
+		if reason := getNodeIneligibility(&node, podSpec); reason != "" {
+			logFields["reason"] = reason
+			log.WithFields(logFields).Debug("Node can't run the Trident controller.")
+			continue
+		}
+
+		free := getNodeFreeResources(&node, nodeRequests[node.Name], nodePodCounts[node.Name])
+		for name, quantity := range free {
+			logFields[string(name)] = quantity.String()
+		}
+		if shortfalls := getResourceShortfalls(requests, free); len(shortfalls) > 0 {
+			logFields["shortfalls"] = strings.Join(shortfalls, ", ")
+			log.WithFields(logFields).Info("Node doesn't have room for the Trident controller.")
+			continue
+		}
+		candidates++
+		log.WithFields(logFields).Info("Node has room for the Trident controller.")
+	}
+
+	requestFields := log.Fields{"nodes": len(nodes)}
+	for name, quantity := range requests {
+		requestFields["request."+string(name)] = quantity.String()
+	}
+	if candidates == 0 {
+		log.WithFields(requestFields).Warning("No schedulable node has room for the Trident controller; " +
+			"its pod would stay pending or be evicted. Free resources on a node, or change the node " +
+			"affinity or resource requests of the controller.")
+		return
+	}
+	preCheckPassed("nodeCapacity", fmt.Sprintf("%d nodes have room for the Trident controller.", candidates))
+}
+
+// getNodeIneligibility returns why a pod can't be scheduled to a node regardless of its
+// resources, or an empty string if it can.
+func getNodeIneligibility(node *v1.Node, podSpec *v1.PodSpec) string {
+
+	if node.Spec.Unschedulable {
+		return "node is cordoned"
+	}
+	if !isNodeReady(node) {
+		return "node is not ready"
+	}
+
+	if !labels.SelectorFromSet(podSpec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return "node doesn't match the node selector"
+	}
+	if podSpec.Affinity != nil && podSpec.Affinity.NodeAffinity != nil &&
+		podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		if !nodeMatchesSelectorTerms(node,
+			podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms) {
+			return "node doesn't match the node affinity"
+		}
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for _, toleration := range podSpec.Tolerations {
+			if tolerationMatchesTaint(&toleration, &taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return fmt.Sprintf("node has taint %s=%s:%s", taint.Key, taint.Value, taint.Effect)
+		}
+	}
+	return ""
+}
+
+// isNodeReady returns whether a node reports that it is ready.
+func isNodeReady(node *v1.Node) bool {
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// nodeMatchesSelectorTerms returns whether a node matches any of the required node affinity
+// terms, each of which matches only if all of its label and field expressions do.  As for the
+// scheduler, a term without expressions matches no node.
+func nodeMatchesSelectorTerms(node *v1.Node, terms []v1.NodeSelectorTerm) bool {
+
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		matches := true
+		for _, requirement := range term.MatchExpressions {
+			if !nodeMatchesSelectorRequirement(node, &requirement) {
+				matches = false
+				break
+			}
+		}
+		for _, requirement := range term.MatchFields {
+			if !matches || !nodeMatchesFieldRequirement(node, &requirement) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeMatchesSelectorRequirement returns whether a node's labels satisfy a node affinity
+// expression.
+func nodeMatchesSelectorRequirement(node *v1.Node, requirement *v1.NodeSelectorRequirement) bool {
+	value, exists := node.Labels[requirement.Key]
+	return selectorRequirementMatches(value, exists, requirement)
+}
+
+// nodeMatchesFieldRequirement returns whether a node's fields satisfy a node affinity field
+// expression.  The node name is the only field the scheduler supports.
+func nodeMatchesFieldRequirement(node *v1.Node, requirement *v1.NodeSelectorRequirement) bool {
+	if requirement.Key != "metadata.name" {
+		return false
+	}
+	return selectorRequirementMatches(node.Name, true, requirement)
+}
+
+// selectorRequirementMatches returns whether a label or field value, if it exists, satisfies a
+// node affinity expression.
+func selectorRequirementMatches(value string, exists bool, requirement *v1.NodeSelectorRequirement) bool {
+
+	switch requirement.Operator {
+	case v1.NodeSelectorOpIn, v1.NodeSelectorOpNotIn:
+		found := false
+		for _, candidate := range requirement.Values {
+			if exists && candidate == value {
+				found = true
+			}
+		}
+		return found == (requirement.Operator == v1.NodeSelectorOpIn)
+	case v1.NodeSelectorOpExists:
+		return exists
+	case v1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if !exists || len(requirement.Values) != 1 {
+			return false
+		}
+		labelValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		bound, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if requirement.Operator == v1.NodeSelectorOpGt {
+			return labelValue > bound
+		}
+		return labelValue < bound
+	default:
+		return false
+	}
+}
+
+// tolerationMatchesTaint returns whether a toleration tolerates a taint.
+func tolerationMatchesTaint(toleration *v1.Toleration, taint *v1.Taint) bool {
+
+	if toleration.Effect != "" && toleration.Effect != taint.Effect {
+		return false
+	}
+	if toleration.Key != "" && toleration.Key != taint.Key {
+		return false
+	}
+	switch toleration.Operator {
+	case "", v1.TolerationOpEqual:
+		return toleration.Key != "" && toleration.Value == taint.Value
+	case v1.TolerationOpExists:
+		return true
+	default:
+		return false
+	}
+}
+
+// getNodeFreeResources returns a node's allocatable resources less those requested by the pods
+// on it.  Resources the node doesn't report are omitted.
+func getNodeFreeResources(node *v1.Node, used v1.ResourceList, podCount int64) v1.ResourceList {
+
+	free := v1.ResourceList{}
+	for _, name := range nodeCapacityResources {
+		allocatable, ok := node.Status.Allocatable[name]
+		if !ok {
+			continue
+		}
+		remaining := allocatable.DeepCopy()
+		if usedQuantity, ok := used[name]; ok {
+			remaining.Sub(usedQuantity)
+		}
+		free[name] = remaining
+	}
+	if allocatablePods, ok := node.Status.Allocatable[v1.ResourcePods]; ok {
+		free[v1.ResourcePods] = *resource.NewQuantity(allocatablePods.Value()-podCount, resource.DecimalSI)
+	}
+	return free
+}
+
+// getResourceShortfalls returns a description of each requested resource that exceeds what is
+// free on a node, including room for one more pod.
+func getResourceShortfalls(requests, free v1.ResourceList) []string {
+
+	shortfalls := make([]string, 0)
+	for _, name := range nodeCapacityResources {
+		request, requested := requests[name]
+		available, reported := free[name]
+		if requested && reported && request.Cmp(available) > 0 {
+			shortfalls = append(shortfalls, fmt.Sprintf("%s requests %s, %s free", name, request.String(),
+				available.String()))
+		}
+	}
+	if pods, ok := free[v1.ResourcePods]; ok && pods.Value() < 1 {
+		shortfalls = append(shortfalls, "no room for more pods")
+	}
+	return shortfalls
+}
@@ -0,0 +1,501 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newCapacityTestNode returns a ready node with the specified labels and taints.
+func newCapacityTestNode(name string, labels map[string]string, taints ...v1.Taint) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       v1.NodeSpec{Taints: taints},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+// newRequiredAffinity returns an affinity that requires one of the specified node selector terms.
+func newRequiredAffinity(terms ...v1.NodeSelectorTerm) *v1.Affinity {
+	return &v1.Affinity{
+		NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: terms},
+		},
+	}
+}
+
+func TestGetNodeIneligibility(t *testing.T) {
+
+	noScheduleTaint := v1.Taint{Key: "dedicated", Value: "db", Effect: v1.TaintEffectNoSchedule}
+	preferTaint := v1.Taint{Key: "spot", Value: "true", Effect: v1.TaintEffectPreferNoSchedule}
+
+	cordoned := newCapacityTestNode("node1", nil)
+	cordoned.Spec.Unschedulable = true
+	notReady := newCapacityTestNode("node1", nil)
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+	noConditions := newCapacityTestNode("node1", nil)
+	noConditions.Status.Conditions = nil
+
+	for _, test := range []struct {
+		name       string
+		node       *v1.Node
+		podSpec    v1.PodSpec
+		ineligible bool
+	}{
+		{
+			name: "eligible",
+			node: newCapacityTestNode("node1", map[string]string{"zone": "a"}),
+		},
+		{
+			name:       "cordoned",
+			node:       cordoned,
+			ineligible: true,
+		},
+		{
+			name:       "not ready",
+			node:       notReady,
+			ineligible: true,
+		},
+		{
+			name:       "no ready condition",
+			node:       noConditions,
+			ineligible: true,
+		},
+		{
+			name:    "node selector matches",
+			node:    newCapacityTestNode("node1", map[string]string{"zone": "a", "tier": "infra"}),
+			podSpec: v1.PodSpec{NodeSelector: map[string]string{"zone": "a"}},
+		},
+		{
+			name:       "node selector doesn't match",
+			node:       newCapacityTestNode("node1", map[string]string{"zone": "b"}),
+			podSpec:    v1.PodSpec{NodeSelector: map[string]string{"zone": "a"}},
+			ineligible: true,
+		},
+		{
+			name: "affinity matches",
+			node: newCapacityTestNode("node1", map[string]string{"zone": "a"}),
+			podSpec: v1.PodSpec{Affinity: newRequiredAffinity(v1.NodeSelectorTerm{
+				MatchExpressions: []v1.NodeSelectorRequirement{
+					{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a", "b"}},
+				},
+			})},
+		},
+		{
+			name: "affinity doesn't match",
+			node: newCapacityTestNode("node1", map[string]string{"zone": "c"}),
+			podSpec: v1.PodSpec{Affinity: newRequiredAffinity(v1.NodeSelectorTerm{
+				MatchExpressions: []v1.NodeSelectorRequirement{
+					{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a", "b"}},
+				},
+			})},
+			ineligible: true,
+		},
+		{
+			name: "affinity matches second term",
+			node: newCapacityTestNode("node1", map[string]string{"zone": "c"}),
+			podSpec: v1.PodSpec{Affinity: newRequiredAffinity(
+				v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+					{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+				}},
+				v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+					{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"c"}},
+				}},
+			)},
+		},
+		{
+			name: "affinity term needs all expressions",
+			node: newCapacityTestNode("node1", map[string]string{"zone": "a"}),
+			podSpec: v1.PodSpec{Affinity: newRequiredAffinity(v1.NodeSelectorTerm{
+				MatchExpressions: []v1.NodeSelectorRequirement{
+					{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+					{Key: "tier", Operator: v1.NodeSelectorOpExists},
+				},
+			})},
+			ineligible: true,
+		},
+		{
+			name:       "empty affinity term matches no node",
+			node:       newCapacityTestNode("node1", map[string]string{"zone": "a"}),
+			podSpec:    v1.PodSpec{Affinity: newRequiredAffinity(v1.NodeSelectorTerm{})},
+			ineligible: true,
+		},
+		{
+			name: "affinity field matches",
+			node: newCapacityTestNode("node1", nil),
+			podSpec: v1.PodSpec{Affinity: newRequiredAffinity(v1.NodeSelectorTerm{
+				MatchFields: []v1.NodeSelectorRequirement{
+					{Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"node1"}},
+				},
+			})},
+		},
+		{
+			name: "affinity field doesn't match",
+			node: newCapacityTestNode("node2", nil),
+			podSpec: v1.PodSpec{Affinity: newRequiredAffinity(v1.NodeSelectorTerm{
+				MatchFields: []v1.NodeSelectorRequirement{
+					{Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"node1"}},
+				},
+			})},
+			ineligible: true,
+		},
+		{
+			name: "affinity expression and field both needed",
+			node: newCapacityTestNode("node1", map[string]string{"zone": "b"}),
+			podSpec: v1.PodSpec{Affinity: newRequiredAffinity(v1.NodeSelectorTerm{
+				MatchExpressions: []v1.NodeSelectorRequirement{
+					{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+				},
+				MatchFields: []v1.NodeSelectorRequirement{
+					{Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"node1"}},
+				},
+			})},
+			ineligible: true,
+		},
+		{
+			name:       "untolerated taint",
+			node:       newCapacityTestNode("node1", nil, noScheduleTaint),
+			ineligible: true,
+		},
+		{
+			name: "tolerated taint",
+			node: newCapacityTestNode("node1", nil, noScheduleTaint),
+			podSpec: v1.PodSpec{Tolerations: []v1.Toleration{
+				{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "db", Effect: v1.TaintEffectNoSchedule},
+			}},
+		},
+		{
+			name: "PreferNoSchedule taint ignored",
+			node: newCapacityTestNode("node1", nil, preferTaint),
+		},
+	} {
+		reason := getNodeIneligibility(test.node, &test.podSpec)
+		if test.ineligible && reason == "" {
+			t.Errorf("%s: expected the node to be ineligible", test.name)
+		} else if !test.ineligible && reason != "" {
+			t.Errorf("%s: expected the node to be eligible, got '%s'", test.name, reason)
+		}
+	}
+}
+
+func TestTolerationMatchesTaint(t *testing.T) {
+
+	taint := v1.Taint{Key: "dedicated", Value: "db", Effect: v1.TaintEffectNoSchedule}
+
+	for _, test := range []struct {
+		name       string
+		toleration v1.Toleration
+		expected   bool
+	}{
+		{
+			name:       "equal",
+			toleration: v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "db"},
+			expected:   true,
+		},
+		{
+			name:       "default operator is equal",
+			toleration: v1.Toleration{Key: "dedicated", Value: "db", Effect: v1.TaintEffectNoSchedule},
+			expected:   true,
+		},
+		{
+			name:       "other value",
+			toleration: v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "web"},
+			expected:   false,
+		},
+		{
+			name:       "other key",
+			toleration: v1.Toleration{Key: "gpu", Operator: v1.TolerationOpEqual, Value: "db"},
+			expected:   false,
+		},
+		{
+			name:       "other effect",
+			toleration: v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoExecute},
+			expected:   false,
+		},
+		{
+			name:       "exists",
+			toleration: v1.Toleration{Key: "dedicated", Operator: v1.TolerationOpExists},
+			expected:   true,
+		},
+		{
+			name:       "exists without key tolerates everything",
+			toleration: v1.Toleration{Operator: v1.TolerationOpExists},
+			expected:   true,
+		},
+		{
+			name:       "equal without key",
+			toleration: v1.Toleration{Operator: v1.TolerationOpEqual, Value: "db"},
+			expected:   false,
+		},
+		{
+			name:       "unknown operator",
+			toleration: v1.Toleration{Key: "dedicated", Operator: "Matches", Value: "db"},
+			expected:   false,
+		},
+	} {
+		if matches := tolerationMatchesTaint(&test.toleration, &taint); matches != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, matches)
+		}
+	}
+}
+
+func TestNodeMatchesSelectorRequirement(t *testing.T) {
+
+	node := newCapacityTestNode("node1", map[string]string{"zone": "a", "cores": "8", "arch": "amd64"})
+
+	for _, test := range []struct {
+		name        string
+		requirement v1.NodeSelectorRequirement
+		expected    bool
+	}{
+		{
+			name:        "in",
+			requirement: v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a", "b"}},
+			expected:    true,
+		},
+		{
+			name:        "not in values",
+			requirement: v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"b"}},
+			expected:    false,
+		},
+		{
+			name:        "in missing label",
+			requirement: v1.NodeSelectorRequirement{Key: "rack", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+			expected:    false,
+		},
+		{
+			name:        "not in",
+			requirement: v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpNotIn, Values: []string{"b"}},
+			expected:    true,
+		},
+		{
+			name:        "not in with value",
+			requirement: v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpNotIn, Values: []string{"a"}},
+			expected:    false,
+		},
+		{
+			name:        "not in missing label",
+			requirement: v1.NodeSelectorRequirement{Key: "rack", Operator: v1.NodeSelectorOpNotIn, Values: []string{"a"}},
+			expected:    true,
+		},
+		{
+			name:        "exists",
+			requirement: v1.NodeSelectorRequirement{Key: "arch", Operator: v1.NodeSelectorOpExists},
+			expected:    true,
+		},
+		{
+			name:        "exists missing label",
+			requirement: v1.NodeSelectorRequirement{Key: "rack", Operator: v1.NodeSelectorOpExists},
+			expected:    false,
+		},
+		{
+			name:        "does not exist",
+			requirement: v1.NodeSelectorRequirement{Key: "rack", Operator: v1.NodeSelectorOpDoesNotExist},
+			expected:    true,
+		},
+		{
+			name:        "does not exist with label",
+			requirement: v1.NodeSelectorRequirement{Key: "arch", Operator: v1.NodeSelectorOpDoesNotExist},
+			expected:    false,
+		},
+		{
+			name:        "greater than",
+			requirement: v1.NodeSelectorRequirement{Key: "cores", Operator: v1.NodeSelectorOpGt, Values: []string{"4"}},
+			expected:    true,
+		},
+		{
+			name:        "not greater than",
+			requirement: v1.NodeSelectorRequirement{Key: "cores", Operator: v1.NodeSelectorOpGt, Values: []string{"8"}},
+			expected:    false,
+		},
+		{
+			name:        "less than",
+			requirement: v1.NodeSelectorRequirement{Key: "cores", Operator: v1.NodeSelectorOpLt, Values: []string{"16"}},
+			expected:    true,
+		},
+		{
+			name:        "less than non-numeric label",
+			requirement: v1.NodeSelectorRequirement{Key: "zone", Operator: v1.NodeSelectorOpLt, Values: []string{"16"}},
+			expected:    false,
+		},
+		{
+			name:        "greater than several values",
+			requirement: v1.NodeSelectorRequirement{Key: "cores", Operator: v1.NodeSelectorOpGt, Values: []string{"1", "2"}},
+			expected:    false,
+		},
+		{
+			name:        "unknown operator",
+			requirement: v1.NodeSelectorRequirement{Key: "zone", Operator: "Like", Values: []string{"a"}},
+			expected:    false,
+		},
+	} {
+		if matches := nodeMatchesSelectorRequirement(node, &test.requirement); matches != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, matches)
+		}
+	}
+}
+
+func TestNodeMatchesFieldRequirement(t *testing.T) {
+
+	node := newCapacityTestNode("node1", map[string]string{"zone": "a"})
+
+	for _, test := range []struct {
+		name        string
+		requirement v1.NodeSelectorRequirement
+		expected    bool
+	}{
+		{
+			name: "name in",
+			requirement: v1.NodeSelectorRequirement{
+				Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"node1"}},
+			expected: true,
+		},
+		{
+			name: "name not in",
+			requirement: v1.NodeSelectorRequirement{
+				Key: "metadata.name", Operator: v1.NodeSelectorOpNotIn, Values: []string{"node1"}},
+			expected: false,
+		},
+		{
+			name:        "unsupported field",
+			requirement: v1.NodeSelectorRequirement{Key: "spec.podCIDR", Operator: v1.NodeSelectorOpExists},
+			expected:    false,
+		},
+	} {
+		if matches := nodeMatchesFieldRequirement(node, &test.requirement); matches != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, matches)
+		}
+	}
+}
+
+func TestGetPodRequests(t *testing.T) {
+
+	requests := func(cpu, memory string) v1.ResourceRequirements {
+		list := v1.ResourceList{}
+		if cpu != "" {
+			list[v1.ResourceCPU] = resource.MustParse(cpu)
+		}
+		if memory != "" {
+			list[v1.ResourceMemory] = resource.MustParse(memory)
+		}
+		return v1.ResourceRequirements{Requests: list}
+	}
+
+	for _, test := range []struct {
+		name           string
+		podSpec        v1.PodSpec
+		expectedCPU    string
+		expectedMemory string
+	}{
+		{
+			name:    "no requests",
+			podSpec: v1.PodSpec{Containers: []v1.Container{{Name: "a"}}},
+		},
+		{
+			name: "containers summed",
+			podSpec: v1.PodSpec{Containers: []v1.Container{
+				{Name: "a", Resources: requests("100m", "128Mi")},
+				{Name: "b", Resources: requests("250m", "")},
+			}},
+			expectedCPU:    "350m",
+			expectedMemory: "128Mi",
+		},
+		{
+			name: "smaller init container",
+			podSpec: v1.PodSpec{
+				Containers:     []v1.Container{{Name: "a", Resources: requests("500m", "256Mi")}},
+				InitContainers: []v1.Container{{Name: "init", Resources: requests("100m", "64Mi")}},
+			},
+			expectedCPU:    "500m",
+			expectedMemory: "256Mi",
+		},
+		{
+			name: "larger init container",
+			podSpec: v1.PodSpec{
+				Containers: []v1.Container{{Name: "a", Resources: requests("100m", "64Mi")}},
+				InitContainers: []v1.Container{
+					{Name: "init1", Resources: requests("1", "")},
+					{Name: "init2", Resources: requests("", "1Gi")},
+				},
+			},
+			expectedCPU:    "1",
+			expectedMemory: "1Gi",
+		},
+	} {
+		podRequests := getPodRequests(&test.podSpec)
+		for name, expected := range map[v1.ResourceName]string{
+			v1.ResourceCPU:    test.expectedCPU,
+			v1.ResourceMemory: test.expectedMemory,
+		} {
+			quantity, ok := podRequests[name]
+			if expected == "" {
+				if ok {
+					t.Errorf("%s: expected no %s request, got %s", test.name, name, quantity.String())
+				}
+			} else if !ok || quantity.Cmp(resource.MustParse(expected)) != 0 {
+				t.Errorf("%s: expected %s request %s, got %s", test.name, name, expected, quantity.String())
+			}
+		}
+	}
+}
+
+func TestGetResourceShortfalls(t *testing.T) {
+
+	list := func(values map[v1.ResourceName]string) v1.ResourceList {
+		resources := v1.ResourceList{}
+		for name, value := range values {
+			resources[name] = resource.MustParse(value)
+		}
+		return resources
+	}
+
+	for _, test := range []struct {
+		name     string
+		requests v1.ResourceList
+		free     v1.ResourceList
+		expected []string
+	}{
+		{
+			name:     "fits",
+			requests: list(map[v1.ResourceName]string{v1.ResourceCPU: "100m", v1.ResourceMemory: "128Mi"}),
+			free:     list(map[v1.ResourceName]string{v1.ResourceCPU: "1", v1.ResourceMemory: "1Gi", v1.ResourcePods: "10"}),
+			expected: []string{},
+		},
+		{
+			name:     "exactly fits",
+			requests: list(map[v1.ResourceName]string{v1.ResourceCPU: "1"}),
+			free:     list(map[v1.ResourceName]string{v1.ResourceCPU: "1", v1.ResourcePods: "1"}),
+			expected: []string{},
+		},
+		{
+			name:     "not enough CPU and memory",
+			requests: list(map[v1.ResourceName]string{v1.ResourceCPU: "2", v1.ResourceMemory: "2Gi"}),
+			free:     list(map[v1.ResourceName]string{v1.ResourceCPU: "1", v1.ResourceMemory: "1Gi"}),
+			expected: []string{"cpu requests 2, 1 free", "memory requests 2Gi, 1Gi free"},
+		},
+		{
+			name:     "unreported resource ignored",
+			requests: list(map[v1.ResourceName]string{v1.ResourceEphemeralStorage: "10Gi"}),
+			free:     list(map[v1.ResourceName]string{v1.ResourceCPU: "1"}),
+			expected: []string{},
+		},
+		{
+			name:     "no room for pods",
+			requests: list(map[v1.ResourceName]string{}),
+			free:     list(map[v1.ResourceName]string{v1.ResourcePods: "0"}),
+			expected: []string{"no room for more pods"},
+		},
+	} {
+		shortfalls := getResourceShortfalls(test.requests, test.free)
+		if !reflect.DeepEqual(shortfalls, test.expected) {
+			t.Errorf("%s: expected shortfalls %v, got %v", test.name, test.expected, shortfalls)
+		}
+	}
+}
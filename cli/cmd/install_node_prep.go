@@ -0,0 +1,162 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The default minimums of the node limits checked by --check-node-prep.  The kernel defaults of
+// 8192 inotify watches, 128 inotify instances, and 1024 open files are soon exhausted on a node
+// with many pods and volumes.
+const (
+	DefaultMinInotifyWatches   = 65536
+	DefaultMinInotifyInstances = 512
+	DefaultMinOpenFiles        = 65536
+)
+
+// nodePrepResult holds the limits sampled on a node by --check-node-prep.
+type nodePrepResult struct {
+	Node             string   `json:"node"`
+	InotifyWatches   int64    `json:"inotifyWatches"`
+	InotifyInstances int64    `json:"inotifyInstances"`
+	OpenFiles        int64    `json:"openFiles"`
+	Warnings         []string `json:"warnings,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+// nodePrepScript prints the inotify limits of the node's kernel and the open file limit a
+// container gets from the node's container runtime, which the CSI node plugin also gets.
+const nodePrepScript = "echo $(cat /proc/sys/fs/inotify/max_user_watches) " +
+	"$(cat /proc/sys/fs/inotify/max_user_instances) $(ulimit -n)"
+
+// validateNodePrepArguments checks the thresholds of --check-node-prep, which only applies to the
+// node plugin of CSI Trident.
+func validateNodePrepArguments() error {
+
+	if !checkNodePrep {
+		return nil
+	}
+	if !csi {
+		return errors.New("--check-node-prep may only be specified with --csi")
+	}
+	if minInotifyWatches <= 0 || minInotifyInstances <= 0 || minOpenFiles <= 0 {
+		return errors.New("--min-inotify-watches, --min-inotify-instances, and --min-open-files must be " +
+			"positive")
+	}
+	return nil
+}
+
+// checkNodePrepLimits samples the inotify and open file limits on each ready node with a
+// diagnostic pod, and warns about nodes whose limits are below the thresholds.  The CSI node
+// plugin fails in ways that are hard to trace back to these limits, and only once a busy node
+// runs out, so the values of every node are reported.  Nodes are sampled one at a time.
+func checkNodePrepLimits() {
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		log.WithField("error", err).Warning("Could not list nodes, skipping node prep check.")
+		return
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	// Each pod gets its own name, since a deleted pod may linger while it terminates
+	results := make([]nodePrepResult, 0, len(nodes))
+	for _, node := range nodes {
+		if !isNodeReady(&node) {
+			log.WithField("node", node.Name).Debug("Node is not ready, skipping node prep check.")
+			continue
+		}
+		podName := fmt.Sprintf("trident-node-prep-%d", len(results))
+		results = append(results, sampleNodePrepLimits(podName, node.Name))
+	}
+	installResult.NodePrep = results
+
+	for _, result := range results {
+		if result.Error != "" || len(result.Warnings) > 0 {
+			return
+		}
+	}
+	preCheckPassed("nodePrep", fmt.Sprintf("The limits of %d nodes are at least the thresholds.", len(results)))
+}
+
+// sampleNodePrepLimits runs a diagnostic pod on a node to sample its limits, and logs them with a
+// warning for each limit that is below its threshold.
+func sampleNodePrepLimits(podName, nodeName string) nodePrepResult {
+
+	result := nodePrepResult{Node: nodeName}
+	logFields := log.Fields{"node": nodeName}
+
+	podResult, err := runDiagnosticPod(podName, nodeName, false, []string{"sh", "-c", nodePrepScript})
+	if err == nil && !podResult.Succeeded {
+		err = fmt.Errorf("diagnostic pod failed; %s", podResult.Output)
+	}
+	var values []int64
+	if err == nil {
+		values, err = parseNodePrepOutput(podResult.Output)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		log.WithFields(logFields).WithField("error", err).Warning("Could not sample the node limits.")
+		return result
+	}
+
+	result.InotifyWatches, result.InotifyInstances, result.OpenFiles = values[0], values[1], values[2]
+	logFields["inotifyWatches"] = result.InotifyWatches
+	logFields["inotifyInstances"] = result.InotifyInstances
+	logFields["openFiles"] = result.OpenFiles
+
+	for _, limit := range []struct {
+		name      string
+		value     int64
+		threshold int64
+	}{
+		{"fs.inotify.max_user_watches", result.InotifyWatches, minInotifyWatches},
+		{"fs.inotify.max_user_instances", result.InotifyInstances, minInotifyInstances},
+		{"open files (ulimit -n)", result.OpenFiles, minOpenFiles},
+	} {
+		if limit.value < limit.threshold {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s is %d, below %d", limit.name,
+				limit.value, limit.threshold))
+		}
+	}
+
+	if len(result.Warnings) > 0 {
+		logFields["below"] = strings.Join(result.Warnings, "; ")
+		log.WithFields(logFields).Warning("Node limits are below the thresholds; the CSI node plugin " +
+			"may fail once the node is busy. Raise the limits on the node.")
+		return result
+	}
+	log.WithFields(logFields).Info("Node limits are at least the thresholds.")
+	return result
+}
+
+// parseNodePrepOutput parses the inotify watch, inotify instance, and open file limits printed by
+// nodePrepScript.  An unlimited open file limit is reported as the largest value.
+func parseNodePrepOutput(output string) ([]int64, error) {
+
+	fields := strings.Fields(output)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected output '%s'", output)
+	}
+	values := make([]int64, 0, len(fields))
+	for _, field := range fields {
+		if field == "unlimited" {
+			values = append(values, math.MaxInt64)
+			continue
+		}
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected output '%s'", output)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
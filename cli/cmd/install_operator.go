@@ -0,0 +1,76 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TridentOperatorAPIGroup is the API group of the custom resources with which the Trident
+	// operator manages an installation
+	TridentOperatorAPIGroup = "trident.netapp.io"
+
+	// maxOwnerDepth bounds the walk up a pod's owners, which for an operator-managed controller
+	// is the pod, its replica set, its deployment, and the operator's custom resource
+	maxOwnerDepth = 3
+)
+
+// checkOperatorManagedPods ensures no pod with the installer's app label is managed by the Trident
+// operator.  The operator labels its pods the same way, so the installer would otherwise find, and
+// then modify or delete, pods that the operator recreates.
+func checkOperatorManagedPods() error {
+
+	pods, err := client.GetPodsByLabel(appLabel, true)
+	if err != nil {
+		return fmt.Errorf("could not list pods with label %s; %v", appLabel, err)
+	}
+
+	var conflicts []string
+	for _, pod := range pods {
+		owned, owner, err := isOperatorOwned(pod.OwnerReferences, pod.Namespace, 0)
+		if err != nil {
+			return fmt.Errorf("could not check the owners of pod %s/%s; %v", pod.Namespace, pod.Name, err)
+		}
+		if owned {
+			log.WithFields(log.Fields{
+				"pod":       pod.Name,
+				"namespace": pod.Namespace,
+				"owner":     owner,
+			}).Error("Pod is managed by the Trident operator.")
+			conflicts = append(conflicts, pod.Namespace+"/"+pod.Name)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("pods %s have the label %s but are managed by the Trident operator; use the "+
+			"operator to manage this installation", strings.Join(conflicts, ", "), appLabel)
+	}
+	return nil
+}
+
+// isOperatorOwned walks up the owners of an object and reports whether any is a Trident operator
+// resource, returning that owner's kind and name.
+func isOperatorOwned(ownerRefs []metav1.OwnerReference, namespace string, depth int) (bool, string, error) {
+
+	for _, ownerRef := range ownerRefs {
+		if strings.SplitN(ownerRef.APIVersion, "/", 2)[0] == TridentOperatorAPIGroup {
+			return true, ownerRef.Kind + "/" + ownerRef.Name, nil
+		}
+		if depth+1 >= maxOwnerDepth {
+			continue
+		}
+		parentRefs, err := client.GetOwnerReferences(strings.ToLower(ownerRef.Kind), ownerRef.Name, namespace)
+		if err != nil {
+			return false, "", err
+		}
+		if owned, owner, err := isOperatorOwned(parentRefs, namespace, depth+1); err != nil || owned {
+			return owned, owner, err
+		}
+	}
+	return false, "", nil
+}
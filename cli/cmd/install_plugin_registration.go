@@ -0,0 +1,130 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	tridentconfig "github.com/netapp/trident/config"
+)
+
+// pluginSocketName is the name of the CSI node plugin's socket in its plugin directory.
+const pluginSocketName = "csi.sock"
+
+// validatePluginRegistrationArguments checks the --plugin-registration-path, which must be an
+// absolute path to the node plugin's socket.  Only driver registrars from v0.4 on accept the path,
+// so the default registrar image can't be used with it.
+func validatePluginRegistrationArguments() error {
+
+	if pluginRegistrationPath == "" {
+		return nil
+	}
+	if !csi {
+		return errors.New("--plugin-registration-path may only be specified with --csi")
+	}
+	if !path.IsAbs(pluginRegistrationPath) || path.Clean(pluginRegistrationPath) != pluginRegistrationPath {
+		return fmt.Errorf("--plugin-registration-path must be a clean, absolute path, not '%s'",
+			pluginRegistrationPath)
+	}
+	if path.Base(pluginRegistrationPath) != pluginSocketName {
+		return fmt.Errorf("--plugin-registration-path must name the socket %s, not '%s'", pluginSocketName,
+			path.Base(pluginRegistrationPath))
+	}
+	if csiRegistrarImage == k8s_client.DefaultCSIRegistrarImage {
+		return fmt.Errorf("--plugin-registration-path requires a --csi-registrar-image of v0.4 or later, "+
+			"as %s doesn't accept a registration path", k8s_client.DefaultCSIRegistrarImage)
+	}
+	return nil
+}
+
+// getPluginRegistrationPath returns the host path at which the node plugin's socket is expected.
+func getPluginRegistrationPath() string {
+	if pluginRegistrationPath != "" {
+		return pluginRegistrationPath
+	}
+	return k8s_client.DefaultPluginRegistrationPath
+}
+
+// waitForPluginSockets waits until the node plugin's socket appears at the registration path on
+// each node, which it checks through the host filesystem mounted in the Trident node pods.  The
+// driver registrar reports that path to the kubelet, so if the socket is elsewhere, such as on a
+// distribution whose kubelet uses another root directory, registration fails without an error.
+func waitForPluginSockets(nodeNames []string) error {
+
+	socketPath := getPluginRegistrationPath()
+	hostSocketPath := path.Join("/host", socketPath)
+
+	var missing []string
+	found := make(map[string]bool)
+
+	checkSockets := func() error {
+		pods, err := client.GetPodsByLabel(TridentNodeLabel, false)
+		if err != nil {
+			return err
+		}
+		nodePods := make(map[string]string)
+		for _, pod := range pods {
+			if pod.Spec.NodeName != "" && !isPodEvicted(&pod) {
+				nodePods[pod.Spec.NodeName] = pod.Name
+			}
+		}
+
+		missing = nil
+		for _, nodeName := range nodeNames {
+			if found[nodeName] {
+				continue
+			}
+			podName, ok := nodePods[nodeName]
+			if ok {
+				_, err = client.Exec(podName, tridentconfig.ContainerTrident, []string{"test", "-S", hostSocketPath})
+			}
+			if !ok || err != nil {
+				missing = append(missing, nodeName)
+				continue
+			}
+			found[nodeName] = true
+		}
+		if len(missing) > 0 {
+			return errors.New("socket not found on all nodes")
+		}
+		return nil
+	}
+	socketNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+			"path":      socketPath,
+			"missing":   strings.Join(missing, ","),
+		}).Debug("CSI plugin socket not yet found on all nodes, waiting.")
+	}
+	socketBackoff := backoff.NewExponentialBackOff()
+	socketBackoff.MaxElapsedTime = getStepTimeout(StepRegistration)
+
+	log.WithField("path", socketPath).Info("Waiting for the CSI plugin socket on each node.")
+
+	if err := backoff.RetryNotify(checkSockets, socketBackoff, socketNotify); err != nil {
+		for _, nodeName := range missing {
+			log.WithFields(log.Fields{
+				"node": nodeName,
+				"path": socketPath,
+			}).Warning("CSI plugin socket not found at the registration path.")
+		}
+		return fmt.Errorf("the CSI plugin socket was not found at %s on nodes %s after %3.2f seconds, "+
+			"so the kubelet can't register the driver; use --plugin-registration-path to set the path "+
+			"of the kubelet plugin directory on these nodes", socketPath, strings.Join(missing, ", "),
+			socketBackoff.MaxElapsedTime.Seconds())
+	}
+
+	log.WithFields(log.Fields{
+		"nodes": len(nodeNames),
+		"path":  socketPath,
+	}).Info("CSI plugin socket is at the registration path on each node.")
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// installProfile is a named set of install flag defaults.  Flags specified on the command
+// line override the values of a profile.
+type installProfile struct {
+	Description string
+	// Flags maps flag names to the values the profile sets
+	Flags map[string]string
+	// NonCSIFlags are set as well unless --csi is specified, as CSI Trident doesn't support them
+	NonCSIFlags map[string]string
+}
+
+// installProfiles are the built-in profiles that --profile accepts.
+var installProfiles = map[string]installProfile{
+	"minimal": {
+		Description: "A small Trident volume and no extras, for short-lived or resource-constrained clusters.",
+		Flags: map[string]string{
+			"volume-size":        "1Gi",
+			"controller-workers": "1",
+			"k8s-timeout":        "120s",
+		},
+	},
+	"dev": {
+		Description: "Debug logging kept in a rotated file, and a failed Trident pod left in place for debugging.",
+		Flags: map[string]string{
+			"debug":             "true",
+			"log-max-size":      "10",
+			"log-max-backups":   "2",
+			"retain-failed-pod": "true",
+		},
+	},
+	"production": {
+		Description: "Qualified versions only, bounded resources and logs, events, and pods replaced on config changes.",
+		Flags: map[string]string{
+			"volume-size":                       "10Gi",
+			"strict-version":                    "true",
+			"strict-quota":                      "true",
+			"config-checksum":                   "true",
+			"emit-events":                       "true",
+			"trident-ephemeral-storage-request": "256Mi",
+			"trident-ephemeral-storage-limit":   "1Gi",
+			"log-max-size":                      "100",
+			"log-max-backups":                   "5",
+			"log-max-age":                       "168h",
+			"k8s-timeout":                       "300s",
+		},
+		NonCSIFlags: map[string]string{
+			"controller-workers": "4",
+		},
+	},
+}
+
+// getInstallProfileNames returns the names of the built-in profiles in sorted order.
+func getInstallProfileNames() []string {
+	names := make([]string, 0, len(installProfiles))
+	for name := range installProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyInstallProfile sets each flag of the named profile that wasn't specified on the command
+// line, leaving out those CSI Trident doesn't support if --csi is specified, and returns the flags
+// it set.  It must run before the flag values are used, including to initialize logging, so it
+// leaves logging to its caller.
+func applyInstallProfile(flags *pflag.FlagSet, profileName string) ([]string, error) {
+
+	if profileName == "" {
+		return nil, nil
+	}
+	profile, ok := installProfiles[profileName]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile '%s'; choose one of %s", profileName,
+			strings.Join(getInstallProfileNames(), ", "))
+	}
+
+	profileFlags := make(map[string]string, len(profile.Flags)+len(profile.NonCSIFlags))
+	for flagName, value := range profile.Flags {
+		profileFlags[flagName] = value
+	}
+	if csiFlag, err := flags.GetBool("csi"); err != nil {
+		return nil, fmt.Errorf("could not apply profile %s; %v", profileName, err)
+	} else if !csiFlag {
+		for flagName, value := range profile.NonCSIFlags {
+			profileFlags[flagName] = value
+		}
+	}
+
+	flagNames := make([]string, 0, len(profileFlags))
+	for flagName := range profileFlags {
+		flagNames = append(flagNames, flagName)
+	}
+	sort.Strings(flagNames)
+
+	applied := make([]string, 0, len(flagNames))
+	for _, flagName := range flagNames {
+		if flags.Changed(flagName) {
+			continue
+		}
+		value := profileFlags[flagName]
+		if err := flags.Set(flagName, value); err != nil {
+			return nil, fmt.Errorf("could not apply --%s=%s of profile %s; %v", flagName, value, profileName, err)
+		}
+		applied = append(applied, fmt.Sprintf("--%s=%s", flagName, value))
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// newProfileTestFlags returns a flag set with the flags of every profile, parsed from args.
+func newProfileTestFlags(t *testing.T, args ...string) *pflag.FlagSet {
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Bool("csi", false, "")
+	for _, profile := range installProfiles {
+		for _, profileFlags := range []map[string]string{profile.Flags, profile.NonCSIFlags} {
+			for flagName := range profileFlags {
+				if flags.Lookup(flagName) == nil {
+					flags.String(flagName, "", "")
+				}
+			}
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("could not parse %v; %v", args, err)
+	}
+	return flags
+}
+
+func TestApplyInstallProfile(t *testing.T) {
+
+	for _, test := range []struct {
+		name        string
+		profile     string
+		args        []string
+		expected    map[string]string
+		notApplied  []string
+		expectError bool
+	}{
+		{
+			name:     "no profile",
+			profile:  "",
+			expected: map[string]string{"volume-size": "", "controller-workers": ""},
+		},
+		{
+			name:        "unknown profile",
+			profile:     "huge",
+			expectError: true,
+		},
+		{
+			name:     "minimal",
+			profile:  "minimal",
+			expected: map[string]string{"volume-size": "1Gi", "controller-workers": "1", "k8s-timeout": "120s"},
+		},
+		{
+			name:       "command line overrides profile",
+			profile:    "minimal",
+			args:       []string{"--volume-size=5Gi"},
+			expected:   map[string]string{"volume-size": "5Gi", "controller-workers": "1"},
+			notApplied: []string{"volume-size"},
+		},
+		{
+			name:     "production",
+			profile:  "production",
+			expected: map[string]string{"volume-size": "10Gi", "controller-workers": "4", "strict-version": "true"},
+		},
+		{
+			name:       "production with CSI",
+			profile:    "production",
+			args:       []string{"--csi"},
+			expected:   map[string]string{"volume-size": "10Gi", "controller-workers": "", "strict-version": "true"},
+			notApplied: []string{"controller-workers"},
+		},
+	} {
+		flags := newProfileTestFlags(t, test.args...)
+		applied, err := applyInstallProfile(flags, test.profile)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error, got flags %v", test.name, applied)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error; %v", test.name, err)
+			continue
+		}
+		for flagName, value := range test.expected {
+			if actual := flags.Lookup(flagName).Value.String(); actual != value {
+				t.Errorf("%s: expected --%s '%s', got '%s'", test.name, flagName, value, actual)
+			}
+		}
+		for _, flagName := range test.notApplied {
+			for _, appliedFlag := range applied {
+				if strings.HasPrefix(appliedFlag, "--"+flagName+"=") {
+					t.Errorf("%s: --%s should not have been applied", test.name, flagName)
+				}
+			}
+		}
+	}
+
+	// The applied flags are reported in sorted order
+	applied, err := applyInstallProfile(newProfileTestFlags(t), "minimal")
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	expected := []string{"--controller-workers=1", "--k8s-timeout=120s", "--volume-size=1Gi"}
+	if !reflect.DeepEqual(applied, expected) {
+		t.Errorf("expected applied flags %v, got %v", expected, applied)
+	}
+}
+
+func TestInstallProfileFlagsExist(t *testing.T) {
+
+	flags := installCmd.Flags()
+	for name, profile := range installProfiles {
+		for _, profileFlags := range []map[string]string{profile.Flags, profile.NonCSIFlags} {
+			for flagName := range profileFlags {
+				if flags.Lookup(flagName) == nil {
+					t.Errorf("profile %s sets unknown install flag --%s", name, flagName)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netapp/trident/cli/k8s_client"
+)
+
+// validateImageRegistryArguments checks that the --image-registry is a DNS-1123 subdomain with an
+// optional port, such as registry.internal:5000.
+func validateImageRegistryArguments() error {
+
+	if imageRegistry == "" {
+		return nil
+	}
+
+	host := imageRegistry
+	if strings.Contains(imageRegistry, ":") {
+		var port string
+		var err error
+		if host, port, err = net.SplitHostPort(imageRegistry); err != nil {
+			return fmt.Errorf("'%s' is not a valid image registry; %v", imageRegistry, err)
+		}
+		if portNumber, err := strconv.Atoi(port); err != nil || portNumber < 1 || portNumber > 65535 {
+			return fmt.Errorf("'%s' is not a valid image registry; the port must be between 1 and 65535",
+				imageRegistry)
+		}
+	}
+	if !dns1123DomainRegex.MatchString(host) {
+		return fmt.Errorf("'%s' is not a valid image registry; the host must be a DNS-1123 subdomain, "+
+			"which must consist of lower case alphanumeric characters, '-' or '.', and must start and "+
+			"end with an alphanumeric character", imageRegistry)
+	}
+	return nil
+}
+
+// getRegistryImage returns an image pulled from the --image-registry mirror instead of its own
+// registry, keeping its repository path and tag, so netapp/trident:18.07.0 becomes
+// <registry>/netapp/trident:18.07.0.  The image is unchanged if no registry was specified.
+func getRegistryImage(image string) string {
+
+	if imageRegistry == "" {
+		return image
+	}
+
+	// The first path component names a registry only if it looks like a host, as Docker decides
+	if parts := strings.SplitN(image, "/", 2); len(parts) == 2 &&
+		(strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		image = parts[1]
+	}
+	return imageRegistry + "/" + image
+}
+
+// applyImageRegistry pulls the CSI sidecar images left at their defaults from the
+// --image-registry mirror.  Images specified explicitly are used as they are.
+func applyImageRegistry() {
+
+	if imageRegistry == "" {
+		return
+	}
+	if csiAttacherImage == k8s_client.DefaultCSIAttacherImage {
+		csiAttacherImage = getRegistryImage(csiAttacherImage)
+	}
+	if csiProvisionerImage == k8s_client.DefaultCSIProvisionerImage {
+		csiProvisionerImage = getRegistryImage(csiProvisionerImage)
+	}
+	if csiRegistrarImage == k8s_client.DefaultCSIRegistrarImage {
+		csiRegistrarImage = getRegistryImage(csiRegistrarImage)
+	}
+	if csiLivenessProbe && csiLivenessProbeImage == k8s_client.DefaultCSILivenessProbeImage {
+		csiLivenessProbeImage = getRegistryImage(csiLivenessProbeImage)
+	}
+}
+
+// checkImagePullSecrets checks the names given with --image-pull-secret and, unless the cluster
+// isn't used, that each secret exists in the Trident namespace, since pods referencing a missing
+// pull secret can't pull their images from an authenticated registry.
+func checkImagePullSecrets(checkExists bool) error {
+
+	for _, secretName := range imagePullSecrets {
+		if !dns1123DomainRegex.MatchString(secretName) {
+			return fmt.Errorf("'%s' is not a valid image pull secret name; a DNS-1123 subdomain must "+
+				"consist of lower case alphanumeric characters, '-' or '.', and must start and end with "+
+				"an alphanumeric character", secretName)
+		}
+		if !checkExists {
+			continue
+		}
+		exists, err := client.CheckSecretExists(secretName)
+		if err != nil {
+			return fmt.Errorf("could not check for image pull secret %s; %v", secretName, err)
+		} else if !exists {
+			return fmt.Errorf("image pull secret %s not found in namespace %s; create it before "+
+				"installing Trident", secretName, TridentPodNamespace)
+		}
+		log.WithField("secret", secretName).Debug("Found image pull secret.")
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+)
+
+// redactedFlags hold credentials, so their values are left out of the pre-check report.
+var redactedFlags = map[string]bool{
+	"ucp-bearer-token":          true,
+	"k8s-api-header":            true,
+	"notify-webhook-header":     true,
+	"notify-webhook-basic-auth": true,
+}
+
+// preCheckReport records the outcome of the installer's pre-checks, so that a dry run can leave
+// evidence for change-approval processes.  Checks that pass are recorded as the installer
+// proceeds; the first failed check ends the run and is reported as the error.
+type preCheckReport struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Succeeded bool              `json:"succeeded"`
+	Error     string            `json:"error,omitempty"`
+	Cluster   clusterIdentity   `json:"cluster"`
+	Namespace string            `json:"namespace"`
+	Flags     map[string]string `json:"flags"`
+	Checks    []preCheck        `json:"checks"`
+	Warnings  []string          `json:"warnings,omitempty"`
+}
+
+type clusterIdentity struct {
+	APIServer         string `json:"apiServer,omitempty"`
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	Flavor            string `json:"flavor,omitempty"`
+	ClusterID         string `json:"clusterID,omitempty"` // UID of the kube-system namespace
+}
+
+type preCheck struct {
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+}
+
+var (
+	preCheckResults preCheckReport
+	preCheckMutex   sync.Mutex
+)
+
+// validateDryRunOutputArguments checks the pre-check report options.
+func validateDryRunOutputArguments() error {
+	if dryRunOutputFile != "" && !dryRun {
+		return errors.New("--dry-run-output-file may only be specified with --dry-run")
+	}
+	return nil
+}
+
+// preCheckPassed records a pre-check that passed.
+func preCheckPassed(name, message string) {
+	preCheckMutex.Lock()
+	defer preCheckMutex.Unlock()
+	preCheckResults.Checks = append(preCheckResults.Checks, preCheck{Name: name, Message: message})
+}
+
+// preCheckWarningHook copies the installer's warnings into the pre-check report.
+type preCheckWarningHook struct{}
+
+func (h *preCheckWarningHook) Levels() []log.Level {
+	return []log.Level{log.WarnLevel}
+}
+
+func (h *preCheckWarningHook) Fire(entry *log.Entry) error {
+	preCheckMutex.Lock()
+	defer preCheckMutex.Unlock()
+	preCheckResults.Warnings = append(preCheckResults.Warnings, entry.Message)
+	return nil
+}
+
+// getClusterIdentity describes the cluster the installer is connected to, as far as it can tell.
+func getClusterIdentity() clusterIdentity {
+
+	var identity clusterIdentity
+	if client == nil {
+		return identity
+	}
+
+	identity.KubernetesVersion = client.Version().String()
+	identity.Flavor = string(client.Flavor())
+	if apiServer, err := client.GetAPIServerURL(); err == nil {
+		identity.APIServer = apiServer
+	}
+	if namespace, err := client.GetNamespace("kube-system"); err == nil && namespace != nil {
+		identity.ClusterID = string(namespace.UID)
+	}
+	return identity
+}
+
+// getEffectiveFlags returns the value of each install flag, including defaults.
+func getEffectiveFlags(flags *pflag.FlagSet) map[string]string {
+
+	effective := make(map[string]string)
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if redactedFlags[flag.Name] && flag.Changed {
+			effective[flag.Name] = "<redacted>"
+		} else {
+			effective[flag.Name] = flag.Value.String()
+		}
+	})
+	return effective
+}
+
+// getPreCheckReport returns the pre-check report of a run that ended with the specified error,
+// including the effective values of the specified flags.
+func getPreCheckReport(checkError error, flags *pflag.FlagSet) *preCheckReport {
+
+	preCheckMutex.Lock()
+	report := preCheckResults
+	preCheckMutex.Unlock()
+
+	report.Timestamp = time.Now().UTC()
+	report.Succeeded = checkError == nil
+	if checkError != nil {
+		report.Error = checkError.Error()
+	}
+	report.Cluster = getClusterIdentity()
+	report.Namespace = TridentPodNamespace
+	report.Flags = getEffectiveFlags(flags)
+
+	return &report
+}
+
+// writeDryRunReport writes the pre-check report as JSON to the file specified on the command
+// line, if any.  Failing to write it fails the dry run, since the report is its only product.
+func writeDryRunReport(checkError error, flags *pflag.FlagSet) {
+
+	if dryRunOutputFile == "" {
+		return
+	}
+
+	reportJSON, err := json.MarshalIndent(getPreCheckReport(checkError, flags), "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(dryRunOutputFile, append(reportJSON, '\n'), 0600)
+	}
+	if err != nil {
+		log.Fatalf("Could not write the pre-check report to %s; %v", dryRunOutputFile, err)
+	}
+	log.WithField("file", dryRunOutputFile).Info("Wrote the pre-check report.")
+}
@@ -0,0 +1,151 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	tridentconfig "github.com/netapp/trident/config"
+)
+
+const (
+	// An etcd v3 snapshot is a bolt database, which starts with a page header followed by a
+	// meta page that holds the bolt magic number and file format version
+	boltMetaOffset = 16
+	boltMagic      = 0xED0CDAED
+	boltVersion    = 2
+)
+
+// validateRestoreSnapshotArguments checks that the snapshot to restore is an etcd v3 snapshot,
+// and that it can be copied into the Trident pod.  The snapshot is restored by an init container
+// that waits for the installer, so the generated YAML can't be used on its own.
+func validateRestoreSnapshotArguments() error {
+
+	if restoreSnapshot == "" {
+		return nil
+	}
+	if generateYAML || useYAML || bundlePath != "" || diffLive {
+		return errors.New("--restore-from-snapshot may not be combined with --generate-custom-yaml, " +
+			"--use-custom-yaml, --generate-bundle, or --diff")
+	}
+
+	snapshotFile, err := os.Open(restoreSnapshot)
+	if err != nil {
+		return fmt.Errorf("could not open etcd snapshot; %v", err)
+	}
+	defer snapshotFile.Close()
+
+	header := make([]byte, boltMetaOffset+8)
+	if _, err = io.ReadFull(snapshotFile, header); err != nil {
+		return fmt.Errorf("%s is not an etcd snapshot; %v", restoreSnapshot, err)
+	}
+	magic := binary.LittleEndian.Uint32(header[boltMetaOffset:])
+	version := binary.LittleEndian.Uint32(header[boltMetaOffset+4:])
+	if magic != boltMagic {
+		return fmt.Errorf("%s is not an etcd v3 snapshot", restoreSnapshot)
+	}
+	if version != boltVersion {
+		return fmt.Errorf("etcd snapshot %s has unsupported format version %d", restoreSnapshot, version)
+	}
+
+	log.WithField("snapshot", restoreSnapshot).Info("Restoring Trident metadata from etcd snapshot.")
+	return nil
+}
+
+// copyEtcdSnapshot copies the snapshot into the restore init container of the Trident pod, and
+// then signals the init container to restore it.
+func copyEtcdSnapshot() error {
+
+	var pod *v1.Pod
+
+	checkInitContainerRunning := func() error {
+		var err error
+		if pod, err = client.GetPodByLabel(appLabel, false); err != nil {
+			return err
+		}
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name == k8s_client.EtcdRestoreContainerName && status.State.Running != nil {
+				return nil
+			}
+		}
+		return errors.New("restore container not running")
+	}
+	initNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+			"status":    err,
+		}).Debug("etcd restore container not yet running, waiting.")
+	}
+	initBackoff := backoff.NewExponentialBackOff()
+	initBackoff.MaxElapsedTime = getStepTimeout(StepRestore)
+
+	log.Info("Waiting for the etcd restore container to start.")
+
+	if err := backoff.RetryNotify(checkInitContainerRunning, initBackoff, initNotify); err != nil {
+		return fmt.Errorf("the etcd restore container was not running after %3.2f seconds; use "+
+			"'%s describe pod -l %s -n %s' for more information", initBackoff.MaxElapsedTime.Seconds(), client.CLI(),
+			appLabel, TridentPodNamespace)
+	}
+
+	if err := client.CopyToPod(pod.Name, k8s_client.EtcdRestoreContainerName, restoreSnapshot,
+		k8s_client.EtcdRestoreSnapshotFile); err != nil {
+		return fmt.Errorf("could not copy etcd snapshot to pod %s; %v", pod.Name, err)
+	}
+	touchCommand := []string{"touch", k8s_client.EtcdRestoreReadyFile}
+	if output, err := client.Exec(pod.Name, k8s_client.EtcdRestoreContainerName, touchCommand); err != nil {
+		return fmt.Errorf("could not start the etcd restore; %v; %s", err, strings.TrimSpace(string(output)))
+	}
+
+	log.WithField("pod", pod.Name).Info("Copied etcd snapshot to the Trident pod.")
+	return nil
+}
+
+// verifyRestoredState confirms that etcd came up with the Trident metadata from the snapshot.
+func verifyRestoredState(pod *v1.Pod) error {
+
+	counts := make(map[string]int)
+	for _, object := range []struct {
+		name   string
+		prefix string
+	}{
+		{"backends", tridentconfig.BackendURL},
+		{"storageClasses", tridentconfig.StorageClassURL},
+		{"volumes", tridentconfig.VolumeURL},
+	} {
+		output, err := client.Exec(pod.Name, tridentconfig.ContainerEtcd,
+			getEtcdctlCommand("get", object.prefix, "--prefix", "--keys-only"))
+		if err != nil {
+			return fmt.Errorf("could not read restored Trident metadata; %v; %s", err,
+				strings.TrimSpace(string(output)))
+		}
+		for _, line := range strings.Split(string(output), "\n") {
+			if strings.TrimSpace(line) != "" {
+				counts[object.name]++
+			}
+		}
+	}
+
+	logFields := log.Fields{}
+	total := 0
+	for name, count := range counts {
+		logFields[name] = count
+		total += count
+	}
+	if total == 0 {
+		return fmt.Errorf("etcd snapshot %s contains no Trident metadata", restoreSnapshot)
+	}
+
+	log.WithFields(logFields).Info("Restored Trident metadata from etcd snapshot.")
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The installation steps recorded in the --state-file.  Only steps that can't simply be repeated
+// are recorded; the others, such as replacing the RBAC objects or waiting for the Trident pod, are
+// repeated when an installation is resumed.
+const (
+	ResumeStepNetworkPolicy   = "networkPolicy"
+	ResumeStepPV              = "pv"
+	ResumeStepCAConfigMap     = "caConfigMap"
+	ResumeStepHeadlessService = "headlessService"
+	ResumeStepDeployment      = "deployment"
+	ResumeStepService         = "service"
+	ResumeStepStatefulSet     = "statefulset"
+	ResumeStepDaemonSet       = "daemonset"
+	ResumeStepRestore         = "restore"
+	ResumeStepSeedBackends    = "seedBackends"
+)
+
+// installProgress is the content of the --state-file, which records the steps an installation
+// completed so that an interrupted installation can be resumed.
+type installProgress struct {
+	Namespace      string   `json:"namespace"`
+	CSI            bool     `json:"csi"`
+	CompletedSteps []string `json:"completedSteps"`
+	UpdatedAt      string   `json:"updatedAt"`
+
+	path string
+}
+
+// resumeProgress is the progress of the installation, or nil if no state file was specified.
+var resumeProgress *installProgress
+
+// validateStateFileArguments reads the --state-file, if it exists, to resume the installation it
+// records.  The recorded installation must be of the same Trident flavor in the same namespace.
+func validateStateFileArguments() error {
+
+	if stateFile == "" {
+		return nil
+	}
+
+	resumeProgress = &installProgress{
+		Namespace:      TridentPodNamespace,
+		CSI:            csi,
+		CompletedSteps: make([]string, 0),
+		path:           stateFile,
+	}
+
+	stateBytes, err := ioutil.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not read state file; %v", err)
+	}
+
+	var recorded installProgress
+	if err = json.Unmarshal(stateBytes, &recorded); err != nil {
+		return fmt.Errorf("could not parse state file %s; %v", stateFile, err)
+	}
+	if recorded.Namespace != TridentPodNamespace || recorded.CSI != csi {
+		return fmt.Errorf("state file %s records an installation of %s in namespace %s; "+
+			"use another state file", stateFile, getTridentFlavorName(recorded.CSI), recorded.Namespace)
+	}
+	resumeProgress.CompletedSteps = append(resumeProgress.CompletedSteps, recorded.CompletedSteps...)
+
+	if len(recorded.CompletedSteps) > 0 {
+		log.WithFields(log.Fields{
+			"stateFile": stateFile,
+			"steps":     recorded.CompletedSteps,
+			"updatedAt": recorded.UpdatedAt,
+		}).Info("Resuming an interrupted installation.")
+	}
+	return nil
+}
+
+// getTridentFlavorName returns the name of a Trident flavor for messages.
+func getTridentFlavorName(csi bool) string {
+	if csi {
+		return "CSI Trident"
+	}
+	return "Trident"
+}
+
+// completed returns whether an earlier run of the installation completed a step.
+func (p *installProgress) completed(step string) bool {
+
+	if p == nil {
+		return false
+	}
+	for _, completedStep := range p.CompletedSteps {
+		if completedStep == step {
+			return true
+		}
+	}
+	return false
+}
+
+// skip returns whether an earlier run of the installation completed a step, logging that the step
+// is skipped if so.
+func (p *installProgress) skip(step string) bool {
+
+	if !p.completed(step) {
+		return false
+	}
+	log.WithField("step", step).Info("Skipping a step completed by an earlier installation.")
+	return true
+}
+
+// complete records a completed step in the state file.  A state file that can't be written only
+// means the step would be repeated, so the installation proceeds.
+func (p *installProgress) complete(step string) {
+
+	if p == nil || p.completed(step) {
+		return
+	}
+	p.CompletedSteps = append(p.CompletedSteps, step)
+	p.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	if err := p.write(); err != nil {
+		log.WithFields(log.Fields{
+			"stateFile": p.path,
+			"step":      step,
+			"error":     err,
+		}).Warning("Could not record the installation step in the state file.")
+	}
+}
+
+// write replaces the state file, through a temporary file so an interruption can't leave a
+// partially written one.
+func (p *installProgress) write() error {
+
+	stateBytes, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	tempPath := p.path + ".tmp"
+	if err = ioutil.WriteFile(tempPath, stateBytes, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, p.path)
+}
+
+// finish removes the state file of a completed installation, so the state file can't cause a
+// later installation to skip steps.
+func (p *installProgress) finish() {
+
+	if p == nil {
+		return
+	}
+	if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+		log.WithFields(log.Fields{
+			"stateFile": p.path,
+			"error":     err,
+		}).Warning("Could not remove the state file.")
+		return
+	}
+	log.WithField("stateFile", p.path).Debug("Removed the state file of the completed installation.")
+}
@@ -0,0 +1,373 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultSeedConcurrency is the number of backends seeded at once by default
+	DefaultSeedConcurrency = 2
+
+	// seedBackendInterval is the minimum time between backend creation requests, so a Trident
+	// controller that just started isn't overwhelmed
+	seedBackendInterval = 500 * time.Millisecond
+
+	// seedBackendRetryTime bounds the retries of each backend after a transient failure
+	seedBackendRetryTime = 2 * time.Minute
+
+	// SeededBackendsAnnotation records on the Trident PVC, which lives as long as the backends
+	// stored on its volume, a checksum of the config each backend was last seeded with
+	SeededBackendsAnnotation = "trident.netapp.io/seeded-backends"
+
+	SeedStatusSeeded    = "seeded"
+	SeedStatusUpdated   = "updated"
+	SeedStatusUnchanged = "unchanged"
+	SeedStatusFailed    = "failed"
+)
+
+// urlUserInfoRegex matches the user info of a URL, such as the SolidFire endpoint, which
+// drivers leave out of the configs they report.
+var urlUserInfoRegex = regexp.MustCompile(`(://)[^/@\s]+@`)
+
+// seededBackend is the outcome of adding one --seed-backend config to Trident.
+type seededBackend struct {
+	ConfigFile string `json:"configFile"`
+	Name       string `json:"name,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+
+	// checksum is that of the config the backend now has, if it was seeded, updated, or unchanged
+	checksum string
+}
+
+// validateSeedBackendArguments checks that each backend to seed is a readable config for a
+// driver in this build, so problems are found before installation starts.
+func validateSeedBackendArguments() error {
+
+	if seedConcurrency < 1 {
+		return fmt.Errorf("--seed-concurrency must be positive, not %d", seedConcurrency)
+	}
+	if checkExistingBackends && len(seedBackends) == 0 {
+		return errors.New("--check-existing-backends requires --seed-backend")
+	}
+	for _, configFile := range seedBackends {
+		if _, err := readSeedBackendConfig(configFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSeedBackendConfig returns a backend config to seed as JSON.
+func readSeedBackendConfig(configFile string) (string, error) {
+
+	configBytes, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return "", fmt.Errorf("could not read backend config %s; %v", configFile, err)
+	}
+	configJSON, err := backendConfigToJSON(configFile, configBytes)
+	if err != nil {
+		return "", err
+	}
+	if err = validateStorageDriverName(configJSON); err != nil {
+		return "", fmt.Errorf("backend config %s is invalid; %v", configFile, err)
+	}
+	return configJSON, nil
+}
+
+// seedTridentBackends adds the --seed-backend configs to the newly installed Trident, with at
+// most --seed-concurrency requests in flight and no more than one started per interval.  Each
+// backend is retried after a transient failure.  The outcome of each is reported when all are
+// done, and an error is returned if any could not be added.
+func seedTridentBackends() error {
+
+	var existingBackends map[string]map[string]interface{}
+	seededChecksums := getSeededBackendChecksums()
+	if checkExistingBackends {
+		var err error
+		if existingBackends, err = getExistingBackendConfigs(); err != nil {
+			return err
+		}
+	}
+
+	results := make([]seededBackend, len(seedBackends))
+	indexes := make(chan int)
+	limiter := time.NewTicker(seedBackendInterval)
+	defer limiter.Stop()
+
+	log.WithFields(log.Fields{
+		"backends":    len(seedBackends),
+		"concurrency": seedConcurrency,
+	}).Info("Seeding storage backends.")
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < seedConcurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = seedTridentBackend(seedBackends[i], existingBackends, seededChecksums, limiter.C)
+			}
+		}()
+	}
+	for i := range seedBackends {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	installResult.SeededBackends = results
+	if OutputFormat == "" {
+		writeSeededBackendTable(results)
+	}
+	recordSeededBackendChecksums(seededChecksums, results)
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == SeedStatusFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d storage backends could not be seeded", failed, len(results))
+	}
+
+	log.WithField("backends", len(results)).Info("Seeded storage backends.")
+	return nil
+}
+
+// seedTridentBackend adds one backend config to Trident through the CLI in the Trident pod.  If
+// the existing backends are specified, a backend of the same name is updated instead, or left as
+// it is if it was last seeded with the same config and still reports the config's values.
+func seedTridentBackend(
+	configFile string, existingBackends map[string]map[string]interface{}, seededChecksums map[string]string,
+	limiter <-chan time.Time,
+) seededBackend {
+
+	result := seededBackend{ConfigFile: configFile, Status: SeedStatusFailed}
+	logFields := log.Fields{"backend": configFile}
+
+	configJSON, err := readSeedBackendConfig(configFile)
+	if err == nil {
+		configJSON, err = useBackendCredentialsSecret(configFile, configJSON)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	checksum := getBackendConfigChecksum(configJSON)
+	encodedConfig := base64.StdEncoding.EncodeToString([]byte(configJSON))
+	cliCommand := []string{"tridentctl", "-s", PodServer, "create", "backend", "-o", "name",
+		"--base64", encodedConfig}
+	seededStatus, failureText := SeedStatusSeeded, "could not create backend"
+
+	if existingBackends != nil {
+		var config map[string]interface{}
+		if err = json.Unmarshal([]byte(configJSON), &config); err != nil {
+			result.Error = fmt.Sprintf("could not parse backend config %s; %v", configFile, err)
+			return result
+		}
+		// The name of a backend without backendName is chosen by its driver, so it can't be compared
+		name, _ := config["backendName"].(string)
+		if existingConfig, ok := existingBackends[name]; ok {
+			result.Name = name
+			if seededChecksums[name] == checksum && backendConfigMatches(existingConfig, config) {
+				result.Status, result.checksum = SeedStatusUnchanged, checksum
+				log.WithFields(logFields).WithField("name", name).Info("Storage backend is unchanged.")
+				return result
+			}
+			cliCommand = []string{"tridentctl", "-s", PodServer, "update", "backend", name, "-o", "name",
+				"--base64", encodedConfig}
+			seededStatus, failureText = SeedStatusUpdated, "could not update backend"
+		}
+	}
+
+	// Trident rejecting the config is permanent; anything else may succeed on a retry
+	var permanentError error
+	createBackend := func() error {
+		<-limiter
+		output, err := client.Exec(TridentPodName, tridentContainerName, cliCommand)
+		trimmedOutput := strings.TrimSpace(string(output))
+		if err != nil {
+			if strings.Contains(trimmedOutput, failureText) {
+				permanentError = errors.New(trimmedOutput)
+				return nil
+			}
+			if trimmedOutput != "" {
+				return fmt.Errorf("%v; %s", err, trimmedOutput)
+			}
+			return err
+		}
+		result.Name = trimmedOutput
+		return nil
+	}
+	createNotify := func(err error, duration time.Duration) {
+		log.WithFields(logFields).WithFields(log.Fields{
+			"increment": duration,
+			"error":     err,
+		}).Debug("Could not seed backend, retrying.")
+	}
+	createBackoff := backoff.NewExponentialBackOff()
+	createBackoff.MaxElapsedTime = seedBackendRetryTime
+
+	if err = backoff.RetryNotify(createBackend, createBackoff, createNotify); err != nil {
+		result.Error = err.Error()
+	} else if permanentError != nil {
+		result.Error = permanentError.Error()
+	} else {
+		result.Status, result.checksum = seededStatus, checksum
+	}
+
+	if result.Status == SeedStatusSeeded {
+		log.WithFields(logFields).WithField("name", result.Name).Info("Seeded storage backend.")
+	} else if result.Status == SeedStatusUpdated {
+		log.WithFields(logFields).WithField("name", result.Name).Info("Updated storage backend.")
+	} else {
+		log.WithFields(logFields).WithField("error", result.Error).Error("Could not seed storage backend.")
+	}
+	return result
+}
+
+// getExistingBackendConfigs returns the config of each backend Trident already has, by name,
+// through the CLI in the Trident pod.
+func getExistingBackendConfigs() (map[string]map[string]interface{}, error) {
+
+	cliCommand := []string{"tridentctl", "-s", PodServer, "get", "backend", "-o", "json"}
+	output, err := client.Exec(TridentPodName, tridentContainerName, cliCommand)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the existing backends from Trident; %v; %s", err,
+			strings.TrimSpace(string(output)))
+	}
+
+	var backendsResponse struct {
+		Items []struct {
+			Name   string                 `json:"name"`
+			Config map[string]interface{} `json:"config"`
+		} `json:"items"`
+	}
+	if err = json.Unmarshal(output, &backendsResponse); err != nil {
+		return nil, fmt.Errorf("could not parse the existing backends; %v", err)
+	}
+
+	backends := make(map[string]map[string]interface{}, len(backendsResponse.Items))
+	for _, backend := range backendsResponse.Items {
+		backends[backend.Name] = backend.Config
+	}
+	log.WithField("backends", len(backends)).Debug("Got the existing storage backends.")
+	return backends, nil
+}
+
+// getBackendConfigChecksum returns a checksum of a backend config to seed.
+func getBackendConfigChecksum(configJSON string) string {
+	hash := sha256.Sum256([]byte(configJSON))
+	return hex.EncodeToString(hash[:])
+}
+
+// getSeededBackendChecksums returns the checksum of the config each backend was last seeded with,
+// by name, as recorded on the Trident PVC.  A backend without one is considered changed.
+func getSeededBackendChecksums() map[string]string {
+
+	checksums := make(map[string]string)
+	pvc, err := client.GetPVC(pvcName)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not get the checksums of the seeded backend configs.")
+		return checksums
+	}
+	if annotation, ok := pvc.Annotations[SeededBackendsAnnotation]; ok {
+		if err = json.Unmarshal([]byte(annotation), &checksums); err != nil {
+			log.WithField("error", err).Warning("Could not parse the checksums of the seeded backend configs.")
+			return make(map[string]string)
+		}
+	}
+	return checksums
+}
+
+// recordSeededBackendChecksums records on the Trident PVC the checksum of the config of each
+// backend that was seeded, updated, or found unchanged, so a later installation can tell whether a
+// config changed.  Failing to record them only means the backends are updated again next time.
+func recordSeededBackendChecksums(checksums map[string]string, results []seededBackend) {
+
+	for _, result := range results {
+		if result.checksum != "" && result.Name != "" {
+			checksums[result.Name] = result.checksum
+		}
+	}
+	checksumsJSON, err := json.Marshal(checksums)
+	if err == nil {
+		err = client.AnnotateObject("pvc", pvcName, SeededBackendsAnnotation, string(checksumsJSON))
+	}
+	if err != nil {
+		log.WithField("error", err).Warning("Could not record the checksums of the seeded backend configs.")
+	}
+}
+
+// backendConfigMatches returns whether an existing backend's config has each value of a backend
+// config to seed.  Values the existing config doesn't report, such as credentials, can't be
+// compared, so they are ignored; the checksum of the config the backend was seeded with is what
+// detects changes to them.  The user info of a URL is left out of the reported config as well.
+func backendConfigMatches(existing, seed interface{}) bool {
+
+	switch seedValue := seed.(type) {
+	case map[string]interface{}:
+		existingMap, ok := existing.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range seedValue {
+			if existingValue, ok := existingMap[key]; ok && !backendConfigMatches(existingValue, value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		existingList, ok := existing.([]interface{})
+		if !ok || len(existingList) != len(seedValue) {
+			return false
+		}
+		for i := range seedValue {
+			if !backendConfigMatches(existingList[i], seedValue[i]) {
+				return false
+			}
+		}
+		return true
+	case string:
+		return fmt.Sprint(existing) == urlUserInfoRegex.ReplaceAllString(seedValue, "${1}")
+	default:
+		// Drivers may report a configured number as a string, or the reverse
+		return fmt.Sprint(existing) == fmt.Sprint(seed)
+	}
+}
+
+func writeSeededBackendTable(results []seededBackend) {
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Config File", "Backend", "Status", "Error"})
+
+	for _, result := range results {
+		table.Append([]string{
+			result.ConfigFile,
+			result.Name,
+			result.Status,
+			result.Error,
+		})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	sa "github.com/netapp/trident/storage_attribute"
+)
+
+// StorageClassFsTypeParam is the storage class parameter that chooses the filesystem of a volume
+const StorageClassFsTypeParam = "fsType"
+
+// storageClassPoolParams are the storage class parameters that select storage pools.
+var storageClassPoolParams = []string{
+	sa.StoragePools,
+	sa.AdditionalStoragePools,
+	sa.ExcludeStoragePools,
+}
+
+// storageClassAttributeParams are the storage class parameters that request storage attributes.
+var storageClassAttributeParams = []string{
+	sa.Media,
+	sa.ProvisioningType,
+	sa.BackendType,
+	sa.Snapshots,
+	sa.Clones,
+	sa.Encryption,
+	sa.IOPS,
+}
+
+// storageClassFsTypes are the filesystems the block storage drivers can format a volume with.
+var storageClassFsTypes = []string{"ext3", "ext4", "xfs"}
+
+// defaultVolumeParams are the parameters of the storage class to create, parsed from
+// --default-volume-params.
+var defaultVolumeParams = make(map[string]string)
+
+// getStorageClassParamNames returns the storage class parameters Trident recognizes.
+func getStorageClassParamNames() []string {
+
+	names := []string{StorageClassFsTypeParam}
+	names = append(names, storageClassPoolParams...)
+	names = append(names, storageClassAttributeParams...)
+	sort.Strings(names)
+	return names
+}
+
+// validateStorageClassArguments checks the name of the storage class to create and the parameters
+// to create it with.  Each parameter must be one Trident recognizes, with a valid value, so the
+// storage class provisions volumes as intended instead of failing each request.
+func validateStorageClassArguments() error {
+
+	if createStorageClass == "" {
+		if len(defaultVolumeParamArgs) > 0 {
+			return errors.New("--default-volume-params requires --create-storage-class")
+		}
+		return nil
+	}
+
+	if !dns1123DomainRegex.MatchString(createStorageClass) {
+		return fmt.Errorf("'%s' is not a valid storage class name; a DNS-1123 subdomain must consist of "+
+			"lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric "+
+			"character", createStorageClass)
+	}
+
+	for _, param := range defaultVolumeParamArgs {
+		keyValue := strings.SplitN(param, "=", 2)
+		key := strings.TrimSpace(keyValue[0])
+		if len(keyValue) != 2 || key == "" {
+			return fmt.Errorf("invalid volume parameter '%s'; expected key=value", param)
+		}
+		value := strings.TrimSpace(keyValue[1])
+		if _, ok := defaultVolumeParams[key]; ok {
+			return fmt.Errorf("volume parameter %s is specified more than once", key)
+		}
+		if err := validateStorageClassParam(key, value); err != nil {
+			return fmt.Errorf("invalid volume parameter %s; %v", key, err)
+		}
+		defaultVolumeParams[key] = value
+	}
+
+	return nil
+}
+
+// validateStorageClassParam checks a storage class parameter the way the Trident frontend
+// interprets it.
+func validateStorageClassParam(key, value string) error {
+
+	if key == StorageClassFsTypeParam {
+		for _, fsType := range storageClassFsTypes {
+			if value == fsType {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected one of %s", strings.Join(storageClassFsTypes, ", "))
+	}
+
+	for _, poolParam := range storageClassPoolParams {
+		if key == poolParam {
+			// format:  backend1:pool1,pool2;backend2:pool1
+			_, err := sa.CreateBackendStoragePoolsMapFromEncodedString(value)
+			return err
+		}
+	}
+
+	for _, attributeParam := range storageClassAttributeParams {
+		if key == attributeParam {
+			_, err := sa.CreateAttributeRequestFromAttributeValue(key, value)
+			return err
+		}
+	}
+
+	return fmt.Errorf("unknown parameter; expected one of %s", strings.Join(getStorageClassParamNames(), ", "))
+}
+
+// getStorageClassYAML returns the YAML of the storage class to create, served by the Trident
+// flavor being installed.
+func getStorageClassYAML() string {
+
+	provisioner := TridentProvisioner
+	if csi {
+		provisioner = CSIDriverName
+	}
+	return k8s_client.GetStorageClassYAML(createStorageClass, provisioner, appLabelValue, defaultVolumeParams)
+}
+
+// createTridentStorageClass creates the storage class specified by --create-storage-class.  An
+// existing storage class of that name is left as it is, since volumes may already use it.
+func createTridentStorageClass() error {
+
+	logFields := log.Fields{"storageClass": createStorageClass}
+
+	storageClasses, err := client.GetStorageClasses()
+	if err != nil {
+		return fmt.Errorf("could not list storage classes; %v", err)
+	}
+	for _, storageClass := range storageClasses {
+		if storageClass.Name == createStorageClass {
+			log.WithFields(logFields).Warning("Storage class already exists, not replacing it.")
+			return nil
+		}
+	}
+
+	if useYAML && fileExists(storageClassPath) {
+		err = client.CreateObjectByFile(storageClassPath)
+		logFields["path"] = storageClassPath
+	} else {
+		err = createObjectByYAML(StorageClassFilename, getStorageClassYAML())
+	}
+	if err != nil {
+		return fmt.Errorf("could not create storage class %s; %v", createStorageClass, err)
+	}
+
+	installResult.StorageClass = createStorageClass
+	log.WithFields(logFields).Info("Created storage class.")
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const installWebhookTimeout = 30 * time.Second
+
+// installSummary describes the outcome of 'tridentctl install'.  It is written to stdout when an
+// output format is specified, and sent to any notification webhook, so automation can consume
+// it instead of parsing the log.
+type installSummary struct {
+	Succeeded       bool              `json:"succeeded"`
+	Error           string            `json:"error,omitempty"`
+	DryRun          bool              `json:"dryRun,omitempty"`
+	Namespace       string            `json:"namespace"`
+	CSI             bool              `json:"csi"`
+	TridentImage    string            `json:"tridentImage"`
+	ImageVersion    string            `json:"imageVersion,omitempty"`
+	TridentVersion  string            `json:"tridentVersion,omitempty"`
+	VersionMismatch bool              `json:"versionMismatch,omitempty"`
+	PVC             string            `json:"pvc"`
+	PV              string            `json:"pv"`
+	PVAnnotations   map[string]string `json:"pvAnnotations,omitempty"`
+	VolumeEncrypted bool              `json:"volumeEncrypted,omitempty"`
+	Volume          *volumeDetails    `json:"volume,omitempty"`
+	SeededBackends  []seededBackend   `json:"seededBackends,omitempty"`
+	BackendConfigs  []configDirEntry  `json:"backendConfigs,omitempty"`
+	BackendDriver   string            `json:"backendDriver,omitempty"`
+	BackendProtocol string            `json:"backendProtocol,omitempty"`
+	StorageClass    string            `json:"storageClass,omitempty"`
+	K8sVersionSkew  bool              `json:"kubernetesVersionSkew,omitempty"`
+	AggregatedInto  []string          `json:"aggregatedInto,omitempty"`
+	NodePrep        []nodePrepResult  `json:"nodePrep,omitempty"`
+}
+
+// volumeDetails describes the Trident volume as the storage backend created it, which may
+// differ from what was requested, such as a size rounded up by the storage system.
+type volumeDetails struct {
+	InternalName  string            `json:"internalName"`
+	Backend       string            `json:"backend"`
+	Pool          string            `json:"pool,omitempty"`
+	Protocol      string            `json:"protocol"`
+	RequestedSize string            `json:"requestedSize"`
+	SizeBytes     string            `json:"sizeBytes,omitempty"`
+	QoS           map[string]string `json:"qos,omitempty"`
+	Encrypted     bool              `json:"encrypted,omitempty"`
+	SnapReserve   string            `json:"snapshotReserve,omitempty"`
+	UsableBytes   string            `json:"usableBytes,omitempty"`
+}
+
+// installResult accumulates the details reported in the install summary as installation proceeds.
+var installResult installSummary
+
+// getInstallSummary returns the summary of an installation that ended with the specified error.
+func getInstallSummary(installError error) *installSummary {
+
+	summary := installResult
+	summary.Succeeded = installError == nil
+	if installError != nil {
+		summary.Error = installError.Error()
+	}
+	summary.DryRun = dryRun
+	summary.Namespace = TridentPodNamespace
+	summary.CSI = csi
+	summary.TridentImage = tridentImage
+	summary.PVC = pvcName
+	summary.PV = pvName
+
+	return &summary
+}
+
+// writeInstallSummary writes the install summary in the output format specified on the command line.
+func writeInstallSummary(installError error) {
+	switch OutputFormat {
+	case FormatJSON:
+		WriteJSON(getInstallSummary(installError))
+	case FormatYAML:
+		WriteYAML(getInstallSummary(installError))
+	}
+}
+
+// validateNotifyWebhookArguments checks the install summary webhook options.
+func validateNotifyWebhookArguments() error {
+
+	if notifyWebhook == "" {
+		if len(notifyWebhookHeaders) > 0 || notifyWebhookBasicAuth != "" {
+			return errors.New("--notify-webhook-header and --notify-webhook-basic-auth require --notify-webhook")
+		}
+		return nil
+	}
+
+	webhookURL, err := url.Parse(notifyWebhook)
+	if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+		return fmt.Errorf("invalid webhook URL '%s'", notifyWebhook)
+	}
+	if _, err = parseHTTPHeaders(notifyWebhookHeaders); err != nil {
+		return err
+	}
+	if notifyWebhookBasicAuth != "" && !strings.Contains(notifyWebhookBasicAuth, ":") {
+		return errors.New("--notify-webhook-basic-auth must be specified as user:password")
+	}
+
+	return nil
+}
+
+// notifyInstallWebhook POSTs the install summary to the webhook specified on the command line.
+// The outcome of the installation doesn't depend on the notification, so failures are only logged.
+func notifyInstallWebhook(installError error) {
+
+	logFields := log.Fields{"webhook": notifyWebhook}
+
+	if err := postInstallSummary(getInstallSummary(installError)); err != nil {
+		log.WithFields(logFields).WithField("error", err).Warning("Could not send the install summary.")
+		return
+	}
+	log.WithFields(logFields).Info("Sent the install summary.")
+}
+
+func postInstallSummary(summary *installSummary) error {
+
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest("POST", notifyWebhook, bytes.NewBuffer(summaryJSON))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	// Arguments were validated before installation started
+	headers, _ := parseHTTPHeaders(notifyWebhookHeaders)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	if notifyWebhookBasicAuth != "" {
+		userPassword := strings.SplitN(notifyWebhookBasicAuth, ":", 2)
+		request.SetBasicAuth(userPassword[0], userPassword[1])
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+		Timeout:   installWebhookTimeout,
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	"github.com/netapp/trident/utils"
+)
+
+// versionSkewClient is a Kubernetes client whose server version changes each time it is refreshed,
+// as when a cluster is upgraded during an installation.  Only the version methods are implemented.
+type versionSkewClient struct {
+	k8s_client.Interface
+	version  *utils.Version
+	versions []string
+}
+
+func (c *versionSkewClient) Version() *utils.Version {
+	return c.version
+}
+
+func (c *versionSkewClient) RefreshVersion() (*utils.Version, error) {
+	if len(c.versions) == 0 {
+		return nil, errors.New("server unavailable")
+	}
+	c.version = utils.MustParseSemantic(c.versions[0])
+	c.versions = c.versions[1:]
+	return c.version, nil
+}
+
+func TestRefreshKubernetesVersion(t *testing.T) {
+
+	defer func(c k8s_client.Interface, v *utils.Version, r installSummary) {
+		client, targetVersion, installResult = c, v, r
+	}(client, targetVersion, installResult)
+
+	// The server version as refreshed before each step of a long installation
+	testClient := &versionSkewClient{
+		version:  utils.MustParseSemantic("v1.10.3"),
+		versions: []string{"v1.10.5", "v1.11.0"},
+	}
+	client, targetVersion, installResult = testClient, nil, installSummary{}
+
+	steps := []struct {
+		step            string
+		expectedVersion string
+		expectedSkew    bool
+	}{
+		{"pre-checks", "1.10.5", false},
+		{"Trident PV", "1.11.0", true},
+		{"Trident workloads", "1.11.0", true}, // refresh fails, so the last version is kept
+	}
+	for _, step := range steps {
+		refreshKubernetesVersion(step.step)
+		if version := getKubernetesVersion().String(); version != step.expectedVersion {
+			t.Errorf("%s: expected Kubernetes version %s, got %s", step.step, step.expectedVersion, version)
+		}
+		if installResult.K8sVersionSkew != step.expectedSkew {
+			t.Errorf("%s: expected version skew %v, got %v", step.step, step.expectedSkew,
+				installResult.K8sVersionSkew)
+		}
+	}
+
+	// A target version isn't changed by the cluster's version
+	testClient.versions = []string{"v1.12.0"}
+	targetVersion = utils.MustParseSemantic("v1.10.0")
+	refreshKubernetesVersion("Trident workloads")
+	if version := getKubernetesVersion().String(); version != "1.10.0" {
+		t.Errorf("expected the target Kubernetes version 1.10.0, got %s", version)
+	}
+}
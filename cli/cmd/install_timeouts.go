@@ -0,0 +1,106 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Installation steps whose wait may be given its own timeout with --step-timeout
+const (
+	StepNamespace    = "namespace"
+	StepPV           = "pv"
+	StepRestore      = "restore"
+	StepPod          = "pod"
+	StepEtcd         = "etcd"
+	StepREST         = "rest"
+	StepDaemonSet    = "daemonset"
+	StepRegistration = "registration"
+	StepDiagnostic   = "diagnostic"
+)
+
+// installSteps describes what the installer waits for in each step.
+var installSteps = map[string]string{
+	StepNamespace:    "a terminating namespace to be deleted",
+	StepPV:           "the Trident PVC to be bound to its PV",
+	StepRestore:      "the etcd restore init container to start",
+	StepPod:          "the Trident pod to be running",
+	StepEtcd:         "etcd to be healthy",
+	StepREST:         "the Trident REST interface to be available",
+	StepDaemonSet:    "the CSI node pods to be ready",
+	StepRegistration: "the CSI driver to be registered on each node",
+	StepDiagnostic:   "each diagnostic pod to finish",
+}
+
+// stepTimeouts are the timeouts specified with --step-timeout, by step
+var stepTimeouts = make(map[string]time.Duration)
+
+// getInstallStepNames returns the names of the steps whose timeout may be specified, sorted.
+func getInstallStepNames() []string {
+
+	names := make([]string, 0, len(installSteps))
+	for name := range installSteps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateStepTimeoutArguments parses the per-step timeouts specified as name=duration.
+func validateStepTimeoutArguments() error {
+
+	for _, stepTimeout := range stepTimeoutArgs {
+		nameValue := strings.SplitN(stepTimeout, "=", 2)
+		name := strings.TrimSpace(nameValue[0])
+		if len(nameValue) != 2 {
+			return fmt.Errorf("invalid step timeout '%s'; expected name=duration", stepTimeout)
+		}
+		if _, ok := installSteps[name]; !ok {
+			return fmt.Errorf("unknown installation step '%s' in --step-timeout; expected one of %s", name,
+				strings.Join(getInstallStepNames(), ", "))
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(nameValue[1]))
+		if err != nil {
+			return fmt.Errorf("invalid timeout for step %s; %v", name, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("the timeout for step %s must be positive", name)
+		}
+		stepTimeouts[name] = timeout
+	}
+
+	// The waits most often slowed by the storage or the image registry have flags of their own
+	for _, stepFlag := range []struct {
+		flagName string
+		step     string
+		timeout  time.Duration
+	}{
+		{"pvc-bind-timeout", StepPV, pvcBindTimeout},
+		{"pod-start-timeout", StepPod, podStartTimeout},
+		{"rest-timeout", StepREST, restTimeout},
+	} {
+		if stepFlag.timeout == 0 {
+			continue
+		}
+		if stepFlag.timeout < 0 {
+			return fmt.Errorf("--%s must be positive", stepFlag.flagName)
+		}
+		if _, ok := stepTimeouts[stepFlag.step]; ok {
+			return fmt.Errorf("--%s cannot be used with --step-timeout %s", stepFlag.flagName, stepFlag.step)
+		}
+		stepTimeouts[stepFlag.step] = stepFlag.timeout
+	}
+	return nil
+}
+
+// getStepTimeout returns how long to wait for an installation step, which is --k8s-timeout unless
+// the step was given its own timeout.
+func getStepTimeout(step string) time.Duration {
+	if timeout, ok := stepTimeouts[step]; ok {
+		return timeout
+	}
+	return k8sTimeout
+}
@@ -0,0 +1,42 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// checkTokenAudience detects the audiences of the service account tokens the API server accepts,
+// and checks that a projected token with the audience specified on the command line is one of
+// them, since otherwise the Trident controller couldn't authenticate.  Without --token-audience,
+// the audiences are only detected for the report of a dry run.
+func checkTokenAudience() error {
+
+	if tokenAudience == "" && !dryRun {
+		return nil
+	}
+
+	audiences, err := client.GetDefaultTokenAudiences()
+	if err != nil {
+		log.WithField("error", err).Warning("Could not detect the service account token audiences the " +
+			"API server accepts.")
+		return nil
+	}
+	log.WithField("audiences", strings.Join(audiences, ",")).Debug("Detected service account token audiences.")
+
+	if tokenAudience == "" {
+		preCheckPassed("tokenAudience", "The API server accepts token audiences "+strings.Join(audiences, ", ")+".")
+		return nil
+	}
+	for _, audience := range audiences {
+		if audience == tokenAudience {
+			preCheckPassed("tokenAudience", "The API server accepts token audience "+tokenAudience+".")
+			return nil
+		}
+	}
+	return fmt.Errorf("the API server accepts service account tokens with audiences %s, not %s",
+		strings.Join(audiences, ", "), tokenAudience)
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
+)
+
+// checkStaleVolumeAttachments warns about volume attachments of the Trident CSI driver left by an
+// earlier installation that no pod uses, since they can block new attachments of their volumes.
+// With --prune-volume-attachments, the stale attachments are deleted so the CSI attacher of the
+// new installation detaches them.  An attachment is only considered stale if its PV is gone, is
+// unbound, or isn't used by any pod on the attachment's node, so a live attachment is never
+// deleted.  Problems listing the objects are only logged, and nothing is pruned then.
+func checkStaleVolumeAttachments() {
+
+	attachments, err := client.ListVolumeAttachmentsByDriver(CSIDriverName)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not list volume attachments.")
+		return
+	}
+	if len(attachments) == 0 {
+		log.WithField("driver", CSIDriverName).Debug("No volume attachments found.")
+		return
+	}
+
+	stale, err := getStaleVolumeAttachments(attachments)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not check for stale volume attachments.")
+		return
+	}
+	if len(stale) == 0 {
+		preCheckPassed("volumeAttachments", "No stale volume attachments found.")
+		return
+	}
+
+	for _, attachment := range stale {
+		logFields := log.Fields{
+			"volumeAttachment": attachment.Name,
+			"node":             attachment.Spec.NodeName,
+			"pv":               *attachment.Spec.Source.PersistentVolumeName,
+		}
+		if !pruneVolumeAttachments {
+			log.WithFields(logFields).Warning("Volume attachment is not used by any pod and may block " +
+				"new attachments of its volume; use --prune-volume-attachments to remove it.")
+		} else if dryRun {
+			log.WithFields(logFields).Info("Stale volume attachment would be removed.")
+		} else if err = client.DeleteObjectByName("volumeattachment", attachment.Name, true); err != nil {
+			logFields["error"] = err
+			log.WithFields(logFields).Warning("Could not remove stale volume attachment.")
+		} else {
+			log.WithFields(logFields).Info("Removed stale volume attachment.")
+		}
+	}
+}
+
+// getStaleVolumeAttachments returns the volume attachments whose PV no pod uses on the
+// attachment's node.  Attachments of inline volumes, or that are already being deleted, are
+// never returned.
+func getStaleVolumeAttachments(
+	attachments []storagev1beta1.VolumeAttachment,
+) ([]storagev1beta1.VolumeAttachment, error) {
+
+	pvs, err := client.GetPVs()
+	if err != nil {
+		return nil, err
+	}
+	claims := make(map[string]string)
+	for _, pv := range pvs {
+		if pv.Spec.ClaimRef != nil && pv.Status.Phase == v1.VolumeBound {
+			claims[pv.Name] = pv.Spec.ClaimRef.Namespace + "/" + pv.Spec.ClaimRef.Name
+		}
+	}
+
+	// Pods that have finished no longer hold their volumes
+	pods, err := client.GetPodsByLabel("", true)
+	if err != nil {
+		return nil, err
+	}
+	usedClaims := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				usedClaims[pod.Spec.NodeName+"|"+pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] = true
+			}
+		}
+	}
+
+	stale := make([]storagev1beta1.VolumeAttachment, 0)
+	for _, attachment := range attachments {
+		pvName := attachment.Spec.Source.PersistentVolumeName
+		if pvName == nil || attachment.DeletionTimestamp != nil {
+			continue
+		}
+		claim, bound := claims[*pvName]
+		if !bound || !usedClaims[attachment.Spec.NodeName+"|"+claim] {
+			stale = append(stale, attachment)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	tridentconfig "github.com/netapp/trident/config"
+	"github.com/netapp/trident/logging"
+)
+
+// validateExtraVolumeArguments reads the additional volumes of the Trident controller pod, and
+// their mounts in the Trident container, from the files specified on the command line.  Each file
+// is a JSON or YAML list, as in a pod spec.  The volumes and mounts may not use the names or paths
+// of the ones the installer manages.
+func validateExtraVolumeArguments() error {
+
+	if extraVolumesFile == "" && extraVolumeMountsFile == "" {
+		return nil
+	}
+	if extraVolumesFile == "" {
+		return errors.New("--extra-volume-mount requires --extra-volume")
+	}
+
+	if err := readExtraVolumeFile(extraVolumesFile, &extraVolumes); err != nil {
+		return fmt.Errorf("could not read extra volumes; %v", err)
+	}
+	if extraVolumeMountsFile != "" {
+		if err := readExtraVolumeFile(extraVolumeMountsFile, &extraVolumeMounts); err != nil {
+			return fmt.Errorf("could not read extra volume mounts; %v", err)
+		}
+	}
+
+	reservedNames := make(map[string]bool)
+	for _, name := range k8s_client.ReservedVolumeNames {
+		reservedNames[name] = true
+	}
+
+	volumeNames := make(map[string]bool)
+	for _, volume := range extraVolumes {
+		switch {
+		case volume.Name == "":
+			return errors.New("each extra volume must have a name")
+		case reservedNames[volume.Name] || strings.HasPrefix(volume.Name, k8s_client.CredentialsVolumePrefix):
+			return fmt.Errorf("extra volume %s has the name of a volume the installer manages", volume.Name)
+		case volumeNames[volume.Name]:
+			return fmt.Errorf("extra volume %s is specified more than once", volume.Name)
+		}
+		volumeNames[volume.Name] = true
+	}
+
+	reservedPaths := getReservedMountPaths()
+	mountPaths := make([]string, 0)
+	for _, mount := range extraVolumeMounts {
+		if !volumeNames[mount.Name] {
+			return fmt.Errorf("extra volume mount %s does not name an extra volume", mount.Name)
+		}
+		if !path.IsAbs(mount.MountPath) {
+			return fmt.Errorf("extra volume mount %s must have an absolute mount path", mount.Name)
+		}
+		mountPath := path.Clean(mount.MountPath)
+		for _, reservedPath := range reservedPaths {
+			if mountPathsOverlap(mountPath, reservedPath) {
+				return fmt.Errorf("extra volume mount %s at %s overlaps %s, which the installer manages",
+					mount.Name, mountPath, reservedPath)
+			}
+		}
+		for _, otherPath := range mountPaths {
+			if otherPath == mountPath {
+				return fmt.Errorf("more than one extra volume is mounted at %s", mountPath)
+			}
+		}
+		mountPaths = append(mountPaths, mountPath)
+	}
+
+	return nil
+}
+
+// readExtraVolumeFile reads a JSON or YAML list of volumes or volume mounts.
+func readExtraVolumeFile(filePath string, list interface{}) error {
+
+	listBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(listBytes, list)
+}
+
+// getReservedMountPaths returns the paths at which the installer may mount volumes in the Trident
+// container of the controller pod.
+func getReservedMountPaths() []string {
+
+	paths := []string{"/tmp", tridentconfig.CredentialsSecretsPath, logging.LogRoot, k8s_client.ServiceAccountTokenPath}
+	if csi {
+		paths = append(paths, "/plugin", "/etc")
+	} else {
+		paths = append(paths, tridentconfig.KubernetesAPICAPath)
+	}
+	return paths
+}
+
+// mountPathsOverlap returns whether either of two clean, absolute paths is within the other.
+func mountPathsOverlap(path1, path2 string) bool {
+	return path1 == path2 || strings.HasPrefix(path1, path2+"/") || strings.HasPrefix(path2, path1+"/") ||
+		path1 == "/" || path2 == "/"
+}
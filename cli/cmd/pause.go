@@ -0,0 +1,197 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const PausedReplicasAnnotation = "trident.netapp.io/paused-replicas"
+
+func init() {
+	RootCmd.AddCommand(pauseCmd, resumeCmd)
+	for _, cmd := range []*cobra.Command{pauseCmd, resumeCmd} {
+		cmd.Flags().BoolVar(&silent, "silent", false, "Disable most output.")
+		cmd.Flags().BoolVar(&csi, "csi", false, "Work with CSI Trident (experimental).")
+		cmd.Flags().DurationVar(&k8sTimeout, "k8s-timeout", 180*time.Second, "The number of seconds to wait before timing out on Kubernetes operations.")
+	}
+}
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Scale the Trident controller to zero for maintenance",
+	Long: "Scale the Trident controller to zero replicas, recording the current replica count so " +
+		"'tridentctl resume' can restore it. While Trident is paused, no volumes are provisioned or " +
+		"deleted, but existing volumes remain available, and the CSI node plugin keeps running.",
+	PersistentPreRun: pauseResumePreRun,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := pauseTrident(); err != nil {
+			log.Fatalf("Pause failed; %v", err)
+		}
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:              "resume",
+	Short:            "Restore the Trident controller after 'tridentctl pause'",
+	PersistentPreRun: pauseResumePreRun,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := resumeTrident(); err != nil {
+			log.Fatalf("Resume failed; %v", err)
+		}
+	},
+}
+
+func pauseResumePreRun(cmd *cobra.Command, args []string) {
+	initInstallerLogging()
+	if err := discoverUninstallationEnvironment(); err != nil {
+		log.Fatalf("Pre-checks failed; %v", err)
+	}
+	processUninstallationArguments()
+	if err := validateUninstallationArguments(); err != nil {
+		log.Fatalf("Invalid arguments; %v", err)
+	}
+}
+
+// getTridentController returns the type, name, replica count, and annotations of the
+// Trident controller workload, which is a deployment or, for CSI Trident, a statefulset.
+func getTridentController() (typeName, name string, replicas int32, annotations map[string]string, err error) {
+
+	replicas = 1
+
+	if !csi {
+		deployment, err := client.GetDeploymentByLabel(appLabel, false)
+		if err != nil {
+			return "", "", 0, nil, fmt.Errorf("could not find the Trident deployment; %v", err)
+		}
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		return "deployment", deployment.Name, replicas, deployment.Annotations, nil
+	}
+
+	statefulSet, err := client.GetStatefulSetByLabel(appLabel, false)
+	if err != nil {
+		return "", "", 0, nil, fmt.Errorf("could not find the Trident statefulset; %v", err)
+	}
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	return "statefulset", statefulSet.Name, replicas, statefulSet.Annotations, nil
+}
+
+// pauseTrident scales the Trident controller to zero, first recording its replica count in
+// an annotation on the controller.
+func pauseTrident() error {
+
+	typeName, name, replicas, annotations, err := getTridentController()
+	if err != nil {
+		return err
+	}
+	logFields := log.Fields{typeName: name, "namespace": TridentPodNamespace}
+
+	// The recorded count only means Trident is paused once the controller is scaled to zero, since a
+	// pause may have failed after recording it, in which case scaling down is retried
+	pausedReplicas, recorded := annotations[PausedReplicasAnnotation]
+	if recorded && replicas == 0 {
+		log.WithFields(logFields).WithField("replicas", pausedReplicas).Info("Trident is already paused.")
+		return nil
+	} else if recorded {
+		log.WithFields(logFields).WithField("replicas", pausedReplicas).Info("Trident replica count was " +
+			"recorded by an earlier pause, but Trident is still running; scaling it down.")
+	} else if replicas == 0 {
+		return fmt.Errorf("the Trident %s is already scaled to zero, so there is no replica count to "+
+			"restore; scale it manually", typeName)
+	}
+
+	// Record the replica count before scaling, so a failure in between leaves it resumable
+	if !recorded {
+		pausedReplicas = strconv.Itoa(int(replicas))
+		if err = client.AnnotateObject(typeName, name, PausedReplicasAnnotation, pausedReplicas); err != nil {
+			return fmt.Errorf("could not record the Trident replica count; %v", err)
+		}
+	}
+	if err = client.ScaleObject(typeName, name, 0); err != nil {
+		return fmt.Errorf("could not scale the Trident %s; %v", typeName, err)
+	}
+
+	if err = waitForTridentPodDeleted(); err != nil {
+		return err
+	}
+
+	log.WithFields(logFields).WithField("replicas", pausedReplicas).Info("Trident is paused. Volumes will not " +
+		"be provisioned or deleted until you run 'tridentctl resume'.")
+	return nil
+}
+
+// resumeTrident scales the Trident controller back to the replica count recorded by pauseTrident.
+func resumeTrident() error {
+
+	typeName, name, _, annotations, err := getTridentController()
+	if err != nil {
+		return err
+	}
+	logFields := log.Fields{typeName: name, "namespace": TridentPodNamespace}
+
+	pausedReplicas, ok := annotations[PausedReplicasAnnotation]
+	if !ok {
+		log.WithFields(logFields).Info("Trident is not paused.")
+		return nil
+	}
+	replicas, err := strconv.ParseInt(pausedReplicas, 10, 32)
+	if err != nil || replicas < 1 {
+		return fmt.Errorf("invalid replica count '%s' in annotation %s", pausedReplicas, PausedReplicasAnnotation)
+	}
+
+	if err = client.ScaleObject(typeName, name, int32(replicas)); err != nil {
+		return fmt.Errorf("could not scale the Trident %s; %v", typeName, err)
+	}
+	if err = client.RemoveObjectAnnotation(typeName, name, PausedReplicasAnnotation); err != nil {
+		return fmt.Errorf("could not remove the recorded Trident replica count; %v", err)
+	}
+
+	pod, err := waitForTridentPod()
+	if err != nil {
+		return err
+	}
+	TridentPodName = pod.Name
+	if _, err = waitForRESTInterface(); err != nil {
+		return fmt.Errorf("%v; use 'tridentctl logs' to learn more", err)
+	}
+
+	log.WithFields(logFields).WithField("replicas", replicas).Info("Trident is resumed.")
+	return nil
+}
+
+// waitForTridentPodDeleted waits for the Trident controller pod to be gone.
+func waitForTridentPodDeleted() error {
+
+	checkPodDeleted := func() error {
+		if pod, err := client.GetPodByLabel(appLabel, false); err == nil && pod != nil {
+			return errors.New("pod still exists")
+		}
+		return nil
+	}
+	podNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+		}).Debugf("Trident pod still exists, waiting.")
+	}
+	podBackoff := backoff.NewExponentialBackOff()
+	podBackoff.MaxElapsedTime = k8sTimeout
+
+	log.Info("Waiting for Trident pod to stop.")
+
+	if err := backoff.RetryNotify(checkPodDeleted, podBackoff, podNotify); err != nil {
+		return fmt.Errorf("Trident pod was still present after %3.2f seconds", k8sTimeout.Seconds())
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/netapp/trident/cli/k8s_client"
+)
+
+func init() {
+	RootCmd.AddCommand(rbacReportCmd)
+	rbacReportCmd.Flags().BoolVar(&csi, "csi", false, "Report the permissions of CSI Trident.")
+	rbacReportCmd.Flags().StringVar(&targetK8sVersion, "target-k8s-version", "", "The Kubernetes version to report for, instead of that of the cluster.")
+	rbacReportCmd.Flags().StringVar(&targetFlavor, "target-flavor", "", "The orchestrator flavor (k8s or openshift) to report for, instead of that of the cluster.")
+	rbacReportCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use for Kubernetes API requests.")
+	rbacReportCmd.Flags().StringVar(&kubeContext, "context", "", "The kubeconfig context to use for Kubernetes API requests.")
+}
+
+var rbacReportCmd = &cobra.Command{
+	Use:   "rbac-report",
+	Short: "Report the Kubernetes permissions a Trident installation grants",
+	Long: "Report the rules of the cluster role that 'tridentctl install' creates for Trident, grouped " +
+		"by resource, and the service account it is bound to. The report is for the Kubernetes version " +
+		"and flavor of the cluster, or for those specified with --target-k8s-version and " +
+		"--target-flavor, in which case no cluster is needed. Nothing is changed in the cluster.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// Keep stdout clear for the report
+		initInstallerLogging()
+		log.SetOutput(os.Stderr)
+
+		report, err := getRBACReport()
+		if err != nil {
+			return err
+		}
+
+		switch OutputFormat {
+		case FormatJSON:
+			WriteJSON(report)
+		case FormatYAML:
+			WriteYAML(report)
+		default:
+			writeRBACReportTable(report)
+		}
+		return nil
+	},
+}
+
+// rbacReport describes the Kubernetes permissions a Trident installation grants.
+type rbacReport struct {
+	KubernetesVersion string           `json:"kubernetesVersion"`
+	Flavor            string           `json:"flavor"`
+	CSI               bool             `json:"csi"`
+	ClusterRole       string           `json:"clusterRole"`
+	Rules             []rbacReportRule `json:"rules"`
+	Subjects          []rbacv1.Subject `json:"subjects"`
+	OpenShiftSCC      string           `json:"openShiftSCC,omitempty"`
+}
+
+// rbacReportRule lists the verbs granted on one resource.
+type rbacReportRule struct {
+	APIGroup string   `json:"apiGroup"`
+	Resource string   `json:"resource"`
+	Verbs    []string `json:"verbs"`
+}
+
+// getRBACReport renders the cluster role and binding the installer would create, and groups the
+// role's rules by resource.
+func getRBACReport() (*rbacReport, error) {
+
+	if err := parseTargetVersionAndFlavor(); err != nil {
+		return nil, err
+	}
+
+	// Only ask the cluster for what wasn't specified
+	if targetVersion == nil || targetFlavor == "" {
+		kubectlConfig, err := getKubectlConfig()
+		if err != nil {
+			return nil, err
+		}
+		if client, err = k8s_client.NewKubectlClientForConfig(kubectlConfig); err != nil {
+			return nil, fmt.Errorf("could not initialize Kubernetes client; %v", err)
+		}
+		if TridentPodNamespace == "" {
+			TridentPodNamespace = client.Namespace()
+		}
+	} else if TridentPodNamespace == "" {
+		TridentPodNamespace = PreferredNamespace
+	}
+
+	version := getKubernetesVersion()
+	flavor := getKubernetesFlavor()
+
+	var clusterRole rbacv1.ClusterRole
+	if err := yaml.Unmarshal([]byte(k8s_client.GetClusterRoleYAML(flavor, version, csi, nil)), &clusterRole); err != nil {
+		return nil, fmt.Errorf("could not parse the cluster role; %v", err)
+	}
+	var binding rbacv1.ClusterRoleBinding
+	bindingYAML := k8s_client.GetClusterRoleBindingYAML(TridentPodNamespace, flavor, version, csi)
+	if err := yaml.Unmarshal([]byte(bindingYAML), &binding); err != nil {
+		return nil, fmt.Errorf("could not parse the cluster role binding; %v", err)
+	}
+
+	report := &rbacReport{
+		KubernetesVersion: version.String(),
+		Flavor:            string(flavor),
+		CSI:               csi,
+		ClusterRole:       clusterRole.Name,
+		Rules:             groupRBACRules(clusterRole.Rules),
+		Subjects:          binding.Subjects,
+	}
+	if flavor == k8s_client.FlavorOpenShift {
+		report.OpenShiftSCC = k8s_client.OpenShiftSCCName
+	}
+	return report, nil
+}
+
+// groupRBACRules merges the verbs that the rules grant on each resource, sorted by API group and
+// resource.  Non-resource URLs are listed as resources without an API group.
+func groupRBACRules(rules []rbacv1.PolicyRule) []rbacReportRule {
+
+	type resourceKey struct{ apiGroup, resource string }
+	verbs := make(map[resourceKey]map[string]bool)
+
+	addVerbs := func(key resourceKey, ruleVerbs []string) {
+		if verbs[key] == nil {
+			verbs[key] = make(map[string]bool)
+		}
+		for _, verb := range ruleVerbs {
+			verbs[key][verb] = true
+		}
+	}
+	for _, rule := range rules {
+		for _, apiGroup := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				addVerbs(resourceKey{apiGroup, resource}, rule.Verbs)
+			}
+		}
+		for _, url := range rule.NonResourceURLs {
+			addVerbs(resourceKey{"", url}, rule.Verbs)
+		}
+	}
+
+	grouped := make([]rbacReportRule, 0, len(verbs))
+	for key, verbSet := range verbs {
+		rule := rbacReportRule{APIGroup: key.apiGroup, Resource: key.resource}
+		for verb := range verbSet {
+			rule.Verbs = append(rule.Verbs, verb)
+		}
+		sort.Strings(rule.Verbs)
+		grouped = append(grouped, rule)
+	}
+	sort.Slice(grouped, func(i, j int) bool {
+		if grouped[i].APIGroup != grouped[j].APIGroup {
+			return grouped[i].APIGroup < grouped[j].APIGroup
+		}
+		return grouped[i].Resource < grouped[j].Resource
+	})
+	return grouped
+}
+
+func writeRBACReportTable(report *rbacReport) {
+
+	fmt.Printf("Cluster role %s (Kubernetes %s, %s", report.ClusterRole, report.KubernetesVersion, report.Flavor)
+	if report.CSI {
+		fmt.Print(", CSI")
+	}
+	fmt.Println(")")
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Resource", "API Group", "Verbs"})
+	for _, rule := range report.Rules {
+		apiGroup := rule.APIGroup
+		if strings.HasPrefix(rule.Resource, "/") {
+			apiGroup = "(non-resource URL)"
+		} else if apiGroup == "" {
+			apiGroup = "core"
+		}
+		table.Append([]string{rule.Resource, apiGroup, strings.Join(rule.Verbs, ", ")})
+	}
+	table.Render()
+
+	for _, subject := range report.Subjects {
+		fmt.Printf("Bound to %s %s/%s\n", subject.Kind, subject.Namespace, subject.Name)
+	}
+	if report.OpenShiftSCC != "" {
+		fmt.Printf("Service account added to the %s security context constraints\n", report.OpenShiftSCC)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestGroupRBACRules(t *testing.T) {
+
+	for _, test := range []struct {
+		name     string
+		rules    []rbacv1.PolicyRule
+		expected []rbacReportRule
+	}{
+		{
+			name:     "no rules",
+			rules:    nil,
+			expected: []rbacReportRule{},
+		},
+		{
+			name: "one rule per resource",
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods", "secrets"}, Verbs: []string{"list", "get"}},
+			},
+			expected: []rbacReportRule{
+				{APIGroup: "", Resource: "pods", Verbs: []string{"get", "list"}},
+				{APIGroup: "", Resource: "secrets", Verbs: []string{"get", "list"}},
+			},
+		},
+		{
+			name: "verbs merged across rules",
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"delete", "get"}},
+			},
+			expected: []rbacReportRule{
+				{APIGroup: "", Resource: "pods", Verbs: []string{"delete", "get", "list"}},
+			},
+		},
+		{
+			name: "sorted by API group and resource",
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"storage.k8s.io"}, Resources: []string{"storageclasses"}, Verbs: []string{"get"}},
+				{APIGroups: []string{"apps", ""}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+			},
+			expected: []rbacReportRule{
+				{APIGroup: "", Resource: "deployments", Verbs: []string{"get"}},
+				{APIGroup: "apps", Resource: "deployments", Verbs: []string{"get"}},
+				{APIGroup: "storage.k8s.io", Resource: "storageclasses", Verbs: []string{"get"}},
+			},
+		},
+		{
+			name: "non-resource URLs",
+			rules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/version", "/healthz"}, Verbs: []string{"get"}},
+			},
+			expected: []rbacReportRule{
+				{APIGroup: "", Resource: "/healthz", Verbs: []string{"get"}},
+				{APIGroup: "", Resource: "/version", Verbs: []string{"get"}},
+			},
+		},
+		{
+			name: "wildcards kept",
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			},
+			expected: []rbacReportRule{
+				{APIGroup: "*", Resource: "*", Verbs: []string{"*"}},
+			},
+		},
+	} {
+		grouped := groupRBACRules(test.rules)
+		if !reflect.DeepEqual(grouped, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, grouped)
+		}
+	}
+}
+
+func TestParseTargetFlavor(t *testing.T) {
+
+	defer func(version, flavor string) {
+		targetK8sVersion, targetFlavor = version, flavor
+	}(targetK8sVersion, targetFlavor)
+	targetK8sVersion = ""
+
+	for _, test := range []struct {
+		flavor      string
+		expectError bool
+	}{
+		{flavor: ""},
+		{flavor: "k8s"},
+		{flavor: "openshift"},
+		{flavor: "kubernetes", expectError: true},
+		{flavor: "OpenShift", expectError: true},
+	} {
+		targetFlavor = test.flavor
+		err := parseTargetVersionAndFlavor()
+		if test.expectError && err == nil {
+			t.Errorf("expected an error for flavor '%s'", test.flavor)
+		} else if !test.expectError && err != nil {
+			t.Errorf("unexpected error for flavor '%s'; %v", test.flavor, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RootCmd.AddCommand(reconcileNodeLabelsCmd)
+	reconcileNodeLabelsCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report the nodes with a ready Trident node plugin, but don't change any labels.")
+	reconcileNodeLabelsCmd.Flags().BoolVar(&silent, "silent", false, "Disable most output.")
+}
+
+var reconcileNodeLabelsCmd = &cobra.Command{
+	Use:   "reconcile-node-labels",
+	Short: "Update the node-ready label of CSI Trident to match the nodes where its plugin is ready",
+	Long: "Set the " + NodeReadyLabelKey + " label that 'tridentctl install --label-ready-nodes' " +
+		"sets on each node where the Trident node plugin is ready and registered, and remove it from " +
+		"every other node, such as a node whose plugin has failed since the installation. Run it " +
+		"periodically, for example from a CronJob, to keep the label current.",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Only CSI Trident runs node plugins
+		csi = true
+		initInstallerLogging()
+		if err := discoverUninstallationEnvironment(); err != nil {
+			log.Fatalf("Pre-checks failed; %v", err)
+		}
+		processInstallationArguments()
+		if err := validateUninstallationArguments(); err != nil {
+			log.Fatalf("Invalid arguments; %v", err)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := reconcileNodeReadyLabels(); err != nil {
+			log.Fatalf("Node label reconciliation failed; %v", err)
+		}
+	},
+}
+
+// reconcileNodeReadyLabels labels the nodes where the Trident node plugin is ready now, and removes
+// the label from the nodes where it no longer is.
+func reconcileNodeReadyLabels() error {
+
+	readyNodeNames, err := getReadyTridentNodes()
+	if err != nil {
+		return err
+	}
+	log.WithField("nodes", len(readyNodeNames)).Info("Found the nodes with a ready Trident node plugin.")
+
+	if dryRun {
+		for _, nodeName := range readyNodeNames {
+			log.WithField("node", nodeName).Info("Trident node plugin is ready.")
+		}
+		return nil
+	}
+	return labelTridentReadyNodes(readyNodeNames, nil)
+}
+
+// getReadyTridentNodes returns the names of the ready nodes with a ready Trident node pod, on which
+// the kubelet has registered the CSI driver.
+func getReadyTridentNodes() ([]string, error) {
+
+	pods, err := client.GetPodsByLabel(TridentNodeLabel, false)
+	if err != nil {
+		return nil, fmt.Errorf("could not list Trident node pods; %v", err)
+	}
+	readyPods := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" && !isPodEvicted(&pod) && isPodReady(&pod) {
+			readyPods[pod.Spec.NodeName] = true
+		}
+	}
+
+	nodes, err := client.GetNodes()
+	if err != nil {
+		return nil, fmt.Errorf("could not list nodes; %v", err)
+	}
+	var readyNodeNames []string
+	for _, node := range nodes {
+		if !readyPods[node.Name] {
+			continue
+		}
+		if !isNodeReady(&node) || !isCSIDriverRegistered(&node) {
+			log.WithFields(log.Fields{
+				"node":       node.Name,
+				"ready":      isNodeReady(&node),
+				"registered": isCSIDriverRegistered(&node),
+			}).Debug("Trident node pod is ready, but its node isn't.")
+			continue
+		}
+		readyNodeNames = append(readyNodeNames, node.Name)
+	}
+	sort.Strings(readyNodeNames)
+
+	return readyNodeNames, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/netapp/trident/cli/k8s_client"
+)
+
+func init() {
+	RootCmd.AddCommand(repairRBACCmd)
+	repairRBACCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report any drift, but don't change anything.")
+	repairRBACCmd.Flags().BoolVar(&silent, "silent", false, "Disable most output.")
+	repairRBACCmd.Flags().BoolVar(&csi, "csi", false, "Repair the RBAC objects of CSI Trident (experimental).")
+}
+
+var repairRBACCmd = &cobra.Command{
+	Use:   "repair-rbac",
+	Short: "Detect and repair drift in the Trident cluster role binding",
+	Long: "Compare the Trident cluster role binding with the one the installer creates for the " +
+		"Trident namespace and service account, and restore it if it has drifted.",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initInstallerLogging()
+		if err := discoverUninstallationEnvironment(); err != nil {
+			log.Fatalf("Pre-checks failed; %v", err)
+		}
+		processInstallationArguments()
+		if err := validateUninstallationArguments(); err != nil {
+			log.Fatalf("Invalid arguments; %v", err)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := repairClusterRoleBinding(); err != nil {
+			log.Fatalf("RBAC repair failed; %v", err)
+		}
+	},
+}
+
+// repairClusterRoleBinding restores the Trident cluster role binding to the state computed by
+// GetClusterRoleBindingYAML, reporting each difference it finds.
+func repairClusterRoleBinding() error {
+
+	if !useKubernetesRBAC {
+		return errors.New("RBAC repair is not supported with Docker EE / UCP")
+	}
+
+	desiredYAML := k8s_client.GetClusterRoleBindingYAML(TridentPodNamespace, client.Flavor(), client.Version(), csi)
+	var desired rbacv1.ClusterRoleBinding
+	if err := yaml.Unmarshal([]byte(desiredYAML), &desired); err != nil {
+		return fmt.Errorf("could not parse the expected cluster role binding; %v", err)
+	}
+
+	actual, err := client.GetClusterRoleBinding(desired.Name)
+	if err != nil {
+		return fmt.Errorf("could not get cluster role binding %s; %v", desired.Name, err)
+	}
+
+	if actual == nil {
+		log.WithField("clusterRoleBinding", desired.Name).Warning("Cluster role binding does not exist.")
+		if dryRun {
+			return nil
+		}
+		if err = client.CreateObjectByYAML(desiredYAML); err != nil {
+			return fmt.Errorf("could not create cluster role binding; %v", err)
+		}
+		log.WithField("clusterRoleBinding", desired.Name).Info("Created cluster role binding.")
+		return nil
+	}
+
+	drifted := false
+	for _, subject := range actual.Subjects {
+		if !containsSubject(desired.Subjects, subject) {
+			drifted = true
+			log.WithFields(subjectLogFields(subject)).Warning("Cluster role binding has an unexpected subject.")
+		}
+	}
+	for _, subject := range desired.Subjects {
+		if !containsSubject(actual.Subjects, subject) {
+			drifted = true
+			log.WithFields(subjectLogFields(subject)).Warning("Cluster role binding is missing a subject.")
+		}
+	}
+	roleRefDrifted := actual.RoleRef.Name != desired.RoleRef.Name
+	if roleRefDrifted {
+		drifted = true
+		log.WithFields(log.Fields{
+			"roleRef":  actual.RoleRef.Name,
+			"expected": desired.RoleRef.Name,
+		}).Warning("Cluster role binding refers to the wrong cluster role.")
+	}
+
+	if !drifted {
+		log.WithField("clusterRoleBinding", desired.Name).Info("Cluster role binding is correct.")
+		return nil
+	}
+	if dryRun {
+		log.WithField("clusterRoleBinding", desired.Name).Info("Dry run; cluster role binding was not changed.")
+		return nil
+	}
+
+	// The role reference of a binding is immutable, so a binding to the wrong role must be recreated
+	if roleRefDrifted {
+		if err = client.DeleteObjectByYAML(desiredYAML, true); err != nil {
+			return fmt.Errorf("could not delete cluster role binding; %v", err)
+		}
+		err = client.CreateObjectByYAML(desiredYAML)
+	} else {
+		err = client.ReplaceObjectByYAML(desiredYAML)
+	}
+	if err != nil {
+		return fmt.Errorf("could not update cluster role binding; %v", err)
+	}
+
+	log.WithField("clusterRoleBinding", desired.Name).Info("Repaired cluster role binding.")
+	return nil
+}
+
+func containsSubject(subjects []rbacv1.Subject, subject rbacv1.Subject) bool {
+	for _, s := range subjects {
+		if s.Kind == subject.Kind && s.Name == subject.Name && s.Namespace == subject.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func subjectLogFields(subject rbacv1.Subject) log.Fields {
+	return log.Fields{
+		"kind":      subject.Kind,
+		"name":      subject.Name,
+		"namespace": subject.Namespace,
+	}
+}
@@ -3,6 +3,7 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -293,6 +294,21 @@ func TunnelCommandRaw(commandArgs []string) ([]byte, error) {
 	return output, err
 }
 
+// confirmAction asks the user to confirm an action on the terminal and reports whether
+// the user answered yes.
+func confirmAction(prompt string) bool {
+
+	fmt.Printf("%s [y/N]: ", prompt)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 func GetErrorFromHTTPResponse(response *http.Response, responseBody []byte) error {
 
 	var errorResponse api.ErrorResponse
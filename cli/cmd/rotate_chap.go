@@ -0,0 +1,334 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+
+	"github.com/netapp/trident/cli/k8s_client"
+	"github.com/netapp/trident/storage"
+	"github.com/netapp/trident/utils"
+)
+
+var (
+	assumeYes bool
+)
+
+func init() {
+	RootCmd.AddCommand(rotateCHAPCmd)
+	rotateCHAPCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Run all the pre-checks, but don't change anything.")
+	rotateCHAPCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation.")
+	rotateCHAPCmd.Flags().BoolVar(&silent, "silent", false, "Disable most output.")
+	rotateCHAPCmd.Flags().BoolVar(&csi, "csi", false, "Rotate the credentials of CSI Trident (experimental).")
+	rotateCHAPCmd.Flags().StringVar(&pvName, "pv", "", "The name of the PV used by Trident.")
+	rotateCHAPCmd.Flags().DurationVar(&k8sTimeout, "k8s-timeout", 180*time.Second, "The number of seconds to wait before timing out on Kubernetes operations.")
+}
+
+var rotateCHAPCmd = &cobra.Command{
+	Use:   "rotate-chap",
+	Short: "Rotate the iSCSI CHAP credentials of the Trident volume",
+	Long: "Generate new iSCSI CHAP credentials for the volume used by Trident, update them on the " +
+		"storage backend and in the Kubernetes secret, and restart the Trident pod so it logs in " +
+		"with the new credentials. The storage backend config in the setup directory is used to " +
+		"reach the backend.",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initInstallerLogging()
+		if err := discoverUninstallationEnvironment(); err != nil {
+			log.Fatalf("Pre-checks failed; %v", err)
+		}
+		processInstallationArguments()
+		if err := validateUninstallationArguments(); err != nil {
+			log.Fatalf("Invalid arguments; %v", err)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := rotateCHAPCredentials(); err != nil {
+			log.Fatalf("CHAP rotation failed; %v", err)
+		}
+	},
+}
+
+// chapRotator is implemented by storage drivers that can replace their iSCSI CHAP secrets.
+type chapRotator interface {
+	NewCHAPCredentials() (*utils.IscsiAccessInfo, error)
+	SetCHAPCredentials(accessInfo *utils.IscsiAccessInfo) error
+}
+
+// rotateCHAPCredentials replaces the CHAP credentials of the Trident volume.  The Kubernetes
+// secrets are updated first, so that they can be restored if the backend can't be updated, then
+// the backend, and finally the Trident pod is restarted.  The established iSCSI session of the
+// running pod is unaffected by either, so the volume remains attached until the replacement pod
+// logs in with the updated secret.
+func rotateCHAPCredentials() error {
+
+	// Ensure the Trident PV uses CHAP
+	pv, err := client.GetPV(pvName)
+	if err != nil {
+		return fmt.Errorf("could not get PV %s; %v", pvName, err)
+	}
+	if pv.Spec.ISCSI == nil || !pv.Spec.ISCSI.SessionCHAPAuth || pv.Spec.ISCSI.SecretRef == nil {
+		return fmt.Errorf("PV %s is not an iSCSI volume that uses CHAP", pvName)
+	}
+	secretName := pv.Spec.ISCSI.SecretRef.Name
+
+	// Every PV of the tenant account uses a copy of the secret, which must all be rotated together
+	secretCopies, err := getCHAPSecretCopies(secretName)
+	if err != nil {
+		return err
+	}
+
+	pod, err := client.GetPodByLabel(appLabel, false)
+	if err != nil {
+		return fmt.Errorf("could not find the Trident pod; %v", err)
+	}
+
+	// Start the storage driver and ensure it can rotate its CHAP secrets
+	backend, err := loadStorageDriver()
+	if err != nil {
+		return err
+	}
+	rotator, ok := backend.Driver.(chapRotator)
+	if !ok {
+		return fmt.Errorf("the %s driver does not support rotating CHAP credentials", backend.GetDriverName())
+	}
+
+	logFields := log.Fields{
+		"pv":         pvName,
+		"secret":     secretName,
+		"namespaces": strings.Join(getCHAPSecretNamespaces(secretCopies), ","),
+		"pod":        pod.Name,
+	}
+
+	if dryRun {
+		log.WithFields(logFields).Info("Dry run completed, no problems found.")
+		return nil
+	}
+
+	log.WithFields(logFields).Warning("The CHAP secrets are shared by every volume the backend's " +
+		"tenant account owns; the secret is updated in each namespace its PVs use, and initiators " +
+		"outside this cluster must use the new secrets the next time they log in.")
+	if !assumeYes && !confirmAction(fmt.Sprintf("Rotate the CHAP credentials of PV %s?", pvName)) {
+		return errors.New("rotation was not confirmed")
+	}
+
+	accessInfo, err := rotator.NewCHAPCredentials()
+	if err != nil {
+		return err
+	}
+
+	// Update the Kubernetes secrets, restoring those already updated if one can't be
+	for i, secretCopy := range secretCopies {
+		if err = updateCHAPSecret(secretCopy.namespace, secretName, accessInfo); err != nil {
+			err = fmt.Errorf("could not update CHAP secret %s in namespace %s; %v", secretName,
+				secretCopy.namespace, err)
+			return restoreCHAPSecrets(secretName, secretCopies[:i], err)
+		}
+	}
+
+	// Update the backend, restoring the Kubernetes secrets if that fails
+	if err = rotator.SetCHAPCredentials(accessInfo); err != nil {
+		return restoreCHAPSecrets(secretName, secretCopies, err)
+	}
+	expectedSecretName := (&storage.VolumeExternal{
+		Backend: backend.Name,
+		Config:  &storage.VolumeConfig{AccessInfo: utils.VolumeAccessInfo{IscsiAccessInfo: *accessInfo}},
+	}).GetCHAPSecretName()
+	if expectedSecretName != secretName {
+		log.WithFields(log.Fields{
+			"secret":   secretName,
+			"expected": expectedSecretName,
+		}).Warning("The CHAP secret used by the Trident PV does not match the name Trident expects.")
+	}
+
+	// Restart the Trident pod so it logs in with the new secrets
+	if err = client.DeleteObjectByName("pod", pod.Name, false); err != nil {
+		return fmt.Errorf("could not restart Trident pod %s; %v", pod.Name, err)
+	}
+	log.WithField("pod", pod.Name).Info("Deleted Trident pod so it restarts with the new credentials.")
+
+	newPod, err := waitForReplacementTridentPod(pod.Name)
+	if err != nil {
+		return err
+	}
+	TridentPodName = newPod.Name
+	if _, err = waitForRESTInterface(); err != nil {
+		return fmt.Errorf("%v; use 'tridentctl logs' to learn more", err)
+	}
+
+	log.Info("CHAP credentials rotated.")
+	return nil
+}
+
+// chapSecretCopy is a copy of the iSCSI CHAP secret of a tenant account in one namespace.
+type chapSecretCopy struct {
+	namespace  string
+	accessInfo *utils.IscsiAccessInfo
+}
+
+// getCHAPSecretCopies returns the copies of an iSCSI CHAP secret that the iSCSI PVs refer to.
+// Trident keeps the CHAP secret of a tenant account in its own namespace, or, before Kubernetes
+// 1.9, in the namespace of each PVC, and every copy holds the secrets of the whole account.
+func getCHAPSecretCopies(secretName string) ([]chapSecretCopy, error) {
+
+	pvs, err := client.GetPVs()
+	if err != nil {
+		return nil, fmt.Errorf("could not list PVs; %v", err)
+	}
+	namespaces := map[string]bool{TridentPodNamespace: true}
+	for _, pv := range pvs {
+		iscsi := pv.Spec.ISCSI
+		if iscsi == nil || iscsi.SecretRef == nil || iscsi.SecretRef.Name != secretName {
+			continue
+		}
+		namespace := iscsi.SecretRef.Namespace
+		if namespace == "" && pv.Spec.ClaimRef != nil {
+			namespace = pv.Spec.ClaimRef.Namespace
+		}
+		if namespace != "" {
+			namespaces[namespace] = true
+		}
+	}
+
+	sortedNamespaces := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		sortedNamespaces = append(sortedNamespaces, namespace)
+	}
+	sort.Strings(sortedNamespaces)
+
+	defer client.SetNamespace(TridentPodNamespace)
+
+	secretCopies := make([]chapSecretCopy, 0, len(sortedNamespaces))
+	for _, namespace := range sortedNamespaces {
+		client.SetNamespace(namespace)
+		secretExists, err := client.CheckSecretExists(secretName)
+		if err != nil {
+			return nil, fmt.Errorf("could not check for iSCSI CHAP secret %s in namespace %s; %v",
+				secretName, namespace, err)
+		} else if !secretExists {
+			if namespace == TridentPodNamespace {
+				return nil, fmt.Errorf("iSCSI CHAP secret %s does not exist", secretName)
+			}
+			log.WithFields(log.Fields{
+				"secret":    secretName,
+				"namespace": namespace,
+			}).Warning("A PV refers to an iSCSI CHAP secret that does not exist, skipping it.")
+			continue
+		}
+		secret, err := client.GetSecret(secretName)
+		if err != nil {
+			return nil, fmt.Errorf("could not get iSCSI CHAP secret %s in namespace %s; %v",
+				secretName, namespace, err)
+		}
+		secretCopies = append(secretCopies, chapSecretCopy{
+			namespace: namespace,
+			accessInfo: &utils.IscsiAccessInfo{
+				IscsiUsername:        string(secret.Data["node.session.auth.username"]),
+				IscsiInitiatorSecret: string(secret.Data["node.session.auth.password"]),
+				IscsiTargetSecret:    string(secret.Data["node.session.auth.password_in"]),
+			},
+		})
+	}
+	return secretCopies, nil
+}
+
+// getCHAPSecretNamespaces returns the namespaces of the copies of an iSCSI CHAP secret.
+func getCHAPSecretNamespaces(secretCopies []chapSecretCopy) []string {
+
+	namespaces := make([]string, 0, len(secretCopies))
+	for _, secretCopy := range secretCopies {
+		namespaces = append(namespaces, secretCopy.namespace)
+	}
+	return namespaces
+}
+
+// restoreCHAPSecrets restores the previous contents of the copies of an iSCSI CHAP secret after
+// the rotation failed with the specified error, which it returns along with any copy that still
+// doesn't match the backend.
+func restoreCHAPSecrets(secretName string, secretCopies []chapSecretCopy, rotationErr error) error {
+
+	var unrestored []string
+	for _, secretCopy := range secretCopies {
+		if err := updateCHAPSecret(secretCopy.namespace, secretName, secretCopy.accessInfo); err != nil {
+			log.WithFields(log.Fields{
+				"secret":    secretName,
+				"namespace": secretCopy.namespace,
+			}).Errorf("Could not restore iSCSI CHAP secret; %v", err)
+			unrestored = append(unrestored, secretCopy.namespace)
+			continue
+		}
+		log.WithFields(log.Fields{
+			"secret":    secretName,
+			"namespace": secretCopy.namespace,
+		}).Info("Restored iSCSI CHAP secret.")
+	}
+	if len(unrestored) > 0 {
+		return fmt.Errorf("%v; CHAP secret %s no longer matches the backend in namespaces %s, so restore "+
+			"it manually before any pod using it restarts", rotationErr, secretName, strings.Join(unrestored, ","))
+	}
+	return rotationErr
+}
+
+// updateCHAPSecret replaces the contents of an existing iSCSI CHAP secret in a namespace.
+func updateCHAPSecret(namespace, secretName string, accessInfo *utils.IscsiAccessInfo) error {
+
+	client.SetNamespace(namespace)
+	defer client.SetNamespace(TridentPodNamespace)
+
+	secretYAML := k8s_client.GetCHAPSecretYAML(secretName,
+		accessInfo.IscsiUsername,
+		accessInfo.IscsiInitiatorSecret,
+		accessInfo.IscsiTargetSecret,
+		appLabelValue)
+
+	if err := client.ReplaceObjectByYAML(secretYAML); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"secret":    secretName,
+		"namespace": namespace,
+	}).Info("Updated iSCSI CHAP secret.")
+
+	return nil
+}
+
+// waitForReplacementTridentPod waits for a Trident pod other than the deleted one to be running.
+func waitForReplacementTridentPod(oldPodName string) (*v1.Pod, error) {
+
+	var pod *v1.Pod
+
+	checkPodRunning := func() error {
+		var podError error
+		pod, podError = client.GetPodByLabel(appLabel, false)
+		if podError != nil || pod.Name == oldPodName || pod.Status.Phase != v1.PodRunning {
+			return errors.New("replacement pod not running")
+		}
+		return nil
+	}
+	podNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+		}).Debugf("Replacement Trident pod not yet running, waiting.")
+	}
+	podBackoff := backoff.NewExponentialBackOff()
+	podBackoff.MaxElapsedTime = k8sTimeout
+
+	log.Info("Waiting for Trident pod to restart.")
+
+	if err := backoff.RetryNotify(checkPodRunning, podBackoff, podNotify); err != nil {
+		return nil, fmt.Errorf("Trident pod was not running after %3.2f seconds; use '%s describe pod "+
+			"-l %s -n %s' for more information", k8sTimeout.Seconds(), client.CLI(), appLabel, client.Namespace())
+	}
+
+	log.WithField("pod", pod.Name).Info("Trident pod restarted.")
+
+	return pod, nil
+}
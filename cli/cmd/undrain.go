@@ -0,0 +1,50 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"github.com/netapp/trident/cli/api"
+)
+
+func init() {
+	RootCmd.AddCommand(undrainCmd)
+}
+
+var undrainCmd = &cobra.Command{
+	Use:    "undrain",
+	Short:  "Let Trident start storage operations again after a drain",
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if OperatingMode == ModeTunnel {
+			TunnelCommand([]string{"undrain"})
+			return nil
+		} else {
+			return undrain()
+		}
+	},
+}
+
+// undrain asks a drained Trident to start storage operations again, such as when it couldn't be
+// deleted after all.
+func undrain() error {
+
+	baseURL, err := GetBaseURL()
+	if err != nil {
+		return err
+	}
+
+	response, responseBody, err := api.InvokeRESTAPI("DELETE", baseURL+"/drain", nil, Debug)
+	if err != nil {
+		return err
+	} else if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not undrain Trident: %v",
+			GetErrorFromHTTPResponse(response, responseBody))
+	}
+
+	return nil
+}
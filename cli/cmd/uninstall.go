@@ -1,20 +1,33 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/netapp/trident/cli/k8s_client"
 	"github.com/netapp/trident/cli/ucp_client"
+	tridentconfig "github.com/netapp/trident/config"
+	"github.com/netapp/trident/frontend/rest"
+)
+
+const (
+	// TridentProvisioner is the provisioner of storage classes served by non-CSI Trident
+	TridentProvisioner = "netapp.io/trident"
 )
 
 var (
-	deleteAll bool
+	deleteAll             bool
+	prune                 bool
+	drainOperations       bool
+	uninstallDrainTimeout time.Duration
 )
 
 func init() {
@@ -22,6 +35,11 @@ func init() {
 	uninstallCmd.Flags().BoolVarP(&deleteAll, "all", "a", false, "Deletes almost all artifacts of Trident, including the PVC and PV used by Trident; however, it doesn't delete the volume used by Trident from the storage backend. Use with caution!")
 	uninstallCmd.Flags().BoolVarP(&silent, "silent", "", false, "Disable most output during uninstallation.")
 	uninstallCmd.Flags().BoolVar(&csi, "csi", false, "Uninstall CSI Trident (experimental).")
+	uninstallCmd.Flags().BoolVar(&useExistingClusterRole, "use-existing-clusterrole", false, "Keep the Trident cluster role even if the cluster role binding doesn't record that it was created outside the installer.")
+	uninstallCmd.Flags().BoolVar(&prune, "prune", false, "Also delete the storage classes that use the Trident provisioner, and with --all the secrets with the Trident label.")
+	uninstallCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation before pruning.")
+	uninstallCmd.Flags().BoolVar(&drainOperations, "drain", false, "Stop Trident from starting storage operations and let those in flight finish before deleting it.")
+	uninstallCmd.Flags().DurationVar(&uninstallDrainTimeout, "drain-timeout", rest.DefaultDrainTimeout, "How long to wait for the storage operations in flight to finish with --drain.")
 
 	uninstallCmd.Flags().StringVar(&ucpBearerToken, "ucp-bearer-token", "", "UCP authorization token.")
 	uninstallCmd.Flags().StringVar(&ucpHost, "ucp-host", "", "IP address of the UCP host.")
@@ -145,6 +163,13 @@ func validateUninstallationArguments() error {
 			"character", TridentPodNamespace)
 	}
 
+	if uninstallDrainTimeout <= 0 {
+		return errors.New("'drain-timeout' must be greater than 0")
+	}
+	if err := validateDrainTimeout("drain-timeout", uninstallDrainTimeout); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -152,6 +177,34 @@ func uninstallTrident() error {
 
 	var anyErrors = false
 
+	// Ensure the Trident operator doesn't manage pods with the same label
+	if err := checkOperatorManagedPods(); err != nil {
+		return err
+	}
+
+	// Confirm what will be pruned before deleting anything
+	var pruneStorageClasses, pruneSecrets []string
+	if prune {
+		var err error
+		if pruneStorageClasses, pruneSecrets, err = getPrunableObjects(); err != nil {
+			return err
+		}
+		if !confirmPrune(pruneStorageClasses, pruneSecrets) {
+			return errors.New("pruning was not confirmed")
+		}
+	}
+
+	// Let the storage operations in flight finish before Trident is deleted, and let it start them
+	// again if it couldn't be deleted after all
+	tridentDeleted := false
+	if drainOperations && drainTrident() {
+		defer func() {
+			if !tridentDeleted {
+				undrainTrident()
+			}
+		}()
+	}
+
 	if !csi {
 
 		log.WithFields(log.Fields{
@@ -192,6 +245,7 @@ func uninstallTrident() error {
 				anyErrors = true
 			} else {
 				log.Info("Deleted Trident deployment.")
+				tridentDeleted = true
 			}
 		}
 
@@ -232,6 +286,11 @@ func uninstallTrident() error {
 			}
 		}
 
+		// Remove the label set on nodes where the node plugin was ready
+		if removeNodeReadyLabels() {
+			anyErrors = true
+		}
+
 		if statefulset, err := client.GetStatefulSetByLabel(appLabel, true); err != nil {
 
 			log.WithFields(log.Fields{
@@ -264,6 +323,7 @@ func uninstallTrident() error {
 				anyErrors = true
 			} else {
 				log.Info("Deleted Trident statefulset.")
+				tridentDeleted = true
 			}
 		}
 
@@ -304,8 +364,39 @@ func uninstallTrident() error {
 
 	}
 
+	// Delete the headless service for the controller pod's subdomain, if any
+	if !csi {
+		if err := client.DeleteServiceByLabel(appLabel); err != nil {
+			log.WithField("error", err).Warning("Could not delete headless service.")
+			anyErrors = true
+		} else {
+			log.Debug("Deleted headless service.")
+		}
+	}
+
+	// Delete the network policy, if any
+	networkPolicyYAML := k8s_client.GetNetworkPolicyYAML(appLabelValue, nil, csi)
+	if err := client.DeleteObjectByYAML(networkPolicyYAML, true); err != nil {
+		log.WithField("error", err).Warning("Could not delete network policy.")
+		anyErrors = true
+	} else {
+		log.Debug("Deleted network policy.")
+	}
+
+	// Delete the Kubernetes API server CA config map, if any
+	if err := client.DeleteObjectByName("configmap", k8s_client.K8sAPICAConfigMapName, true); err != nil {
+		log.WithField("error", err).Warning("Could not delete Kubernetes API server CA config map.")
+		anyErrors = true
+	} else {
+		log.Debug("Deleted Kubernetes API server CA config map.")
+	}
+
 	anyErrors = removeRBACObjects(log.InfoLevel) || anyErrors
 
+	if prune {
+		anyErrors = pruneTridentObjects(pruneStorageClasses, pruneSecrets) || anyErrors
+	}
+
 	if deleteAll {
 
 		// Ensure the Trident PVC may be uniquely identified, then delete it
@@ -360,6 +451,163 @@ func uninstallTrident() error {
 	return nil
 }
 
+// drainTrident stops the running Trident from starting storage operations and waits for those in
+// flight to finish, so deleting Trident doesn't interrupt one.  Operations that didn't finish
+// before the --drain-timeout are reported, since they may have left objects on the storage
+// backends.  A Trident that can't be drained, such as one that predates draining, is only warned
+// about, so it can still be uninstalled.  It returns whether Trident was drained.
+func drainTrident() bool {
+
+	pod, err := client.GetPodByLabel(appLabel, false)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not find the Trident pod, skipping drain.")
+		return false
+	}
+
+	log.WithFields(log.Fields{
+		"pod":     pod.Name,
+		"timeout": uninstallDrainTimeout,
+	}).Info("Draining Trident storage operations.")
+
+	cliCommand := []string{"tridentctl", "-s", PodServer, "drain", "--timeout",
+		uninstallDrainTimeout.String(), "-o", "json"}
+	output, err := client.Exec(pod.Name, tridentconfig.ContainerTrident, cliCommand)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"pod":    pod.Name,
+			"error":  err,
+			"output": strings.TrimSpace(string(output)),
+		}).Warning("Could not drain Trident, storage operations in flight may be interrupted.")
+		return false
+	}
+
+	var drainResponse rest.DrainResponse
+	if err = json.Unmarshal(output, &drainResponse); err != nil {
+		log.WithField("error", err).Warning("Could not parse the drain response.")
+		return true
+	}
+	if len(drainResponse.PendingOperations) == 0 {
+		log.Info("Drained Trident storage operations.")
+		return true
+	}
+	for _, operation := range drainResponse.PendingOperations {
+		log.WithField("operation", operation).Warning("Storage operation did not finish before the " +
+			"drain timeout; check its objects on the storage backend.")
+	}
+	return true
+}
+
+// undrainTrident lets a drained Trident that couldn't be deleted start storage operations again,
+// so it doesn't keep failing them until it is restarted.
+func undrainTrident() {
+
+	pod, err := client.GetPodByLabel(appLabel, false)
+	if err != nil {
+		log.WithField("error", err).Warning("Could not find the Trident pod to undrain it; restart it " +
+			"to resume storage operations.")
+		return
+	}
+
+	cliCommand := []string{"tridentctl", "-s", PodServer, "undrain"}
+	if output, err := client.Exec(pod.Name, tridentconfig.ContainerTrident, cliCommand); err != nil {
+		log.WithFields(log.Fields{
+			"pod":    pod.Name,
+			"error":  err,
+			"output": strings.TrimSpace(string(output)),
+		}).Warning("Could not undrain Trident; restart it to resume storage operations.")
+		return
+	}
+	log.WithField("pod", pod.Name).Info("Trident was not deleted, so it resumed storage operations.")
+}
+
+// getPrunableObjects returns the storage classes that use the provisioner of the Trident flavor
+// being uninstalled, and the secrets in the Trident namespace with the Trident label, such as the
+// iSCSI CHAP secret of the Trident volume.  Nothing else is considered owned by Trident.  Unless
+// the Trident PV is deleted as well, the secrets are kept, since a reinstalled Trident needs the
+// CHAP secret to attach the kept volume and the credentials secrets of the backends stored on it.
+func getPrunableObjects() (storageClasses, secrets []string, err error) {
+
+	provisioner := TridentProvisioner
+	if csi {
+		provisioner = CSIDriverName
+	}
+
+	allStorageClasses, err := client.GetStorageClasses()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not list storage classes; %v", err)
+	}
+	for _, storageClass := range allStorageClasses {
+		if storageClass.Provisioner == provisioner {
+			storageClasses = append(storageClasses, storageClass.Name)
+		}
+	}
+
+	if !deleteAll {
+		log.Info("Keeping the secrets with the Trident label, which the Trident volume may need; " +
+			"use --all with --prune to delete them.")
+		return storageClasses, nil, nil
+	}
+
+	labeledSecrets, err := client.GetSecretsByLabel(appLabel)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not list secrets with label %s; %v", appLabel, err)
+	}
+	for _, secret := range labeledSecrets {
+		secrets = append(secrets, secret.Name)
+	}
+
+	return storageClasses, secrets, nil
+}
+
+// confirmPrune lists the objects to be pruned and asks the user to confirm deleting them.
+func confirmPrune(storageClasses, secrets []string) bool {
+
+	if len(storageClasses) == 0 && len(secrets) == 0 {
+		log.Info("Found no storage classes or secrets to prune.")
+		return true
+	}
+
+	fmt.Println("The following objects will be deleted:")
+	for _, name := range storageClasses {
+		fmt.Printf("  storageclass/%s\n", name)
+	}
+	for _, name := range secrets {
+		fmt.Printf("  secret/%s (namespace %s)\n", name, TridentPodNamespace)
+	}
+
+	return assumeYes || confirmAction("Uninstall Trident and delete these objects?")
+}
+
+// pruneTridentObjects deletes the storage classes and secrets found by getPrunableObjects.
+func pruneTridentObjects(storageClasses, secrets []string) (anyErrors bool) {
+
+	for _, name := range storageClasses {
+		if err := client.DeleteObjectByName("storageclass", name, true); err != nil {
+			log.WithFields(log.Fields{
+				"storageClass": name,
+				"error":        err,
+			}).Warning("Could not delete storage class.")
+			anyErrors = true
+		} else {
+			log.WithField("storageClass", name).Info("Deleted storage class.")
+		}
+	}
+
+	for _, name := range secrets {
+		if err := client.DeleteObjectByName("secret", name, true); err != nil {
+			log.WithFields(log.Fields{
+				"secret": name,
+				"error":  err,
+			}).Warning("Could not delete secret.")
+			anyErrors = true
+		} else {
+			log.WithField("secret", name).Info("Deleted secret.")
+		}
+	}
+
+	return anyErrors
+}
+
 func fileExists(filePath string) bool {
 	_, err := os.Stat(filePath)
 	return err == nil
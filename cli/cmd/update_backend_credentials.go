@@ -0,0 +1,313 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/netapp/trident/cli/api"
+	tridentconfig "github.com/netapp/trident/config"
+	"github.com/netapp/trident/storage/factory"
+	drivers "github.com/netapp/trident/storage_drivers"
+)
+
+var (
+	credentialsUsername     string
+	credentialsPasswordFile string
+)
+
+func init() {
+	RootCmd.AddCommand(updateBackendCredentialsCmd)
+	updateBackendCredentialsCmd.Flags().StringVarP(&filename, "filename", "f", "", "Path to the YAML or JSON backend config that references the credentials secret. Defaults to the backend config in the setup directory.")
+	updateBackendCredentialsCmd.Flags().StringVar(&credentialsUsername, "username", "", "The new username. Defaults to the username in the secret.")
+	updateBackendCredentialsCmd.Flags().StringVar(&credentialsPasswordFile, "password-file", "", "Path to a file "+
+		"holding the new password, or '-' to read it from stdin, which requires --yes or --dry-run.")
+	updateBackendCredentialsCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Run all the pre-checks, but don't change anything.")
+	updateBackendCredentialsCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation.")
+	updateBackendCredentialsCmd.Flags().BoolVar(&silent, "silent", false, "Disable most output.")
+	updateBackendCredentialsCmd.Flags().BoolVar(&csi, "csi", false, "Update the credentials of CSI Trident (experimental).")
+	updateBackendCredentialsCmd.Flags().DurationVar(&k8sTimeout, "k8s-timeout", 180*time.Second, "The number of seconds to wait before timing out on Kubernetes operations.")
+}
+
+var updateBackendCredentialsCmd = &cobra.Command{
+	Use:   "update-backend-credentials",
+	Short: "Update the credentials secret of a backend without restarting Trident",
+	Long: "Check the new credentials against the storage backend, update the Kubernetes secret " +
+		"referenced by the credentials field of the backend config, and have Trident reinitialize " +
+		"the backend once the updated secret is mounted in the Trident pod. Trident keeps serving " +
+		"requests throughout, and the command verifies that the backend is online afterward.",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initInstallerLogging()
+		if err := discoverUninstallationEnvironment(); err != nil {
+			log.Fatalf("Pre-checks failed; %v", err)
+		}
+		processInstallationArguments()
+		if err := validateUninstallationArguments(); err != nil {
+			log.Fatalf("Invalid arguments; %v", err)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := updateBackendCredentials(); err != nil {
+			log.Fatalf("Credentials update failed; %v", err)
+		}
+	},
+}
+
+// updateBackendCredentials replaces the credentials in a backend's credentials secret.  Trident
+// reads a mounted secret each time it initializes a backend, so once the kubelet has refreshed
+// the mounted copy, updating the backend with its unchanged config makes it use the new
+// credentials.  The new credentials are checked against the storage backend first, so a bad
+// password is rejected before the running backend is touched.
+func updateBackendCredentials() error {
+
+	credentialsPassword, err := readCredentialsPassword()
+	if err != nil {
+		return err
+	}
+
+	// Find the credentials secret referenced by the backend config
+	configPath := filename
+	if configPath == "" {
+		if configPath, err = findBackendConfigFile(); err != nil {
+			return err
+		}
+	}
+	configBytes, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("could not read the storage backend config file; %v", err)
+	}
+	configJSON, err := backendConfigToJSON(configPath, configBytes)
+	if err != nil {
+		return err
+	}
+	var commonConfig drivers.CommonStorageDriverConfig
+	if err = json.Unmarshal([]byte(configJSON), &commonConfig); err != nil {
+		return fmt.Errorf("could not parse the storage backend config file; %v", err)
+	}
+	secretName, err := drivers.GetCredentialsSecretName(&commonConfig)
+	if err != nil {
+		return err
+	} else if secretName == "" {
+		return fmt.Errorf("the backend config %s does not reference a credentials secret", configPath)
+	}
+
+	secret, err := client.GetSecret(secretName)
+	if err != nil {
+		return err
+	}
+	newCredentials := map[string]string{
+		drivers.CredentialsKeyUsername: credentialsUsername,
+		drivers.CredentialsKeyPassword: credentialsPassword,
+	}
+	if newCredentials[drivers.CredentialsKeyUsername] == "" {
+		newCredentials[drivers.CredentialsKeyUsername] = strings.TrimSpace(
+			string(secret.Data[drivers.CredentialsKeyUsername]))
+	}
+
+	// Ensure the storage backend accepts the new credentials
+	if csi {
+		tridentconfig.CurrentDriverContext = tridentconfig.ContextCSI
+	} else {
+		tridentconfig.CurrentDriverContext = tridentconfig.ContextKubernetes
+	}
+	factory.CredentialsResolver = func(string) (map[string]string, error) {
+		return newCredentials, nil
+	}
+	backend, err := factory.NewStorageBackendForConfig(configJSON)
+	if err != nil {
+		return fmt.Errorf("the storage backend did not accept the new credentials; %v", err)
+	}
+	backendName := backend.Name
+	backend.Terminate()
+
+	// Ensure Trident is running and manages the backend
+	pod, err := client.GetPodByLabel(appLabel, false)
+	if err != nil {
+		return fmt.Errorf("could not find the Trident pod; %v", err)
+	}
+	TridentPodName = pod.Name
+	if _, err = getTridentBackend(backendName); err != nil {
+		return err
+	}
+
+	logFields := log.Fields{
+		"backend":   backendName,
+		"secret":    secretName,
+		"pod":       pod.Name,
+		"namespace": TridentPodNamespace,
+	}
+
+	if dryRun {
+		log.WithFields(logFields).Info("Dry run completed, no problems found.")
+		return nil
+	}
+
+	if !assumeYes && !confirmAction(fmt.Sprintf("Update the credentials of backend %s?", backendName)) {
+		return errors.New("update was not confirmed")
+	}
+
+	// Update the Kubernetes secret
+	secret.APIVersion, secret.Kind = "v1", "Secret"
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	for key, value := range newCredentials {
+		secret.Data[key] = []byte(value)
+	}
+	secretJSON, err := json.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	if err = client.ReplaceObjectByYAML(string(secretJSON)); err != nil {
+		return fmt.Errorf("could not update credentials secret %s; %v", secretName, err)
+	}
+	log.WithFields(logFields).Info("Updated credentials secret.")
+
+	// Reinitialize the backend once the Trident pod sees the new credentials
+	if err = waitForMountedCredentials(secretName, newCredentials); err != nil {
+		return err
+	}
+	cliCommand := []string{"tridentctl", "-s", PodServer, "update", "backend", backendName,
+		"-o", "name", "--base64", base64.StdEncoding.EncodeToString([]byte(configJSON))}
+	if output, err := client.Exec(TridentPodName, tridentContainerName, cliCommand); err != nil {
+		return fmt.Errorf("could not update backend %s with the new credentials; %v; %s",
+			backendName, err, strings.TrimSpace(string(output)))
+	}
+
+	if err = waitForBackendOnline(backendName); err != nil {
+		return err
+	}
+
+	log.WithFields(logFields).Info("Backend credentials updated.")
+	return nil
+}
+
+// readCredentialsPassword reads the new password from the --password-file, or from stdin, so it
+// doesn't show up in the process list or shell history.  A trailing newline isn't part of it.
+func readCredentialsPassword() (string, error) {
+
+	var passwordBytes []byte
+	var err error
+
+	switch credentialsPasswordFile {
+	case "":
+		return "", errors.New("--password-file must be specified")
+	case "-":
+		// The confirmation prompt can't read stdin once the password has been read from it
+		if !assumeYes && !dryRun {
+			return "", errors.New("--yes or --dry-run must be specified to read the password from stdin")
+		}
+		passwordBytes, err = ioutil.ReadAll(os.Stdin)
+	default:
+		passwordBytes, err = ioutil.ReadFile(credentialsPasswordFile)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read the new password; %v", err)
+	}
+
+	password := strings.TrimRight(string(passwordBytes), "\r\n")
+	if password == "" {
+		return "", errors.New("the new password is empty")
+	}
+	return password, nil
+}
+
+// getTridentBackend gets a backend through the CLI in the Trident pod.
+func getTridentBackend(backendName string) (*api.Backend, error) {
+
+	cliCommand := []string{"tridentctl", "-s", PodServer, "get", "backend", backendName, "-o", "json"}
+	output, err := client.Exec(TridentPodName, tridentContainerName, cliCommand)
+	if err != nil {
+		return nil, fmt.Errorf("could not get backend %s from Trident; %v; %s",
+			backendName, err, strings.TrimSpace(string(output)))
+	}
+
+	var backendsResponse api.MultipleBackendResponse
+	if err = json.Unmarshal(output, &backendsResponse); err != nil {
+		return nil, err
+	}
+	if len(backendsResponse.Items) != 1 {
+		return nil, fmt.Errorf("could not get backend %s from Trident", backendName)
+	}
+	return &backendsResponse.Items[0], nil
+}
+
+// waitForMountedCredentials waits for the kubelet to refresh the copy of a credentials secret
+// mounted in the Trident pod, which may take a minute or more.
+func waitForMountedCredentials(secretName string, credentials map[string]string) error {
+
+	checkMountedCredentials := func() error {
+		for key, value := range credentials {
+			cliCommand := []string{"cat", path.Join(tridentconfig.CredentialsSecretsPath, secretName, key)}
+			output, err := client.Exec(TridentPodName, tridentContainerName, cliCommand)
+			if err != nil {
+				return fmt.Errorf("%v; %s", err, strings.TrimSpace(string(output)))
+			}
+			if strings.TrimSpace(string(output)) != value {
+				return fmt.Errorf("mounted %s not yet updated", key)
+			}
+		}
+		return nil
+	}
+	mountNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+			"status":    err,
+		}).Debug("Mounted credentials secret not yet updated, waiting.")
+	}
+	mountBackoff := backoff.NewExponentialBackOff()
+	mountBackoff.MaxElapsedTime = k8sTimeout
+
+	log.Info("Waiting for the Trident pod to see the updated credentials secret.")
+
+	if err := backoff.RetryNotify(checkMountedCredentials, mountBackoff, mountNotify); err != nil {
+		return fmt.Errorf("the Trident pod did not see the updated credentials secret %s after %3.2f "+
+			"seconds; %v. If the secret isn't mounted in the Trident pod, reinstall Trident with "+
+			"'--credentials-secret %s'", secretName, k8sTimeout.Seconds(), err, secretName)
+	}
+
+	return nil
+}
+
+// waitForBackendOnline waits for Trident to report that a backend is online.
+func waitForBackendOnline(backendName string) error {
+
+	checkBackendOnline := func() error {
+		backend, err := getTridentBackend(backendName)
+		if err != nil {
+			return err
+		}
+		if !backend.Online {
+			return errors.New("backend offline")
+		}
+		return nil
+	}
+	onlineNotify := func(err error, duration time.Duration) {
+		log.WithFields(log.Fields{
+			"increment": duration,
+			"status":    err,
+		}).Debug("Backend not yet online, waiting.")
+	}
+	onlineBackoff := backoff.NewExponentialBackOff()
+	onlineBackoff.MaxElapsedTime = k8sTimeout
+
+	if err := backoff.RetryNotify(checkBackendOnline, onlineBackoff, onlineNotify); err != nil {
+		return fmt.Errorf("backend %s was not online after %3.2f seconds; %v; use 'tridentctl logs' "+
+			"to learn more", backendName, k8sTimeout.Seconds(), err)
+	}
+
+	log.WithField("backend", backendName).Info("Backend is online.")
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	RootCmd.AddCommand(validateCmd)
+
+	// Validate accepts every install flag, so the checks see exactly what an install would.
+	// The install flags are registered by install.go's init, which runs before this one.
+	validateCmd.Flags().AddFlagSet(installCmd.Flags())
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Run the Trident install pre-checks without installing",
+	Long: "Run every check that 'tridentctl install --dry-run' runs, with the same flags, and write " +
+		"a report of the checks that passed, any warnings, and the check that failed, if any. The " +
+		"report is JSON unless '-o yaml' is specified, and the command exits with a nonzero status " +
+		"if any check failed.",
+	Run: func(cmd *cobra.Command, args []string) {
+		report := validateInstallation(cmd.Flags())
+		if OutputFormat == FormatYAML {
+			WriteYAML(report)
+		} else {
+			WriteJSON(report)
+		}
+		if !report.Succeeded {
+			os.Exit(1)
+		}
+	},
+}
+
+// validateInstallation runs the install pre-checks with the specified install flags and returns
+// their report.  No changes are made to the cluster.
+func validateInstallation(flags *pflag.FlagSet) *preCheckReport {
+
+	dryRun = true
+
+	profileFlags, err := applyInstallProfile(flags, installProfileName)
+
+	// Keep stdout clear for the report, and collect the warnings for it
+	initInstallerLogging()
+	log.SetOutput(os.Stderr)
+	log.AddHook(&preCheckWarningHook{})
+
+	if err == nil && installProfileName != "" {
+		log.WithFields(log.Fields{
+			"profile": installProfileName,
+			"flags":   strings.Join(profileFlags, " "),
+		}).Info("Applied install profile.")
+	}
+	if err == nil && isFleetInstall() {
+		err = errors.New("validate checks one cluster; use 'tridentctl install --dry-run' with " +
+			"--contexts or --contexts-file to check several")
+	}
+	if err == nil && (generateYAML || bundlePath != "" || diffLive) {
+		err = errors.New("validate does not generate YAML or compare objects; " +
+			"use 'tridentctl install' for that")
+	}
+	argumentsRejected := err != nil
+	if err == nil {
+		err = discoverInstallationEnvironment()
+	}
+	if err == nil {
+		preCheckPassed("environment", "Kubernetes "+getKubernetesVersion().String())
+		volumeAccessModeSpecified = flags.Changed("volume-access-mode")
+		processInstallationArguments()
+		err = validateInstallationArguments()
+		argumentsRejected = err != nil
+	}
+	if err == nil {
+		preCheckPassed("arguments", "")
+		err = installTrident()
+	}
+
+	if err != nil {
+		log.Errorf("Pre-checks failed; %v", err)
+	} else {
+		log.Info("All pre-checks passed.")
+	}
+
+	// Rejected arguments are reported on stdout, but no report file is written for them
+	if !argumentsRejected {
+		writeDryRunReport(err, flags)
+	}
+
+	return getPreCheckReport(err, flags)
+}
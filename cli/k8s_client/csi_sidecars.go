@@ -0,0 +1,124 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package k8s_client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/netapp/trident/utils"
+)
+
+const (
+	CSIAttacher      = "csi-attacher"
+	CSIProvisioner   = "csi-provisioner"
+	CSIRegistrar     = "driver-registrar"
+	CSILivenessProbe = "liveness-probe"
+
+	DefaultCSIAttacherImage      = "quay.io/k8scsi/csi-attacher:v0.2.0"
+	DefaultCSIProvisionerImage   = "quay.io/k8scsi/csi-provisioner:v0.2.1"
+	DefaultCSIRegistrarImage     = "quay.io/k8scsi/driver-registrar:v0.2.0"
+	DefaultCSILivenessProbeImage = "quay.io/k8scsi/livenessprobe:v0.4.1"
+
+	// CSILivenessProbePort is the port of the liveness probe sidecar's health endpoint.  The node
+	// pods use the host network, so this avoids 9808, the default of other CSI drivers' probes.
+	CSILivenessProbePort = 9819
+)
+
+// csiSidecarRelease describes the Kubernetes versions with which a minor release of a CSI
+// sidecar works, along with the image to recommend for those Kubernetes versions.
+type csiSidecarRelease struct {
+	version       string
+	minKubernetes string
+	maxKubernetes string // Empty if there is no known maximum
+	image         string
+}
+
+// csiSidecarCompatibility is the version matrix of the CSI sidecars Trident deploys.  Each
+// sidecar release implements one version of the CSI spec, which in turn is only supported
+// by a range of Kubernetes releases.
+var csiSidecarCompatibility = map[string][]csiSidecarRelease{
+	CSIAttacher: {
+		{"v0.2", "v1.9", "v1.10", DefaultCSIAttacherImage},
+		{"v0.3", "v1.10", "v1.16", "quay.io/k8scsi/csi-attacher:v0.3.0"},
+		{"v0.4", "v1.10", "v1.16", "quay.io/k8scsi/csi-attacher:v0.4.2"},
+		{"v1.0", "v1.13", "", "quay.io/k8scsi/csi-attacher:v1.0.1"},
+	},
+	CSIProvisioner: {
+		{"v0.2", "v1.9", "v1.10", DefaultCSIProvisionerImage},
+		{"v0.3", "v1.10", "v1.16", "quay.io/k8scsi/csi-provisioner:v0.3.1"},
+		{"v0.4", "v1.10", "v1.16", "quay.io/k8scsi/csi-provisioner:v0.4.2"},
+		{"v1.0", "v1.13", "", "quay.io/k8scsi/csi-provisioner:v1.0.1"},
+	},
+	CSIRegistrar: {
+		{"v0.2", "v1.9", "v1.10", DefaultCSIRegistrarImage},
+		{"v0.3", "v1.10", "v1.12", "quay.io/k8scsi/driver-registrar:v0.3.0"},
+		{"v0.4", "v1.10", "v1.12", "quay.io/k8scsi/driver-registrar:v0.4.2"},
+	},
+	CSILivenessProbe: {
+		{"v0.4", "v1.10", "v1.12", DefaultCSILivenessProbeImage},
+		{"v1.0", "v1.13", "", "quay.io/k8scsi/livenessprobe:v1.0.2"},
+	},
+}
+
+// includes returns whether a Kubernetes version is within the range supported by a sidecar release.
+func (r csiSidecarRelease) includes(k8sVersion *utils.Version) bool {
+	minorVersion := k8sVersion.ToMajorMinorVersion()
+	if !minorVersion.AtLeast(utils.MustParseGeneric(r.minKubernetes)) {
+		return false
+	}
+	return r.maxKubernetes == "" || !utils.MustParseGeneric(r.maxKubernetes).LessThan(minorVersion)
+}
+
+// CheckCSISidecarImage checks the version tag of a CSI sidecar image against the Kubernetes
+// version.  It returns false if the sidecar version isn't in the compatibility matrix, in which
+// case nothing is known about its compatibility, and an error if the sidecar is known not to
+// work with the Kubernetes version.
+func CheckCSISidecarImage(sidecar, image string, k8sVersion *utils.Version) (bool, error) {
+
+	releases, ok := csiSidecarCompatibility[sidecar]
+	if !ok {
+		return false, nil
+	}
+
+	// Parse the version from the image tag
+	tagIndex := strings.LastIndex(image, ":")
+	if tagIndex < 0 || strings.Contains(image[tagIndex:], "/") {
+		return false, nil
+	}
+	sidecarVersion, err := utils.ParseGeneric(image[tagIndex+1:])
+	if err != nil {
+		return false, nil
+	}
+
+	sidecarMinorVersion := "v" + sidecarVersion.ToMajorMinorVersion().ShortString()
+
+	for _, release := range releases {
+		if release.version != sidecarMinorVersion {
+			continue
+		}
+		if release.includes(k8sVersion) {
+			return true, nil
+		}
+
+		err = fmt.Errorf("%s %s does not support Kubernetes %s", sidecar, release.version,
+			k8sVersion.ToMajorMinorVersion().ShortString())
+		if suggestion := getCSISidecarImage(sidecar, k8sVersion); suggestion != "" {
+			err = fmt.Errorf("%v; use %s instead", err, suggestion)
+		}
+		return true, err
+	}
+
+	return false, nil
+}
+
+// getCSISidecarImage returns the recommended image of a CSI sidecar for a Kubernetes version,
+// or an empty string if no known sidecar release supports that version.
+func getCSISidecarImage(sidecar string, k8sVersion *utils.Version) string {
+	for _, release := range csiSidecarCompatibility[sidecar] {
+		if release.includes(k8sVersion) {
+			return release.image
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package k8s_client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// startHeaderProxy starts a reverse proxy on the loopback interface that forwards every request
+// to the configured API server after adding the configured headers.  The CLI reaches the proxy
+// over plain HTTP, so the proxy itself connects to the API server with the CA bundle, client
+// certificate, and credentials of the kubeconfig.  The proxy runs for the life of the process,
+// and its URL is returned so the CLI may be pointed at it.
+func startHeaderProxy(config KubectlConfig) (string, error) {
+
+	server := config.Server
+
+	target, err := url.Parse(server)
+	if err != nil {
+		return "", fmt.Errorf("invalid API server URL %s; %v", server, err)
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return "", fmt.Errorf("invalid API server URL %s; a scheme and host are required", server)
+	}
+
+	transport, err := getProxyTransport(config)
+	if err != nil {
+		return "", err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+		for name, value := range config.Headers {
+			req.Header.Set(name, value)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	go func() {
+		if err := http.Serve(listener, proxy); err != nil {
+			log.WithField("server", server).Errorf("API server proxy stopped; %v", err)
+		}
+	}()
+
+	return "http://" + listener.Addr().String(), nil
+}
+
+// getProxyTransport returns a transport that connects to the configured API server the way the CLI
+// would, using the TLS settings and credentials of the configured kubeconfig and context.
+func getProxyTransport(config KubectlConfig) (http.RoundTripper, error) {
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if config.Kubeconfig != "" {
+		loadingRules.ExplicitPath = config.Kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: config.Context,
+		ClusterInfo:    clientcmdapi.Cluster{Server: config.Server},
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not read the kubeconfig; %v", err)
+	}
+
+	transport, err := rest.TransportFor(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure the connection to the API server; %v", err)
+	}
+	return transport, nil
+}
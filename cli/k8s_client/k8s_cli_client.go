@@ -2,6 +2,7 @@ package k8s_client
 
 import (
 	"bufio"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +15,10 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	tridentconfig "github.com/netapp/trident/config"
 	"github.com/netapp/trident/utils"
@@ -27,16 +32,27 @@ const (
 
 	FlavorKubernetes OrchestratorFlavor = "k8s"
 	FlavorOpenShift  OrchestratorFlavor = "openshift"
+
+	// OpenShiftSCCResource is the resource name of OpenShift security context constraints
+	OpenShiftSCCResource = "securitycontextconstraints"
+	// OpenShiftSCCName is the security context constraint that the Trident service account is added to
+	OpenShiftSCCName = "anyuid"
 )
 
 type Interface interface {
 	Version() *utils.Version
+	RefreshVersion() (*utils.Version, error)
 	Flavor() OrchestratorFlavor
 	CLI() string
+	GetAPIServerURL() (string, error)
+	GetAPIServerCA() ([]byte, error)
+	GetDefaultTokenAudiences() ([]string, error)
 	Namespace() string
 	SetNamespace(namespace string)
 	GetCurrentNamespace() (string, error)
 	Exec(pod, container string, commandArgs []string) ([]byte, error)
+	CopyToPod(pod, container, localPath, podPath string) error
+	GetPodLogs(pod, container string) ([]byte, error)
 	GetDeploymentByLabel(label string, allNamespaces bool) (*v1beta1.Deployment, error)
 	GetDeploymentsByLabel(label string, allNamespaces bool) ([]v1beta1.Deployment, error)
 	CheckDeploymentExistsByLabel(label string, allNamespaces bool) (bool, string, error)
@@ -54,6 +70,7 @@ type Interface interface {
 	CheckDaemonSetExistsByLabel(label string, allNamespaces bool) (bool, string, error)
 	DeleteDaemonSetByLabel(label string) error
 	GetPodByLabel(label string, allNamespaces bool) (*v1.Pod, error)
+	GetPodsByLabel(label string, allNamespaces bool) ([]v1.Pod, error)
 	GetPVC(pvcName string) (*v1.PersistentVolumeClaim, error)
 	GetPVCByLabel(label string, allNamespaces bool) (*v1.PersistentVolumeClaim, error)
 	CheckPVCExists(pvcName string) (bool, error)
@@ -61,16 +78,36 @@ type Interface interface {
 	DeletePVCByLabel(label string) error
 	GetPV(pvName string) (*v1.PersistentVolume, error)
 	GetPVByLabel(label string) (*v1.PersistentVolume, error)
+	GetPVs() ([]v1.PersistentVolume, error)
 	CheckPVExists(pvName string) (bool, error)
 	DeletePVByLabel(label string) error
 	CheckSecretExists(secretName string) (bool, error)
+	GetSecret(secretName string) (*v1.Secret, error)
+	GetSecretsByLabel(label string) ([]v1.Secret, error)
 	CheckNamespaceExists(namespace string) (bool, error)
+	GetNamespace(namespace string) (*v1.Namespace, error)
+	GetObjectJSON(typeName, objectName string) ([]byte, error)
+	GetOwnerReferences(typeName, objectName, namespace string) ([]metav1.OwnerReference, error)
+	GetClusterRoleBinding(name string) (*rbacv1.ClusterRoleBinding, error)
+	GetClusterRoles() ([]rbacv1.ClusterRole, error)
+	GetClusterRole(name string) (*rbacv1.ClusterRole, error)
+	GetResourceQuotas(namespace string) ([]v1.ResourceQuota, error)
+	GetNodes() ([]v1.Node, error)
+	AddNodeLabel(nodeName, key, value string) error
+	RemoveNodeLabel(nodeName, key string) error
+	GetStorageClasses() ([]storagev1.StorageClass, error)
+	ListVolumeAttachmentsByDriver(driverName string) ([]storagev1beta1.VolumeAttachment, error)
+	GetEventsForObject(kind, name string) ([]v1.Event, error)
 	CreateObjectByFile(filePath string) error
 	CreateObjectByName(typeName, objectName string, additionalArgs []string) error
 	CreateObjectByYAML(yaml string) error
+	ReplaceObjectByYAML(yaml string) error
 	DeleteObjectByFile(filePath string, ignoreNotFound bool) error
 	DeleteObjectByName(typeName, objectName string, ignoreNotFound bool) error
 	DeleteObjectByYAML(yaml string, ignoreNotFound bool) error
+	ScaleObject(typeName, objectName string, replicas int32) error
+	AnnotateObject(typeName, objectName, key, value string) error
+	RemoveObjectAnnotation(typeName, objectName, key string) error
 	AddTridentUserToOpenShiftSCC() error
 	RemoveTridentUserFromOpenShiftSCC() error
 	ReadDeploymentFromFile(filePath string) (*v1beta1.Deployment, error)
@@ -81,16 +118,44 @@ type Interface interface {
 }
 
 type KubectlClient struct {
-	cli       string
-	flavor    OrchestratorFlavor
-	version   *utils.Version
-	namespace string
+	cli        string
+	globalArgs []string
+	flavor     OrchestratorFlavor
+	version    *utils.Version
+	namespace  string
+
+	// sccResource is the discovered resource name of OpenShift security context constraints
+	sccResource string
+}
+
+// KubectlConfig describes how to reach the Kubernetes API server, if not by the CLI's defaults.
+type KubectlConfig struct {
+	// Server is the URL of the API server, or of a proxy in front of it
+	Server string
+	// Kubeconfig is the path to a kubeconfig file
+	Kubeconfig string
+	// Context is the kubeconfig context to use
+	Context string
+	// Headers are added to every API request, such as for an authenticating proxy.  Server
+	// must be specified with Headers.
+	Headers map[string]string
 }
 
 func NewKubectlClient() (Interface, error) {
+	return NewKubectlClientForConfig(KubectlConfig{})
+}
+
+// NewKubectlClientForConfig returns a CLI-based client that reaches the API server as described
+// by the specified config.  Every CLI invocation made by the client includes the config.
+func NewKubectlClientForConfig(config KubectlConfig) (Interface, error) {
+
+	globalArgs, err := getGlobalArgs(config)
+	if err != nil {
+		return nil, err
+	}
 
 	// Discover which CLI to use (kubectl or oc)
-	cli, err := discoverKubernetesCLI()
+	cli, err := discoverKubernetesCLI(globalArgs)
 	if err != nil {
 		return nil, err
 	}
@@ -104,10 +169,10 @@ func NewKubectlClient() (Interface, error) {
 		fallthrough
 	case CLIKubernetes:
 		flavor = FlavorKubernetes
-		version, err = discoverKubernetesServerVersion(cli)
+		version, err = discoverKubernetesServerVersion(cli, globalArgs)
 	case CLIOpenShift:
 		flavor = FlavorOpenShift
-		version, err = discoverOpenShiftServerVersion(cli)
+		version, err = discoverOpenShiftServerVersion(cli, globalArgs)
 	}
 	if err != nil {
 		return nil, err
@@ -115,23 +180,15 @@ func NewKubectlClient() (Interface, error) {
 
 	// Ensure the version is a supported one
 	minSupportedVersion := utils.MustParseSemantic(tridentconfig.KubernetesVersionMin)
-	maxSupportedVersion := utils.MustParseSemantic(tridentconfig.KubernetesVersionMax)
 	if !version.AtLeast(minSupportedVersion) {
 		return nil, fmt.Errorf("Trident requires Kubernetes %s or later", minSupportedVersion.ShortString())
 	}
-	mmVersion := version.ToMajorMinorVersion()
-	maxSupportedMMVersion := maxSupportedVersion.ToMajorMinorVersion()
-	if maxSupportedMMVersion.LessThan(mmVersion) {
-		log.WithFields(log.Fields{
-			"kubernetesVersion":   version.ShortString(),
-			"maxSupportedVersion": maxSupportedMMVersion.String(),
-		}).Warning("Trident has not been qualified with this version of Kubernetes.")
-	}
 
 	client := &KubectlClient{
-		cli:     cli,
-		flavor:  flavor,
-		version: version,
+		cli:        cli,
+		globalArgs: globalArgs,
+		flavor:     flavor,
+		version:    version,
 	}
 
 	// Get current namespace
@@ -151,16 +208,59 @@ func NewKubectlClient() (Interface, error) {
 	return client, nil
 }
 
-func discoverKubernetesCLI() (string, error) {
+// getGlobalArgs returns the CLI arguments that direct every invocation to the configured API server.
+func getGlobalArgs(config KubectlConfig) ([]string, error) {
+
+	globalArgs := make([]string, 0)
+
+	server := config.Server
+	if len(config.Headers) > 0 {
+		if server == "" {
+			return nil, errors.New("an API server URL is required with custom API request headers")
+		}
+
+		// The CLI can't add arbitrary headers, so route its requests through a local proxy that does
+		proxyURL, err := startHeaderProxy(config)
+		if err != nil {
+			return nil, fmt.Errorf("could not start API server proxy; %v", err)
+		}
+		log.WithFields(log.Fields{
+			"server": server,
+			"proxy":  proxyURL,
+		}).Debug("Started local API server proxy.")
+		server = proxyURL
+	}
+
+	if server != "" {
+		globalArgs = append(globalArgs, "--server="+server)
+	}
+	if config.Kubeconfig != "" {
+		globalArgs = append(globalArgs, "--kubeconfig="+config.Kubeconfig)
+	}
+	if config.Context != "" {
+		globalArgs = append(globalArgs, "--context="+config.Context)
+	}
+
+	return globalArgs, nil
+}
+
+// command returns a command that invokes the CLI with the specified arguments.
+func (c *KubectlClient) command(args ...string) *exec.Cmd {
+	return exec.Command(c.cli, append(append([]string{}, c.globalArgs...), args...)...)
+}
+
+func discoverKubernetesCLI(globalArgs []string) (string, error) {
+
+	versionArgs := append(append([]string{}, globalArgs...), "version")
 
 	// Try the OpenShift CLI first
-	_, err := exec.Command(CLIOpenShift, "version").CombinedOutput()
+	_, err := exec.Command(CLIOpenShift, versionArgs...).CombinedOutput()
 	if err == nil {
 		return CLIOpenShift, nil
 	}
 
 	// Fall back to the K8S CLI
-	_, err = exec.Command(CLIKubernetes, "version").CombinedOutput()
+	_, err = exec.Command(CLIKubernetes, versionArgs...).CombinedOutput()
 	if err == nil {
 		return CLIKubernetes, nil
 	}
@@ -168,11 +268,11 @@ func discoverKubernetesCLI() (string, error) {
 	return "", errors.New("could not find the Kubernetes CLI.")
 }
 
-func discoverKubernetesServerVersion(kubernetesCLI string) (*utils.Version, error) {
+func discoverKubernetesServerVersion(kubernetesCLI string, globalArgs []string) (*utils.Version, error) {
 
 	const k8SServerVersionPrefix = "Server Version: "
 
-	cmd := exec.Command(kubernetesCLI, "version", "--short")
+	cmd := exec.Command(kubernetesCLI, append(append([]string{}, globalArgs...), "version", "--short")...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -193,9 +293,9 @@ func discoverKubernetesServerVersion(kubernetesCLI string) (*utils.Version, erro
 	return nil, errors.New("could not get Kubernetes server version.")
 }
 
-func discoverOpenShiftServerVersion(kubernetesCLI string) (*utils.Version, error) {
+func discoverOpenShiftServerVersion(kubernetesCLI string, globalArgs []string) (*utils.Version, error) {
 
-	cmd := exec.Command(kubernetesCLI, "version")
+	cmd := exec.Command(kubernetesCLI, append(append([]string{}, globalArgs...), "version")...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -221,10 +321,146 @@ func discoverOpenShiftServerVersion(kubernetesCLI string) (*utils.Version, error
 	return nil, errors.New("could not get OpenShift server version.")
 }
 
+// GetAPIServerURL returns the API server URL of the current kubeconfig context.
+func (c *KubectlClient) GetAPIServerURL() (string, error) {
+
+	args := []string{"config", "view", "--minify", "-o", "jsonpath={.clusters[0].cluster.server}"}
+	out, err := c.command(args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not get the API server URL from the kubeconfig; %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GetAPIServerCA returns the API server CA bundle of the current kubeconfig context, which may
+// be embedded in the kubeconfig or in a file it refers to.  If the context specifies no CA,
+// nil is returned.
+func (c *KubectlClient) GetAPIServerCA() ([]byte, error) {
+
+	args := []string{"config", "view", "--raw", "--minify", "-o",
+		"jsonpath={.clusters[0].cluster.certificate-authority-data}"}
+	out, err := c.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the API server CA from the kubeconfig; %v", err)
+	}
+	if caData := strings.TrimSpace(string(out)); caData != "" {
+		return base64.StdEncoding.DecodeString(caData)
+	}
+
+	args = []string{"config", "view", "--raw", "--minify", "-o",
+		"jsonpath={.clusters[0].cluster.certificate-authority}"}
+	out, err = c.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the API server CA from the kubeconfig; %v", err)
+	}
+	if caFile := strings.TrimSpace(string(out)); caFile != "" {
+		return ioutil.ReadFile(caFile)
+	}
+
+	return nil, nil
+}
+
+// GetDefaultTokenAudiences returns the audiences of a service account token requested without
+// any, which are the audiences the API server accepts.  The token is requested for the default
+// service account of the default namespace, and it isn't stored anywhere.
+func (c *KubectlClient) GetDefaultTokenAudiences() ([]string, error) {
+
+	tokenRequest := `{"apiVersion":"authentication.k8s.io/v1","kind":"TokenRequest","spec":{"expirationSeconds":600}}`
+
+	args := []string{"create", "--raw", "/api/v1/namespaces/default/serviceaccounts/default/token", "-f", "-"}
+	cmd := c.command(args...)
+	cmd.Stdin = strings.NewReader(tokenRequest)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not request a service account token; %v", err)
+	}
+
+	var response struct {
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	if err = json.Unmarshal(out, &response); err != nil {
+		return nil, fmt.Errorf("could not parse the token request; %v", err)
+	}
+
+	// The audiences are in the claims, which are the second part of the token
+	tokenParts := strings.Split(response.Status.Token, ".")
+	if len(tokenParts) != 3 {
+		return nil, errors.New("the requested service account token is not a JWT")
+	}
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(tokenParts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode the token claims; %v", err)
+	}
+
+	// The audience claim may be a single string or a list
+	var claims struct {
+		Audience json.RawMessage `json:"aud"`
+	}
+	if err = json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, fmt.Errorf("could not parse the token claims; %v", err)
+	}
+	var audiences []string
+	if err = json.Unmarshal(claims.Audience, &audiences); err != nil {
+		var audience string
+		if err = json.Unmarshal(claims.Audience, &audience); err != nil {
+			return nil, fmt.Errorf("could not parse the token audience; %v", err)
+		}
+		audiences = []string{audience}
+	}
+	return audiences, nil
+}
+
+// CheckQualifiedVersion returns an error if Trident has not been qualified with a Kubernetes version,
+// which is newer than the supported ones.
+func CheckQualifiedVersion(version *utils.Version) error {
+
+	maxSupportedMMVersion := utils.MustParseSemantic(tridentconfig.KubernetesVersionMax).ToMajorMinorVersion()
+	if maxSupportedMMVersion.LessThan(version.ToMajorMinorVersion()) {
+		return fmt.Errorf("Trident has not been qualified with Kubernetes %s; the latest qualified version is %s",
+			version.ShortString(), maxSupportedMMVersion.String())
+	}
+	return nil
+}
+
+// CheckVersionSkew returns an error if the Kubernetes server changed to another major or minor
+// version, as when a cluster is upgraded, since decisions based on the earlier version may no
+// longer hold.  Patch releases don't change the APIs the installer depends on.
+func CheckVersionSkew(previous, current *utils.Version) error {
+
+	if previous.MajorVersion() != current.MajorVersion() || previous.MinorVersion() != current.MinorVersion() {
+		return fmt.Errorf("the Kubernetes version changed from %s to %s", previous.ShortString(),
+			current.ShortString())
+	}
+	return nil
+}
+
 func (c *KubectlClient) Version() *utils.Version {
 	return c.version
 }
 
+// RefreshVersion discovers the Kubernetes server version again, since it may change while the
+// cluster is upgraded, and returns it.  Version returns the refreshed version from then on.
+func (c *KubectlClient) RefreshVersion() (*utils.Version, error) {
+
+	var version *utils.Version
+	var err error
+
+	switch c.flavor {
+	case FlavorOpenShift:
+		version, err = discoverOpenShiftServerVersion(c.cli, c.globalArgs)
+	default:
+		version, err = discoverKubernetesServerVersion(c.cli, c.globalArgs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.version = version
+	return version, nil
+}
+
 func (c *KubectlClient) Flavor() OrchestratorFlavor {
 	return c.flavor
 }
@@ -244,7 +480,7 @@ func (c *KubectlClient) SetNamespace(namespace string) {
 func (c *KubectlClient) GetCurrentNamespace() (string, error) {
 
 	// Get current namespace from service account info
-	cmd := exec.Command(c.cli, "get", "serviceaccount", "default", "-o=json")
+	cmd := c.command("get", "serviceaccount", "default", "-o=json")
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return "", err
@@ -284,7 +520,39 @@ func (c *KubectlClient) Exec(pod, container string, commandArgs []string) ([]byt
 	log.Debugf("Invoking tunneled command: %s %v", c.cli, strings.Join(execCommand, " "))
 
 	// Invoke command inside the Trident pod
-	return exec.Command(c.cli, execCommand...).CombinedOutput()
+	return c.command(execCommand...).CombinedOutput()
+}
+
+// GetPodLogs returns the logs of a container of a pod in the client's namespace.  The container
+// may be omitted if the pod has only one.
+func (c *KubectlClient) GetPodLogs(pod, container string) ([]byte, error) {
+
+	args := []string{"logs", pod, "-n", c.namespace}
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	out, err := c.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the logs of pod %s; %v", pod, err)
+	}
+	return out, nil
+}
+
+// CopyToPod copies a local file into a container of a pod in the client's namespace.
+func (c *KubectlClient) CopyToPod(pod, container, localPath, podPath string) error {
+
+	args := []string{"cp", localPath, c.namespace + "/" + pod + ":" + podPath, "-c", container}
+	if out, err := c.command(args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v; %s", err, strings.TrimSpace(string(out)))
+	}
+
+	log.WithFields(log.Fields{
+		"pod":       pod,
+		"container": container,
+		"path":      podPath,
+	}).Debug("Copied file to pod.")
+
+	return nil
 }
 
 // GetDeploymentByLabel returns a deployment object matching the specified label if it is unique
@@ -314,7 +582,7 @@ func (c *KubectlClient) GetDeploymentsByLabel(label string, allNamespaces bool)
 	} else {
 		cmdArgs = append(cmdArgs, "--namespace", c.namespace)
 	}
-	cmd := exec.Command(c.cli, cmdArgs...)
+	cmd := c.command(cmdArgs...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -358,7 +626,7 @@ func (c *KubectlClient) CheckDeploymentExistsByLabel(label string, allNamespaces
 func (c *KubectlClient) DeleteDeploymentByLabel(label string) error {
 
 	cmdArgs := []string{"delete", "deployment", "-l", label, "--namespace", c.namespace}
-	_, err := exec.Command(c.cli, cmdArgs...).CombinedOutput()
+	_, err := c.command(cmdArgs...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -398,7 +666,7 @@ func (c *KubectlClient) GetServicesByLabel(label string, allNamespaces bool) ([]
 	} else {
 		cmdArgs = append(cmdArgs, "--namespace", c.namespace)
 	}
-	cmd := exec.Command(c.cli, cmdArgs...)
+	cmd := c.command(cmdArgs...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -442,7 +710,7 @@ func (c *KubectlClient) CheckServiceExistsByLabel(label string, allNamespaces bo
 func (c *KubectlClient) DeleteServiceByLabel(label string) error {
 
 	cmdArgs := []string{"delete", "service", "-l", label, "--namespace", c.namespace}
-	_, err := exec.Command(c.cli, cmdArgs...).CombinedOutput()
+	_, err := c.command(cmdArgs...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -482,7 +750,7 @@ func (c *KubectlClient) GetStatefulSetsByLabel(label string, allNamespaces bool)
 	} else {
 		cmdArgs = append(cmdArgs, "--namespace", c.namespace)
 	}
-	cmd := exec.Command(c.cli, cmdArgs...)
+	cmd := c.command(cmdArgs...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -526,7 +794,7 @@ func (c *KubectlClient) CheckStatefulSetExistsByLabel(label string, allNamespace
 func (c *KubectlClient) DeleteStatefulSetByLabel(label string) error {
 
 	cmdArgs := []string{"delete", "statefulset", "-l", label, "--namespace", c.namespace}
-	_, err := exec.Command(c.cli, cmdArgs...).CombinedOutput()
+	_, err := c.command(cmdArgs...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -566,7 +834,7 @@ func (c *KubectlClient) GetDaemonSetsByLabel(label string, allNamespaces bool) (
 	} else {
 		cmdArgs = append(cmdArgs, "--namespace", c.namespace)
 	}
-	cmd := exec.Command(c.cli, cmdArgs...)
+	cmd := c.command(cmdArgs...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -610,7 +878,7 @@ func (c *KubectlClient) CheckDaemonSetExistsByLabel(label string, allNamespaces
 func (c *KubectlClient) DeleteDaemonSetByLabel(label string) error {
 
 	cmdArgs := []string{"delete", "daemonset", "-l", label, "--namespace", c.namespace}
-	_, err := exec.Command(c.cli, cmdArgs...).CombinedOutput()
+	_, err := c.command(cmdArgs...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -626,6 +894,23 @@ func (c *KubectlClient) DeleteDaemonSetByLabel(label string) error {
 // GetPodByLabel returns a pod object matching the specified label
 func (c *KubectlClient) GetPodByLabel(label string, allNamespaces bool) (*v1.Pod, error) {
 
+	pods, err := c.GetPodsByLabel(label, allNamespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pods) == 1 {
+		return &pods[0], nil
+	} else if len(pods) > 1 {
+		return nil, fmt.Errorf("multiple pods have the label %s", label)
+	} else {
+		return nil, fmt.Errorf("no pods have the label %s", label)
+	}
+}
+
+// GetPodsByLabel returns all pod objects matching the specified label
+func (c *KubectlClient) GetPodsByLabel(label string, allNamespaces bool) ([]v1.Pod, error) {
+
 	// Get pod info
 	cmdArgs := []string{"get", "pod", "-l", label, "-o=json"}
 	if allNamespaces {
@@ -633,7 +918,7 @@ func (c *KubectlClient) GetPodByLabel(label string, allNamespaces bool) (*v1.Pod
 	} else {
 		cmdArgs = append(cmdArgs, "--namespace", c.namespace)
 	}
-	cmd := exec.Command(c.cli, cmdArgs...)
+	cmd := c.command(cmdArgs...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -650,13 +935,7 @@ func (c *KubectlClient) GetPodByLabel(label string, allNamespaces bool) (*v1.Pod
 		return nil, err
 	}
 
-	if len(podList.Items) == 1 {
-		return &podList.Items[0], nil
-	} else if len(podList.Items) > 1 {
-		return nil, fmt.Errorf("multiple pods have the label %s", label)
-	} else {
-		return nil, fmt.Errorf("no pods have the label %s", label)
-	}
+	return podList.Items, nil
 }
 
 func (c *KubectlClient) GetPVC(pvcName string) (*v1.PersistentVolumeClaim, error) {
@@ -664,7 +943,7 @@ func (c *KubectlClient) GetPVC(pvcName string) (*v1.PersistentVolumeClaim, error
 	var pvc v1.PersistentVolumeClaim
 
 	args := []string{"get", "pvc", pvcName, "--namespace", c.namespace, "-o=json"}
-	out, err := exec.Command(c.cli, args...).CombinedOutput()
+	out, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return nil, err
 	}
@@ -688,7 +967,7 @@ func (c *KubectlClient) GetPVCByLabel(label string, allNamespaces bool) (*v1.Per
 	} else {
 		cmdArgs = append(cmdArgs, "--namespace", c.namespace)
 	}
-	cmd := exec.Command(c.cli, cmdArgs...)
+	cmd := c.command(cmdArgs...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -718,7 +997,7 @@ func (c *KubectlClient) GetPVCByLabel(label string, allNamespaces bool) (*v1.Per
 // It only returns an error if the check failed, not if the PVC doesn't exist.
 func (c *KubectlClient) CheckPVCExists(pvcName string) (bool, error) {
 	args := []string{"get", "pvc", pvcName, "--namespace", c.namespace, "--ignore-not-found"}
-	out, err := exec.Command(c.cli, args...).CombinedOutput()
+	out, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return false, err
 	}
@@ -742,7 +1021,7 @@ func (c *KubectlClient) CheckPVCBound(pvcName string) (bool, error) {
 func (c *KubectlClient) DeletePVCByLabel(label string) error {
 
 	cmdArgs := []string{"delete", "pvc", "-l", label, "--namespace", c.namespace}
-	_, err := exec.Command(c.cli, cmdArgs...).CombinedOutput()
+	_, err := c.command(cmdArgs...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -760,7 +1039,7 @@ func (c *KubectlClient) GetPV(pvName string) (*v1.PersistentVolume, error) {
 	var pv v1.PersistentVolume
 
 	args := []string{"get", "pv", pvName, "-o=json"}
-	out, err := exec.Command(c.cli, args...).CombinedOutput()
+	out, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return nil, err
 	}
@@ -779,7 +1058,7 @@ func (c *KubectlClient) GetPVByLabel(label string) (*v1.PersistentVolume, error)
 
 	// Get PV info
 	cmdArgs := []string{"get", "pv", "-l", label, "-o=json"}
-	cmd := exec.Command(c.cli, cmdArgs...)
+	cmd := c.command(cmdArgs...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
@@ -805,11 +1084,35 @@ func (c *KubectlClient) GetPVByLabel(label string) (*v1.PersistentVolume, error)
 	}
 }
 
+// GetPVs returns all PVs in the cluster.
+func (c *KubectlClient) GetPVs() ([]v1.PersistentVolume, error) {
+
+	cmdArgs := []string{"get", "pv", "-o=json"}
+	cmd := c.command(cmdArgs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var pvList v1.PersistentVolumeList
+	if err := json.NewDecoder(stdout).Decode(&pvList); err != nil {
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return pvList.Items, nil
+}
+
 // CheckPVExists returns true if the specified PV exists, false otherwise.
 // It only returns an error if the check failed, not if the PV doesn't exist.
 func (c *KubectlClient) CheckPVExists(pvName string) (bool, error) {
 	args := []string{"get", "pv", pvName, "--ignore-not-found"}
-	out, err := exec.Command(c.cli, args...).CombinedOutput()
+	out, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return false, err
 	}
@@ -819,7 +1122,7 @@ func (c *KubectlClient) CheckPVExists(pvName string) (bool, error) {
 func (c *KubectlClient) DeletePVByLabel(label string) error {
 
 	cmdArgs := []string{"delete", "pv", "-l", label}
-	_, err := exec.Command(c.cli, cmdArgs...).CombinedOutput()
+	_, err := c.command(cmdArgs...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -833,24 +1136,345 @@ func (c *KubectlClient) DeletePVByLabel(label string) error {
 // It only returns an error if the check failed, not if the secret doesn't exist.
 func (c *KubectlClient) CheckSecretExists(secretName string) (bool, error) {
 	args := []string{"get", "secret", secretName, "--namespace", c.namespace, "--ignore-not-found"}
-	out, err := exec.Command(c.cli, args...).CombinedOutput()
+	out, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return false, err
 	}
 	return len(out) > 0, nil
 }
 
+// GetSecret returns the specified secret in the client's namespace.  The secret's data is
+// never logged.
+func (c *KubectlClient) GetSecret(secretName string) (*v1.Secret, error) {
+
+	var secret v1.Secret
+
+	args := []string{"get", "secret", secretName, "--namespace", c.namespace, "-o=json"}
+	out, err := c.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get secret %s; %v", secretName, err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("secret %s does not exist", secretName)
+	}
+
+	if err = json.Unmarshal(out, &secret); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// GetSecretsByLabel returns all secrets in the client's namespace matching the specified label.
+func (c *KubectlClient) GetSecretsByLabel(label string) ([]v1.Secret, error) {
+
+	cmdArgs := []string{"get", "secret", "-l", label, "--namespace", c.namespace, "-o=json"}
+	cmd := c.command(cmdArgs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var secretList v1.SecretList
+	if err := json.NewDecoder(stdout).Decode(&secretList); err != nil {
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return secretList.Items, nil
+}
+
 // CheckNamespaceExists returns true if the specified namespace exists, false otherwise.
 // It only returns an error if the check failed, not if the namespace doesn't exist.
 func (c *KubectlClient) CheckNamespaceExists(namespace string) (bool, error) {
 	args := []string{"get", "namespace", namespace, "--ignore-not-found"}
-	out, err := exec.Command(c.cli, args...).CombinedOutput()
+	out, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return false, err
 	}
 	return len(out) > 0, nil
 }
 
+// GetObjectJSON returns the JSON of the specified object in the client's namespace (or of a
+// cluster-scoped object), or nil if it doesn't exist.
+func (c *KubectlClient) GetObjectJSON(typeName, objectName string) ([]byte, error) {
+
+	args := []string{"get", typeName, objectName, "--namespace", c.namespace, "--ignore-not-found", "-o=json"}
+	out, err := c.command(args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// GetOwnerReferences returns the owner references of an object in the specified namespace, or
+// nil if the object doesn't exist.
+func (c *KubectlClient) GetOwnerReferences(
+	typeName, objectName, namespace string,
+) ([]metav1.OwnerReference, error) {
+
+	var object struct {
+		Metadata metav1.ObjectMeta `json:"metadata"`
+	}
+
+	args := []string{"get", typeName, objectName, "--namespace", namespace, "--ignore-not-found", "-o=json"}
+	out, err := c.command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get %s %s; %v", typeName, objectName, err)
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	if err = json.Unmarshal(out, &object); err != nil {
+		return nil, err
+	}
+	return object.Metadata.OwnerReferences, nil
+}
+
+// GetNamespace returns the specified namespace, or nil if it doesn't exist.
+func (c *KubectlClient) GetNamespace(namespace string) (*v1.Namespace, error) {
+
+	var ns v1.Namespace
+
+	args := []string{"get", "namespace", namespace, "--ignore-not-found", "-o=json"}
+	out, err := c.command(args...).CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	err = yaml.Unmarshal(out, &ns)
+	if err != nil {
+		return nil, err
+	}
+	return &ns, nil
+}
+
+// GetClusterRoleBinding returns the specified cluster role binding, or nil if it doesn't exist.
+func (c *KubectlClient) GetClusterRoleBinding(name string) (*rbacv1.ClusterRoleBinding, error) {
+
+	var binding rbacv1.ClusterRoleBinding
+
+	args := []string{"get", "clusterrolebinding", name, "--ignore-not-found", "-o=json"}
+	out, err := c.command(args...).CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	err = yaml.Unmarshal(out, &binding)
+	if err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// GetClusterRoles returns all cluster roles.
+func (c *KubectlClient) GetClusterRoles() ([]rbacv1.ClusterRole, error) {
+
+	cmdArgs := []string{"get", "clusterrole", "-o=json"}
+	cmd := c.command(cmdArgs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var clusterRoleList rbacv1.ClusterRoleList
+	if err := json.NewDecoder(stdout).Decode(&clusterRoleList); err != nil {
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return clusterRoleList.Items, nil
+}
+
+// GetClusterRole returns the specified cluster role, or nil if it doesn't exist.
+func (c *KubectlClient) GetClusterRole(name string) (*rbacv1.ClusterRole, error) {
+
+	var clusterRole rbacv1.ClusterRole
+
+	args := []string{"get", "clusterrole", name, "--ignore-not-found", "-o=json"}
+	out, err := c.command(args...).CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	err = yaml.Unmarshal(out, &clusterRole)
+	if err != nil {
+		return nil, err
+	}
+	return &clusterRole, nil
+}
+
+// GetResourceQuotas returns all resource quotas in the specified namespace.
+func (c *KubectlClient) GetResourceQuotas(namespace string) ([]v1.ResourceQuota, error) {
+
+	cmdArgs := []string{"get", "resourcequota", "--namespace", namespace, "-o=json"}
+	cmd := c.command(cmdArgs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var quotaList v1.ResourceQuotaList
+	if err := json.NewDecoder(stdout).Decode(&quotaList); err != nil {
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return quotaList.Items, nil
+}
+
+// GetStorageClasses returns all storage classes in the cluster.
+func (c *KubectlClient) GetStorageClasses() ([]storagev1.StorageClass, error) {
+
+	cmdArgs := []string{"get", "storageclass", "-o=json"}
+	cmd := c.command(cmdArgs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var storageClassList storagev1.StorageClassList
+	if err := json.NewDecoder(stdout).Decode(&storageClassList); err != nil {
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return storageClassList.Items, nil
+}
+
+// ListVolumeAttachmentsByDriver returns the volume attachments handled by the named CSI driver.
+func (c *KubectlClient) ListVolumeAttachmentsByDriver(driverName string) ([]storagev1beta1.VolumeAttachment, error) {
+
+	cmdArgs := []string{"get", "volumeattachment", "-o=json"}
+	cmd := c.command(cmdArgs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var attachmentList storagev1beta1.VolumeAttachmentList
+	if err := json.NewDecoder(stdout).Decode(&attachmentList); err != nil {
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	attachments := make([]storagev1beta1.VolumeAttachment, 0)
+	for _, attachment := range attachmentList.Items {
+		if attachment.Spec.Attacher == driverName {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+// GetNodes returns all nodes in the cluster.
+func (c *KubectlClient) GetNodes() ([]v1.Node, error) {
+
+	cmdArgs := []string{"get", "node", "-o=json"}
+	cmd := c.command(cmdArgs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var nodeList v1.NodeList
+	if err := json.NewDecoder(stdout).Decode(&nodeList); err != nil {
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return nodeList.Items, nil
+}
+
+// AddNodeLabel sets a label on a node, replacing any value the label already has.
+func (c *KubectlClient) AddNodeLabel(nodeName, key, value string) error {
+
+	args := []string{"label", "node", nodeName, key + "=" + value, "--overwrite"}
+	if out, err := c.command(args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not label node %s; %v; %s", nodeName, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// RemoveNodeLabel removes a label from a node.  Removing a label the node doesn't have succeeds.
+func (c *KubectlClient) RemoveNodeLabel(nodeName, key string) error {
+
+	args := []string{"label", "node", nodeName, key + "-"}
+	out, err := c.command(args...).CombinedOutput()
+	if err != nil && !strings.Contains(string(out), "not found") {
+		return fmt.Errorf("could not remove label %s from node %s; %v; %s", key, nodeName, err,
+			strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// GetEventsForObject returns the events in the current namespace that pertain to the specified object.
+func (c *KubectlClient) GetEventsForObject(kind, name string) ([]v1.Event, error) {
+
+	fieldSelector := fmt.Sprintf("involvedObject.kind=%s,involvedObject.name=%s", kind, name)
+	cmdArgs := []string{"get", "event", "--namespace", c.namespace, "--field-selector", fieldSelector, "-o=json"}
+	cmd := c.command(cmdArgs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var eventList v1.EventList
+	if err := json.NewDecoder(stdout).Decode(&eventList); err != nil {
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+
+	return eventList.Items, nil
+}
+
 // CreateObjectByFile creates an object from a YAML/JSON file at the specified path.
 func (c *KubectlClient) CreateObjectByFile(filePath string) error {
 
@@ -860,7 +1484,7 @@ func (c *KubectlClient) CreateObjectByFile(filePath string) error {
 		"-f",
 		filePath,
 	}
-	_, err := exec.Command(c.cli, args...).CombinedOutput()
+	_, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -882,7 +1506,7 @@ func (c *KubectlClient) CreateObjectByName(typeName, objectName string, addition
 		args = append(args, additionalArgs...)
 	}
 
-	_, err := exec.Command(c.cli, args...).CombinedOutput()
+	_, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -895,7 +1519,7 @@ func (c *KubectlClient) CreateObjectByName(typeName, objectName string, addition
 func (c *KubectlClient) CreateObjectByYAML(yaml string) error {
 
 	args := []string{fmt.Sprintf("--namespace=%s", c.namespace), "create", "-f", "-"}
-	cmd := exec.Command(c.cli, args...)
+	cmd := c.command(args...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return err
@@ -916,6 +1540,30 @@ func (c *KubectlClient) CreateObjectByYAML(yaml string) error {
 	return nil
 }
 
+func (c *KubectlClient) ReplaceObjectByYAML(yaml string) error {
+
+	args := []string{fmt.Sprintf("--namespace=%s", c.namespace), "replace", "-f", "-"}
+	cmd := c.command(args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer stdin.Close()
+		stdin.Write([]byte(yaml))
+	}()
+
+	_, err = cmd.CombinedOutput()
+	if err != nil {
+		return err
+	}
+
+	log.Debug("Replaced Kubernetes object by YAML.")
+
+	return nil
+}
+
 func (c *KubectlClient) DeleteObjectByFile(filePath string, ignoreNotFound bool) error {
 
 	args := []string{
@@ -925,7 +1573,7 @@ func (c *KubectlClient) DeleteObjectByFile(filePath string, ignoreNotFound bool)
 		"-f",
 		filePath,
 	}
-	_, err := exec.Command(c.cli, args...).CombinedOutput()
+	_, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -944,7 +1592,7 @@ func (c *KubectlClient) DeleteObjectByName(typeName, objectName string, ignoreNo
 		typeName,
 		objectName,
 	}
-	_, err := exec.Command(c.cli, args...).CombinedOutput()
+	_, err := c.command(args...).CombinedOutput()
 	if err != nil {
 		return err
 	}
@@ -954,6 +1602,76 @@ func (c *KubectlClient) DeleteObjectByName(typeName, objectName string, ignoreNo
 	return nil
 }
 
+// ScaleObject sets the replica count of a deployment or statefulset.
+func (c *KubectlClient) ScaleObject(typeName, objectName string, replicas int32) error {
+
+	args := []string{
+		fmt.Sprintf("--namespace=%s", c.namespace),
+		"scale",
+		typeName,
+		objectName,
+		fmt.Sprintf("--replicas=%d", replicas),
+	}
+	out, err := c.command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s; %v", string(out), err)
+	}
+
+	log.WithFields(log.Fields{
+		typeName:   objectName,
+		"replicas": replicas,
+	}).Debug("Scaled Kubernetes object.")
+
+	return nil
+}
+
+// AnnotateObject sets an annotation on a Kubernetes object, replacing any existing value.
+func (c *KubectlClient) AnnotateObject(typeName, objectName, key, value string) error {
+
+	args := []string{
+		fmt.Sprintf("--namespace=%s", c.namespace),
+		"annotate",
+		"--overwrite",
+		typeName,
+		objectName,
+		fmt.Sprintf("%s=%s", key, value),
+	}
+	out, err := c.command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s; %v", string(out), err)
+	}
+
+	log.WithFields(log.Fields{
+		typeName: objectName,
+		key:      value,
+	}).Debug("Annotated Kubernetes object.")
+
+	return nil
+}
+
+// RemoveObjectAnnotation removes an annotation from a Kubernetes object, if present.
+func (c *KubectlClient) RemoveObjectAnnotation(typeName, objectName, key string) error {
+
+	args := []string{
+		fmt.Sprintf("--namespace=%s", c.namespace),
+		"annotate",
+		typeName,
+		objectName,
+		key + "-",
+	}
+	out, err := c.command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s; %v", string(out), err)
+	}
+
+	log.WithFields(log.Fields{
+		typeName:     objectName,
+		"annotation": key,
+	}).Debug("Removed annotation from Kubernetes object.")
+
+	return nil
+}
+
 func (c *KubectlClient) DeleteObjectByYAML(yaml string, ignoreNotFound bool) error {
 
 	args := []string{
@@ -963,7 +1681,7 @@ func (c *KubectlClient) DeleteObjectByYAML(yaml string, ignoreNotFound bool) err
 		"-f",
 		"-",
 	}
-	cmd := exec.Command(c.cli, args...)
+	cmd := c.command(args...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return err
@@ -984,49 +1702,173 @@ func (c *KubectlClient) DeleteObjectByYAML(yaml string, ignoreNotFound bool) err
 	return nil
 }
 
-func (c *KubectlClient) AddTridentUserToOpenShiftSCC() error {
+// discoverOpenShiftSCCResource returns the fully qualified name of the security context constraint
+// resource, which the CLI accepts in place of a plain resource name.  Some OpenShift-derived
+// distributions serve SCCs from a different API group or version than stock OpenShift, so the
+// API server's discovery endpoints are searched for the resource instead of assuming its location.
+func (c *KubectlClient) discoverOpenShiftSCCResource() (string, error) {
 
-	if c.flavor != FlavorOpenShift {
-		return errors.New("The current client context is not OpenShift.")
+	if c.sccResource != "" {
+		return c.sccResource, nil
 	}
 
-	// This command appears to be idempotent, so no need to call isTridentUserInOpenShiftSCC() first.
-	args := []string{
-		fmt.Sprintf("--namespace=%s", c.namespace),
-		"adm",
-		"policy",
-		"add-scc-to-user",
-		"anyuid",
-		"-z",
-		"trident",
+	// Search the API groups, trying each group's preferred version first
+	out, err := c.command("get", "--raw", "/apis").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not discover the API groups; %v", err)
 	}
-	_, err := exec.Command(c.cli, args...).CombinedOutput()
+	var groupList metav1.APIGroupList
+	if err = json.Unmarshal(out, &groupList); err != nil {
+		return "", fmt.Errorf("could not parse the API groups; %v", err)
+	}
+	for _, group := range groupList.Groups {
+		versions := []string{group.PreferredVersion.Version}
+		for _, version := range group.Versions {
+			if version.Version != group.PreferredVersion.Version {
+				versions = append(versions, version.Version)
+			}
+		}
+		for _, version := range versions {
+			found, err := c.servesResource("/apis/"+group.Name+"/"+version, OpenShiftSCCResource)
+			if err != nil {
+				return "", err
+			} else if found {
+				c.sccResource = fmt.Sprintf("%s.%s.%s", OpenShiftSCCResource, version, group.Name)
+				break
+			}
+		}
+		if c.sccResource != "" {
+			break
+		}
+	}
+
+	// Older OpenShift releases serve SCCs from the legacy core API group
+	if c.sccResource == "" {
+		found, err := c.servesResource("/api/v1", OpenShiftSCCResource)
+		if err != nil {
+			return "", err
+		} else if found {
+			c.sccResource = OpenShiftSCCResource
+		}
+	}
+
+	if c.sccResource == "" {
+		return "", fmt.Errorf("the cluster appears to be OpenShift, but the API server does not serve "+
+			"%s in any API group", OpenShiftSCCResource)
+	}
+
+	log.WithField("resource", c.sccResource).Debug("Discovered security context constraint resource.")
+
+	return c.sccResource, nil
+}
+
+// servesResource returns whether the API server serves a resource at the specified
+// group version discovery path, such as /apis/apps/v1.
+func (c *KubectlClient) servesResource(groupVersionPath, resource string) (bool, error) {
+
+	out, err := c.command("get", "--raw", groupVersionPath).Output()
 	if err != nil {
-		return err
+		// A group version that can't be discovered can't serve the resource either
+		log.WithFields(log.Fields{
+			"path":  groupVersionPath,
+			"error": err,
+		}).Debug("Could not discover API resources.")
+		return false, nil
+	}
+	var resourceList metav1.APIResourceList
+	if err = json.Unmarshal(out, &resourceList); err != nil {
+		return false, fmt.Errorf("could not parse the API resources at %s; %v", groupVersionPath, err)
+	}
+	for _, apiResource := range resourceList.APIResources {
+		if apiResource.Name == resource {
+			return true, nil
+		}
 	}
-	return nil
+	return false, nil
 }
 
-func (c *KubectlClient) RemoveTridentUserFromOpenShiftSCC() error {
+// getOpenShiftSCCUsers returns the discovered SCC resource and the users of the SCC
+// that Trident is added to.
+func (c *KubectlClient) getOpenShiftSCCUsers() (string, []string, error) {
 
 	if c.flavor != FlavorOpenShift {
-		return errors.New("The current client context is not OpenShift.")
+		return "", nil, errors.New("The current client context is not OpenShift.")
 	}
 
-	// This command appears to be idempotent, so no need to call isTridentUserInOpenShiftSCC() first.
-	args := []string{
-		fmt.Sprintf("--namespace=%s", c.namespace),
-		"adm",
-		"policy",
-		"remove-scc-from-user",
-		"anyuid",
-		"-z",
-		"trident",
+	sccResource, err := c.discoverOpenShiftSCCResource()
+	if err != nil {
+		return "", nil, err
+	}
+
+	out, err := c.command("get", sccResource, OpenShiftSCCName, "-o", "json").CombinedOutput()
+	if err != nil {
+		return "", nil, fmt.Errorf("could not get security context constraint %s; %v; %s",
+			OpenShiftSCCName, err, strings.TrimSpace(string(out)))
 	}
-	_, err := exec.Command(c.cli, args...).CombinedOutput()
+	var scc struct {
+		Users []string `json:"users"`
+	}
+	if err = json.Unmarshal(out, &scc); err != nil {
+		return "", nil, fmt.Errorf("could not parse security context constraint %s; %v", OpenShiftSCCName, err)
+	}
+	return sccResource, scc.Users, nil
+}
+
+// tridentSCCUser returns the SCC user name of the Trident service account.
+func (c *KubectlClient) tridentSCCUser() string {
+	return fmt.Sprintf("system:serviceaccount:%s:trident", c.namespace)
+}
+
+func (c *KubectlClient) AddTridentUserToOpenShiftSCC() error {
+
+	sccResource, users, err := c.getOpenShiftSCCUsers()
+	if err != nil {
+		return err
+	}
+
+	tridentUser := c.tridentSCCUser()
+	for _, user := range users {
+		if user == tridentUser {
+			return nil
+		}
+	}
+
+	// The users list may be null, in which case it must be replaced rather than appended to
+	patch := `[{"op":"add","path":"/users/-","value":"` + tridentUser + `"}]`
+	if users == nil {
+		patch = `[{"op":"add","path":"/users","value":["` + tridentUser + `"]}]`
+	}
+	args := []string{"patch", sccResource, OpenShiftSCCName, "--type=json", "-p", patch}
+	out, err := c.command(args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v; %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (c *KubectlClient) RemoveTridentUserFromOpenShiftSCC() error {
+
+	sccResource, users, err := c.getOpenShiftSCCUsers()
 	if err != nil {
 		return err
 	}
+
+	tridentUser := c.tridentSCCUser()
+	for index, user := range users {
+		if user != tridentUser {
+			continue
+		}
+
+		// Test the value first so a concurrent change to the list can't remove another user
+		patch := fmt.Sprintf(`[{"op":"test","path":"/users/%d","value":"%s"},{"op":"remove","path":"/users/%d"}]`,
+			index, tridentUser, index)
+		args := []string{"patch", sccResource, OpenShiftSCCName, "--type=json", "-p", patch}
+		out, err := c.command(args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%v; %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
 	return nil
 }
 
@@ -0,0 +1,36 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package k8s_client
+
+import (
+	"testing"
+
+	"github.com/netapp/trident/utils"
+)
+
+func TestCheckVersionSkew(t *testing.T) {
+
+	// The server version as discovered before each step of a long installation
+	steps := []struct {
+		version      string
+		expectedSkew bool
+	}{
+		{"v1.10.3", false},
+		{"v1.10.5", false},
+		{"v1.11.0", true},
+		{"v1.11.0", false},
+		{"v2.0.0", true},
+	}
+
+	previous := utils.MustParseSemantic("v1.10.3")
+	for _, step := range steps {
+		current := utils.MustParseSemantic(step.version)
+		err := CheckVersionSkew(previous, current)
+		if step.expectedSkew && err == nil {
+			t.Errorf("Expected skew from %s to %s", previous.String(), current.String())
+		} else if !step.expectedSkew && err != nil {
+			t.Errorf("Expected no skew from %s to %s; %v", previous.String(), current.String(), err)
+		}
+		previous = current
+	}
+}
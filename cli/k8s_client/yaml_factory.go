@@ -2,12 +2,479 @@ package k8s_client
 
 import (
 	"encoding/base64"
+	"path"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ghodss/yaml"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tridentconfig "github.com/netapp/trident/config"
+	"github.com/netapp/trident/logging"
 	"github.com/netapp/trident/utils"
 )
 
+// PodTemplateOptions holds optional settings that are rendered into the pod templates
+// of the Trident deployment, statefulset, and daemonset.
+type PodTemplateOptions struct {
+	DNSConfig          *v1.PodDNSConfig
+	TridentResources   *v1.ResourceRequirements
+	ControllerAffinity *v1.Affinity
+	TopologySpread     []TopologySpreadConstraint
+	PodAnnotations     map[string]string
+	ReadOnlyRootFS     bool
+	SchedulerName      string
+	PodHostname        string
+	PodSubdomain       string
+	TridentArgs        []string
+	TridentEnv         []v1.EnvVar
+
+	// CSI sidecar images, which default to the versions Trident was qualified with
+	CSIAttacherImage    string
+	CSIProvisionerImage string
+	CSIRegistrarImage   string
+
+	// CSI liveness probe sidecar image of the node pods, which have no liveness probe if empty
+	CSILivenessProbeImage string
+
+	// Secrets referenced by backend configs, which are mounted in the Trident controller
+	CredentialsSecrets []string
+
+	// Whether to mount the Kubernetes API server CA config map in the Trident controller
+	KubernetesAPICA bool
+
+	// Size limit of the volume for Trident log files, if Trident writes them
+	LogVolumeSizeLimit string
+
+	// Whether an init container restores etcd from a snapshot the installer copies into the pod
+	EtcdRestore bool
+
+	// Additional volumes of the Trident controller pod, and their mounts in the Trident container
+	ExtraVolumes      []v1.Volume
+	ExtraVolumeMounts []v1.VolumeMount
+
+	// Number of old ReplicaSets or ControllerRevisions each workload keeps, or the Kubernetes default if nil
+	RevisionHistoryLimit *int32
+
+	// Audience of a projected service account token mounted in the Trident container of the
+	// controller instead of the default token, which has the API server's audiences
+	TokenAudience string
+
+	// Where the Trident and etcd containers write their termination messages, and whether the
+	// end of the container log is used if a failed container wrote none
+	TerminationMessagePath   string
+	TerminationMessagePolicy v1.TerminationMessagePolicy
+
+	// Secrets with which the Trident pods pull their images from authenticated registries
+	ImagePullSecrets []string
+
+	// Host path of the CSI node plugin's socket, which the driver registrar passes to the kubelet,
+	// if not the default
+	PluginRegistrationPath string
+}
+
+// CredentialsVolumePrefix begins the name of the volume of each mounted credentials secret.
+const CredentialsVolumePrefix = "backend-credentials-"
+
+// DefaultPluginRegistrationPath is the host path of the CSI node plugin's socket in the default
+// kubelet plugin directory.
+const DefaultPluginRegistrationPath = "/var/lib/kubelet/plugins/io.netapp.trident.csi/csi.sock"
+
+// ReservedVolumeNames are the names of the volumes the installer may add to the Trident pods.
+var ReservedVolumeNames = []string{
+	"etcd-vol", "etcd-restore", "tmp-dir", "k8s-api-ca", "trident-logs", "socket-dir", "etc-dir",
+	"trident-token",
+}
+
+// TopologySpreadConstraint mirrors the pod spec field of the same name, which is newer than the
+// Kubernetes API types vendored here.
+type TopologySpreadConstraint struct {
+	MaxSkew           int32                 `json:"maxSkew"`
+	TopologyKey       string                `json:"topologyKey"`
+	WhenUnsatisfiable string                `json:"whenUnsatisfiable"`
+	LabelSelector     *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// applyPodTemplateOptions renders the optional pod settings into a workload template.  Settings
+// whose placeholder doesn't appear in the template don't apply to that workload.
+func applyPodTemplateOptions(template string, options PodTemplateOptions) string {
+
+	var dnsConfigYAML, resourcesYAML, affinityYAML, topologySpreadYAML, annotationsYAML, argsYAML string
+
+	if len(options.PodAnnotations) > 0 {
+		annotationsYAML = getFieldYAML("annotations", options.PodAnnotations)
+	}
+	if options.DNSConfig != nil {
+		dnsConfigYAML = getFieldYAML("dnsConfig", options.DNSConfig)
+	}
+	if options.TridentResources != nil {
+		resourcesYAML = getFieldYAML("resources", options.TridentResources)
+	}
+	if options.ControllerAffinity != nil {
+		affinityYAML = getFieldYAML("affinity", options.ControllerAffinity)
+	}
+	if len(options.TopologySpread) > 0 {
+		topologySpreadYAML = getFieldYAML("topologySpreadConstraints", options.TopologySpread)
+	}
+	if len(options.TridentArgs) > 0 {
+		if argsBytes, err := yaml.Marshal(options.TridentArgs); err == nil {
+			argsYAML = string(argsBytes)
+		}
+	}
+
+	// The deployment's Trident container has no env list of its own, unlike the statefulset's
+	var envYAML, envVarsYAML string
+	if len(options.TridentEnv) > 0 {
+		envYAML = getFieldYAML("env", options.TridentEnv)
+		if envBytes, err := yaml.Marshal(options.TridentEnv); err == nil {
+			envVarsYAML = string(envBytes)
+		}
+	}
+
+	var imagePullSecretsYAML string
+	if len(options.ImagePullSecrets) > 0 {
+		secretRefs := make([]v1.LocalObjectReference, 0, len(options.ImagePullSecrets))
+		for _, secretName := range options.ImagePullSecrets {
+			secretRefs = append(secretRefs, v1.LocalObjectReference{Name: secretName})
+		}
+		imagePullSecretsYAML = getFieldYAML("imagePullSecrets", secretRefs)
+	}
+
+	var schedulerNameYAML, hostnameYAML, revisionHistoryLimitYAML string
+	if options.RevisionHistoryLimit != nil {
+		revisionHistoryLimitYAML = "revisionHistoryLimit: " + strconv.Itoa(int(*options.RevisionHistoryLimit))
+	}
+	if options.SchedulerName != "" {
+		schedulerNameYAML = "schedulerName: " + options.SchedulerName
+	}
+	if options.PodHostname != "" {
+		hostnameYAML = "hostname: " + options.PodHostname + "\n"
+	}
+	if options.PodSubdomain != "" {
+		hostnameYAML += "subdomain: " + options.PodSubdomain + "\n"
+	}
+
+	template = replaceBlock(template, "{REVISION_HISTORY_LIMIT}", revisionHistoryLimitYAML)
+	template = replaceBlock(template, "{POD_ANNOTATIONS}", annotationsYAML)
+	template = replaceBlock(template, "{SCHEDULER_NAME}", schedulerNameYAML)
+	template = replaceBlock(template, "{IMAGE_PULL_SECRETS}", imagePullSecretsYAML)
+	template = replaceBlock(template, "{POD_HOSTNAME}", hostnameYAML)
+	template = replaceBlock(template, "{DNS_CONFIG}", dnsConfigYAML)
+	template = replaceBlock(template, "{TRIDENT_RESOURCES}", resourcesYAML)
+	template = replaceBlock(template, "{AFFINITY}", affinityYAML)
+	template = replaceBlock(template, "{TOPOLOGY_SPREAD}", topologySpreadYAML)
+	template = replaceBlock(template, "{TRIDENT_ARGS}", argsYAML)
+	template = replaceBlock(template, "{TRIDENT_ENV}", envYAML)
+	template = replaceBlock(template, "{TRIDENT_ENV_VARS}", envVarsYAML)
+
+	var terminationMessageYAML string
+	if options.TerminationMessagePath != "" {
+		terminationMessageYAML = "terminationMessagePath: " + options.TerminationMessagePath + "\n"
+	}
+	if options.TerminationMessagePolicy != "" {
+		terminationMessageYAML += "terminationMessagePolicy: " + string(options.TerminationMessagePolicy) + "\n"
+	}
+	template = replaceBlock(template, "{TERMINATION_MESSAGE}", terminationMessageYAML)
+
+	// The node plugin creates its socket in the plugin directory, which the registrar must report
+	// to the kubelet if it isn't the default
+	pluginDir := path.Dir(DefaultPluginRegistrationPath)
+	var registrationPathYAML string
+	if options.PluginRegistrationPath != "" {
+		pluginDir = path.Dir(options.PluginRegistrationPath)
+		registrationPathYAML = `- "--kubelet-registration-path=` + options.PluginRegistrationPath + `"`
+	}
+	template = strings.Replace(template, "{PLUGIN_DIR}", pluginDir, 1)
+	template = replaceBlock(template, "{KUBELET_REGISTRATION_PATH}", registrationPathYAML)
+
+	template = strings.Replace(template, "{CSI_ATTACHER_IMAGE}",
+		getImageOrDefault(options.CSIAttacherImage, DefaultCSIAttacherImage), 1)
+	template = strings.Replace(template, "{CSI_PROVISIONER_IMAGE}",
+		getImageOrDefault(options.CSIProvisionerImage, DefaultCSIProvisionerImage), 1)
+	template = strings.Replace(template, "{CSI_REGISTRAR_IMAGE}",
+		getImageOrDefault(options.CSIRegistrarImage, DefaultCSIRegistrarImage), 1)
+	if options.CSILivenessProbeImage != "" {
+		template = replaceBlock(template, "{CSI_LIVENESS_PROBE}", csiLivenessProbeYAML)
+		template = replaceBlock(template, "{CSI_LIVENESS_PROBE_CONTAINER}",
+			strings.Replace(csiLivenessProbeContainerYAML, "{IMAGE}", options.CSILivenessProbeImage, 1))
+	} else {
+		template = replaceBlock(template, "{CSI_LIVENESS_PROBE}", "")
+		template = replaceBlock(template, "{CSI_LIVENESS_PROBE_CONTAINER}", "")
+	}
+
+	// Each credentials secret is mounted read-only in a directory named for the secret
+	var credentialsMountsYAML, credentialsVolumesYAML string
+	for i, secretName := range options.CredentialsSecrets {
+		volumeName := CredentialsVolumePrefix + strconv.Itoa(i)
+		mountYAML := strings.Replace(credentialsVolumeMountYAML, "{NAME}", volumeName, 1)
+		credentialsMountsYAML += strings.Replace(mountYAML, "{PATH}",
+			path.Join(tridentconfig.CredentialsSecretsPath, secretName), 1)
+		volumeYAML := strings.Replace(credentialsVolumeYAML, "{NAME}", volumeName, 1)
+		credentialsVolumesYAML += strings.Replace(volumeYAML, "{SECRET}", secretName, 1)
+	}
+	template = replaceBlock(template, "{CREDENTIALS_VOLUME_MOUNT}", credentialsMountsYAML)
+	template = replaceBlock(template, "{CREDENTIALS_VOLUME}", credentialsVolumesYAML)
+
+	var extraMountsYAML, extraVolumesYAML string
+	if len(options.ExtraVolumeMounts) > 0 {
+		if mountsBytes, err := yaml.Marshal(options.ExtraVolumeMounts); err == nil {
+			extraMountsYAML = string(mountsBytes)
+		}
+	}
+	if len(options.ExtraVolumes) > 0 {
+		if volumesBytes, err := yaml.Marshal(options.ExtraVolumes); err == nil {
+			extraVolumesYAML = string(volumesBytes)
+		}
+	}
+	template = replaceBlock(template, "{EXTRA_VOLUME_MOUNT}", extraMountsYAML)
+	template = replaceBlock(template, "{EXTRA_VOLUME}", extraVolumesYAML)
+
+	// With a read-only root filesystem, each container gets a writable /tmp
+	var tridentMountsYAML string
+	if options.ReadOnlyRootFS {
+		template = replaceBlock(template, "{SECURITY_CONTEXT}", readOnlyRootFSSecurityContextYAML)
+		template = replaceBlock(template, "{TMP_VOLUME_MOUNT}", tmpVolumeMountYAML)
+		template = replaceBlock(template, "{TMP_VOLUME}", tmpVolumeYAML)
+		tridentMountsYAML = tmpVolumeMountYAML
+	} else {
+		for _, placeholder := range []string{"{SECURITY_CONTEXT}", "{TMP_VOLUME_MOUNT}", "{TMP_VOLUME}"} {
+			template = replaceBlock(template, placeholder, "")
+		}
+	}
+
+	// The Trident container of the deployment has no other volume mounts
+	tridentMountsYAML += credentialsMountsYAML
+	if options.KubernetesAPICA {
+		tridentMountsYAML += k8sAPICAVolumeMountYAML
+		template = replaceBlock(template, "{K8S_API_CA_VOLUME}", k8sAPICAVolumeYAML)
+	} else {
+		template = replaceBlock(template, "{K8S_API_CA_VOLUME}", "")
+	}
+	tridentMountsYAML += extraMountsYAML
+	if options.TokenAudience != "" {
+		tridentMountsYAML += tokenVolumeMountYAML
+		template = replaceBlock(template, "{TOKEN_VOLUME_MOUNT}", tokenVolumeMountYAML)
+		template = replaceBlock(template, "{TOKEN_VOLUME}",
+			strings.Replace(tokenVolumeYAML, "{AUDIENCE}", strconv.Quote(options.TokenAudience), 1))
+	} else {
+		template = replaceBlock(template, "{TOKEN_VOLUME_MOUNT}", "")
+		template = replaceBlock(template, "{TOKEN_VOLUME}", "")
+	}
+	if options.LogVolumeSizeLimit != "" {
+		tridentMountsYAML += logVolumeMountYAML
+		template = replaceBlock(template, "{LOG_VOLUME_MOUNT}", logVolumeMountYAML)
+		template = replaceBlock(template, "{LOG_VOLUME}",
+			strings.Replace(logVolumeYAML, "{SIZE_LIMIT}", options.LogVolumeSizeLimit, 1))
+	} else {
+		template = replaceBlock(template, "{LOG_VOLUME_MOUNT}", "")
+		template = replaceBlock(template, "{LOG_VOLUME}", "")
+	}
+	if tridentMountsYAML != "" {
+		tridentMountsYAML = "volumeMounts:\n" + tridentMountsYAML
+	}
+	template = replaceBlock(template, "{TRIDENT_VOLUME_MOUNTS}", tridentMountsYAML)
+
+	if options.EtcdRestore {
+		template = replaceBlock(template, "{INIT_CONTAINERS}", etcdRestoreInitContainersYAML)
+		template = replaceBlock(template, "{ETCD_RESTORE_VOLUME}", etcdRestoreVolumeYAML)
+	} else {
+		template = replaceBlock(template, "{INIT_CONTAINERS}", "")
+		template = replaceBlock(template, "{ETCD_RESTORE_VOLUME}", "")
+	}
+
+	return template
+}
+
+const (
+	// EtcdRestoreContainerName is the init container that restores etcd from a snapshot
+	EtcdRestoreContainerName = "etcd-restore"
+
+	// EtcdRestorePath is where the installer copies the snapshot into the init container, and
+	// the file it then creates to signal that the copy is complete
+	EtcdRestorePath         = "/restore"
+	EtcdRestoreSnapshotFile = EtcdRestorePath + "/snapshot.db"
+	EtcdRestoreReadyFile    = EtcdRestorePath + "/ready"
+)
+
+// etcdRestoreInitContainersYAML restores etcd from a snapshot, but only into an empty data
+// directory, so the restore happens once even if the pod is later replaced.
+const etcdRestoreInitContainersYAML = `initContainers:
+- name: ` + EtcdRestoreContainerName + `
+  image: {ETCD_IMAGE}
+  command:
+  - /bin/sh
+  - -c
+  - |
+    set -e
+    if [ -d /var/etcd/data/member ]; then exit 0; fi
+    until [ -f ` + EtcdRestoreReadyFile + ` ]; do sleep 1; done
+    ETCDCTL_API=3 etcdctl snapshot restore ` + EtcdRestoreSnapshotFile + ` --name etcd1 \
+      --initial-cluster etcd1=http://127.0.0.1:8002 \
+      --initial-advertise-peer-urls http://127.0.0.1:8002 --data-dir /var/etcd/data/restore
+    mv /var/etcd/data/restore/member /var/etcd/data/member
+    rmdir /var/etcd/data/restore
+  volumeMounts:
+  - name: etcd-vol
+    mountPath: /var/etcd/data
+  - name: etcd-restore
+    mountPath: ` + EtcdRestorePath + `
+`
+
+const etcdRestoreVolumeYAML = `- name: etcd-restore
+  emptyDir: {}
+`
+
+// csiLivenessProbeYAML restarts the Trident node container if it stops answering the CSI Probe
+// calls that the liveness probe sidecar makes.
+var csiLivenessProbeYAML = `ports:
+- name: healthz
+  containerPort: ` + strconv.Itoa(CSILivenessProbePort) + `
+  protocol: TCP
+livenessProbe:
+  httpGet:
+    path: /healthz
+    port: healthz
+  failureThreshold: 5
+  initialDelaySeconds: 10
+  periodSeconds: 10
+  timeoutSeconds: 3
+`
+
+var csiLivenessProbeContainerYAML = `- name: ` + CSILivenessProbe + `
+  image: {IMAGE}
+  args:
+  - "--csi-address=$(ADDRESS)"
+  - "--health-port=` + strconv.Itoa(CSILivenessProbePort) + `"
+  - "--connection-timeout=3s"
+  env:
+  - name: ADDRESS
+    value: /plugin/csi.sock
+  volumeMounts:
+  - name: plugin-dir
+    mountPath: /plugin
+`
+
+const readOnlyRootFSSecurityContextYAML = `securityContext:
+  readOnlyRootFilesystem: true
+`
+
+const tmpVolumeMountYAML = `- name: tmp-dir
+  mountPath: /tmp
+`
+
+const tmpVolumeYAML = `- name: tmp-dir
+  emptyDir: {}
+`
+
+const credentialsVolumeMountYAML = `- name: {NAME}
+  mountPath: {PATH}
+  readOnly: true
+`
+
+const credentialsVolumeYAML = `- name: {NAME}
+  secret:
+    secretName: {SECRET}
+`
+
+const k8sAPICAVolumeMountYAML = `- name: k8s-api-ca
+  mountPath: ` + tridentconfig.KubernetesAPICAPath + `
+  readOnly: true
+`
+
+const k8sAPICAVolumeYAML = `- name: k8s-api-ca
+  configMap:
+    name: ` + K8sAPICAConfigMapName + `
+`
+
+const logVolumeMountYAML = `- name: trident-logs
+  mountPath: ` + logging.LogRoot + `
+`
+
+const logVolumeYAML = `- name: trident-logs
+  emptyDir:
+    sizeLimit: {SIZE_LIMIT}
+`
+
+// ServiceAccountTokenPath is where the kubelet mounts the service account token, CA, and namespace.
+// A container with its own volume mounted there doesn't get the default token.
+const ServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// TokenExpirationSeconds is the lifetime of the projected service account token, which the
+// kubelet replaces before it expires
+const TokenExpirationSeconds = 3600
+
+const tokenVolumeMountYAML = `- name: trident-token
+  mountPath: ` + ServiceAccountTokenPath + `
+  readOnly: true
+`
+
+// tokenVolumeYAML projects everything the default token volume has, with a token of a specific
+// audience.  The vendored API types predate service account token projection.
+var tokenVolumeYAML = `- name: trident-token
+  projected:
+    sources:
+    - serviceAccountToken:
+        audience: {AUDIENCE}
+        expirationSeconds: ` + strconv.Itoa(TokenExpirationSeconds) + `
+        path: token
+    - configMap:
+        name: kube-root-ca.crt
+        items:
+        - key: ca.crt
+          path: ca.crt
+    - downwardAPI:
+        items:
+        - path: namespace
+          fieldRef:
+            apiVersion: v1
+            fieldPath: metadata.namespace
+`
+
+func getImageOrDefault(image, defaultImage string) string {
+	if image == "" {
+		return defaultImage
+	}
+	return image
+}
+
+// getFieldYAML returns a YAML stanza consisting of a single field with the specified value.
+func getFieldYAML(name string, value interface{}) string {
+
+	fieldYAML, err := yaml.Marshal(map[string]interface{}{name: value})
+	if err != nil {
+		return ""
+	}
+	return string(fieldYAML)
+}
+
+// replaceBlock replaces each occurrence of a placeholder that stands alone on a template line
+// with a multi-line YAML block, indenting each line of the block to the placeholder's column.
+// If the block is empty, the placeholder lines are removed.
+func replaceBlock(template, placeholder, block string) string {
+
+	index := strings.Index(template, placeholder)
+	if index < 0 {
+		return template
+	}
+	lineStart := strings.LastIndex(template[:index], "\n") + 1
+	indent := template[lineStart:index]
+	lineEnd := index + len(placeholder)
+	if lineEnd < len(template) && template[lineEnd] == '\n' {
+		lineEnd++
+	}
+
+	var indentedBlock string
+	for _, line := range strings.Split(strings.TrimRight(block, "\n"), "\n") {
+		if line != "" {
+			indentedBlock += indent + line + "\n"
+		}
+	}
+
+	return template[:lineStart] + indentedBlock + replaceBlock(template[lineEnd:], placeholder, block)
+}
+
 func GetNamespaceYAML(namespace string) string {
 	return strings.Replace(namespaceYAMLTemplate, "{NAMESPACE}", namespace, 1)
 }
@@ -35,25 +502,35 @@ metadata:
   name: {NAME}
 `
 
-func GetClusterRoleYAML(flavor OrchestratorFlavor, version *utils.Version, csi bool) string {
+// GetClusterRoleYAML returns the YAML of the Trident cluster role, with the specified labels, if
+// any, such as those that aggregate it into other cluster roles.
+func GetClusterRoleYAML(flavor OrchestratorFlavor, version *utils.Version, csi bool, labels map[string]string) string {
+
+	var clusterRoleYAML string
 	switch flavor {
 	case FlavorOpenShift:
 		if csi {
-			return clusterRoleOpenShiftCSIYAML
+			clusterRoleYAML = clusterRoleOpenShiftCSIYAML
 		} else {
-			return clusterRoleOpenShiftYAML
+			clusterRoleYAML = clusterRoleOpenShiftYAML
 		}
 	default:
 		fallthrough
 	case FlavorKubernetes:
 		if csi {
-			return clusterRoleKubernetesV1CSIYAML
+			clusterRoleYAML = clusterRoleKubernetesV1CSIYAML
 		} else if version.AtLeast(utils.MustParseSemantic("v1.8.0")) {
-			return clusterRoleKubernetesV1YAML
+			clusterRoleYAML = clusterRoleKubernetesV1YAML
 		} else {
-			return clusterRoleKubernetesV1Alpha1YAML
+			clusterRoleYAML = clusterRoleKubernetesV1Alpha1YAML
 		}
 	}
+
+	var labelsYAML string
+	if len(labels) > 0 {
+		labelsYAML = getFieldYAML("labels", labels)
+	}
+	return replaceBlock(clusterRoleYAML, "{LABELS}", labelsYAML)
 }
 
 const clusterRoleOpenShiftYAML = `---
@@ -61,6 +538,7 @@ kind: ClusterRole
 apiVersion: v1
 metadata:
   name: trident
+  {LABELS}
 rules:
   - apiGroups: [""]
     resources: ["persistentvolumes"]
@@ -84,6 +562,7 @@ kind: ClusterRole
 apiVersion: v1
 metadata:
   name: trident-csi
+  {LABELS}
 rules:
   - apiGroups: [""]
     resources: ["persistentvolumes"]
@@ -113,6 +592,7 @@ kind: ClusterRole
 apiVersion: rbac.authorization.k8s.io/v1
 metadata:
   name: trident
+  {LABELS}
 rules:
   - apiGroups: [""]
     resources: ["persistentvolumes"]
@@ -136,6 +616,7 @@ kind: ClusterRole
 apiVersion: rbac.authorization.k8s.io/v1
 metadata:
   name: trident-csi
+  {LABELS}
 rules:
   - apiGroups: [""]
     resources: ["persistentvolumes"]
@@ -165,6 +646,7 @@ kind: ClusterRole
 apiVersion: rbac.authorization.k8s.io/v1alpha1
 metadata:
   name: trident
+  {LABELS}
 rules:
   - apiGroups: [""]
     resources: ["persistentvolumes"]
@@ -255,7 +737,45 @@ roleRef:
   apiGroup: rbac.authorization.k8s.io
 `
 
-func GetDeploymentYAML(pvcName, tridentImage, etcdImage, label string, debug bool) string {
+// Workload API versions.  The apps/v1 versions are available as of Kubernetes 1.9, and the
+// beta versions are removed as of Kubernetes 1.16.
+const (
+	DeploymentAPIVersionV1Beta1  = "extensions/v1beta1"
+	StatefulSetAPIVersionV1Beta1 = "apps/v1beta1"
+	DaemonSetAPIVersionV1Beta1   = "extensions/v1beta1"
+	DaemonSetAPIVersionV1Beta2   = "apps/v1beta2"
+	AppsAPIVersionV1             = "apps/v1"
+)
+
+// GetDeploymentAPIVersion returns the newest deployment API version the cluster serves.
+func GetDeploymentAPIVersion(version *utils.Version) string {
+	if version.AtLeast(utils.MustParseSemantic("v1.9.0")) {
+		return AppsAPIVersionV1
+	}
+	return DeploymentAPIVersionV1Beta1
+}
+
+// GetStatefulSetAPIVersion returns the newest statefulset API version the cluster serves.
+func GetStatefulSetAPIVersion(version *utils.Version) string {
+	if version.AtLeast(utils.MustParseSemantic("v1.9.0")) {
+		return AppsAPIVersionV1
+	}
+	return StatefulSetAPIVersionV1Beta1
+}
+
+// GetDaemonSetAPIVersion returns the newest daemonset API version the cluster serves.
+func GetDaemonSetAPIVersion(version *utils.Version) string {
+	if version.AtLeast(utils.MustParseSemantic("v1.9.0")) {
+		return AppsAPIVersionV1
+	} else if version.AtLeast(utils.MustParseSemantic("v1.8.0")) {
+		return DaemonSetAPIVersionV1Beta2
+	}
+	return DaemonSetAPIVersionV1Beta1
+}
+
+func GetDeploymentYAML(
+	pvcName, tridentImage, etcdImage, label string, debug bool, version *utils.Version, options PodTemplateOptions,
+) string {
 
 	var debugLine string
 	if debug {
@@ -264,16 +784,18 @@ func GetDeploymentYAML(pvcName, tridentImage, etcdImage, label string, debug boo
 		debugLine = "#- -debug"
 	}
 
-	deploymentYAML := strings.Replace(deploymentYAMLTemplate, "{TRIDENT_IMAGE}", tridentImage, 1)
-	deploymentYAML = strings.Replace(deploymentYAML, "{ETCD_IMAGE}", etcdImage, 1)
+	deploymentYAML := strings.Replace(deploymentYAMLTemplate, "{API_VERSION}", GetDeploymentAPIVersion(version), 1)
+	deploymentYAML = strings.Replace(deploymentYAML, "{TRIDENT_IMAGE}", tridentImage, 1)
 	deploymentYAML = strings.Replace(deploymentYAML, "{DEBUG}", debugLine, 1)
 	deploymentYAML = strings.Replace(deploymentYAML, "{PVC_NAME}", pvcName, 1)
 	deploymentYAML = strings.Replace(deploymentYAML, "{LABEL}", label, -1)
+	deploymentYAML = applyPodTemplateOptions(deploymentYAML, options)
+	deploymentYAML = strings.Replace(deploymentYAML, "{ETCD_IMAGE}", etcdImage, -1)
 	return deploymentYAML
 }
 
 const deploymentYAMLTemplate = `---
-apiVersion: extensions/v1beta1
+apiVersion: {API_VERSION}
 kind: Deployment
 metadata:
   name: trident
@@ -281,15 +803,32 @@ metadata:
     app: {LABEL}
 spec:
   replicas: 1
+  {REVISION_HISTORY_LIMIT}
+  selector:
+    matchLabels:
+      app: {LABEL}
   template:
     metadata:
       labels:
         app: {LABEL}
+      {POD_ANNOTATIONS}
     spec:
       serviceAccount: trident
+      {SCHEDULER_NAME}
+      {IMAGE_PULL_SECRETS}
+      {POD_HOSTNAME}
+      {DNS_CONFIG}
+      {AFFINITY}
+      {TOPOLOGY_SPREAD}
+      {INIT_CONTAINERS}
       containers:
       - name: trident-main
         image: {TRIDENT_IMAGE}
+        {TRIDENT_RESOURCES}
+        {SECURITY_CONTEXT}
+        {TERMINATION_MESSAGE}
+        {TRIDENT_VOLUME_MOUNTS}
+        {TRIDENT_ENV}
         command:
         - /usr/local/bin/trident_orchestrator
         args:
@@ -299,6 +838,7 @@ spec:
         #- -k8s_api_server
         #- __KUBERNETES_SERVER__:__KUBERNETES_PORT__
         {DEBUG}
+        {TRIDENT_ARGS}
         livenessProbe:
           exec:
             command:
@@ -313,6 +853,8 @@ spec:
           timeoutSeconds: 90
       - name: etcd
         image: {ETCD_IMAGE}
+        {SECURITY_CONTEXT}
+        {TERMINATION_MESSAGE}
         command:
         - /usr/local/bin/etcd
         args:
@@ -333,6 +875,7 @@ spec:
         volumeMounts:
         - name: etcd-vol
           mountPath: /var/etcd/data
+        {TMP_VOLUME_MOUNT}
         livenessProbe:
           exec:
             command:
@@ -347,6 +890,35 @@ spec:
       - name: etcd-vol
         persistentVolumeClaim:
           claimName: {PVC_NAME}
+      {ETCD_RESTORE_VOLUME}
+      {TMP_VOLUME}
+      {CREDENTIALS_VOLUME}
+      {K8S_API_CA_VOLUME}
+      {LOG_VOLUME}
+      {TOKEN_VOLUME}
+      {EXTRA_VOLUME}
+`
+
+// GetHeadlessServiceYAML returns a headless service that gives the Trident controller pod a DNS
+// name within the named subdomain.
+func GetHeadlessServiceYAML(subdomain, label string) string {
+
+	serviceYAML := strings.Replace(headlessServiceYAMLTemplate, "{NAME}", subdomain, 1)
+	serviceYAML = strings.Replace(serviceYAML, "{LABEL}", label, -1)
+	return serviceYAML
+}
+
+const headlessServiceYAMLTemplate = `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {NAME}
+  labels:
+    app: {LABEL}
+spec:
+  clusterIP: None
+  selector:
+    app: {LABEL}
 `
 
 func GetCSIServiceYAML(label string) string {
@@ -370,7 +942,9 @@ spec:
       port: 12345
 `
 
-func GetCSIStatefulSetYAML(pvcName, tridentImage, etcdImage, label string, debug bool) string {
+func GetCSIStatefulSetYAML(
+	pvcName, tridentImage, etcdImage, label string, debug bool, version *utils.Version, options PodTemplateOptions,
+) string {
 
 	var debugLine string
 	if debug {
@@ -379,16 +953,18 @@ func GetCSIStatefulSetYAML(pvcName, tridentImage, etcdImage, label string, debug
 		debugLine = "#- -debug"
 	}
 
-	statefulSetYAML := strings.Replace(statefulSetYAMLTemplate, "{TRIDENT_IMAGE}", tridentImage, 1)
-	statefulSetYAML = strings.Replace(statefulSetYAML, "{ETCD_IMAGE}", etcdImage, 1)
+	statefulSetYAML := strings.Replace(statefulSetYAMLTemplate, "{API_VERSION}", GetStatefulSetAPIVersion(version), 1)
+	statefulSetYAML = strings.Replace(statefulSetYAML, "{TRIDENT_IMAGE}", tridentImage, 1)
 	statefulSetYAML = strings.Replace(statefulSetYAML, "{DEBUG}", debugLine, 1)
 	statefulSetYAML = strings.Replace(statefulSetYAML, "{PVC_NAME}", pvcName, 1)
 	statefulSetYAML = strings.Replace(statefulSetYAML, "{LABEL}", label, -1)
+	statefulSetYAML = applyPodTemplateOptions(statefulSetYAML, options)
+	statefulSetYAML = strings.Replace(statefulSetYAML, "{ETCD_IMAGE}", etcdImage, -1)
 	return statefulSetYAML
 }
 
 const statefulSetYAMLTemplate = `---
-apiVersion: apps/v1beta1
+apiVersion: {API_VERSION}
 kind: StatefulSet
 metadata:
   name: trident-csi
@@ -397,15 +973,29 @@ metadata:
 spec:
   serviceName: "trident-csi"
   replicas: 1
+  {REVISION_HISTORY_LIMIT}
+  selector:
+    matchLabels:
+      app: {LABEL}
   template:
     metadata:
       labels:
         app: {LABEL}
+      {POD_ANNOTATIONS}
     spec:
       serviceAccount: trident-csi
+      {SCHEDULER_NAME}
+      {IMAGE_PULL_SECRETS}
+      {DNS_CONFIG}
+      {AFFINITY}
+      {TOPOLOGY_SPREAD}
+      {INIT_CONTAINERS}
       containers:
       - name: trident-main
         image: {TRIDENT_IMAGE}
+        {TRIDENT_RESOURCES}
+        {SECURITY_CONTEXT}
+        {TERMINATION_MESSAGE}
         command:
         - /usr/local/bin/trident_orchestrator
         args:
@@ -414,6 +1004,7 @@ spec:
         - "--csi_node_name=$(KUBE_NODE_NAME)"
         - "--csi_endpoint=$(CSI_ENDPOINT)"
         {DEBUG}
+        {TRIDENT_ARGS}
         livenessProbe:
           exec:
             command:
@@ -434,13 +1025,21 @@ spec:
               fieldPath: spec.nodeName
         - name: CSI_ENDPOINT
           value: unix://plugin/csi.sock
+        {TRIDENT_ENV_VARS}
         volumeMounts:
         - name: socket-dir
           mountPath: /plugin
         - name: etc-dir
           mountPath: /etc
+        {TMP_VOLUME_MOUNT}
+        {CREDENTIALS_VOLUME_MOUNT}
+        {LOG_VOLUME_MOUNT}
+        {TOKEN_VOLUME_MOUNT}
+        {EXTRA_VOLUME_MOUNT}
       - name: etcd
         image: {ETCD_IMAGE}
+        {SECURITY_CONTEXT}
+        {TERMINATION_MESSAGE}
         command:
         - /usr/local/bin/etcd
         args:
@@ -461,6 +1060,7 @@ spec:
         volumeMounts:
         - name: etcd-vol
           mountPath: /var/etcd/data
+        {TMP_VOLUME_MOUNT}
         livenessProbe:
           exec:
             command:
@@ -472,7 +1072,8 @@ spec:
           periodSeconds: 15
           timeoutSeconds: 10
       - name: csi-attacher
-        image: quay.io/k8scsi/csi-attacher:v0.2.0
+        image: {CSI_ATTACHER_IMAGE}
+        {SECURITY_CONTEXT}
         args:
         - "--v=9"
         - "--csi-address=$(ADDRESS)"
@@ -482,8 +1083,10 @@ spec:
         volumeMounts:
         - name: socket-dir
           mountPath: /var/lib/csi/sockets/pluginproxy/
+        {TMP_VOLUME_MOUNT}
       - name: csi-provisioner
-        image: quay.io/k8scsi/csi-provisioner:v0.2.1
+        image: {CSI_PROVISIONER_IMAGE}
+        {SECURITY_CONTEXT}
         args:
         - "--v=9"
         - "--provisioner=io.netapp.trident.csi"
@@ -494,19 +1097,28 @@ spec:
         volumeMounts:
         - name: socket-dir
           mountPath: /var/lib/csi/sockets/pluginproxy/
+        {TMP_VOLUME_MOUNT}
       volumes:
       - name: etcd-vol
         persistentVolumeClaim:
           claimName: {PVC_NAME}
+      {ETCD_RESTORE_VOLUME}
       - name: socket-dir
         emptyDir:
       - name: etc-dir
         hostPath:
           path: /etc
           type: Directory
+      {TMP_VOLUME}
+      {CREDENTIALS_VOLUME}
+      {LOG_VOLUME}
+      {TOKEN_VOLUME}
+      {EXTRA_VOLUME}
 `
 
-func GetCSIDaemonSetYAML(tridentImage, label string, debug bool) string {
+func GetCSIDaemonSetYAML(
+	tridentImage, label string, debug bool, version *utils.Version, options PodTemplateOptions,
+) string {
 
 	var debugLine string
 	if debug {
@@ -515,20 +1127,23 @@ func GetCSIDaemonSetYAML(tridentImage, label string, debug bool) string {
 		debugLine = "#- -debug"
 	}
 
-	daemonSetYAML := strings.Replace(daemonSetYAMLTemplate, "{TRIDENT_IMAGE}", tridentImage, 1)
+	daemonSetYAML := strings.Replace(daemonSetYAMLTemplate, "{API_VERSION}", GetDaemonSetAPIVersion(version), 1)
+	daemonSetYAML = strings.Replace(daemonSetYAML, "{TRIDENT_IMAGE}", tridentImage, 1)
 	daemonSetYAML = strings.Replace(daemonSetYAML, "{LABEL}", label, -1)
 	daemonSetYAML = strings.Replace(daemonSetYAML, "{DEBUG}", debugLine, 1)
+	daemonSetYAML = applyPodTemplateOptions(daemonSetYAML, options)
 	return daemonSetYAML
 }
 
 const daemonSetYAMLTemplate = `---
-apiVersion: apps/v1beta2
+apiVersion: {API_VERSION}
 kind: DaemonSet
 metadata:
   name: trident-csi
   labels:
     app: {LABEL}
 spec:
+  {REVISION_HISTORY_LIMIT}
   selector:
     matchLabels:
       app: {LABEL}
@@ -536,8 +1151,12 @@ spec:
     metadata:
       labels:
         app: {LABEL}
+      {POD_ANNOTATIONS}
     spec:
       serviceAccount: trident-csi
+      {SCHEDULER_NAME}
+      {IMAGE_PULL_SECRETS}
+      {DNS_CONFIG}
       hostNetwork: true
       hostIPC: true
       containers:
@@ -548,6 +1167,8 @@ spec:
             add: ["SYS_ADMIN"]
           allowPrivilegeEscalation: true
         image: {TRIDENT_IMAGE}
+        {TRIDENT_RESOURCES}
+        {TERMINATION_MESSAGE}
         command:
         - /usr/local/bin/trident_orchestrator
         args:
@@ -556,6 +1177,7 @@ spec:
         - "--csi_endpoint=$(CSI_ENDPOINT)"
         - "--rest=false"
         {DEBUG}
+        {TRIDENT_ARGS}
         env:
         - name: KUBE_NODE_NAME
           valueFrom:
@@ -583,11 +1205,14 @@ spec:
         - name: host-dir
           mountPath: /host
           mountPropagation: "Bidirectional"
+        {LOG_VOLUME_MOUNT}
+        {CSI_LIVENESS_PROBE}
       - name: driver-registrar
-        image: quay.io/k8scsi/driver-registrar:v0.2.0
+        image: {CSI_REGISTRAR_IMAGE}
         args:
         - "--v=9"
         - "--csi-address=$(ADDRESS)"
+        {KUBELET_REGISTRATION_PATH}
         env:
         - name: ADDRESS
           value: /plugin/csi.sock
@@ -598,10 +1223,11 @@ spec:
         volumeMounts:
         - name: plugin-dir
           mountPath: /plugin
+      {CSI_LIVENESS_PROBE_CONTAINER}
       volumes:
       - name: plugin-dir
         hostPath:
-          path: /var/lib/kubelet/plugins/io.netapp.trident.csi
+          path: {PLUGIN_DIR}
           type: DirectoryOrCreate
       - name: plugins-mount-dir
         hostPath:
@@ -627,13 +1253,94 @@ spec:
         hostPath:
           path: /
           type: Directory
+      {LOG_VOLUME}
+`
+
+func GetNetworkPolicyYAML(label string, backendCIDRs []string, csi bool) string {
+
+	var name string
+	if csi {
+		name = "trident-csi"
+	} else {
+		name = "trident"
+	}
+
+	var backendEgress string
+	for _, cidr := range backendCIDRs {
+		backendEgress += "\n  - to:\n    - ipBlock:\n        cidr: " + cidr
+	}
+
+	networkPolicyYAML := strings.Replace(networkPolicyYAMLTemplate, "{NAME}", name, 1)
+	networkPolicyYAML = strings.Replace(networkPolicyYAML, "{LABEL}", label, -1)
+	networkPolicyYAML = strings.Replace(networkPolicyYAML, "{BACKEND_EGRESS}", backendEgress, 1)
+	return networkPolicyYAML
+}
+
+const networkPolicyYAMLTemplate = `---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: {NAME}
+  labels:
+    app: {LABEL}
+spec:
+  podSelector:
+    matchLabels:
+      app: {LABEL}
+  policyTypes:
+  - Ingress
+  - Egress
+  ingress:
+  - from:
+    - namespaceSelector: {}
+    ports:
+    - protocol: TCP
+      port: 8000
+  egress:
+  - ports:
+    - protocol: UDP
+      port: 53
+    - protocol: TCP
+      port: 53
+  - ports:
+    - protocol: TCP
+      port: 443
+    - protocol: TCP
+      port: 6443{BACKEND_EGRESS}
 `
 
-func GetPVCYAML(pvcName, namespace, size, label string) string {
+// GetStorageClassYAML returns the YAML of a storage class served by Trident, with the specified
+// parameters, if any.
+func GetStorageClassYAML(name, provisioner, label string, parameters map[string]string) string {
+
+	var parametersYAML string
+	if len(parameters) > 0 {
+		parametersYAML = getFieldYAML("parameters", parameters)
+	}
+
+	storageClassYAML := strings.Replace(storageClassYAMLTemplate, "{NAME}", name, 1)
+	storageClassYAML = strings.Replace(storageClassYAML, "{PROVISIONER}", provisioner, 1)
+	storageClassYAML = strings.Replace(storageClassYAML, "{LABEL}", label, 1)
+	return replaceBlock(storageClassYAML, "{PARAMETERS}", parametersYAML)
+}
+
+const storageClassYAMLTemplate = `---
+apiVersion: storage.k8s.io/v1
+kind: StorageClass
+metadata:
+  name: {NAME}
+  labels:
+    app: {LABEL}
+provisioner: {PROVISIONER}
+{PARAMETERS}
+`
+
+func GetPVCYAML(pvcName, namespace, size, accessMode, label string) string {
 
 	pvcYAML := strings.Replace(persistentVolumeClaimYAMLTemplate, "{PVC_NAME}", pvcName, 1)
 	pvcYAML = strings.Replace(pvcYAML, "{NAMESPACE}", namespace, 1)
 	pvcYAML = strings.Replace(pvcYAML, "{SIZE}", size, 1)
+	pvcYAML = strings.Replace(pvcYAML, "{ACCESS_MODE}", accessMode, 1)
 	pvcYAML = strings.Replace(pvcYAML, "{LABEL}", label, -1)
 	return pvcYAML
 }
@@ -648,7 +1355,7 @@ metadata:
   namespace: {NAMESPACE}
 spec:
   accessModes:
-  - ReadWriteOnce
+  - {ACCESS_MODE}
   resources:
     requests:
       storage: {SIZE}
@@ -658,16 +1365,30 @@ spec:
   storageClassName: ''
 `
 
-func GetNFSPVYAML(pvName, size, pvcName, pvcNamespace, nfsServer, nfsPath, label string) string {
+// applyPVAnnotations renders the annotations of a Trident PV, if any, into its YAML.
+func applyPVAnnotations(pvYAML string, annotations map[string]string) string {
+
+	var annotationsYAML string
+	if len(annotations) > 0 {
+		annotationsYAML = getFieldYAML("annotations", annotations)
+	}
+	return replaceBlock(pvYAML, "{PV_ANNOTATIONS}", annotationsYAML)
+}
+
+func GetNFSPVYAML(
+	pvName, size, pvcName, pvcNamespace, nfsServer, nfsPath, accessMode, label string,
+	annotations map[string]string,
+) string {
 
 	pvYAML := strings.Replace(persistentVolumeNFSYAMLTemplate, "{PV_NAME}", pvName, 1)
 	pvYAML = strings.Replace(pvYAML, "{SIZE}", size, 1)
+	pvYAML = strings.Replace(pvYAML, "{ACCESS_MODE}", accessMode, 1)
 	pvYAML = strings.Replace(pvYAML, "{PVC_NAME}", pvcName, 1)
 	pvYAML = strings.Replace(pvYAML, "{PVC_NAMESPACE}", pvcNamespace, 1)
 	pvYAML = strings.Replace(pvYAML, "{SERVER}", nfsServer, 1)
 	pvYAML = strings.Replace(pvYAML, "{PATH}", nfsPath, 1)
 	pvYAML = strings.Replace(pvYAML, "{LABEL}", label, 1)
-	return pvYAML
+	return applyPVAnnotations(pvYAML, annotations)
 }
 
 const persistentVolumeNFSYAMLTemplate = `---
@@ -677,11 +1398,12 @@ metadata:
   labels:
     app: {LABEL}
   name: {PV_NAME}
+  {PV_ANNOTATIONS}
 spec:
   capacity:
     storage: {SIZE}
   accessModes:
-    - ReadWriteOnce
+    - {ACCESS_MODE}
   persistentVolumeReclaimPolicy: Retain
   claimRef:
     apiVersion: v1
@@ -693,7 +1415,10 @@ spec:
     path: {PATH}
 `
 
-func GetISCSIPVYAML(pvName, size, pvcName, pvcNamespace, targetPortal, iqn string, lun int32, label string) string {
+func GetISCSIPVYAML(
+	pvName, size, pvcName, pvcNamespace, targetPortal, iqn string, lun int32, label string,
+	annotations map[string]string,
+) string {
 
 	pvYAML := strings.Replace(persistentVolumeISCSIYAMLTemplate, "{PV_NAME}", pvName, 1)
 	pvYAML = strings.Replace(pvYAML, "{SIZE}", size, 1)
@@ -703,7 +1428,7 @@ func GetISCSIPVYAML(pvName, size, pvcName, pvcNamespace, targetPortal, iqn strin
 	pvYAML = strings.Replace(pvYAML, "{IQN}", iqn, 1)
 	pvYAML = strings.Replace(pvYAML, "{LUN}", strconv.FormatInt(int64(lun), 10), 1)
 	pvYAML = strings.Replace(pvYAML, "{LABEL}", label, 1)
-	return pvYAML
+	return applyPVAnnotations(pvYAML, annotations)
 }
 
 const persistentVolumeISCSIYAMLTemplate = `---
@@ -713,6 +1438,7 @@ metadata:
   labels:
     app: {LABEL}
   name: {PV_NAME}
+  {PV_ANNOTATIONS}
 spec:
   capacity:
     storage: {SIZE}
@@ -734,7 +1460,7 @@ spec:
 
 func GetCHAPISCSIPVYAML(
 	pvName, size, pvcName, pvcNamespace, secretName,
-	targetPortal, iqn string, lun int32, label string,
+	targetPortal, iqn string, lun int32, label string, annotations map[string]string,
 ) string {
 
 	pvYAML := strings.Replace(persistentVolumeCHAPISCSIYAMLTemplate, "{PV_NAME}", pvName, 1)
@@ -746,7 +1472,7 @@ func GetCHAPISCSIPVYAML(
 	pvYAML = strings.Replace(pvYAML, "{LUN}", strconv.FormatInt(int64(lun), 10), 1)
 	pvYAML = strings.Replace(pvYAML, "{SECRET_NAME}", secretName, 1)
 	pvYAML = strings.Replace(pvYAML, "{LABEL}", label, 1)
-	return pvYAML
+	return applyPVAnnotations(pvYAML, annotations)
 }
 
 const persistentVolumeCHAPISCSIYAMLTemplate = `---
@@ -756,6 +1482,7 @@ metadata:
   labels:
     app: {LABEL}
   name: {PV_NAME}
+  {PV_ANNOTATIONS}
 spec:
   capacity:
     storage: {SIZE}
@@ -779,7 +1506,156 @@ spec:
       name: {SECRET_NAME}
 `
 
-func GetCHAPSecretYAML(secretName, userName, initiatorSecret, targetSecret string) string {
+func GetFCPVYAML(
+	pvName, size, pvcName, pvcNamespace string, targetWWNs []string, lun int32, accessMode, label string,
+	annotations map[string]string,
+) string {
+
+	wwnsYAML := ""
+	for _, wwn := range targetWWNs {
+		wwnsYAML += "\n      - " + strings.ToLower(wwn)
+	}
+
+	pvYAML := strings.Replace(persistentVolumeFCYAMLTemplate, "{PV_NAME}", pvName, 1)
+	pvYAML = strings.Replace(pvYAML, "{SIZE}", size, 1)
+	pvYAML = strings.Replace(pvYAML, "{PVC_NAME}", pvcName, 1)
+	pvYAML = strings.Replace(pvYAML, "{PVC_NAMESPACE}", pvcNamespace, 1)
+	pvYAML = strings.Replace(pvYAML, "{TARGET_WWNS}", wwnsYAML, 1)
+	pvYAML = strings.Replace(pvYAML, "{LUN}", strconv.FormatInt(int64(lun), 10), 1)
+	pvYAML = strings.Replace(pvYAML, "{ACCESS_MODE}", accessMode, 1)
+	pvYAML = strings.Replace(pvYAML, "{LABEL}", label, 1)
+	return applyPVAnnotations(pvYAML, annotations)
+}
+
+const persistentVolumeFCYAMLTemplate = `---
+apiVersion: v1
+kind: PersistentVolume
+metadata:
+  labels:
+    app: {LABEL}
+  name: {PV_NAME}
+  {PV_ANNOTATIONS}
+spec:
+  capacity:
+    storage: {SIZE}
+  accessModes:
+    - {ACCESS_MODE}
+  persistentVolumeReclaimPolicy: Retain
+  claimRef:
+    apiVersion: v1
+    kind: PersistentVolumeClaim
+    name: {PVC_NAME}
+    namespace: {PVC_NAMESPACE}
+  fc:
+    targetWWNs:{TARGET_WWNS}
+    lun: {LUN}
+    fsType: ext4
+    readOnly: false
+`
+
+const K8sAPICAConfigMapName = "trident-k8s-api-ca"
+
+// GetInstallEventYAML returns an event about the installation of Trident, which refers to the
+// namespace Trident is installed in.
+func GetInstallEventYAML(namespace, eventType, reason, message string, timestamp time.Time) string {
+
+	eventTime := metav1.NewTime(timestamp)
+	event := v1.Event{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Event"},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "trident-installer.",
+			Namespace:    namespace,
+		},
+		InvolvedObject: v1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: namespace},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: "tridentctl"},
+		FirstTimestamp: eventTime,
+		LastTimestamp:  eventTime,
+		Count:          1,
+	}
+	eventYAML, err := yaml.Marshal(event)
+	if err != nil {
+		return ""
+	}
+	return "---\n" + string(eventYAML)
+}
+
+// GetDiagnosticPodYAML returns a pod that runs a command once, so that the installer can
+// check the cluster from where Trident will run.  If nodeName is empty, the scheduler
+// places the pod.
+func GetDiagnosticPodYAML(podName, label, image, nodeName string, hostNetwork bool, command []string) string {
+
+	pod := v1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   podName,
+			Labels: map[string]string{"app": label},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:    "diagnostic",
+				Image:   image,
+				Command: command,
+			}},
+			RestartPolicy: v1.RestartPolicyNever,
+			NodeName:      nodeName,
+			HostNetwork:   hostNetwork,
+		},
+	}
+	podYAML, err := yaml.Marshal(pod)
+	if err != nil {
+		return ""
+	}
+	return "---\n" + string(podYAML)
+}
+
+func GetK8sAPICAConfigMapYAML(label, caPEM string) string {
+
+	var caLines string
+	for _, line := range strings.Split(strings.TrimSpace(caPEM), "\n") {
+		caLines += "    " + strings.TrimRight(line, "\r") + "\n"
+	}
+
+	configMapYAML := strings.Replace(k8sAPICAConfigMapYAMLTemplate, "{NAME}", K8sAPICAConfigMapName, 1)
+	configMapYAML = strings.Replace(configMapYAML, "{LABEL}", label, 1)
+	configMapYAML = strings.Replace(configMapYAML, "{FILENAME}", tridentconfig.KubernetesAPICAFilename, 1)
+	configMapYAML = strings.Replace(configMapYAML, "{CA}\n", caLines, 1)
+	return configMapYAML
+}
+
+const k8sAPICAConfigMapYAMLTemplate = `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {NAME}
+  labels:
+    app: {LABEL}
+data:
+  {FILENAME}: |
+{CA}
+`
+
+// CHAPSecretType is the type of an iSCSI CHAP secret
+const CHAPSecretType = "kubernetes.io/iscsi-chap"
+
+// GetCHAPSecretData returns the unencoded data of an iSCSI CHAP secret with the specified
+// credentials, as rendered by GetCHAPSecretYAML.
+func GetCHAPSecretData(userName, initiatorSecret, targetSecret string) map[string]string {
+	return map[string]string{
+		"discovery.sendtargets.auth.username":    userName,
+		"discovery.sendtargets.auth.password":    initiatorSecret,
+		"discovery.sendtargets.auth.username_in": userName,
+		"discovery.sendtargets.auth.password_in": targetSecret,
+		"node.session.auth.username":             userName,
+		"node.session.auth.password":             initiatorSecret,
+		"node.session.auth.username_in":          userName,
+		"node.session.auth.password_in":          targetSecret,
+	}
+}
+
+func GetCHAPSecretYAML(secretName, userName, initiatorSecret, targetSecret, label string) string {
 
 	encodedUserName := base64.StdEncoding.EncodeToString([]byte(userName))
 	encodedInitiatorSecret := base64.StdEncoding.EncodeToString([]byte(initiatorSecret))
@@ -789,6 +1665,7 @@ func GetCHAPSecretYAML(secretName, userName, initiatorSecret, targetSecret strin
 	secretYAML = strings.Replace(secretYAML, "{USER_NAME}", encodedUserName, -1)
 	secretYAML = strings.Replace(secretYAML, "{INITIATOR_SECRET}", encodedInitiatorSecret, -1)
 	secretYAML = strings.Replace(secretYAML, "{TARGET_SECRET}", encodedTargetSecret, -1)
+	secretYAML = strings.Replace(secretYAML, "{LABEL}", label, 1)
 	return secretYAML
 }
 
@@ -797,6 +1674,8 @@ apiVersion: v1
 kind: Secret
 metadata:
   name: {SECRET_NAME}
+  labels:
+    app: {LABEL}
 type: "kubernetes.io/iscsi-chap"
 data:
   discovery.sendtargets.auth.username: {USER_NAME}
@@ -808,3 +1687,29 @@ data:
   node.session.auth.username_in: {USER_NAME}
   node.session.auth.password_in: {TARGET_SECRET}
 `
+
+// GetCredentialsSecretYAML returns the YAML of a secret holding the username and password of a
+// storage backend, as referenced by the credentials field of a backend config.
+func GetCredentialsSecretYAML(secretName, label, username, password string) string {
+
+	secretYAML := strings.Replace(credentialsSecretYAMLTemplate, "{SECRET_NAME}", secretName, 1)
+	secretYAML = strings.Replace(secretYAML, "{LABEL}", label, 1)
+	secretYAML = strings.Replace(secretYAML, "{USERNAME}",
+		base64.StdEncoding.EncodeToString([]byte(username)), 1)
+	secretYAML = strings.Replace(secretYAML, "{PASSWORD}",
+		base64.StdEncoding.EncodeToString([]byte(password)), 1)
+	return secretYAML
+}
+
+const credentialsSecretYAMLTemplate = `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {SECRET_NAME}
+  labels:
+    app: {LABEL}
+type: Opaque
+data:
+  username: {USERNAME}
+  password: {PASSWORD}
+`
@@ -0,0 +1,360 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package k8s_client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/netapp/trident/utils"
+)
+
+func getTypeMeta(t *testing.T, objectYAML string) metav1.TypeMeta {
+
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal([]byte(objectYAML), &typeMeta); err != nil {
+		t.Fatalf("Could not parse generated YAML; %v", err)
+	}
+	return typeMeta
+}
+
+func TestWorkloadAPIVersions(t *testing.T) {
+
+	tests := []struct {
+		version     string
+		deployment  string
+		statefulSet string
+		daemonSet   string
+	}{
+		{"v1.7.0", DeploymentAPIVersionV1Beta1, StatefulSetAPIVersionV1Beta1, DaemonSetAPIVersionV1Beta1},
+		{"v1.8.5", DeploymentAPIVersionV1Beta1, StatefulSetAPIVersionV1Beta1, DaemonSetAPIVersionV1Beta2},
+		{"v1.9.0", AppsAPIVersionV1, AppsAPIVersionV1, AppsAPIVersionV1},
+		{"v1.11.2", AppsAPIVersionV1, AppsAPIVersionV1, AppsAPIVersionV1},
+		{"v1.16.0", AppsAPIVersionV1, AppsAPIVersionV1, AppsAPIVersionV1},
+		{"v1.22.1", AppsAPIVersionV1, AppsAPIVersionV1, AppsAPIVersionV1},
+	}
+
+	for _, test := range tests {
+		version := utils.MustParseSemantic(test.version)
+
+		deployment := getTypeMeta(t, GetDeploymentYAML(
+			"trident", "trident:test", "etcd:test", "trident", false, version, PodTemplateOptions{}))
+		if deployment.Kind != "Deployment" || deployment.APIVersion != test.deployment {
+			t.Errorf("Kubernetes %s: expected deployment %s, got %s %s", test.version, test.deployment,
+				deployment.Kind, deployment.APIVersion)
+		}
+
+		statefulSet := getTypeMeta(t, GetCSIStatefulSetYAML(
+			"trident", "trident:test", "etcd:test", "trident", false, version, PodTemplateOptions{}))
+		if statefulSet.Kind != "StatefulSet" || statefulSet.APIVersion != test.statefulSet {
+			t.Errorf("Kubernetes %s: expected statefulset %s, got %s %s", test.version, test.statefulSet,
+				statefulSet.Kind, statefulSet.APIVersion)
+		}
+
+		daemonSet := getTypeMeta(t, GetCSIDaemonSetYAML(
+			"trident:test", "trident-node", false, version, PodTemplateOptions{}))
+		if daemonSet.Kind != "DaemonSet" || daemonSet.APIVersion != test.daemonSet {
+			t.Errorf("Kubernetes %s: expected daemonset %s, got %s %s", test.version, test.daemonSet,
+				daemonSet.Kind, daemonSet.APIVersion)
+		}
+	}
+}
+
+func TestRBACAPIVersions(t *testing.T) {
+
+	tests := []struct {
+		version    string
+		apiVersion string
+	}{
+		{"v1.7.0", "rbac.authorization.k8s.io/v1alpha1"},
+		{"v1.8.0", "rbac.authorization.k8s.io/v1"},
+		{"v1.16.0", "rbac.authorization.k8s.io/v1"},
+		{"v1.22.1", "rbac.authorization.k8s.io/v1"},
+	}
+
+	for _, test := range tests {
+		version := utils.MustParseSemantic(test.version)
+
+		clusterRole := getTypeMeta(t, GetClusterRoleYAML(FlavorKubernetes, version, false, nil))
+		if clusterRole.APIVersion != test.apiVersion {
+			t.Errorf("Kubernetes %s: expected cluster role %s, got %s", test.version, test.apiVersion,
+				clusterRole.APIVersion)
+		}
+
+		binding := getTypeMeta(t, GetClusterRoleBindingYAML("trident", FlavorKubernetes, version, false))
+		if binding.APIVersion != test.apiVersion {
+			t.Errorf("Kubernetes %s: expected cluster role binding %s, got %s", test.version, test.apiVersion,
+				binding.APIVersion)
+		}
+	}
+}
+
+func TestExtraVolumes(t *testing.T) {
+
+	options := PodTemplateOptions{
+		ExtraVolumes: []v1.Volume{{
+			Name:         "ca-bundle",
+			VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{}},
+		}},
+		ExtraVolumeMounts: []v1.VolumeMount{{Name: "ca-bundle", MountPath: "/etc/pki/custom", ReadOnly: true}},
+	}
+	version := utils.MustParseSemantic("v1.11.2")
+
+	workloads := map[string]string{
+		"deployment": GetDeploymentYAML(
+			"trident", "trident:test", "etcd:test", "trident", false, version, options),
+		"statefulset": GetCSIStatefulSetYAML(
+			"trident", "trident:test", "etcd:test", "trident", false, version, options),
+	}
+
+	for kind, workloadYAML := range workloads {
+		var workload struct {
+			Spec struct {
+				Template struct {
+					Spec v1.PodSpec `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+		}
+		if err := yaml.Unmarshal([]byte(workloadYAML), &workload); err != nil {
+			t.Fatalf("Could not parse generated %s YAML; %v", kind, err)
+		}
+		podSpec := workload.Spec.Template.Spec
+
+		volumeFound := false
+		for _, volume := range podSpec.Volumes {
+			if volume.Name == "ca-bundle" && volume.ConfigMap != nil {
+				volumeFound = true
+			}
+		}
+		if !volumeFound {
+			t.Errorf("Expected the %s to have the extra volume", kind)
+		}
+
+		for _, container := range podSpec.Containers {
+			mountFound := false
+			for _, mount := range container.VolumeMounts {
+				if mount.Name == "ca-bundle" && mount.MountPath == "/etc/pki/custom" && mount.ReadOnly {
+					mountFound = true
+				}
+			}
+			if container.Name == "trident-main" && !mountFound {
+				t.Errorf("Expected the %s's Trident container to mount the extra volume", kind)
+			} else if container.Name != "trident-main" && mountFound {
+				t.Errorf("Expected only the %s's Trident container to mount the extra volume", kind)
+			}
+		}
+	}
+}
+
+func TestStorageClassYAML(t *testing.T) {
+
+	parameters := map[string]string{
+		"fsType":       "xfs",
+		"snapshots":    "true",
+		"storagePools": "ontapsan:aggr1,aggr2",
+	}
+
+	var storageClass storagev1.StorageClass
+	storageClassYAML := GetStorageClassYAML("basic", "netapp.io/trident", "trident", parameters)
+	if err := yaml.Unmarshal([]byte(storageClassYAML), &storageClass); err != nil {
+		t.Fatalf("Could not parse generated storage class YAML; %v", err)
+	}
+	if storageClass.Name != "basic" || storageClass.Provisioner != "netapp.io/trident" {
+		t.Errorf("Unexpected storage class %s with provisioner %s", storageClass.Name, storageClass.Provisioner)
+	}
+	if !reflect.DeepEqual(storageClass.Parameters, parameters) {
+		t.Errorf("Expected parameters %v, got %v", parameters, storageClass.Parameters)
+	}
+
+	// Without parameters, the storage class has none
+	storageClass = storagev1.StorageClass{}
+	storageClassYAML = GetStorageClassYAML("basic", "netapp.io/trident", "trident", nil)
+	if err := yaml.Unmarshal([]byte(storageClassYAML), &storageClass); err != nil {
+		t.Fatalf("Could not parse generated storage class YAML; %v", err)
+	}
+	if len(storageClass.Parameters) != 0 {
+		t.Errorf("Expected no parameters, got %v", storageClass.Parameters)
+	}
+}
+
+func TestCHAPSecretData(t *testing.T) {
+
+	var secret v1.Secret
+	secretYAML := GetCHAPSecretYAML("chap", "user", "initiator", "target", "trident")
+	if err := yaml.Unmarshal([]byte(secretYAML), &secret); err != nil {
+		t.Fatalf("Could not parse generated secret YAML; %v", err)
+	}
+	if string(secret.Type) != CHAPSecretType {
+		t.Errorf("Expected secret type %s, got %s", CHAPSecretType, secret.Type)
+	}
+
+	data := GetCHAPSecretData("user", "initiator", "target")
+	if len(secret.Data) != len(data) {
+		t.Errorf("Expected %d keys in the secret, got %d", len(data), len(secret.Data))
+	}
+	for key, value := range data {
+		if string(secret.Data[key]) != value {
+			t.Errorf("Expected the secret's %s to be '%s', got '%s'", key, value, secret.Data[key])
+		}
+	}
+}
+
+func TestCredentialsSecretYAML(t *testing.T) {
+
+	var secret v1.Secret
+	secretYAML := GetCredentialsSecretYAML("backend-credentials", "trident", "admin", "pass:word")
+	if err := yaml.Unmarshal([]byte(secretYAML), &secret); err != nil {
+		t.Fatalf("Could not parse generated secret YAML; %v", err)
+	}
+	if secret.Name != "backend-credentials" {
+		t.Errorf("Expected secret name backend-credentials, got %s", secret.Name)
+	}
+	if secret.Labels["app"] != "trident" {
+		t.Errorf("Expected app label trident, got %s", secret.Labels["app"])
+	}
+	if string(secret.Data["username"]) != "admin" || string(secret.Data["password"]) != "pass:word" {
+		t.Errorf("Expected the secret to hold the username and password, got %v", secret.Data)
+	}
+}
+
+func TestClusterRoleLabels(t *testing.T) {
+
+	version := utils.MustParseSemantic("v1.10.0")
+	labels := map[string]string{"rbac.authorization.k8s.io/aggregate-to-admin": "true"}
+
+	for _, csi := range []bool{false, true} {
+		var clusterRole rbacv1.ClusterRole
+		if err := yaml.Unmarshal([]byte(GetClusterRoleYAML(FlavorKubernetes, version, csi, labels)),
+			&clusterRole); err != nil {
+			t.Fatalf("Could not parse generated cluster role YAML; %v", err)
+		}
+		if clusterRole.Labels["rbac.authorization.k8s.io/aggregate-to-admin"] != "true" {
+			t.Errorf("CSI %v: expected the aggregation label, got %v", csi, clusterRole.Labels)
+		}
+
+		clusterRole = rbacv1.ClusterRole{}
+		if err := yaml.Unmarshal([]byte(GetClusterRoleYAML(FlavorKubernetes, version, csi, nil)),
+			&clusterRole); err != nil {
+			t.Fatalf("Could not parse generated cluster role YAML; %v", err)
+		}
+		if len(clusterRole.Labels) != 0 || len(clusterRole.Rules) == 0 {
+			t.Errorf("CSI %v: expected rules and no labels, got %v", csi, clusterRole)
+		}
+	}
+}
+
+func TestImagePullSecrets(t *testing.T) {
+
+	version := utils.MustParseSemantic("v1.11.2")
+	expected := []v1.LocalObjectReference{{Name: "registry-auth"}, {Name: "mirror-auth"}}
+
+	for _, options := range []PodTemplateOptions{{}, {ImagePullSecrets: []string{"registry-auth", "mirror-auth"}}} {
+		workloads := map[string]string{
+			"deployment": GetDeploymentYAML(
+				"trident", "trident:test", "etcd:test", "trident", false, version, options),
+			"statefulset": GetCSIStatefulSetYAML(
+				"trident", "trident:test", "etcd:test", "trident", false, version, options),
+			"daemonset": GetCSIDaemonSetYAML("trident:test", "trident-node", false, version, options),
+		}
+
+		for kind, workloadYAML := range workloads {
+			var workload struct {
+				Spec struct {
+					Template struct {
+						Spec v1.PodSpec `json:"spec"`
+					} `json:"template"`
+				} `json:"spec"`
+			}
+			if err := yaml.Unmarshal([]byte(workloadYAML), &workload); err != nil {
+				t.Fatalf("Could not parse generated %s YAML; %v", kind, err)
+			}
+			pullSecrets := workload.Spec.Template.Spec.ImagePullSecrets
+
+			if len(options.ImagePullSecrets) == 0 && len(pullSecrets) != 0 {
+				t.Errorf("Expected the %s to have no image pull secrets, got %v", kind, pullSecrets)
+			} else if len(options.ImagePullSecrets) > 0 && !reflect.DeepEqual(pullSecrets, expected) {
+				t.Errorf("Expected the %s to have image pull secrets %v, got %v", kind, expected, pullSecrets)
+			}
+		}
+	}
+}
+
+func TestPluginRegistrationPath(t *testing.T) {
+
+	version := utils.MustParseSemantic("v1.11.2")
+	registrationPath := "/var/data/kubelet/plugins/io.netapp.trident.csi/csi.sock"
+
+	tests := []struct {
+		options      PodTemplateOptions
+		pluginDir    string
+		registrarArg string
+	}{
+		{PodTemplateOptions{}, "/var/lib/kubelet/plugins/io.netapp.trident.csi", ""},
+		{PodTemplateOptions{PluginRegistrationPath: registrationPath}, "/var/data/kubelet/plugins/io.netapp.trident.csi",
+			"--kubelet-registration-path=" + registrationPath},
+	}
+
+	for _, test := range tests {
+		var daemonSet struct {
+			Spec struct {
+				Template struct {
+					Spec v1.PodSpec `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+		}
+		daemonSetYAML := GetCSIDaemonSetYAML("trident:test", "trident-node", false, version, test.options)
+		if err := yaml.Unmarshal([]byte(daemonSetYAML), &daemonSet); err != nil {
+			t.Fatalf("Could not parse generated daemonset YAML; %v", err)
+		}
+		podSpec := daemonSet.Spec.Template.Spec
+
+		for _, volume := range podSpec.Volumes {
+			if volume.Name == "plugin-dir" && volume.HostPath.Path != test.pluginDir {
+				t.Errorf("Expected plugin directory %s, got %s", test.pluginDir, volume.HostPath.Path)
+			}
+		}
+		for _, container := range podSpec.Containers {
+			if container.Name != "driver-registrar" {
+				continue
+			}
+			var registrarArg string
+			for _, arg := range container.Args {
+				if strings.HasPrefix(arg, "--kubelet-registration-path=") {
+					registrarArg = arg
+				}
+			}
+			if registrarArg != test.registrarArg {
+				t.Errorf("Expected registrar argument '%s', got '%s'", test.registrarArg, registrarArg)
+			}
+		}
+	}
+}
+
+func TestFCPVYAML(t *testing.T) {
+
+	var pv v1.PersistentVolume
+	pvYAML := GetFCPVYAML("trident", "2Gi", "trident", "trident", []string{"2000005056ABCDEF"}, 0,
+		string(v1.ReadOnlyMany), "trident", nil)
+	if err := yaml.Unmarshal([]byte(pvYAML), &pv); err != nil {
+		t.Fatalf("Could not parse generated PV YAML; %v", err)
+	}
+	if pv.Spec.FC == nil {
+		t.Fatal("Expected an FC volume source in the PV")
+	}
+	if pv.Spec.FC.Lun == nil || *pv.Spec.FC.Lun != 0 {
+		t.Errorf("Expected LUN 0, got %v", pv.Spec.FC.Lun)
+	}
+	if !reflect.DeepEqual(pv.Spec.FC.TargetWWNs, []string{"2000005056abcdef"}) {
+		t.Errorf("Expected the lowercase target WWN, got %v", pv.Spec.FC.TargetWWNs)
+	}
+	if !reflect.DeepEqual(pv.Spec.AccessModes, []v1.PersistentVolumeAccessMode{v1.ReadOnlyMany}) {
+		t.Errorf("Expected access mode %s, got %v", v1.ReadOnlyMany, pv.Spec.AccessModes)
+	}
+}
@@ -62,6 +62,13 @@ const (
 	ContainerTrident = "trident-main"
 	ContainerEtcd    = "etcd"
 
+	// Directory in which the Trident pod mounts the secrets referenced by backend configs
+	CredentialsSecretsPath = "/var/run/secrets/trident.netapp.io/credentials"
+
+	// Directory in which the Trident pod mounts a custom Kubernetes API server CA bundle
+	KubernetesAPICAPath     = "/var/run/secrets/trident.netapp.io/k8s-api-ca"
+	KubernetesAPICAFilename = "ca.crt"
+
 	ContextDocker     DriverContext = "docker"
 	ContextKubernetes DriverContext = "kubernetes"
 	ContextCSI        DriverContext = "csi"
@@ -102,6 +109,9 @@ var (
 
 	OrchestratorVersion = utils.MustParseDate(version())
 
+	// StorageAPITimeout is the HTTP client timeout for storage backend API calls
+	StorageAPITimeout = StorageAPITimeoutSeconds * time.Second
+
 	/* API Server and persistent store variables */
 	BaseURL         = "/" + OrchestratorName + "/v" + OrchestratorAPIVersion
 	VersionURL      = "/" + OrchestratorName + "/v" + OrchestratorAPIVersion + "/version"
@@ -110,6 +120,7 @@ var (
 	TransactionURL  = "/" + OrchestratorName + "/v" + OrchestratorAPIVersion + "/txn"
 	StorageClassURL = "/" + OrchestratorName + "/v" + OrchestratorAPIVersion + "/storageclass"
 	StoreURL        = "/" + OrchestratorName + "/store"
+	DrainURL        = "/" + OrchestratorName + "/v" + OrchestratorAPIVersion + "/drain"
 
 	UsingPassthroughStore bool
 	CurrentDriverContext  DriverContext
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +23,9 @@ import (
 	"github.com/netapp/trident/utils"
 )
 
+// drainPollInterval is how often a drain checks whether the storage operations in flight finished
+const drainPollInterval = 100 * time.Millisecond
+
 type TridentOrchestrator struct {
 	backends       map[string]*storage.Backend
 	volumes        map[string]*storage.Volume
@@ -30,7 +34,14 @@ type TridentOrchestrator struct {
 	storageClasses map[string]*storageclass.StorageClass
 	storeClient    persistentstore.Client
 	bootstrapped   bool
-	bootstrapError error
+
+	// bootstrapError, draining and the storage operations in flight, which a drain waits for, are
+	// guarded by stateMutex rather than mutex, which storage operations hold while they run
+	bootstrapError  error
+	draining        bool
+	operations      map[uint64]string
+	nextOperationID uint64
+	stateMutex      *sync.Mutex
 }
 
 // NewTridentOrchestrator returns a storage orchestrator instance
@@ -44,6 +55,9 @@ func NewTridentOrchestrator(client persistentstore.Client) *TridentOrchestrator
 		storeClient:    client,
 		bootstrapped:   false,
 		bootstrapError: notReadyError(),
+
+		operations: make(map[uint64]string),
+		stateMutex: &sync.Mutex{},
 	}
 }
 
@@ -101,18 +115,20 @@ func (o *TridentOrchestrator) Bootstrap() error {
 
 	// Transform persistent state, if necessary
 	if err = o.transformPersistentState(); err != nil {
-		o.bootstrapError = bootstrapError(err)
-		return o.bootstrapError
+		err = bootstrapError(err)
+		o.setBootstrapError(err)
+		return err
 	}
 
 	// Bootstrap state from persistent store
 	if err = o.bootstrap(); err != nil {
-		o.bootstrapError = bootstrapError(err)
-		return o.bootstrapError
+		err = bootstrapError(err)
+		o.setBootstrapError(err)
+		return err
 	}
 
 	o.bootstrapped = true
-	o.bootstrapError = nil
+	o.setBootstrapError(nil)
 	log.Infof("%s bootstrapped successfully.", strings.Title(config.OrchestratorName))
 	return nil
 }
@@ -356,14 +372,16 @@ func (o *TridentOrchestrator) validateBackendUpdate(
 }
 
 func (o *TridentOrchestrator) GetVersion() (string, error) {
-	return config.OrchestratorVersion.String(), o.bootstrapError
+	return config.OrchestratorVersion.String(), o.getBootstrapError()
 }
 
 // AddBackend handles creation of a new storage backend
 func (o *TridentOrchestrator) AddBackend(configJSON string) (*storage.BackendExternal, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	done, err := o.trackOperation("add backend")
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
 	o.mutex.Lock()
 	defer o.mutex.Unlock()
@@ -430,9 +448,11 @@ func (o *TridentOrchestrator) addBackend(configJSON string) (backendExternal *st
 // UpdateBackend updates an existing backend.
 func (o *TridentOrchestrator) UpdateBackend(backendName, configJSON string) (
 	backendExternal *storage.BackendExternal, err error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	done, err := o.trackOperation("update backend " + backendName)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
 	o.mutex.Lock()
 	defer o.mutex.Unlock()
@@ -570,8 +590,8 @@ func (o *TridentOrchestrator) updateBackend(backendName, configJSON string) (
 }
 
 func (o *TridentOrchestrator) GetBackend(backendName string) (*storage.BackendExternal, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	o.mutex.Lock()
@@ -585,8 +605,8 @@ func (o *TridentOrchestrator) GetBackend(backendName string) (*storage.BackendEx
 }
 
 func (o *TridentOrchestrator) ListBackends() ([]*storage.BackendExternal, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	o.mutex.Lock()
@@ -602,8 +622,8 @@ func (o *TridentOrchestrator) ListBackends() ([]*storage.BackendExternal, error)
 }
 
 func (o *TridentOrchestrator) OfflineBackend(backendName string) error {
-	if o.bootstrapError != nil {
-		return o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return err
 	}
 
 	o.mutex.Lock()
@@ -636,9 +656,11 @@ func (o *TridentOrchestrator) OfflineBackend(backendName string) error {
 func (o *TridentOrchestrator) AddVolume(volumeConfig *storage.VolumeConfig) (
 	externalVol *storage.VolumeExternal, err error) {
 
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	done, err := o.trackOperation("add volume " + volumeConfig.Name)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
 	var (
 		backend *storage.Backend
@@ -731,9 +753,11 @@ func (o *TridentOrchestrator) AddVolume(volumeConfig *storage.VolumeConfig) (
 func (o *TridentOrchestrator) CloneVolume(volumeConfig *storage.VolumeConfig) (
 	*storage.VolumeExternal, error) {
 
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	done, err := o.trackOperation("clone volume " + volumeConfig.Name)
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
 	var (
 		found   bool
@@ -900,8 +924,8 @@ func (o *TridentOrchestrator) addVolumeCleanup(
 }
 
 func (o *TridentOrchestrator) GetVolume(volume string) (*storage.VolumeExternal, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	o.mutex.Lock()
@@ -915,8 +939,8 @@ func (o *TridentOrchestrator) GetVolume(volume string) (*storage.VolumeExternal,
 }
 
 func (o *TridentOrchestrator) GetDriverTypeForVolume(vol *storage.VolumeExternal) (string, error) {
-	if o.bootstrapError != nil {
-		return config.UnknownDriver, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return config.UnknownDriver, err
 	}
 
 	o.mutex.Lock()
@@ -931,8 +955,8 @@ func (o *TridentOrchestrator) GetDriverTypeForVolume(vol *storage.VolumeExternal
 func (o *TridentOrchestrator) GetVolumeType(vol *storage.VolumeExternal) (
 	volumeType config.VolumeType, err error,
 ) {
-	if o.bootstrapError != nil {
-		return config.UnknownVolumeType, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return config.UnknownVolumeType, err
 	}
 
 	o.mutex.Lock()
@@ -960,8 +984,8 @@ func (o *TridentOrchestrator) GetVolumeType(vol *storage.VolumeExternal) (
 }
 
 func (o *TridentOrchestrator) ListVolumes() ([]*storage.VolumeExternal, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	o.mutex.Lock()
@@ -1024,10 +1048,11 @@ func (o *TridentOrchestrator) deleteVolume(volumeName string) error {
 // of normal operation, verifying that the volume is present in Trident and
 // creating a transaction to ensure that the delete eventually completes.
 func (o *TridentOrchestrator) DeleteVolume(volumeName string) (err error) {
-	if o.bootstrapError != nil {
-		err = o.bootstrapError
-		return
+	done, err := o.trackOperation("delete volume " + volumeName)
+	if err != nil {
+		return err
 	}
+	defer done()
 
 	o.mutex.Lock()
 	defer o.mutex.Unlock()
@@ -1067,8 +1092,8 @@ func (o *TridentOrchestrator) DeleteVolume(volumeName string) (err error) {
 }
 
 func (o *TridentOrchestrator) ListVolumesByPlugin(pluginName string) ([]*storage.VolumeExternal, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	o.mutex.Lock()
@@ -1089,8 +1114,8 @@ func (o *TridentOrchestrator) ListVolumesByPlugin(pluginName string) ([]*storage
 func (o *TridentOrchestrator) PublishVolume(
 	volumeName string, publishInfo *utils.VolumePublishInfo,
 ) error {
-	if o.bootstrapError != nil {
-		return o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return err
 	}
 
 	o.mutex.Lock()
@@ -1111,8 +1136,8 @@ func (o *TridentOrchestrator) PublishVolume(
 func (o *TridentOrchestrator) AttachVolume(
 	volumeName, mountpoint string, publishInfo *utils.VolumePublishInfo,
 ) error {
-	if o.bootstrapError != nil {
-		return o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return err
 	}
 
 	o.mutex.Lock()
@@ -1163,8 +1188,8 @@ func (o *TridentOrchestrator) AttachVolume(
 // which the volume will be attached.  It ensures the volume is already mounted, and it attempts to
 // delete the mount point.
 func (o *TridentOrchestrator) DetachVolume(volumeName, mountpoint string) error {
-	if o.bootstrapError != nil {
-		return o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return err
 	}
 
 	if _, ok := o.volumes[volumeName]; !ok {
@@ -1191,8 +1216,8 @@ func (o *TridentOrchestrator) DetachVolume(volumeName, mountpoint string) error
 }
 
 func (o *TridentOrchestrator) ListVolumeSnapshots(volumeName string) ([]*storage.SnapshotExternal, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	volume, ok := o.volumes[volumeName]
@@ -1213,8 +1238,8 @@ func (o *TridentOrchestrator) ListVolumeSnapshots(volumeName string) ([]*storage
 }
 
 func (o *TridentOrchestrator) ReloadVolumes() error {
-	if o.bootstrapError != nil {
-		return o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return err
 	}
 
 	// Lock out all other workflows while we reload the volumes
@@ -1294,8 +1319,8 @@ func (o *TridentOrchestrator) getProtocol(
 }
 
 func (o *TridentOrchestrator) AddStorageClass(scConfig *storageclass.Config) (*storageclass.External, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	o.mutex.Lock()
@@ -1326,8 +1351,8 @@ func (o *TridentOrchestrator) AddStorageClass(scConfig *storageclass.Config) (*s
 }
 
 func (o *TridentOrchestrator) GetStorageClass(scName string) (*storageclass.External, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	o.mutex.Lock()
@@ -1343,8 +1368,8 @@ func (o *TridentOrchestrator) GetStorageClass(scName string) (*storageclass.Exte
 }
 
 func (o *TridentOrchestrator) ListStorageClasses() ([]*storageclass.External, error) {
-	if o.bootstrapError != nil {
-		return nil, o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return nil, err
 	}
 
 	o.mutex.Lock()
@@ -1358,8 +1383,8 @@ func (o *TridentOrchestrator) ListStorageClasses() ([]*storageclass.External, er
 }
 
 func (o *TridentOrchestrator) DeleteStorageClass(scName string) error {
-	if o.bootstrapError != nil {
-		return o.bootstrapError
+	if err := o.getBootstrapError(); err != nil {
+		return err
 	}
 
 	sc, found := o.storageClasses[scName]
@@ -1422,6 +1447,114 @@ func (o *TridentOrchestrator) replaceBackendAndUpdateVolumesOnPersistentStore(
 	return o.storeClient.ReplaceBackendAndUpdateVolumes(origBackend, newBackend)
 }
 
+// getBootstrapError returns the error that operations fail with while the orchestrator isn't ready.
+// A drain doesn't affect it, so reads and probes keep working while storage operations are drained.
+func (o *TridentOrchestrator) getBootstrapError() error {
+
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	return o.bootstrapError
+}
+
+func (o *TridentOrchestrator) setBootstrapError(err error) {
+
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	o.bootstrapError = err
+}
+
+// trackOperation records a storage operation as in flight until the returned function is called,
+// or returns the error the operation fails with if the orchestrator isn't ready or is draining.
+// Checking and recording under one lock means a drain can't miss an operation that starts while
+// the drain begins.
+func (o *TridentOrchestrator) trackOperation(description string) (func(), error) {
+
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	if o.bootstrapError != nil {
+		return func() {}, o.bootstrapError
+	}
+	if o.draining {
+		return func() {}, drainingError()
+	}
+
+	id := o.nextOperationID
+	o.nextOperationID++
+	o.operations[id] = description
+
+	return func() {
+		o.stateMutex.Lock()
+		defer o.stateMutex.Unlock()
+		delete(o.operations, id)
+	}, nil
+}
+
+// Drain stops the orchestrator from starting storage operations, so it can be shut down without
+// interrupting one, and waits up to the timeout for those in flight to finish.  It returns the
+// operations still in flight when the timeout expired.  New operations fail with a NotReadyError,
+// which the frontends retry, so they are started again once Trident is running again, or once
+// Undrain is called if Trident isn't shut down after all.
+func (o *TridentOrchestrator) Drain(timeout time.Duration) []string {
+
+	o.stateMutex.Lock()
+	o.draining = true
+	o.stateMutex.Unlock()
+
+	log.WithField("timeout", timeout).Info("Draining storage operations.")
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := o.getOperations()
+		if len(pending) == 0 {
+			log.Info("Drained storage operations.")
+			return pending
+		}
+		if time.Now().After(deadline) {
+			log.WithField("operations", strings.Join(pending, ", ")).Warning(
+				"Storage operations did not drain before the timeout.")
+			return pending
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// Undrain lets the orchestrator start storage operations again after a drain, such as when
+// shutting it down failed.
+func (o *TridentOrchestrator) Undrain() {
+
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	if o.draining {
+		o.draining = false
+		log.Info("Resumed storage operations.")
+	}
+}
+
+// getOperations returns the storage operations in flight, sorted.
+func (o *TridentOrchestrator) getOperations() []string {
+
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	operations := make([]string, 0, len(o.operations))
+	for _, operation := range o.operations {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+func drainingError() error {
+	return &NotReadyError{
+		fmt.Sprintf("%s is draining for shutdown, please try again later",
+			strings.Title(config.OrchestratorName)),
+	}
+}
+
 func notReadyError() error {
 	return &NotReadyError{
 		fmt.Sprintf("%s is initializing, please try again later",
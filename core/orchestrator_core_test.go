@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -2016,3 +2017,92 @@ func TestOrchestratorNotReady(t *testing.T) {
 		t.Errorf("Expected DeleteStorageClass to return an error.")
 	}
 }
+
+func TestTrackOperation(t *testing.T) {
+
+	orchestrator := getOrchestrator()
+
+	doneB, err := orchestrator.trackOperation("add volume b")
+	if err != nil {
+		t.Fatalf("Unexpected error tracking an operation: %v", err)
+	}
+	doneA, err := orchestrator.trackOperation("add volume a")
+	if err != nil {
+		t.Fatalf("Unexpected error tracking an operation: %v", err)
+	}
+	if operations := orchestrator.getOperations(); !reflect.DeepEqual(operations,
+		[]string{"add volume a", "add volume b"}) {
+		t.Errorf("Expected both operations in flight, got %v.", operations)
+	}
+
+	doneB()
+	if operations := orchestrator.getOperations(); !reflect.DeepEqual(operations, []string{"add volume a"}) {
+		t.Errorf("Expected one operation in flight, got %v.", operations)
+	}
+	doneA()
+	if operations := orchestrator.getOperations(); len(operations) != 0 {
+		t.Errorf("Expected no operations in flight, got %v.", operations)
+	}
+
+	// An operation isn't tracked while the orchestrator isn't ready
+	orchestrator.bootstrapError = notReadyError()
+	if _, err = orchestrator.trackOperation("add volume c"); !IsNotReadyError(err) {
+		t.Errorf("Expected a NotReadyError, got %v.", err)
+	}
+	if operations := orchestrator.getOperations(); len(operations) != 0 {
+		t.Errorf("Expected no operations in flight, got %v.", operations)
+	}
+}
+
+func TestDrain(t *testing.T) {
+
+	orchestrator := getOrchestrator()
+
+	done, err := orchestrator.trackOperation("add volume vol1")
+	if err != nil {
+		t.Fatalf("Unexpected error tracking an operation: %v", err)
+	}
+
+	// An operation that doesn't finish before the timeout is returned
+	pending := orchestrator.Drain(2 * drainPollInterval)
+	if !reflect.DeepEqual(pending, []string{"add volume vol1"}) {
+		t.Errorf("Expected the operation in flight to be pending, got %v.", pending)
+	}
+
+	// New operations are rejected while draining
+	if _, err = orchestrator.AddBackend(""); !IsNotReadyError(err) {
+		t.Errorf("Expected AddBackend to return a NotReadyError while draining, got %v.", err)
+	}
+	if err = orchestrator.DeleteVolume("vol2"); !IsNotReadyError(err) {
+		t.Errorf("Expected DeleteVolume to return a NotReadyError while draining, got %v.", err)
+	}
+
+	// Reads aren't storage operations, so they succeed while draining
+	if _, err = orchestrator.ListBackends(); err != nil {
+		t.Errorf("Expected ListBackends to succeed while draining, got %v.", err)
+	}
+
+	// A drain waits for the operations in flight to finish
+	go func() {
+		time.Sleep(2 * drainPollInterval)
+		done()
+	}()
+	if pending = orchestrator.Drain(time.Minute); len(pending) != 0 {
+		t.Errorf("Expected no pending operations, got %v.", pending)
+	}
+
+	// Operations are accepted again after an undrain
+	orchestrator.Undrain()
+	if done, err = orchestrator.trackOperation("add volume vol3"); err != nil {
+		t.Errorf("Expected no error after an undrain, got %v.", err)
+	}
+	done()
+
+	// An undrain doesn't make an orchestrator that isn't ready accept operations
+	orchestrator.bootstrapError = notReadyError()
+	orchestrator.Drain(0)
+	orchestrator.Undrain()
+	if _, err = orchestrator.trackOperation("add volume vol4"); !IsNotReadyError(err) {
+		t.Errorf("Expected a NotReadyError after an undrain, got %v.", err)
+	}
+}
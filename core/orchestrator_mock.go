@@ -66,6 +66,13 @@ func (m *MockOrchestrator) GetVersion() (string, error) {
 	return config.OrchestratorVersion.String(), nil
 }
 
+func (m *MockOrchestrator) Drain(timeout time.Duration) []string {
+	return []string{}
+}
+
+func (m *MockOrchestrator) Undrain() {
+}
+
 // TODO:  Add extra methods to add backends without needing to provide a valid,
 // stringified JSON config.
 func (m *MockOrchestrator) AddBackend(configJSON string) (*storage.BackendExternal, error) {
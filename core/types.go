@@ -3,6 +3,8 @@
 package core
 
 import (
+	"time"
+
 	"github.com/netapp/trident/config"
 	"github.com/netapp/trident/frontend"
 	"github.com/netapp/trident/storage"
@@ -14,6 +16,8 @@ type Orchestrator interface {
 	Bootstrap() error
 	AddFrontend(f frontend.Plugin)
 	GetVersion() (string, error)
+	Drain(timeout time.Duration) []string
+	Undrain()
 
 	AddBackend(configJSON string) (*storage.BackendExternal, error)
 	UpdateBackend(backendName, configJSON string) (storageBackendExternal *storage.BackendExternal, err error)
@@ -9,7 +9,7 @@ import (
 )
 
 const (
-	KubernetesSyncPeriod = 60 * time.Second
+	DefaultKubernetesSyncPeriod = 60 * time.Second
 
 	// Kubernetes-defined storage class parameters
 	K8sFsType = "fsType"
@@ -39,3 +39,18 @@ const (
 	AnnCloneFromPVC    = AnnPrefix + "/cloneFromPVC"
 	AnnSplitOnClone    = AnnPrefix + "/splitOnClone"
 )
+
+// ProvisioningWorkers is the number of claims the frontend may provision concurrently.  It must
+// be set before the frontend is created.
+var ProvisioningWorkers = 1
+
+// KubernetesSyncPeriod is how often the frontend's informers resync the claims, volumes, and
+// storage classes it watches.  It must be set before the frontend is created.
+var KubernetesSyncPeriod = DefaultKubernetesSyncPeriod
+
+// APIQPS and APIBurst, if positive, replace the client-side rate limits of the frontend's
+// Kubernetes API client.  They must be set before the frontend is created.
+var (
+	APIQPS   float32
+	APIBurst int
+)
@@ -7,6 +7,7 @@ package kubernetes
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"strings"
 	"sync"
 
@@ -88,6 +89,8 @@ type Plugin struct {
 	defaultStorageClasses    map[string]bool
 	storageClassCache        map[string]*StorageClassSummary
 	tridentNamespace         string
+	provisioningSlots        chan struct{}
+	claimsInFlight           map[string]bool
 }
 
 func NewPlugin(o core.Orchestrator, apiServerIP, kubeConfigPath string) (*Plugin, error) {
@@ -110,11 +113,24 @@ func NewPlugin(o core.Orchestrator, apiServerIP, kubeConfigPath string) (*Plugin
 	return newKubernetesPlugin(o, kubeConfig, tridentNamespace)
 }
 
-func NewPluginInCluster(o core.Orchestrator) (*Plugin, error) {
+// NewPluginInCluster returns a frontend that uses the pod's service account to reach the
+// Kubernetes API server.  If apiServerCAFile is specified, the API server is verified with
+// that CA bundle instead of the one provided with the service account.
+func NewPluginInCluster(o core.Orchestrator, apiServerCAFile string) (*Plugin, error) {
 	kubeConfig, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
 	}
+	if apiServerCAFile != "" {
+		kubeConfig.TLSClientConfig.CAFile = apiServerCAFile
+		kubeConfig.TLSClientConfig.CAData = nil
+		log.WithField("caFile", apiServerCAFile).Info("Using custom Kubernetes API server CA.")
+	}
+
+	// Use the current service account token, which may be a projected one that is replaced
+	kubeConfig.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return newTokenFileRoundTripper(serviceAccountTokenFile, rt)
+	}
 
 	// when running in a pod, we use the Trident pod's namespace
 	bytes, err := ioutil.ReadFile(tridentNamespaceFile)
@@ -135,6 +151,19 @@ func newKubernetesPlugin(orchestrator core.Orchestrator, kubeConfig *rest.Config
 		"namespace": tridentNamespace,
 	}).Info("Initializing Kubernetes frontend.")
 
+	if APIQPS > 0 {
+		kubeConfig.QPS = APIQPS
+	}
+	if APIBurst > 0 {
+		kubeConfig.Burst = APIBurst
+	}
+	if APIQPS > 0 || APIBurst > 0 {
+		log.WithFields(log.Fields{
+			"qps":   kubeConfig.QPS,
+			"burst": kubeConfig.Burst,
+		}).Info("Using custom Kubernetes API client rate limits.")
+	}
+
 	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		return nil, err
@@ -153,6 +182,14 @@ func newKubernetesPlugin(orchestrator core.Orchestrator, kubeConfig *rest.Config
 		defaultStorageClasses: make(map[string]bool, 1),
 		storageClassCache:     make(map[string]*StorageClassSummary),
 		tridentNamespace:      tridentNamespace,
+		claimsInFlight:        make(map[string]bool),
+	}
+
+	// With more than one worker, claims are provisioned in the background so that a slow
+	// backend doesn't hold up the claims behind it.
+	if ProvisioningWorkers > 1 {
+		ret.provisioningSlots = make(chan struct{}, ProvisioningWorkers)
+		log.WithField("workers", ProvisioningWorkers).Info("Kubernetes frontend will provision claims concurrently.")
 	}
 
 	ret.kubernetesVersion, err = kubeClient.Discovery().ServerVersion()
@@ -619,8 +656,36 @@ func (p *Plugin) processPendingClaim(claim *v1.PersistentVolumeClaim) {
 		}
 		delete(p.pendingClaimMatchMap, orchestratorClaimName)
 	}
+
+	if p.provisioningSlots == nil {
+		p.mutex.Unlock()
+		p.provisionClaim(orchestratorClaimName, claim)
+		return
+	}
+
+	// Ignore the claim if a volume is already being provisioned for it
+	if p.claimsInFlight[orchestratorClaimName] {
+		p.mutex.Unlock()
+		return
+	}
+	p.claimsInFlight[orchestratorClaimName] = true
 	p.mutex.Unlock()
 
+	go func() {
+		p.provisioningSlots <- struct{}{}
+		defer func() {
+			<-p.provisioningSlots
+			p.mutex.Lock()
+			delete(p.claimsInFlight, orchestratorClaimName)
+			p.mutex.Unlock()
+		}()
+		p.provisionClaim(orchestratorClaimName, claim)
+	}()
+}
+
+// provisionClaim provisions a new volume and PV for a pending claim.
+func (p *Plugin) provisionClaim(orchestratorClaimName string, claim *v1.PersistentVolumeClaim) {
+
 	// We need to provision a new volume for this claim.
 	pv, err := p.createVolumeAndPV(orchestratorClaimName, claim)
 	if err != nil {
@@ -678,7 +743,13 @@ func (p *Plugin) createVolumeAndPV(uniqueName string, claim *v1.PersistentVolume
 	accessModes := claim.Spec.AccessModes
 	annotations := claim.Annotations
 	storageClass := GetPersistentVolumeClaimClass(claim)
-	if storageClassSummary, found := p.storageClassCache[storageClass]; found {
+
+	// Claims may be provisioned concurrently with storage class updates, so the cache is read once
+	// under the lock.  Cached summaries are replaced rather than modified, so this one is stable.
+	p.mutex.Lock()
+	storageClassSummary, found := p.storageClassCache[storageClass]
+	p.mutex.Unlock()
+	if found {
 		storageClassParams = storageClassSummary.Parameters
 	}
 
@@ -790,9 +861,9 @@ func (p *Plugin) createVolumeAndPV(uniqueName string, claim *v1.PersistentVolume
 	case kubeVersion.AtLeast(k8sutilversion.MustParseSemantic("v1.8.0")):
 		pv.Spec.StorageClassName = GetPersistentVolumeClaimClass(claim)
 		// Apply Storage Class mount options and reclaim policy
-		pv.Spec.MountOptions = p.storageClassCache[storageClass].MountOptions
+		pv.Spec.MountOptions = storageClassSummary.MountOptions
 		pv.Spec.PersistentVolumeReclaimPolicy =
-			*p.storageClassCache[storageClass].PersistentVolumeReclaimPolicy
+			*storageClassSummary.PersistentVolumeReclaimPolicy
 	case kubeVersion.AtLeast(k8sutilversion.MustParseSemantic("v1.6.0")):
 		pv.Spec.StorageClassName = GetPersistentVolumeClaimClass(claim)
 	}
@@ -824,6 +895,9 @@ func (p *Plugin) createVolumeAndPV(uniqueName string, claim *v1.PersistentVolume
 
 	driverType, _ := p.orchestrator.GetDriverTypeForVolume(vol)
 	switch {
+	case driverType == drivers.OntapSANStorageDriverName && len(vol.Config.AccessInfo.FcTargetWWNs) > 0:
+		// An ontap-san backend with sanType fcp maps its LUNs to the nodes over FC
+		pv.Spec.FC = CreateFCVolumeSource(vol)
 	case driverType == drivers.SolidfireSANStorageDriverName ||
 		driverType == drivers.OntapSANStorageDriverName ||
 		driverType == drivers.EseriesIscsiStorageDriverName:
@@ -0,0 +1,71 @@
+/*
+ * Copyright 2018 NetApp, Inc. All Rights Reserved.
+ */
+
+package kubernetes
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// tokenFileReadInterval is how often the service account token is read again, well within
+	// the shortest lifetime of a projected token
+	tokenFileReadInterval = time.Minute
+)
+
+// tokenFileRoundTripper sets the bearer token of each request to the contents of a token file,
+// reading the file again periodically.  The client library reads the service account token only
+// once, but the kubelet replaces a projected token before it expires.
+type tokenFileRoundTripper struct {
+	tokenFile string
+	rt        http.RoundTripper
+
+	lock     sync.Mutex
+	token    string
+	readTime time.Time
+}
+
+func newTokenFileRoundTripper(tokenFile string, rt http.RoundTripper) *tokenFileRoundTripper {
+	return &tokenFileRoundTripper{tokenFile: tokenFile, rt: rt}
+}
+
+func (t *tokenFileRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if token := t.getToken(); token != "" {
+		// The request was already copied by the bearer token round tripper of the client library
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return t.rt.RoundTrip(req)
+}
+
+// getToken returns the token, reading the file if it hasn't been read recently.  If the file
+// can't be read, the last token read, if any, is used.
+func (t *tokenFileRoundTripper) getToken() string {
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if time.Since(t.readTime) < tokenFileReadInterval {
+		return t.token
+	}
+	t.readTime = time.Now()
+
+	tokenBytes, err := ioutil.ReadFile(t.tokenFile)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"tokenFile": t.tokenFile,
+			"error":     err,
+		}).Warning("Could not read the service account token.")
+		return t.token
+	}
+	t.token = strings.TrimSpace(string(tokenBytes))
+	return t.token
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package kubernetes
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+type recordingRoundTripper struct {
+	authorization string
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.authorization = req.Header.Get("Authorization")
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestTokenFileRoundTripper(t *testing.T) {
+
+	tokenFile, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tokenFile.Name())
+
+	sendRequest := func(rt http.RoundTripper) {
+		req, _ := http.NewRequest("GET", "https://127.0.0.1/api", nil)
+		req.Header.Set("Authorization", "Bearer startup-token")
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recorder := &recordingRoundTripper{}
+	rt := newTokenFileRoundTripper(tokenFile.Name(), recorder)
+
+	if err = ioutil.WriteFile(tokenFile.Name(), []byte("token1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sendRequest(rt)
+	if recorder.authorization != "Bearer token1" {
+		t.Errorf("Expected the token from the file, got '%s'", recorder.authorization)
+	}
+
+	// A replaced token is used once the file is read again
+	if err = ioutil.WriteFile(tokenFile.Name(), []byte("token2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sendRequest(rt)
+	if recorder.authorization != "Bearer token1" {
+		t.Errorf("Expected the token read recently, got '%s'", recorder.authorization)
+	}
+	rt.readTime = time.Now().Add(-tokenFileReadInterval)
+	sendRequest(rt)
+	if recorder.authorization != "Bearer token2" {
+		t.Errorf("Expected the replaced token, got '%s'", recorder.authorization)
+	}
+
+	// The last token read is used if the file can't be read
+	os.Remove(tokenFile.Name())
+	rt.readTime = time.Time{}
+	sendRequest(rt)
+	if recorder.authorization != "Bearer token2" {
+		t.Errorf("Expected the last token read, got '%s'", recorder.authorization)
+	}
+}
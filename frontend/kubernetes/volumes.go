@@ -110,6 +110,18 @@ func CreateNFSVolumeSource(vol *storage.VolumeExternal) *v1.NFSVolumeSource {
 	}
 }
 
+// CreateFCVolumeSource returns the FC source of a PV for a LUN the storage backend maps to the
+// nodes over FC, which needs no CHAP secret.
+func CreateFCVolumeSource(vol *storage.VolumeExternal) *v1.FCVolumeSource {
+	volConfig := vol.Config
+	lun := volConfig.AccessInfo.FcLunNumber
+	return &v1.FCVolumeSource{
+		TargetWWNs: volConfig.AccessInfo.FcTargetWWNs,
+		Lun:        &lun,
+		FSType:     volConfig.FileSystem,
+	}
+}
+
 func findOrCreateCHAPSecret(k8sClient k8sclient.Interface, kubeVersion *k8sutilversion.Version, vol *storage.VolumeExternal) (string, error) {
 	volConfig := vol.Config
 	secretName := vol.GetCHAPSecretName()
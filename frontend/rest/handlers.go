@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -283,6 +284,46 @@ func GetVersion(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+// DefaultDrainTimeout bounds a drain whose request doesn't specify a timeout.
+const DefaultDrainTimeout = 60 * time.Second
+
+type DrainResponse struct {
+	PendingOperations []string `json:"pendingOperations"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// Drain stops Trident from starting storage operations and waits for those in flight, up to the
+// duration in the timeout query parameter, before Trident is shut down.
+func Drain(w http.ResponseWriter, r *http.Request) {
+	response := &DrainResponse{PendingOperations: []string{}}
+	GetGenericNoArg(w, r, response,
+		func() int {
+			timeout := DefaultDrainTimeout
+			if timeoutParam := r.URL.Query().Get("timeout"); timeoutParam != "" {
+				var err error
+				if timeout, err = time.ParseDuration(timeoutParam); err != nil || timeout < 0 {
+					response.Error = fmt.Sprintf("invalid timeout '%s'", timeoutParam)
+					return http.StatusBadRequest
+				}
+			}
+			response.PendingOperations = orchestrator.Drain(timeout)
+			return http.StatusOK
+		},
+	)
+}
+
+// Undrain lets Trident start storage operations again after a drain, such as when shutting it down
+// failed.
+func Undrain(w http.ResponseWriter, r *http.Request) {
+	DeleteGeneric(w, r,
+		func(string) error {
+			orchestrator.Undrain()
+			return nil
+		},
+		"",
+	)
+}
+
 func AddBackend(w http.ResponseWriter, r *http.Request) {
 	response := &AddBackendResponse{}
 	AddGeneric(w, r, response,
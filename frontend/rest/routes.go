@@ -109,4 +109,16 @@ var routes = Routes{
 		config.StorageClassURL + "/{storageClass}",
 		DeleteStorageClass,
 	},
+	Route{
+		"Drain",
+		"POST",
+		config.DrainURL,
+		Drain,
+	},
+	Route{
+		"Undrain",
+		"DELETE",
+		config.DrainURL,
+		Undrain,
+	},
 }
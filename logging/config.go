@@ -7,5 +7,6 @@ import "github.com/netapp/trident/config"
 const (
 	LogRoot              = "/var/log/" + config.OrchestratorName
 	LogRotationThreshold = 10485760 // 10 MB
+	DefaultLogMaxBackups = 1
 	MaxLogEntryLength    = 64000
 )
@@ -21,15 +21,22 @@ import (
 	"github.com/netapp/trident/utils"
 )
 
+// LogRotation bounds the disk space used by a log file and the backups it is rotated to.
+type LogRotation struct {
+	MaxSize    int64         // size in bytes at which the log file is rotated
+	MaxBackups int           // number of rotated files kept
+	MaxAge     time.Duration // age after which rotated files are removed, or 0 to keep them
+}
+
 // InitLogging configures logging for nDVP.  Logs are written both to a log file as well as stdout/stderr.
 // Since logrus doesn't support multiple writers, each log stream is implemented as a hook.
-func InitLogging(logName string) error {
+func InitLogging(logName string, rotation LogRotation) error {
 
 	// No output except for the hooks
 	log.SetOutput(ioutil.Discard)
 
 	// Write to the log file
-	logFileHook, err := NewFileHook(logName)
+	logFileHook, err := NewFileHook(logName, rotation)
 	if err != nil {
 		return fmt.Errorf("could not initialize logging to file %s: %v", logFileHook.GetLocation(), err)
 	}
@@ -48,6 +55,25 @@ func InitLogging(logName string) error {
 	return nil
 }
 
+// InitFileLogging additionally writes logs to a log file, leaving the existing output in place.
+func InitFileLogging(logName string, rotation LogRotation) error {
+
+	logFileHook, err := NewFileHook(logName, rotation)
+	if err != nil {
+		return fmt.Errorf("could not initialize logging to file: %v", err)
+	}
+	log.AddHook(logFileHook)
+
+	log.WithFields(log.Fields{
+		"logFileLocation": logFileHook.GetLocation(),
+		"maxSize":         rotation.MaxSize,
+		"maxBackups":      rotation.MaxBackups,
+		"maxAge":          rotation.MaxAge,
+	}).Info("Initialized logging to file.")
+
+	return nil
+}
+
 // InitLogLevel configures the logging level.  The debug flag takes precedence if set,
 // otherwise the logLevel flag (debug, info, warn, error, fatal) is used.
 func InitLogLevel(debug bool, logLevel string) error {
@@ -119,12 +145,13 @@ func (hook *ConsoleHook) Fire(entry *log.Entry) error {
 // FileHook sends log entries to a file.
 type FileHook struct {
 	logFileLocation string
+	rotation        LogRotation
 	formatter       log.Formatter
 	mutex           *sync.Mutex
 }
 
-// NewFileHook creates a new log hook for writing to a file.
-func NewFileHook(logName string) (*FileHook, error) {
+// NewFileHook creates a new log hook for writing to a file, which is rotated as specified.
+func NewFileHook(logName string, rotation LogRotation) (*FileHook, error) {
 
 	formatter := &PlainTextFormatter{}
 
@@ -154,7 +181,7 @@ func NewFileHook(logName string) (*FileHook, error) {
 		break
 	}
 
-	return &FileHook{logFileLocation, formatter, &sync.Mutex{}}, nil
+	return &FileHook{logFileLocation, rotation, formatter, &sync.Mutex{}}, nil
 }
 
 func (hook *FileHook) Levels() []log.Level {
@@ -218,17 +245,42 @@ func (hook *FileHook) doLogfileRotation() error {
 	size := fileInfo.Size()
 	logFile.Close()
 
-	if size < LogRotationThreshold {
+	if size < hook.rotation.MaxSize {
 		return nil
 	}
 
-	// Do the rotation.  The Rename call will overwrite any previous .old file.
-	oldLogFileLocation := hook.logFileLocation + ".old"
-	os.Rename(hook.logFileLocation, oldLogFileLocation)
+	// Do the rotation.  Each backup moves down one place, and the Rename calls overwrite the oldest.
+	if hook.rotation.MaxBackups < 1 {
+		os.Remove(hook.logFileLocation)
+		return nil
+	}
+	for i := hook.rotation.MaxBackups - 1; i > 0; i-- {
+		os.Rename(hook.getBackupLocation(i), hook.getBackupLocation(i+1))
+	}
+	os.Rename(hook.logFileLocation, hook.getBackupLocation(1))
+
+	// Remove any backups older than the maximum age
+	if hook.rotation.MaxAge > 0 {
+		for i := 1; i <= hook.rotation.MaxBackups; i++ {
+			backupInfo, err := os.Stat(hook.getBackupLocation(i))
+			if err == nil && time.Since(backupInfo.ModTime()) > hook.rotation.MaxAge {
+				os.Remove(hook.getBackupLocation(i))
+			}
+		}
+	}
 
 	return nil
 }
 
+// getBackupLocation returns the path of a rotated log file.  The most recent one is named as it
+// was before multiple backups were supported.
+func (hook *FileHook) getBackupLocation(backup int) string {
+	if backup == 1 {
+		return hook.logFileLocation + ".old"
+	}
+	return fmt.Sprintf("%s.old.%d", hook.logFileLocation, backup)
+}
+
 // PlainTextFormatter is a formatter than does no coloring *and* does not insist on writing logs as key/value pairs.
 type PlainTextFormatter struct {
 
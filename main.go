@@ -28,6 +28,14 @@ var (
 	// Logging
 	debug    = flag.Bool("debug", false, "Enable debugging output")
 	logLevel = flag.String("log_level", "info", "Logging level (debug, info, warn, error, fatal)")
+	logFile  = flag.Bool("log_file", false, "Also write the log to a file in "+logging.LogRoot+
+		", as is always done for Docker")
+	logMaxSize = flag.Int("log_max_size", logging.LogRotationThreshold>>20,
+		"Size in MiB at which the log file is rotated")
+	logMaxBackups = flag.Int("log_max_backups", logging.DefaultLogMaxBackups,
+		"Number of rotated log files to keep")
+	logMaxAge = flag.Duration("log_max_age", 0, "Age after which rotated log files are removed "+
+		"(0 keeps them)")
 
 	// Kubernetes
 	k8sAPIServer = flag.String("k8s_api_server", "", "Kubernetes API server "+
@@ -35,6 +43,16 @@ var (
 	k8sConfigPath = flag.String("k8s_config_path", "", "Path to KubeConfig file.")
 	k8sPod        = flag.Bool("k8s_pod", false, "Enables dynamic storage provisioning "+
 		"for Kubernetes if running in a pod.")
+	k8sAPICAFile = flag.String("k8s_api_ca_file", "", "Path to the CA bundle with which "+
+		"to verify the Kubernetes API server, if not the service account's.")
+	k8sWorkers = flag.Int("k8s_workers", 1, "Number of PVCs the Kubernetes frontend "+
+		"provisions concurrently.")
+	k8sAPIQPS = flag.Float64("k8s_api_qps", 0, "Queries per second the Kubernetes frontend "+
+		"may send to the API server (default client-go's limit).")
+	k8sAPIBurst = flag.Int("k8s_api_burst", 0, "Burst of queries the Kubernetes frontend "+
+		"may send to the API server (default client-go's limit).")
+	k8sResyncPeriod = flag.Duration("k8s_resync_period", kubernetes.DefaultKubernetesSyncPeriod,
+		"How often the Kubernetes frontend resyncs the objects it watches.")
 
 	// Docker
 	driverName = flag.String("volume_driver", "netapp", "Register as a Docker "+
@@ -43,6 +61,10 @@ var (
 		"Unix domain socket")
 	configPath = flag.String("config", "", "Path to configuration file(s)")
 
+	// Storage backends
+	backendHTTPTimeout = flag.Duration("backend_http_timeout", config.StorageAPITimeout,
+		"HTTP client timeout for storage backend API calls")
+
 	// CSI
 	csiEndpoint = flag.String("csi_endpoint", "", "Register as a CSI storage "+
 		"provider with this endpoint")
@@ -124,6 +146,28 @@ func processCmdLineArgs() {
 			"k8sAPIServer (for Kubernetes) or configPath (for Docker) or csiEndpoint (for CSI).")
 	}
 
+	if *k8sWorkers < 1 {
+		log.Fatal("The number of Kubernetes frontend workers must be positive.")
+	}
+
+	if *k8sAPIQPS < 0 || *k8sAPIBurst < 0 {
+		log.Fatal("The Kubernetes API QPS and burst may not be negative.")
+	}
+
+	if *k8sResyncPeriod <= 0 {
+		log.Fatal("The Kubernetes resync period must be positive.")
+	}
+
+	if *backendHTTPTimeout <= 0 {
+		log.Fatal("The storage backend HTTP timeout must be positive.")
+	}
+
+	if *logMaxSize < 1 || *logMaxBackups < 0 || *logMaxAge < 0 {
+		log.Fatal("The log file size must be positive, and the number and age of rotated log files " +
+			"must not be negative.")
+	}
+	config.StorageAPITimeout = *backendHTTPTimeout
+
 	// Determine persistent store type from arguments
 	storeCount := 0
 	if *etcdV2 != "" {
@@ -214,6 +258,17 @@ func main() {
 
 	processCmdLineArgs()
 
+	logRotation := logging.LogRotation{
+		MaxSize:    int64(*logMaxSize) << 20,
+		MaxBackups: *logMaxBackups,
+		MaxAge:     *logMaxAge,
+	}
+	if *logFile && !enableDocker {
+		if err = logging.InitFileLogging(config.OrchestratorName, logRotation); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	orchestrator := core.NewTridentOrchestrator(storeClient)
 
 	// Create Kubernetes *or* Docker frontend
@@ -221,11 +276,15 @@ func main() {
 
 		var kubernetesFrontend frontend.Plugin
 		config.CurrentDriverContext = config.ContextKubernetes
+		kubernetes.ProvisioningWorkers = *k8sWorkers
+		kubernetes.APIQPS = float32(*k8sAPIQPS)
+		kubernetes.APIBurst = *k8sAPIBurst
+		kubernetes.KubernetesSyncPeriod = *k8sResyncPeriod
 
 		if *k8sAPIServer != "" {
 			kubernetesFrontend, err = kubernetes.NewPlugin(orchestrator, *k8sAPIServer, *k8sConfigPath)
 		} else {
-			kubernetesFrontend, err = kubernetes.NewPluginInCluster(orchestrator)
+			kubernetesFrontend, err = kubernetes.NewPluginInCluster(orchestrator, *k8sAPICAFile)
 		}
 		if err != nil {
 			log.Fatalf("Unable to start the Kubernetes frontend. %v", err)
@@ -238,7 +297,7 @@ func main() {
 		config.CurrentDriverContext = config.ContextDocker
 
 		// Set up multi-output logging
-		err = logging.InitLogging(*driverName)
+		err = logging.InitLogging(*driverName, logRotation)
 		if err != nil {
 			fmt.Fprint(os.Stderr, err)
 			os.Exit(1)
@@ -57,6 +57,21 @@ type Driver interface {
 	GetUpdateType(driver Driver) *roaring.Bitmap
 }
 
+// StateReporter is implemented by drivers that can report whether their storage system is
+// able to serve requests, such as when an array is in maintenance.
+type StateReporter interface {
+	// GetBackendState returns the reason the storage system can't serve requests,
+	// or an empty string if it can.
+	GetBackendState() (string, error)
+}
+
+// FCReporter is implemented by drivers that may attach their volumes over Fibre Channel, which
+// requires FC host bus adapters on the nodes.
+type FCReporter interface {
+	// UsesFC returns whether the driver's volumes are attached over Fibre Channel.
+	UsesFC() bool
+}
+
 type Backend struct {
 	Driver  Driver
 	Name    string
@@ -236,6 +251,28 @@ func (b *Backend) CloneVolume(volConfig *VolumeConfig) (*Volume, error) {
 	return vol, nil
 }
 
+// ImportVolume adds a volume that already exists on the backend, named by volConfig.InternalName,
+// to the Backend, adding the information needed to access it to volConfig.  Unlike AddVolume and
+// CloneVolume, the volume isn't destroyed if that fails, as Trident didn't create it.
+func (b *Backend) ImportVolume(volConfig *VolumeConfig) (*Volume, error) {
+
+	log.WithFields(log.Fields{
+		"volume":       volConfig.Name,
+		"internalName": volConfig.InternalName,
+	}).Debug("Attempting volume import.")
+
+	if err := b.Driver.Get(volConfig.InternalName); err != nil {
+		return nil, err
+	}
+
+	if err := b.Driver.CreateFollowup(volConfig); err != nil {
+		return nil, err
+	}
+	vol := NewVolume(volConfig, b.Name, drivers.UnsetPool, false)
+	b.Volumes[vol.Config.Name] = vol
+	return vol, nil
+}
+
 // HasVolumes returns true if the Backend has one or more volumes
 // provisioned on it.
 func (b *Backend) HasVolumes() bool {
@@ -335,9 +372,27 @@ func (b *Backend) ConstructPersistent() *BackendPersistent {
 		Online:  b.Online,
 	}
 	b.Driver.StoreConfig(&persistentBackend.Config)
+	persistentBackend.Config.removeReferencedCredentials()
 	return persistentBackend
 }
 
+// removeReferencedCredentials replaces a config whose credentials came from a secret with a copy
+// that omits them, so the credentials are read from the secret again instead of being persisted.
+func (c *PersistentStorageBackendConfig) removeReferencedCredentials() {
+	switch {
+	case c.OntapConfig != nil && len(c.OntapConfig.Credentials) > 0:
+		ontapConfig := *c.OntapConfig
+		ontapConfig.Username = ""
+		ontapConfig.Password = ""
+		c.OntapConfig = &ontapConfig
+	case c.EseriesConfig != nil && len(c.EseriesConfig.Credentials) > 0:
+		eseriesConfig := *c.EseriesConfig
+		eseriesConfig.Username = ""
+		eseriesConfig.Password = ""
+		c.EseriesConfig = &eseriesConfig
+	}
+}
+
 // Unfortunately, this method appears to be necessary to avoid arbitrary values
 // ending up in the json.RawMessage fields of CommonStorageDriverConfig.
 // Ideally, BackendPersistent would just store a serialized config, but
@@ -5,7 +5,10 @@
 package factory
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
@@ -19,6 +22,123 @@ import (
 	"github.com/netapp/trident/storage_drivers/solidfire"
 )
 
+// CredentialsResolver reads the secrets referenced by the credentials field of backend configs.
+// Within the Trident pod, the secrets are mounted as files; other callers may replace this.
+var CredentialsResolver drivers.CredentialsResolver = drivers.ReadMountedCredentials
+
+// driverConstructors returns a new, uninitialized driver for each storage driver name in this build.
+var driverConstructors = map[string]func() storage.Driver{
+	drivers.OntapNASStorageDriverName:      func() storage.Driver { return &ontap.NASStorageDriver{} },
+	drivers.OntapNASQtreeStorageDriverName: func() storage.Driver { return &ontap.NASQtreeStorageDriver{} },
+	drivers.OntapSANStorageDriverName:      func() storage.Driver { return &ontap.SANStorageDriver{} },
+	drivers.SolidfireSANStorageDriverName:  func() storage.Driver { return &solidfire.SANStorageDriver{} },
+	drivers.EseriesIscsiStorageDriverName:  func() storage.Driver { return &eseries.SANStorageDriver{} },
+	drivers.FakeStorageDriverName:          func() storage.Driver { return &fake.StorageDriver{} },
+}
+
+// SupportedDriverNames returns the sorted names of the storage drivers in this build.
+func SupportedDriverNames() []string {
+
+	names := make([]string, 0, len(driverConstructors))
+	for name := range driverConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// requiredConfigFields are the fields of a backend config each storage driver can't start
+// without, by their JSON keys.  Alternative keys of a field are separated by '|'.
+var requiredConfigFields = map[string][]string{
+	drivers.OntapNASStorageDriverName:      {"managementLIF"},
+	drivers.OntapNASQtreeStorageDriverName: {"managementLIF"},
+	drivers.OntapSANStorageDriverName:      {"managementLIF"},
+	drivers.SolidfireSANStorageDriverName:  {"EndPoint", "SVIP", "TenantName"},
+	drivers.EseriesIscsiStorageDriverName:  {"webProxyHostname", "controllerA", "controllerB", "hostDataIP|hostData_IP"},
+}
+
+// credentialsDrivers are the storage drivers that need a username and password, which a
+// credentials secret may supply instead of the backend config.
+var credentialsDrivers = map[string]bool{
+	drivers.OntapNASStorageDriverName:      true,
+	drivers.OntapNASQtreeStorageDriverName: true,
+	drivers.OntapSANStorageDriverName:      true,
+	drivers.EseriesIscsiStorageDriverName:  true,
+}
+
+// ValidateStorageBackendConfig checks a backend config without initializing its driver, which
+// would contact the storage system.  The config must be well-formed, name a storage driver in
+// this build, and have each field that driver requires.  It returns the common config and the
+// protocol of the driver's volumes.
+func ValidateStorageBackendConfig(configJSON string) (*drivers.CommonStorageDriverConfig, config.Protocol, error) {
+
+	// Convert config (JSON or YAML) to JSON
+	configJSONBytes, err := yaml.YAMLToJSON([]byte(configJSON))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid config format: %v", err)
+	}
+
+	commonConfig, err := drivers.ValidateCommonSettings(string(configJSONBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("input failed validation: %v", err)
+	}
+	newDriver, ok := driverConstructors[commonConfig.StorageDriverName]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown storage driver: %v; supported drivers are %s",
+			commonConfig.StorageDriverName, strings.Join(SupportedDriverNames(), ", "))
+	}
+
+	// The drivers decode their configs with encoding/json, which matches keys case-insensitively
+	var configMap map[string]interface{}
+	if err = json.Unmarshal(configJSONBytes, &configMap); err != nil {
+		return nil, "", fmt.Errorf("could not parse JSON configuration: %v", err)
+	}
+	specified := make(map[string]bool)
+	for key, value := range configMap {
+		if value != nil && value != "" {
+			specified[strings.ToLower(key)] = true
+		}
+	}
+
+	required := append([]string{}, requiredConfigFields[commonConfig.StorageDriverName]...)
+	if credentialsDrivers[commonConfig.StorageDriverName] {
+		secretName, err := drivers.GetCredentialsSecretName(commonConfig)
+		if err != nil {
+			return nil, "", err
+		}
+		if secretName == "" {
+			required = append(required, drivers.CredentialsKeyUsername, drivers.CredentialsKeyPassword)
+		}
+	}
+	missing := make([]string, 0)
+	for _, field := range required {
+		alternatives := strings.Split(field, "|")
+		found := false
+		for _, key := range alternatives {
+			found = found || specified[strings.ToLower(key)]
+		}
+		if !found {
+			missing = append(missing, alternatives[0])
+		}
+	}
+	if len(missing) > 0 {
+		return nil, "", fmt.Errorf("missing required fields for the %s driver: %s",
+			commonConfig.StorageDriverName, strings.Join(missing, ", "))
+	}
+
+	// The fake driver's protocol is configurable, while the others have just one
+	protocol := newDriver().GetProtocol()
+	if commonConfig.StorageDriverName == drivers.FakeStorageDriverName {
+		var fakeConfig drivers.FakeStorageDriverConfig
+		if err = json.Unmarshal(configJSONBytes, &fakeConfig); err != nil {
+			return nil, "", fmt.Errorf("could not parse JSON configuration: %v", err)
+		}
+		protocol = fakeConfig.Protocol
+	}
+
+	return commonConfig, protocol, nil
+}
+
 func NewStorageBackendForConfig(configJSON string) (sb *storage.Backend, err error) {
 
 	var storageDriver storage.Driver
@@ -46,24 +166,19 @@ func NewStorageBackendForConfig(configJSON string) (sb *storage.Backend, err err
 		return nil, err
 	}
 
+	// Replace any credentials reference with the credentials themselves
+	if configJSON, err = drivers.InjectCredentials(configJSON, commonConfig, CredentialsResolver); err != nil {
+		err = fmt.Errorf("could not resolve backend credentials: %v", err)
+		return nil, err
+	}
+
 	// Pre-driver initialization setup
-	switch commonConfig.StorageDriverName {
-	case drivers.OntapNASStorageDriverName:
-		storageDriver = &ontap.NASStorageDriver{}
-	case drivers.OntapNASQtreeStorageDriverName:
-		storageDriver = &ontap.NASQtreeStorageDriver{}
-	case drivers.OntapSANStorageDriverName:
-		storageDriver = &ontap.SANStorageDriver{}
-	case drivers.SolidfireSANStorageDriverName:
-		storageDriver = &solidfire.SANStorageDriver{}
-	case drivers.EseriesIscsiStorageDriverName:
-		storageDriver = &eseries.SANStorageDriver{}
-	case drivers.FakeStorageDriverName:
-		storageDriver = &fake.StorageDriver{}
-	default:
+	newDriver, ok := driverConstructors[commonConfig.StorageDriverName]
+	if !ok {
 		err = fmt.Errorf("unknown storage driver: %v", commonConfig.StorageDriverName)
 		return nil, err
 	}
+	storageDriver = newDriver()
 
 	log.WithField("driver", commonConfig.StorageDriverName).Debug("Initializing storage driver.")
 
@@ -21,6 +21,7 @@ type VolumeConfig struct {
 	SpaceReserve              string                 `json:"spaceReserve"`
 	SecurityStyle             string                 `json:"securityStyle"`
 	SnapshotPolicy            string                 `json:"snapshotPolicy,omitempty"`
+	SnapshotReserve           string                 `json:"snapshotReserve,omitempty"`
 	ExportPolicy              string                 `json:"exportPolicy,omitempty"`
 	SnapshotDir               string                 `json:"snapshotDirectory,omitempty"`
 	UnixPermissions           string                 `json:"unixPermissions,omitempty"`
@@ -0,0 +1,182 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package storagedrivers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	trident "github.com/netapp/trident/config"
+)
+
+// Keys of the credentials field in a backend config, and of the secret it references
+const (
+	CredentialsKeyName     = "name"
+	CredentialsKeyType     = "type"
+	CredentialsTypeSecret  = "secret"
+	CredentialsKeyUsername = "username"
+	CredentialsKeyPassword = "password"
+)
+
+// RedactedValue replaces the credentials in a redacted backend config
+const RedactedValue = "REDACTED"
+
+// redactedConfigKeys are the lowercase names of the backend config fields, across all drivers,
+// that hold credentials.
+var redactedConfigKeys = map[string]bool{
+	"username":            true,
+	"password":            true,
+	"passwordarray":       true,
+	"initiatorsecret":     true,
+	"targetsecret":        true,
+	"chapusername":        true,
+	"chapinitiatorsecret": true,
+	"chaptargetusername":  true,
+	"chaptargetsecret":    true,
+}
+
+// urlCredentialsRegex matches the user info of a URL, such as the SolidFire endpoint, up to the
+// last '@' of the authority, since passwords are often written there without escaping an '@'.
+var urlCredentialsRegex = regexp.MustCompile(`(://)[^/\s]+@`)
+
+// CredentialsResolver returns the contents of the named credentials secret.
+type CredentialsResolver func(secretName string) (map[string]string, error)
+
+// credentialsDrivers are the drivers whose configs accept a username and password.
+var credentialsDrivers = map[string]bool{
+	EseriesIscsiStorageDriverName:  true,
+	OntapNASStorageDriverName:      true,
+	OntapNASQtreeStorageDriverName: true,
+	OntapSANStorageDriverName:      true,
+}
+
+// ReadMountedCredentials reads a credentials secret from the directory in which the Trident
+// pod mounts it.  Because the files are read each time a backend is initialized, an updated
+// secret takes effect without reinstalling Trident.
+func ReadMountedCredentials(secretName string) (map[string]string, error) {
+
+	secretPath := path.Join(trident.CredentialsSecretsPath, secretName)
+	credentials := make(map[string]string)
+
+	for _, key := range []string{CredentialsKeyUsername, CredentialsKeyPassword} {
+		value, err := ioutil.ReadFile(path.Join(secretPath, key))
+		if err != nil {
+			return nil, fmt.Errorf("could not read key %s of credentials secret %s; is the secret "+
+				"mounted in the Trident pod? %v", key, secretName, err)
+		}
+		credentials[key] = strings.TrimSpace(string(value))
+	}
+
+	return credentials, nil
+}
+
+// GetCredentialsSecretName returns the name of the secret referenced by the credentials field
+// of a backend config, or an error if the field is invalid.
+func GetCredentialsSecretName(config *CommonStorageDriverConfig) (string, error) {
+
+	if len(config.Credentials) == 0 {
+		return "", nil
+	}
+	if credentialsType := config.Credentials[CredentialsKeyType]; credentialsType != CredentialsTypeSecret {
+		return "", fmt.Errorf("unsupported credentials type '%s'; only '%s' is supported",
+			credentialsType, CredentialsTypeSecret)
+	}
+	secretName := config.Credentials[CredentialsKeyName]
+	if secretName == "" {
+		return "", errors.New("the credentials field must include the secret name")
+	}
+	if !credentialsDrivers[config.StorageDriverName] {
+		return "", fmt.Errorf("the %s driver does not support credentials secrets", config.StorageDriverName)
+	}
+
+	return secretName, nil
+}
+
+// InjectCredentials returns a copy of a backend config in which the username and password
+// are replaced by those in the secret referenced by the config's credentials field.  A config
+// without a credentials field is returned unchanged.
+func InjectCredentials(
+	configJSON string, config *CommonStorageDriverConfig, resolve CredentialsResolver,
+) (string, error) {
+
+	secretName, err := GetCredentialsSecretName(config)
+	if err != nil || secretName == "" {
+		return configJSON, err
+	}
+
+	var configMap map[string]interface{}
+	if err = json.Unmarshal([]byte(configJSON), &configMap); err != nil {
+		return "", fmt.Errorf("could not parse JSON configuration: %v", err)
+	}
+	for _, key := range []string{CredentialsKeyUsername, CredentialsKeyPassword} {
+		if value, ok := configMap[key]; ok && value != "" {
+			return "", fmt.Errorf("%s may not be specified with a credentials secret", key)
+		}
+	}
+
+	credentials, err := resolve(secretName)
+	if err != nil {
+		return "", err
+	}
+	for _, key := range []string{CredentialsKeyUsername, CredentialsKeyPassword} {
+		if credentials[key] == "" {
+			return "", fmt.Errorf("credentials secret %s has no %s", secretName, key)
+		}
+		configMap[key] = credentials[key]
+	}
+
+	configBytes, err := json.Marshal(configMap)
+	if err != nil {
+		return "", err
+	}
+	return string(configBytes), nil
+}
+
+// RedactConfig returns a copy of a backend config in which the value of every credentials field,
+// at any depth, and the user info of any URL are replaced by RedactedValue, so the config can be
+// shown to users.
+func RedactConfig(configJSON string) (string, error) {
+
+	var config interface{}
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return "", fmt.Errorf("could not parse JSON configuration: %v", err)
+	}
+
+	configBytes, err := json.MarshalIndent(redactConfigValue(config), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(configBytes), nil
+}
+
+// redactConfigValue returns a config value with its credentials redacted.
+func redactConfigValue(value interface{}) interface{} {
+
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range typedValue {
+			if redactedConfigKeys[strings.ToLower(key)] {
+				if fieldValue != nil && fieldValue != "" {
+					typedValue[key] = RedactedValue
+				}
+			} else {
+				typedValue[key] = redactConfigValue(fieldValue)
+			}
+		}
+		return typedValue
+	case []interface{}:
+		for i, element := range typedValue {
+			typedValue[i] = redactConfigValue(element)
+		}
+		return typedValue
+	case string:
+		return urlCredentialsRegex.ReplaceAllString(typedValue, "${1}"+RedactedValue+"@")
+	default:
+		return value
+	}
+}
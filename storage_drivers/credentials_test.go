@@ -0,0 +1,275 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package storagedrivers
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGetCredentialsSecretName(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		driver      string
+		credentials map[string]string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "no credentials",
+			driver:   OntapNASStorageDriverName,
+			expected: "",
+		},
+		{
+			name:        "secret",
+			driver:      OntapNASStorageDriverName,
+			credentials: map[string]string{"name": "secret1", "type": "secret"},
+			expected:    "secret1",
+		},
+		{
+			name:        "E-series secret",
+			driver:      EseriesIscsiStorageDriverName,
+			credentials: map[string]string{"name": "secret1", "type": "secret"},
+			expected:    "secret1",
+		},
+		{
+			name:        "unsupported type",
+			driver:      OntapSANStorageDriverName,
+			credentials: map[string]string{"name": "secret1", "type": "vault"},
+			expectError: true,
+		},
+		{
+			name:        "missing type",
+			driver:      OntapSANStorageDriverName,
+			credentials: map[string]string{"name": "secret1"},
+			expectError: true,
+		},
+		{
+			name:        "missing name",
+			driver:      OntapNASQtreeStorageDriverName,
+			credentials: map[string]string{"type": "secret"},
+			expectError: true,
+		},
+		{
+			name:        "unsupported driver",
+			driver:      SolidfireSANStorageDriverName,
+			credentials: map[string]string{"name": "secret1", "type": "secret"},
+			expectError: true,
+		},
+	} {
+		config := &CommonStorageDriverConfig{
+			Version:           1,
+			StorageDriverName: test.driver,
+			Credentials:       test.credentials,
+		}
+		secretName, err := GetCredentialsSecretName(config)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error, got secret name '%s'", test.name, secretName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error; %v", test.name, err)
+		} else if secretName != test.expected {
+			t.Errorf("%s: expected secret name '%s', got '%s'", test.name, test.expected, secretName)
+		}
+	}
+}
+
+func TestInjectCredentials(t *testing.T) {
+
+	resolve := func(secretName string) (map[string]string, error) {
+		switch secretName {
+		case "secret1":
+			return map[string]string{"username": "admin", "password": "p@ssw0rd"}, nil
+		case "nopassword":
+			return map[string]string{"username": "admin"}, nil
+		default:
+			return nil, errors.New("secret not found")
+		}
+	}
+	secretConfig := func(secretName string) *CommonStorageDriverConfig {
+		return &CommonStorageDriverConfig{
+			Version:           1,
+			StorageDriverName: OntapNASStorageDriverName,
+			Credentials:       map[string]string{"name": secretName, "type": "secret"},
+		}
+	}
+
+	for _, test := range []struct {
+		name        string
+		configJSON  string
+		config      *CommonStorageDriverConfig
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name:       "secret credentials",
+			configJSON: `{"version":1,"storageDriverName":"ontap-nas","svm":"svm1"}`,
+			config:     secretConfig("secret1"),
+			expected:   map[string]string{"username": "admin", "password": "p@ssw0rd", "svm": "svm1"},
+		},
+		{
+			name:        "username with secret",
+			configJSON:  `{"version":1,"storageDriverName":"ontap-nas","username":"other"}`,
+			config:      secretConfig("secret1"),
+			expectError: true,
+		},
+		{
+			name:        "password with secret",
+			configJSON:  `{"version":1,"storageDriverName":"ontap-nas","password":"other"}`,
+			config:      secretConfig("secret1"),
+			expectError: true,
+		},
+		{
+			name:        "secret without password",
+			configJSON:  `{"version":1,"storageDriverName":"ontap-nas"}`,
+			config:      secretConfig("nopassword"),
+			expectError: true,
+		},
+		{
+			name:        "missing secret",
+			configJSON:  `{"version":1,"storageDriverName":"ontap-nas"}`,
+			config:      secretConfig("missing"),
+			expectError: true,
+		},
+		{
+			name:        "invalid JSON",
+			configJSON:  `{"version":1,`,
+			config:      secretConfig("secret1"),
+			expectError: true,
+		},
+	} {
+		injectedJSON, err := InjectCredentials(test.configJSON, test.config, resolve)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error, got config %s", test.name, injectedJSON)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error; %v", test.name, err)
+			continue
+		}
+		var injected map[string]interface{}
+		if err = json.Unmarshal([]byte(injectedJSON), &injected); err != nil {
+			t.Errorf("%s: injected config is not valid JSON; %v", test.name, err)
+			continue
+		}
+		for key, value := range test.expected {
+			if injected[key] != value {
+				t.Errorf("%s: expected %s '%s', got '%v'", test.name, key, value, injected[key])
+			}
+		}
+	}
+
+	// A config without a credentials field is returned unchanged
+	configJSON := `{"version":1,"storageDriverName":"ontap-nas","username":"admin","password":"secret"}`
+	config := &CommonStorageDriverConfig{Version: 1, StorageDriverName: OntapNASStorageDriverName}
+	injectedJSON, err := InjectCredentials(configJSON, config, resolve)
+	if err != nil {
+		t.Errorf("unexpected error for a config without credentials; %v", err)
+	} else if injectedJSON != configJSON {
+		t.Errorf("config without credentials was changed; got %s", injectedJSON)
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		configJSON  string
+		expected    string
+		expectError bool
+	}{
+		{
+			name: "ONTAP",
+			configJSON: `{"version":1,"storageDriverName":"ontap-nas","managementLIF":"10.0.0.1",
+				"svm":"svm1","username":"admin","password":"p@ssw0rd"}`,
+			expected: `{"version":1,"storageDriverName":"ontap-nas","managementLIF":"10.0.0.1",
+				"svm":"svm1","username":"REDACTED","password":"REDACTED"}`,
+		},
+		{
+			name: "ONTAP CHAP",
+			configJSON: `{"version":1,"storageDriverName":"ontap-san","username":"admin","password":"p@ssw0rd",
+				"chapUsername":"chap","chapInitiatorSecret":"secret1","chapTargetUsername":"target",
+				"chapTargetSecret":"secret2"}`,
+			expected: `{"version":1,"storageDriverName":"ontap-san","username":"REDACTED","password":"REDACTED",
+				"chapUsername":"REDACTED","chapInitiatorSecret":"REDACTED","chapTargetUsername":"REDACTED",
+				"chapTargetSecret":"REDACTED"}`,
+		},
+		{
+			name: "E-series",
+			configJSON: `{"version":1,"storageDriverName":"eseries-iscsi","webProxyHostname":"proxy",
+				"username":"rw","password":"rw","passwordArray":"array","hostDataIP":"10.0.0.2"}`,
+			expected: `{"version":1,"storageDriverName":"eseries-iscsi","webProxyHostname":"proxy",
+				"username":"REDACTED","password":"REDACTED","passwordArray":"REDACTED","hostDataIP":"10.0.0.2"}`,
+		},
+		{
+			name: "SolidFire",
+			configJSON: `{"version":1,"storageDriverName":"solidfire-san",
+				"Endpoint":"https://admin:p@ssw0rd@10.0.0.3/json-rpc/8.0","SVIP":"10.0.0.4:3260",
+				"TenantName":"trident","Types":[{"Type":"Gold","Qos":{"minIOPS":6000}}]}`,
+			expected: `{"version":1,"storageDriverName":"solidfire-san",
+				"Endpoint":"https://REDACTED@10.0.0.3/json-rpc/8.0","SVIP":"10.0.0.4:3260",
+				"TenantName":"trident","Types":[{"Type":"Gold","Qos":{"minIOPS":6000}}]}`,
+		},
+		{
+			name:       "SolidFire CHAP secrets",
+			configJSON: `{"storageDriverName":"solidfire-san","initiatorSecret":"secret1","targetSecret":"secret2"}`,
+			expected:   `{"storageDriverName":"solidfire-san","initiatorSecret":"REDACTED","targetSecret":"REDACTED"}`,
+		},
+		{
+			name:       "key case ignored",
+			configJSON: `{"storageDriverName":"ontap-nas","Username":"admin","PASSWORD":"p@ssw0rd"}`,
+			expected:   `{"storageDriverName":"ontap-nas","Username":"REDACTED","PASSWORD":"REDACTED"}`,
+		},
+		{
+			name: "nested fields",
+			configJSON: `{"storageDriverName":"ontap-nas",
+				"storage":[{"labels":{"password":"p"}},{"url":"http://u@h"}]}`,
+			expected: `{"storageDriverName":"ontap-nas",
+				"storage":[{"labels":{"password":"REDACTED"}},{"url":"http://REDACTED@h"}]}`,
+		},
+		{
+			name:       "empty credentials kept",
+			configJSON: `{"storageDriverName":"ontap-nas","username":"","password":null}`,
+			expected:   `{"storageDriverName":"ontap-nas","username":"","password":null}`,
+		},
+		{
+			name:       "credentials secret reference kept",
+			configJSON: `{"storageDriverName":"ontap-nas","credentials":{"name":"secret1","type":"secret"}}`,
+			expected:   `{"storageDriverName":"ontap-nas","credentials":{"name":"secret1","type":"secret"}}`,
+		},
+		{
+			name:        "invalid JSON",
+			configJSON:  `{"password":`,
+			expectError: true,
+		},
+	} {
+		redactedJSON, err := RedactConfig(test.configJSON)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error, got config %s", test.name, redactedJSON)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error; %v", test.name, err)
+			continue
+		}
+		var redacted, expected interface{}
+		if err = json.Unmarshal([]byte(redactedJSON), &redacted); err != nil {
+			t.Errorf("%s: redacted config is not valid JSON; %v", test.name, err)
+			continue
+		}
+		if err = json.Unmarshal([]byte(test.expected), &expected); err != nil {
+			t.Fatalf("%s: expected config is not valid JSON; %v", test.name, err)
+		}
+		if !reflect.DeepEqual(redacted, expected) {
+			t.Errorf("%s: expected config %s, got %s", test.name, test.expected, redactedJSON)
+		}
+	}
+}
@@ -16,7 +16,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -165,7 +164,7 @@ func (d Client) InvokeAPI(requestBody []byte, method string, resourcePath string
 	}
 	client := &http.Client{
 		Transport: tr,
-		Timeout:   time.Duration(tridentconfig.StorageAPITimeoutSeconds * time.Second),
+		Timeout:   tridentconfig.StorageAPITimeout,
 	}
 	response, err := client.Do(request)
 	if err != nil {
@@ -1062,7 +1062,7 @@ func (d *SANStorageDriver) getVolumeExternal(
 	volumeAttrs *api.VolumeEx, poolAttrs *api.VolumeGroupEx) *storage.VolumeExternal {
 
 	internalName := volumeAttrs.Label
-	name := internalName[len(*d.Config.StoragePrefix):]
+	name := strings.TrimPrefix(internalName, *d.Config.StoragePrefix)
 
 	volumeConfig := &storage.VolumeConfig{
 		Version:         tridentconfig.OrchestratorAPIVersion,
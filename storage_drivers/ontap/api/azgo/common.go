@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"time"
 
 	tridentconfig "github.com/netapp/trident/config"
 	log "github.com/sirupsen/logrus"
@@ -77,7 +76,7 @@ func (o *ZapiRunner) SendZapi(r ZAPIRequest) (*http.Response, error) {
 
 	client := &http.Client{
 		Transport: tr,
-		Timeout:   time.Duration(tridentconfig.StorageAPITimeoutSeconds * time.Second),
+		Timeout:   tridentconfig.StorageAPITimeout,
 	}
 	response, err := client.Do(req)
 	if err != nil {
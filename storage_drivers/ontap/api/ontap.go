@@ -1180,6 +1180,38 @@ func (d Client) GetVserverAggregateNames() ([]string, error) {
 	return aggrNames, nil
 }
 
+// VserverGetState returns the administrative and operational state of the configured vserver,
+// along with the reason it is stopped, if any.  States that ONTAP doesn't report are empty.
+func (d Client) VserverGetState() (adminState, operationalState, stoppedReason string, err error) {
+
+	query := azgo.NewVserverInfoType()
+	query.SetVserverName(d.config.SVM)
+
+	response, err := azgo.NewVserverGetIterRequest().
+		SetMaxRecords(defaultZapiRecords).
+		SetQuery(*query).
+		ExecuteUsing(d.zr)
+
+	if err = GetError(response, err); err != nil {
+		return "", "", "", err
+	}
+	if response.Result.NumRecords() != 1 {
+		return "", "", "", fmt.Errorf("could not find SVM %s", d.config.SVM)
+	}
+
+	vserver := response.Result.AttributesList()[0]
+	if vserver.StatePtr != nil {
+		adminState = string(*vserver.StatePtr)
+	}
+	if vserver.OperationalStatePtr != nil {
+		operationalState = string(*vserver.OperationalStatePtr)
+	}
+	if vserver.OperationalStateStoppedReasonPtr != nil {
+		stoppedReason = string(*vserver.OperationalStateStoppedReasonPtr)
+	}
+	return adminState, operationalState, stoppedReason, nil
+}
+
 // VserverShowAggrGetIterRequest returns the aggregates on the vserver.  Requires ONTAP 9 or later.
 // equivalent to filer::> vserver show-aggregates
 func (d Client) VserverShowAggrGetIterRequest() (response azgo.VserverShowAggrGetIterResponse, err error) {
@@ -1280,6 +1312,29 @@ func (d Client) NetInterfaceGetDataLIFs(protocol string) ([]string, error) {
 	return dataLIFs, nil
 }
 
+// NetInterfaceGetFCPWWPNs returns the WWPNs of the FC data LIFs
+func (d Client) NetInterfaceGetFCPWWPNs() ([]string, error) {
+	lifResponse, err := d.NetInterfaceGet()
+	if err = GetError(lifResponse, err); err != nil {
+		return nil, fmt.Errorf("error checking network interfaces: %v", err)
+	}
+
+	wwpns := make([]string, 0)
+	for _, attrs := range lifResponse.Result.AttributesList() {
+		if attrs.WwpnPtr == nil {
+			continue
+		}
+		for _, proto := range attrs.DataProtocols() {
+			if proto == azgo.DataProtocolType("fcp") {
+				wwpns = append(wwpns, attrs.Wwpn())
+			}
+		}
+	}
+
+	log.WithField("wwpns", wwpns).Debug("FC data LIF WWPNs")
+	return wwpns, nil
+}
+
 // SystemGetVersion returns the system version
 // equivalent to filer::> version
 func (d Client) SystemGetVersion() (response azgo.SystemGetVersionResponse, err error) {
@@ -671,6 +671,33 @@ func GetVolume(name string, client *api.Client, config *drivers.OntapStorageDriv
 	return nil
 }
 
+// getBackendStateCommon reports the reason the configured SVM can't serve requests, or an
+// empty string if it is running.
+func getBackendStateCommon(client *api.Client, config *drivers.OntapStorageDriverConfig) (string, error) {
+
+	if config.DebugTraceFlags["method"] {
+		fields := log.Fields{"Method": "getBackendStateCommon", "Type": "ontap_common"}
+		log.WithFields(fields).Debug(">>>> getBackendStateCommon")
+		defer log.WithFields(fields).Debug("<<<< getBackendStateCommon")
+	}
+
+	adminState, operationalState, stoppedReason, err := client.VserverGetState()
+	if err != nil {
+		return "", fmt.Errorf("could not get the state of SVM %s; %v", config.SVM, err)
+	}
+
+	if adminState != "" && adminState != "running" {
+		return fmt.Sprintf("SVM %s is %s", config.SVM, adminState), nil
+	}
+	if operationalState != "" && operationalState != "running" {
+		if stoppedReason != "" {
+			return fmt.Sprintf("SVM %s is %s (%s)", config.SVM, operationalState, stoppedReason), nil
+		}
+		return fmt.Sprintf("SVM %s is %s", config.SVM, operationalState), nil
+	}
+	return "", nil
+}
+
 // UpdateLoadSharingMirrors checks for the present of LS mirrors on the SVM root volume, and if
 // present, starts an update and waits for them to become idle.
 func UpdateLoadSharingMirrors(client *api.Client) {
@@ -985,6 +1012,9 @@ func getVolumeOptsCommon(
 	if volConfig.SnapshotPolicy != "" {
 		opts["snapshotPolicy"] = volConfig.SnapshotPolicy
 	}
+	if volConfig.SnapshotReserve != "" {
+		opts["snapshotReserve"] = volConfig.SnapshotReserve
+	}
 	if volConfig.UnixPermissions != "" {
 		opts["unixPermissions"] = volConfig.UnixPermissions
 	}
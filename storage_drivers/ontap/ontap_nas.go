@@ -167,6 +167,12 @@ func (d *NASStorageDriver) Create(name string, sizeBytes uint64, opts map[string
 	if snapshotPolicy == "none" {
 		snapshotReserve = 0
 	}
+	if reserve := utils.GetV(opts, "snapshotReserve", ""); reserve != "" {
+		snapshotReserve, err = strconv.Atoi(reserve)
+		if err != nil || snapshotReserve < 0 || snapshotReserve > 100 {
+			return fmt.Errorf("invalid percentage for snapshotReserve: %s", reserve)
+		}
+	}
 
 	log.WithFields(log.Fields{
 		"name":            name,
@@ -396,6 +402,11 @@ func (d *NASStorageDriver) GetProtocol() tridentconfig.Protocol {
 	return tridentconfig.File
 }
 
+// GetBackendState reports the reason the SVM can't serve requests, or an empty string if it can.
+func (d *NASStorageDriver) GetBackendState() (string, error) {
+	return getBackendStateCommon(d.API, &d.Config)
+}
+
 func (d *NASStorageDriver) StoreConfig(
 	b *storage.PersistentStorageBackendConfig,
 ) {
@@ -457,7 +468,7 @@ func (d *NASStorageDriver) getVolumeExternal(
 	volumeSnapshotAttrs := volumeAttrs.VolumeSnapshotAttributesPtr
 
 	internalName := string(volumeIDAttrs.Name())
-	name := internalName[len(*d.Config.StoragePrefix):]
+	name := strings.TrimPrefix(internalName, *d.Config.StoragePrefix)
 
 	volumeConfig := &storage.VolumeConfig{
 		Version:         tridentconfig.OrchestratorAPIVersion,
@@ -475,6 +486,9 @@ func (d *NASStorageDriver) getVolumeExternal(
 		BlockSize:       "",
 		FileSystem:      "",
 	}
+	if volumeSpaceAttrs.PercentageSnapshotReservePtr != nil {
+		volumeConfig.SnapshotReserve = strconv.Itoa(volumeSpaceAttrs.PercentageSnapshotReserve())
+	}
 
 	return &storage.VolumeExternal{
 		Config: volumeConfig,
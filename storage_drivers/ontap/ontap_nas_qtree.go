@@ -1054,6 +1054,11 @@ func (d *NASQtreeStorageDriver) GetProtocol() tridentconfig.Protocol {
 	return tridentconfig.File
 }
 
+// GetBackendState reports the reason the SVM can't serve requests, or an empty string if it can.
+func (d *NASQtreeStorageDriver) GetBackendState() (string, error) {
+	return getBackendStateCommon(d.API, &d.Config)
+}
+
 func (d *NASQtreeStorageDriver) StoreConfig(b *storage.PersistentStorageBackendConfig) {
 	drivers.SanitizeCommonStorageDriverConfig(d.Config.CommonStorageDriverConfig)
 	b.OntapConfig = &d.Config
@@ -1182,7 +1187,7 @@ func (d *NASQtreeStorageDriver) getVolumeExternal(
 	volumeSnapshotAttrs := volumeAttrs.VolumeSnapshotAttributesPtr
 
 	internalName := qtreeAttrs.Qtree()
-	name := internalName[len(*d.Config.StoragePrefix):]
+	name := strings.TrimPrefix(internalName, *d.Config.StoragePrefix)
 
 	size, err := strconv.ParseInt(quotaAttrs.DiskLimit(), 10, 64)
 	if err != nil {
@@ -22,16 +22,25 @@ import (
 
 const LUNAttributeFSType = "com.netapp.ndvp.fstype"
 
+// SAN protocols of the sanType config field, which are also the igroup types
+const (
+	SANTypeISCSI = "iscsi"
+	SANTypeFCP   = "fcp"
+)
+
 func lunPath(name string) string {
 	return fmt.Sprintf("/vol/%v/lun0", name)
 }
 
-// SANStorageDriver is for iSCSI storage provisioning
+// SANStorageDriver is for iSCSI or FC storage provisioning
 type SANStorageDriver struct {
 	initialized bool
 	Config      drivers.OntapStorageDriverConfig
 	API         *api.Client
 	Telemetry   *Telemetry
+
+	// WWPNs of the SVM's FC data LIFs, if the driver uses FC
+	fcTargetWWPNs []string
 }
 
 func (d *SANStorageDriver) GetConfig() *drivers.OntapStorageDriverConfig {
@@ -71,6 +80,9 @@ func (d *SANStorageDriver) Initialize(
 	if config.IgroupName == "" {
 		config.IgroupName = drivers.GetDefaultIgroupName(context)
 	}
+	if config.SANType == "" {
+		config.SANType = SANTypeISCSI
+	}
 
 	d.API, err = InitializeOntapDriver(config)
 	if err != nil {
@@ -84,7 +96,7 @@ func (d *SANStorageDriver) Initialize(
 	}
 
 	// Create igroup
-	igroupResponse, err := d.API.IgroupCreate(d.Config.IgroupName, "iscsi", "linux")
+	igroupResponse, err := d.API.IgroupCreate(d.Config.IgroupName, d.Config.SANType, "linux")
 	if err != nil {
 		return fmt.Errorf("error creating igroup: %v", err)
 	}
@@ -134,6 +146,14 @@ func (d *SANStorageDriver) validate() error {
 		defer log.WithFields(fields).Debug("<<<< validate")
 	}
 
+	switch d.Config.SANType {
+	case SANTypeISCSI:
+	case SANTypeFCP:
+		return d.validateFCP()
+	default:
+		return fmt.Errorf("invalid sanType '%s'; must be %s or %s", d.Config.SANType, SANTypeISCSI, SANTypeFCP)
+	}
+
 	dataLIFs, err := d.API.NetInterfaceGetDataLIFs("iscsi")
 	if err != nil {
 		return err
@@ -168,6 +188,37 @@ func (d *SANStorageDriver) validate() error {
 	return nil
 }
 
+// validateFCP finds the WWPNs of the SVM's FC data LIFs.  Only the Kubernetes frontend, which
+// creates FC PVs for the kubelet to attach, can use FC; Docker and CSI attach LUNs over iSCSI.
+func (d *SANStorageDriver) validateFCP() error {
+
+	if d.Config.DriverContext != tridentconfig.ContextKubernetes {
+		return fmt.Errorf("sanType %s is only supported with Kubernetes without CSI", SANTypeFCP)
+	}
+
+	wwpns, err := d.API.NetInterfaceGetFCPWWPNs()
+	if err != nil {
+		return err
+	}
+	if len(wwpns) == 0 {
+		return fmt.Errorf("no FC data LIFs found on SVM %s", d.Config.SVM)
+	}
+
+	// Kubernetes expects WWNs as hexadecimal without separators
+	d.fcTargetWWPNs = make([]string, 0, len(wwpns))
+	for _, wwpn := range wwpns {
+		d.fcTargetWWPNs = append(d.fcTargetWWPNs, strings.ToLower(strings.Replace(wwpn, ":", "", -1)))
+	}
+	log.WithField("wwpns", d.fcTargetWWPNs).Debug("Found FC LIFs.")
+
+	return nil
+}
+
+// UsesFC returns whether the driver's volumes are attached over FC.
+func (d *SANStorageDriver) UsesFC() bool {
+	return d.Config.SANType == SANTypeFCP
+}
+
 // Create a volume+LUN with the specified options
 func (d *SANStorageDriver) Create(name string, sizeBytes uint64, opts map[string]string) error {
 
@@ -396,6 +447,10 @@ func (d *SANStorageDriver) Publish(name string, publishInfo *utils.VolumePublish
 		defer log.WithFields(fields).Debug("<<<< Publish")
 	}
 
+	if d.UsesFC() {
+		return fmt.Errorf("publishing volumes over %s is not supported", SANTypeFCP)
+	}
+
 	var iqn string
 	var err error
 
@@ -609,6 +664,10 @@ func (d *SANStorageDriver) mapOntapSANLun(volConfig *storage.VolumeConfig) error
 		lunID     int
 	)
 
+	if d.UsesFC() {
+		return d.mapOntapSANLunFCP(volConfig)
+	}
+
 	response, err := d.API.IscsiServiceGetIterRequest()
 	if response.Result.ResultStatusAttr != "passed" || err != nil {
 		return fmt.Errorf("problem retrieving iSCSI services: %v, %v",
@@ -647,10 +706,36 @@ func (d *SANStorageDriver) mapOntapSANLun(volConfig *storage.VolumeConfig) error
 	return nil
 }
 
+// mapOntapSANLunFCP maps the LUN to the FC igroup and records the FC access info of the volume.
+func (d *SANStorageDriver) mapOntapSANLunFCP(volConfig *storage.VolumeConfig) error {
+
+	lunID, err := d.API.LunMapIfNotMapped(d.Config.IgroupName, lunPath(volConfig.InternalName))
+	if err != nil {
+		return err
+	}
+
+	volConfig.AccessInfo.FcTargetWWNs = d.fcTargetWWPNs
+	volConfig.AccessInfo.FcLunNumber = int32(lunID)
+	log.WithFields(log.Fields{
+		"volume":          volConfig.Name,
+		"volume_internal": volConfig.InternalName,
+		"targetWWNs":      volConfig.AccessInfo.FcTargetWWNs,
+		"lunNumber":       volConfig.AccessInfo.FcLunNumber,
+		"igroup":          d.Config.IgroupName,
+	}).Debug("Mapped ONTAP LUN over FC.")
+
+	return nil
+}
+
 func (d *SANStorageDriver) GetProtocol() tridentconfig.Protocol {
 	return tridentconfig.Block
 }
 
+// GetBackendState reports the reason the SVM can't serve requests, or an empty string if it can.
+func (d *SANStorageDriver) GetBackendState() (string, error) {
+	return getBackendStateCommon(d.API, &d.Config)
+}
+
 func (d *SANStorageDriver) StoreConfig(
 	b *storage.PersistentStorageBackendConfig,
 ) {
@@ -737,7 +822,7 @@ func (d *SANStorageDriver) getVolumeExternal(
 	volumeSnapshotAttrs := volumeAttrs.VolumeSnapshotAttributesPtr
 
 	internalName := string(volumeIDAttrs.Name())
-	name := internalName[len(*d.Config.StoragePrefix):]
+	name := strings.TrimPrefix(internalName, *d.Config.StoragePrefix)
 
 	volumeConfig := &storage.VolumeConfig{
 		Version:         tridentconfig.OrchestratorAPIVersion,
@@ -51,3 +51,13 @@ func (c *Client) GetAccountByID(req *GetAccountByIDRequest) (account Account, er
 	}
 	return result.Result.Account, err
 }
+
+// ModifyAccount tbd
+func (c *Client) ModifyAccount(req *ModifyAccountRequest) error {
+	_, err := c.Request("ModifyAccount", req, NewReqID())
+	if err != nil {
+		log.Errorf("Error detected in ModifyAccount API response: %+v", err)
+		return errors.New("device API error")
+	}
+	return nil
+}
@@ -109,7 +109,7 @@ func (c *Client) Request(method string, params interface{}, id int) ([]byte, err
 	}
 	httpClient := &http.Client{
 		Transport: tr,
-		Timeout:   time.Duration(tridentconfig.StorageAPITimeoutSeconds * time.Second),
+		Timeout:   tridentconfig.StorageAPITimeout,
 	}
 	response, err = httpClient.Do(request)
 	if err != nil {
@@ -0,0 +1,29 @@
+// Copyright 2018 NetApp, Inc. All Rights Reserved.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// List cluster faults of the specified types (current, resolved, or all)
+func (c *Client) ListClusterFaults(faultTypes string) ([]ClusterFault, error) {
+	var (
+		clusterFaultsReq    = ListClusterFaultsRequest{BestPractices: false, FaultTypes: faultTypes}
+		clusterFaultsResult ListClusterFaultsResult
+	)
+
+	response, err := c.Request("ListClusterFaults", clusterFaultsReq, NewReqID())
+	if err != nil {
+		log.Errorf("Error detected in ListClusterFaults API response: %+v", err)
+		return nil, errors.New("device API error")
+	}
+	if err := json.Unmarshal([]byte(response), &clusterFaultsResult); err != nil {
+		log.Errorf("Error detected unmarshalling json response: %+v", err)
+		return nil, errors.New("json decode error")
+	}
+	return clusterFaultsResult.Result.Faults, err
+}
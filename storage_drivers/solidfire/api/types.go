@@ -279,6 +279,13 @@ type AddAccountRequest struct {
 	Attributes      interface{} `json:"attributes,omitempty"`
 }
 
+// ModifyAccountRequest tbd
+type ModifyAccountRequest struct {
+	AccountID       int64  `json:"accountID"`
+	InitiatorSecret string `json:"initiatorSecret,omitempty"`
+	TargetSecret    string `json:"targetSecret,omitempty"`
+}
+
 // AddAccountResult tbd
 type AddAccountResult struct {
 	ID     int `json:"id"`
@@ -322,6 +329,27 @@ type GetClusterCapacityResult struct {
 	} `json:"result"`
 }
 
+type ListClusterFaultsRequest struct {
+	BestPractices bool   `json:"bestPractices"`
+	FaultTypes    string `json:"faultTypes,omitempty"`
+}
+
+type ListClusterFaultsResult struct {
+	ID     int `json:"id"`
+	Result struct {
+		Faults []ClusterFault `json:"faults"`
+	} `json:"result"`
+}
+
+type ClusterFault struct {
+	ClusterFaultID int64  `json:"clusterFaultID"`
+	Code           string `json:"code"`
+	Details        string `json:"details"`
+	Severity       string `json:"severity"`
+	Type           string `json:"type"`
+	Resolved       bool   `json:"resolved"`
+}
+
 type GetClusterHardwareInfoResult struct {
 	ID     int `json:"id"`
 	Result struct {
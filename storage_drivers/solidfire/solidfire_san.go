@@ -3,6 +3,7 @@
 package solidfire
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -30,6 +31,9 @@ const (
 
 const MinimumVolumeSizeBytes = 1000000000 // 1 GB
 
+// CHAP secrets must be 12-16 characters long
+const chapSecretLength = 16
+
 // SANStorageDriver is for iSCSI storage provisioning
 type SANStorageDriver struct {
 	initialized      bool
@@ -793,6 +797,91 @@ func (d *SANStorageDriver) Publish(name string, publishInfo *utils.VolumePublish
 	return nil
 }
 
+// NewCHAPCredentials returns new random CHAP secrets for the tenant account used by this driver,
+// along with the account's username.  The secrets aren't applied until SetCHAPCredentials is called.
+func (d *SANStorageDriver) NewCHAPCredentials() (*utils.IscsiAccessInfo, error) {
+
+	if d.Config.DebugTraceFlags["method"] {
+		fields := log.Fields{"Method": "NewCHAPCredentials", "Type": "SANStorageDriver"}
+		log.WithFields(fields).Debug(">>>> NewCHAPCredentials")
+		defer log.WithFields(fields).Debug("<<<< NewCHAPCredentials")
+	}
+
+	account, err := d.Client.GetAccountByID(&api.GetAccountByIDRequest{AccountID: d.AccountID})
+	if err != nil {
+		return nil, fmt.Errorf("could not get account %d; %v", d.AccountID, err)
+	}
+
+	initiatorSecret, err := generateCHAPSecret()
+	if err != nil {
+		return nil, err
+	}
+	targetSecret, err := generateCHAPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &utils.IscsiAccessInfo{
+		IscsiUsername:        account.Username,
+		IscsiInitiatorSecret: initiatorSecret,
+		IscsiTargetSecret:    targetSecret,
+	}, nil
+}
+
+// SetCHAPCredentials replaces the CHAP secrets of the tenant account used by this driver.
+// Established iSCSI sessions are not affected; the new secrets are only required the next time an
+// initiator logs in.  Note that the secrets apply to every volume owned by the tenant account.
+func (d *SANStorageDriver) SetCHAPCredentials(accessInfo *utils.IscsiAccessInfo) error {
+
+	if d.Config.DebugTraceFlags["method"] {
+		fields := log.Fields{"Method": "SetCHAPCredentials", "Type": "SANStorageDriver"}
+		log.WithFields(fields).Debug(">>>> SetCHAPCredentials")
+		defer log.WithFields(fields).Debug("<<<< SetCHAPCredentials")
+	}
+
+	req := api.ModifyAccountRequest{
+		AccountID:       d.AccountID,
+		InitiatorSecret: accessInfo.IscsiInitiatorSecret,
+		TargetSecret:    accessInfo.IscsiTargetSecret,
+	}
+	if err := d.Client.ModifyAccount(&req); err != nil {
+		return fmt.Errorf("could not update CHAP secrets for account %d; %v", d.AccountID, err)
+	}
+
+	log.WithFields(log.Fields{
+		"accountID": d.AccountID,
+		"username":  accessInfo.IscsiUsername,
+	}).Info("Rotated CHAP secrets.")
+
+	return nil
+}
+
+// generateCHAPSecret returns a random alphanumeric CHAP secret.  Random bytes that would favor
+// some characters over others when reduced modulo the alphabet size are discarded.
+func generateCHAPSecret() (string, error) {
+
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	const maxUnbiased = 256 - 256%len(chars)
+
+	secret := make([]byte, 0, chapSecretLength)
+	buf := make([]byte, chapSecretLength)
+	for len(secret) < chapSecretLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("could not generate CHAP secret; %v", err)
+		}
+		for _, b := range buf {
+			if int(b) >= maxUnbiased {
+				continue
+			}
+			secret = append(secret, chars[int(b)%len(chars)])
+			if len(secret) == chapSecretLength {
+				break
+			}
+		}
+	}
+	return string(secret), nil
+}
+
 // List of volumes according to backend device
 func (d *SANStorageDriver) List() (vols []string, err error) {
 
@@ -933,6 +1022,21 @@ func (d *SANStorageDriver) GetVolume(name string) (api.Volume, error) {
 	return vols[0], nil
 }
 
+// GetVolumeQoS returns the IOPS limits the cluster applied to a volume.
+func (d *SANStorageDriver) GetVolumeQoS(name string) (map[string]string, error) {
+
+	volume, err := d.GetVolume(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not get volume %s; %v", name, err)
+	}
+
+	return map[string]string{
+		"minIOPS":   strconv.FormatInt(volume.Qos.MinIOPS, 10),
+		"maxIOPS":   strconv.FormatInt(volume.Qos.MaxIOPS, 10),
+		"burstIOPS": strconv.FormatInt(volume.Qos.BurstIOPS, 10),
+	}, nil
+}
+
 // GetStorageBackendSpecs retrieves storage backend capabilities
 func (d *SANStorageDriver) GetStorageBackendSpecs(backend *storage.Backend) error {
 	if d.Config.BackendName == "" {
@@ -1128,6 +1232,27 @@ func (d *SANStorageDriver) GetProtocol() tridentconfig.Protocol {
 	return tridentconfig.Block
 }
 
+// GetBackendState reports the reason the cluster can't serve requests, or an empty string if
+// it can.  SolidFire raises a critical fault when the cluster can no longer serve I/O.
+func (d *SANStorageDriver) GetBackendState() (string, error) {
+
+	faults, err := d.Client.ListClusterFaults("current")
+	if err != nil {
+		return "", fmt.Errorf("could not list cluster faults; %v", err)
+	}
+
+	reasons := make([]string, 0)
+	for _, fault := range faults {
+		if fault.Severity == "critical" && !fault.Resolved {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", fault.Code, fault.Details))
+		}
+	}
+	if len(reasons) > 0 {
+		return "cluster has critical faults; " + strings.Join(reasons, "; "), nil
+	}
+	return "", nil
+}
+
 func (d *SANStorageDriver) StoreConfig(
 	b *storage.PersistentStorageBackendConfig,
 ) {
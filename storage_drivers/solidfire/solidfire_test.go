@@ -104,3 +104,26 @@ func TestNoUpgradeNewerEndpointAPIVersion(t *testing.T) {
 		t.Error("Client endpoint changed to minimum version.")
 	}
 }
+
+func TestGenerateCHAPSecret(t *testing.T) {
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		secret, err := generateCHAPSecret()
+		if err != nil {
+			t.Fatalf("Received error from generateCHAPSecret: %v", err)
+		}
+		if len(secret) != chapSecretLength {
+			t.Errorf("Expected a secret of %d characters, got %d: %s", chapSecretLength, len(secret), secret)
+		}
+		for _, c := range secret {
+			if !strings.ContainsRune(chars, c) {
+				t.Errorf("Secret %s contains a non-alphanumeric character.", secret)
+			}
+		}
+		if seen[secret] {
+			t.Errorf("Secret %s was generated twice.", secret)
+		}
+		seen[secret] = true
+	}
+}
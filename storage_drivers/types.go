@@ -23,6 +23,7 @@ type CommonStorageDriverConfig struct {
 	DisableDelete     bool                  `json:"disableDelete"`
 	StoragePrefixRaw  json.RawMessage       `json:"storagePrefix,string"`
 	StoragePrefix     *string               `json:"-"`
+	Credentials       map[string]string     `json:"credentials,omitempty"` // Example: {"name":"secret1", "type":"secret"}
 	SerialNumbers     []string              `json:"-"`
 	DriverContext     trident.DriverContext `json:"-"`
 }
@@ -70,6 +71,7 @@ type OntapStorageDriverConfig struct {
 	ManagementLIF                    string `json:"managementLIF"`
 	DataLIF                          string `json:"dataLIF"`
 	IgroupName                       string `json:"igroupName"`
+	SANType                          string `json:"sanType"` // iscsi or fcp, default to iscsi
 	SVM                              string `json:"svm"`
 	Username                         string `json:"username"`
 	Password                         string `json:"password"`
@@ -5,6 +5,7 @@ package utils
 type VolumeAccessInfo struct {
 	IscsiAccessInfo
 	NfsAccessInfo
+	FcAccessInfo
 }
 
 type IscsiAccessInfo struct {
@@ -24,6 +25,11 @@ type NfsAccessInfo struct {
 	NfsPath     string `json:"nfsPath,omitempty"`
 }
 
+type FcAccessInfo struct {
+	FcTargetWWNs []string `json:"fcTargetWwns,omitempty"`
+	FcLunNumber  int32    `json:"fcLunNumber"`
+}
+
 type VolumePublishInfo struct {
 	Localhost      bool     `json:"localhost,omitempty"`
 	HostIQN        []string `json:"hostIQN,omitempty"`